@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/common/migrate"
+	"my-digital-home/pkg/common/version"
+	dao "my-digital-home/pkg/core/user/repository/dao/impl"
+	"my-digital-home/pkg/core/user/service"
+)
+
+const usage = `usage:
+  my-digital-home [serve]                                 启动服务（默认）
+  my-digital-home migrate [up]                            执行全部未执行的迁移
+  my-digital-home migrate status                          列出未执行的迁移
+  my-digital-home rollback [steps]                        回滚最近执行的steps个迁移（默认1）
+  my-digital-home create-admin --username U --email E     创建管理员，密码取--password或从标准输入读取一行
+  my-digital-home version                                 输出版本信息`
+
+// runCommand 执行serve以外的子命令，返回进程退出码
+func runCommand(cfg *config.Config, args []string) int {
+	switch args[0] {
+	case "migrate":
+		action := "up"
+		if len(args) > 1 {
+			action = args[1]
+		}
+		switch action {
+		case "up":
+			return withRunner(cfg, migrateUp)
+		case "status":
+			return withRunner(cfg, migrateStatus)
+		}
+	case "rollback":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				fmt.Fprintf(os.Stderr, "invalid rollback steps %q\n", args[1])
+				return 2
+			}
+			steps = n
+		}
+		return withRunner(cfg, func(ctx context.Context, runner *migrate.Runner) error {
+			return migrateDown(ctx, runner, steps)
+		})
+	case "create-admin":
+		return createAdmin(cfg, args[1:], os.Stdin)
+	case "version":
+		fmt.Printf("%s (commit %s, built %s)\n", version.Version, version.GitCommit, version.BuildTime)
+		return 0
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+		return 0
+	}
+
+	fmt.Fprintln(os.Stderr, usage)
+	return 2
+}
+
+// createAdmin 无论是否已有用户都创建管理员，用于首个管理员之外的补充或找回管理权限
+// 密码未通过--password给出时从stdin读取，避免出现在进程列表与shell历史中
+func createAdmin(cfg *config.Config, args []string, stdin io.Reader) int {
+	fs := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	username := fs.String("username", "", "管理员用户名")
+	email := fs.String("email", "", "管理员邮箱")
+	password := fs.String("password", "", "管理员密码，为空时从标准输入读取")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *username == "" || *email == "" {
+		fmt.Fprintln(os.Stderr, "create-admin requires --username and --email")
+		return 2
+	}
+	if *password == "" {
+		line, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			fmt.Fprintf(os.Stderr, "read password failed: %v\n", err)
+			return 1
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+	if *password == "" {
+		fmt.Fprintln(os.Stderr, "create-admin requires a password")
+		return 2
+	}
+
+	db, err := cfg.InitDB()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "connect database failed: %v\n", err)
+		return 1
+	}
+	admin, err := service.CreateAdmin(context.Background(), dao.NewUserRepository(db), cfg, *username, *email, *password)
+	switch {
+	case errors.Is(err, dao.ErrDuplicateEntry):
+		fmt.Fprintf(os.Stderr, "username or email already exists: %s %s\n", admin.Username, admin.Email)
+		return 1
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "create admin failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("created admin %s\n", admin.Username)
+	return 0
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/network"
+	"github.com/cloudwego/hertz/pkg/network/netpoll"
+	"my-digital-home/pkg/common/config"
+)
+
+// serveAdditionalAddresses Address配置了多个地址时，在主地址以外的地址上以同一引擎提供服务
+// 各地址共享路由、中间件、超时与TLS设置，随主服务一同启动和停机；运维端口（ADMIN_ADDR）不受影响
+// 启动前逐个试绑定，任一地址不可用时返回错误，由调用方拒绝启动；
+// 运行中某个地址停止服务（停机引起的除外）时错误写入返回的通道，由停机信号处理据此退出进程
+func serveAdditionalAddresses(h *server.Hertz, cfg *config.Config) (<-chan error, error) {
+	listeners := cfg.Server.Listeners()
+	if len(listeners) < 2 {
+		return nil, nil
+	}
+	for _, l := range listeners[1:] {
+		if err := checkBindable(l); err != nil {
+			return nil, err
+		}
+	}
+
+	newTransporter := h.GetOptions().TransporterNewer
+	if newTransporter == nil {
+		// 未指定网络层时与Hertz在Linux/macOS上的默认一致
+		newTransporter = netpoll.NewTransporter
+	}
+	onData := func(ctx context.Context, conn interface{}) error {
+		if c, ok := conn.(network.Conn); ok {
+			return h.Serve(ctx, c)
+		}
+		return nil
+	}
+
+	failures := make(chan error, len(listeners)-1)
+	var stopping atomic.Bool
+	h.OnShutdown = append(h.OnShutdown, func(ctx context.Context) {
+		stopping.Store(true)
+	})
+
+	for _, l := range listeners[1:] {
+		opts := *h.GetOptions()
+		opts.Network, opts.Addr = l.Network, l.Addr
+		transport := newTransporter(&opts)
+
+		h.OnRun = append(h.OnRun, func(ctx context.Context) error {
+			go func() {
+				hlog.Infof("also serving on %s %s", l.Network, l.Addr)
+				err := listenAndServe(transport, onData)
+				if stopping.Load() {
+					return
+				}
+				hlog.Errorf("listener on %s stopped: %v", l.Addr, err)
+				failures <- fmt.Errorf("listener on %s stopped: %w", l.Addr, err)
+			}()
+			return nil
+		})
+		h.OnShutdown = append(h.OnShutdown, func(ctx context.Context) {
+			if err := transport.Shutdown(ctx); err != nil && err != ctx.Err() {
+				hlog.CtxWarnf(ctx, "listener on %s shutdown failed: %v", l.Addr, err)
+			}
+		})
+	}
+	return failures, nil
+}
+
+// checkBindable 试绑定后立即释放，让端口占用、权限不足或地址写错在启动时暴露
+// Unix套接字的残留文件与Hertz一样先行删除
+func checkBindable(l config.ListenAddr) error {
+	_ = network.UnlinkUdsFile(l.Network, l.Addr)
+	ln, err := net.Listen(l.Network, l.Addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s %s: %w", l.Network, l.Addr, err)
+	}
+	return ln.Close()
+}
+
+// listenAndServe netpoll网络层绑定失败时直接panic，这里转换为错误
+func listenAndServe(transport network.Transporter, onData network.OnData) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	err = transport.ListenAndServe(onData)
+	if err == nil {
+		err = net.ErrClosed
+	}
+	return err
+}
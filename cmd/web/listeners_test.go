@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/network/standard"
+	"my-digital-home/pkg/common/config"
+)
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestAdditionalListenerBindFailureFailsStartup(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+
+	cfg := config.Default()
+	cfg.Server.Address = freeAddr(t) + "," + busy.Addr().String()
+	h := server.New(server.WithHostPorts(cfg.Server.Address), server.WithTransport(standard.NewTransporter))
+
+	if _, err := serveAdditionalAddresses(h, cfg); err == nil {
+		t.Fatal("Expected an occupied additional address to fail startup")
+	}
+	if len(h.OnRun) != 0 {
+		t.Fatal("Expected no listener to be started after a bind failure")
+	}
+}
+
+func TestAdditionalListenerServesSameRoutes(t *testing.T) {
+	primary, extra := freeAddr(t), freeAddr(t)
+	cfg := config.Default()
+	cfg.Server.Address = primary + "," + extra
+	h := server.New(server.WithHostPorts(primary), server.WithTransport(standard.NewTransporter))
+	h.GET("/ping", func(ctx context.Context, c *app.RequestContext) {
+		c.String(http.StatusOK, "pong")
+	})
+
+	failures, err := serveAdditionalAddresses(h, cfg)
+	if err != nil {
+		t.Fatalf("Expected free addresses to bind, got %v", err)
+	}
+	go h.Run()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = h.Shutdown(ctx)
+	}()
+
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = http.Get(fmt.Sprintf("http://%s/ping", extra)); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Expected the additional address to serve, got %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "pong" {
+		t.Fatalf("Expected 200 pong, got %d %q", resp.StatusCode, body)
+	}
+	select {
+	case err := <-failures:
+		t.Fatalf("Expected no listener failure, got %v", err)
+	default:
+	}
+}
@@ -1,34 +1,275 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"github.com/cloudwego/hertz/pkg/app/server"
+	config2 "github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/network/standard"
+	"gorm.io/gorm"
 	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/core/user/model"
 	dao "my-digital-home/pkg/core/user/repository/dao/impl"
+	"my-digital-home/pkg/core/user/service"
+	"my-digital-home/pkg/web/debug"
+	"my-digital-home/pkg/web/middleware"
 	"my-digital-home/pkg/web/router"
+	"os"
+	"strings"
+	"time"
 )
 
 func main() {
 	// 初始化配置
 	cfg := config.Load()
 
-	// 初始化数据库连接
+	// 子命令（迁移、创建管理员等）执行完即退出；无子命令或serve时启动服务
+	if len(os.Args) > 1 && os.Args[1] != "serve" {
+		os.Exit(runCommand(cfg, os.Args[1:]))
+	}
+
+	// 初始化数据库连接；开启降级启动时连接失败不退出，由后台协程重连
+	var dbGate *middleware.DependencyGate
 	db, err := cfg.InitDB()
 	if err != nil {
-		panic("Failed to initialize database: " + err.Error())
+		if !cfg.Database.DegradedStart {
+			panic("Failed to initialize database: " + err.Error())
+		}
+		hlog.Errorf("[DEGRADED] database unavailable, starting in degraded mode: %v", err)
+		if db, err = cfg.InitDBLazy(); err != nil {
+			panic("Failed to initialize database: " + err.Error())
+		}
+		dbGate = middleware.NewDependencyGate("database", cfg.Database.RetryInterval)
+	} else if err := prepareDatabase(cfg, db); err != nil {
+		panic("Failed to prepare database: " + err.Error())
 	}
 
-	// 注入到DAO层
-	dao.NewUserRepository(db)
+	// 只读副本：降级启动时副本不可用则只使用主库
+	replicas, err := cfg.InitReplicas(db)
+	if err != nil {
+		if dbGate == nil {
+			panic("Failed to initialize read replicas: " + err.Error())
+		}
+		hlog.Warnf("[DEGRADED] read replicas unavailable, all queries go to the primary: %v", err)
+	}
 
 	// 创建Hertz实例
-	h := server.Default(
-		server.WithHostPorts(cfg.Server.Address),
+	network, addr := cfg.Server.Listen()
+	opts := []config2.Option{
+		server.WithNetwork(network),
+		server.WithHostPorts(addr),
+		server.WithReadTimeout(cfg.Server.ReadTimeout),
+		server.WithWriteTimeout(cfg.Server.WriteTimeout),
+		server.WithIdleTimeout(cfg.Server.IdleTimeout),
 		server.WithHandleMethodNotAllowed(true),
-	)
+	}
+	if size := cfg.Middleware.Security.MaxRequestBodySize(); size > 0 {
+		opts = append(opts, server.WithMaxRequestBodySize(int(size)))
+	}
+	// 进程内终止TLS：netpoll不支持TLS，需切换为标准库网络层
+	if cfg.Server.TLS.Enabled {
+		tlsCfg, err := cfg.Server.TLS.Build()
+		if err != nil {
+			panic("Failed to configure TLS: " + err.Error())
+		}
+		opts = append(opts, server.WithTLS(tlsCfg), server.WithTransport(standard.NewTransporter))
+	}
+	h := server.Default(opts...)
+
+	// 多个监听地址：其余地址共享同一引擎，任一地址不可用时拒绝启动
+	listenerFailures, err := serveAdditionalAddresses(h, cfg)
+	if err != nil {
+		panic("Failed to listen on additional address: " + err.Error())
+	}
+
+	// Unix套接字：残留文件由Hertz在监听前与停机时删除，这里只负责设置权限
+	if mode := cfg.Server.SocketFileMode(); mode != 0 {
+		for _, l := range cfg.Server.Listeners() {
+			if l.Network != "unix" {
+				continue
+			}
+			path := l.Addr
+			h.OnRun = append(h.OnRun, func(ctx context.Context) error {
+				go chmodWhenCreated(path, mode)
+				return nil
+			})
+		}
+	}
 
 	// 注册路由
-	router.RegisterAPIs(h, cfg)
+	reloader := config.NewReloader(cfg)
+	admin := newAdminServer(h, cfg)
+	apis := router.RegisterAPIs(h, admin, reloader, db, dbGate, replicas...)
+
+	// 降级启动：数据库恢复并完成启动检查后放行业务接口
+	if dbGate != nil {
+		retryCtx, stopRetry := context.WithCancel(context.Background())
+		go waitForDatabase(retryCtx, cfg, db, dbGate)
+		h.OnShutdown = append(h.OnShutdown, func(ctx context.Context) {
+			stopRetry()
+		})
+	}
+
+	// 日志级别：启动时生效，并随热更新调整
+	applyLogLevel(cfg)
+	reloader.OnReload(func(old, updated *config.Config) {
+		if updated.LogLevel != old.LogLevel {
+			applyLogLevel(updated)
+		}
+	})
+	// 停机信号：先让就绪探针失败并等待流量摘除，再优雅停机；附加地址故障时以非零状态退出
+	waitSignal := drainingSignalWaiter(apis.Health.MarkShuttingDown, cfg.Server.ShutdownDrainDelay, cfg.Server.ReloadOnSIGHUP, listenerFailures)
+	var serveErr error
+	h.SetCustomSignalWaiter(func(errCh chan error) error {
+		serveErr = waitSignal(errCh)
+		return serveErr
+	})
+	// SIGHUP热更新：此时SIGHUP不再触发停机
+	if cfg.Server.ReloadOnSIGHUP {
+		watchCtx, stopWatch := context.WithCancel(context.Background())
+		reloader.WatchSIGHUP(watchCtx)
+		h.OnShutdown = append(h.OnShutdown, func(ctx context.Context) {
+			stopWatch()
+		})
+	}
+
+	// 性能分析：默认关闭；有运维端口时随诊断接口注册（需管理员鉴权），否则使用独立的本机端口
+	if cfg.Debug.EnablePprof && admin == nil {
+		pprofSrv := debug.StartPprofServer(cfg.Debug.PprofAddr)
+		h.OnShutdown = append(h.OnShutdown, func(ctx context.Context) {
+			_ = pprofSrv.Shutdown(ctx)
+		})
+	}
+
+	// 明文端口统一跳转到HTTPS
+	if cfg.Server.TLS.Enabled && cfg.Server.TLS.RedirectAddr != "" {
+		redirectSrv := middleware.StartHTTPSRedirectServer(cfg.Server.TLS.RedirectAddr, addr)
+		h.OnShutdown = append(h.OnShutdown, func(ctx context.Context) {
+			_ = redirectSrv.Shutdown(ctx)
+		})
+	}
 
 	// 启动服务
 	h.Spin()
+	if serveErr != nil {
+		os.Exit(1)
+	}
+}
+
+// prepareDatabase 连接建立后的启动准备：自动迁移、表结构校验与初始管理员
+func prepareDatabase(cfg *config.Config, db *gorm.DB) error {
+	if cfg.AutoMigrateEnabled() {
+		start := time.Now()
+		if err := model.AutoMigrate(db); err != nil {
+			return fmt.Errorf("auto migrate failed: %w", err)
+		}
+		hlog.Infof("Auto migrate finished in %v", time.Since(start).Truncate(time.Millisecond))
+	} else {
+		hlog.Infof("Auto migrate disabled, expecting schema managed by migrations")
+	}
+
+	// 校验表结构与模型一致，避免运行时才暴露字段缺失
+	if mode := cfg.SchemaCheckMode(); mode != config.SchemaCheckOff {
+		problems, err := model.ValidateSchema(db)
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+		if len(problems) > 0 {
+			msg := strings.Join(problems, "; ")
+			if mode == config.SchemaCheckStrict {
+				return fmt.Errorf("schema validation failed: %s", msg)
+			}
+			hlog.Warnf("Schema validation failed: %s", msg)
+		}
+	}
+
+	// 首次启动时创建初始管理员（已有用户则跳过）
+	if _, err := service.BootstrapAdmin(context.Background(), dao.NewUserRepository(db), cfg); err != nil {
+		return fmt.Errorf("bootstrap admin: %w", err)
+	}
+	return nil
+}
+
+// waitForDatabase 按RetryInterval重连数据库，连通且启动检查通过后标记就绪
+// 启动检查失败（如表结构不一致）时保持降级并继续重试，便于排查时探针与指标仍可用
+func waitForDatabase(ctx context.Context, cfg *config.Config, db *gorm.DB, gate *middleware.DependencyGate) {
+	ticker := time.NewTicker(cfg.Database.RetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		sqlDB, err := db.DB()
+		if err == nil {
+			pingCtx, cancel := context.WithTimeout(ctx, cfg.Database.RetryInterval)
+			err = sqlDB.PingContext(pingCtx)
+			cancel()
+		}
+		if err == nil {
+			err = prepareDatabase(cfg, db)
+		}
+		if err != nil {
+			hlog.Warnf("[DEGRADED] database still unavailable, retrying in %v: %v", cfg.Database.RetryInterval, err)
+			continue
+		}
+
+		gate.SetReady()
+		return
+	}
+}
+
+// newAdminServer 按配置创建内部运维端口，与业务端口一同启动和停机；未配置时返回nil
+func newAdminServer(h *server.Hertz, cfg *config.Config) *server.Hertz {
+	if cfg.Admin.Address == "" {
+		return nil
+	}
+
+	admin := server.New(
+		server.WithHostPorts(cfg.Admin.Address),
+		server.WithReadTimeout(cfg.Server.ReadTimeout),
+		server.WithIdleTimeout(cfg.Server.IdleTimeout),
+	)
+	h.OnRun = append(h.OnRun, func(ctx context.Context) error {
+		go func() {
+			hlog.Infof("admin server listening on %s", cfg.Admin.Address)
+			if err := admin.Run(); err != nil {
+				hlog.Fatalf("admin server stopped: %v", err)
+			}
+		}()
+		return nil
+	})
+	h.OnShutdown = append(h.OnShutdown, func(ctx context.Context) {
+		if err := admin.Shutdown(ctx); err != nil {
+			hlog.CtxWarnf(ctx, "admin server shutdown failed: %v", err)
+		}
+	})
+	return admin
+}
+
+// applyLogLevel 设置应用日志级别，未配置时保持Hertz默认
+func applyLogLevel(cfg *config.Config) {
+	if level, ok := cfg.HlogLevel(); ok {
+		hlog.SetLevel(level)
+	}
+}
+
+// chmodWhenCreated 等待监听创建套接字文件后修改权限
+// 创建到修改之间文件权限受umask限制（通常仅属主可连接），不会短暂放宽访问
+func chmodWhenCreated(path string, mode os.FileMode) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			if err := os.Chmod(path, mode); err != nil {
+				hlog.Errorf("chmod unix socket %s failed: %v", path, err)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	hlog.Errorf("unix socket %s not created, permissions left unchanged", path)
 }
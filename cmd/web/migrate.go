@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/common/migrate"
+)
+
+// withRunner 连接数据库并执行迁移操作
+func withRunner(cfg *config.Config, run func(ctx context.Context, runner *migrate.Runner) error) int {
+	db, err := cfg.InitDB()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "connect database failed: %v\n", err)
+		return 1
+	}
+	runner, err := migrate.NewRunner(db)
+	if err == nil {
+		err = run(context.Background(), runner)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func migrateUp(ctx context.Context, runner *migrate.Runner) error {
+	done, err := runner.Up(ctx)
+	for _, m := range done {
+		fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+	}
+	if err == nil && len(done) == 0 {
+		fmt.Println("no pending migrations")
+	}
+	return err
+}
+
+func migrateStatus(ctx context.Context, runner *migrate.Runner) error {
+	pending, err := runner.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range pending {
+		fmt.Printf("pending %04d_%s\n", m.Version, m.Name)
+	}
+	if len(pending) == 0 {
+		fmt.Println("up to date")
+	}
+	return nil
+}
+
+func migrateDown(ctx context.Context, runner *migrate.Runner, steps int) error {
+	done, err := runner.Down(ctx, steps)
+	for _, m := range done {
+		fmt.Printf("rolled back %04d_%s\n", m.Version, m.Name)
+	}
+	return err
+}
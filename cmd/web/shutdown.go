@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// drainingSignalWaiter 替换Hertz默认的信号处理（其收到SIGTERM时直接退出，不执行优雅停机）
+// 收到停机信号后先调用markDraining让就绪探针失败，等待drainDelay让负载均衡摘除流量，
+// 再返回nil交由Hertz优雅停机：关闭监听并等待进行中的请求完成
+// reloadOnSIGHUP为true时SIGHUP留给配置热更新，否则与SIGINT、SIGTERM一样触发停机
+// failures为附加监听地址的故障通道，收到错误时与errCh一样不排空直接返回，进程随之退出
+func drainingSignalWaiter(markDraining func(), drainDelay time.Duration, reloadOnSIGHUP bool, failures <-chan error) func(errCh chan error) error {
+	return func(errCh chan error) error {
+		stopSignals := []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+		if !reloadOnSIGHUP {
+			stopSignals = append(stopSignals, syscall.SIGHUP)
+		}
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, stopSignals...)
+		defer signal.Stop(signals)
+
+		return waitAndDrain(signals, errCh, failures, markDraining, drainDelay)
+	}
+}
+
+// waitAndDrain 等待停机信号并完成排空；排空期间再次收到信号时跳过剩余等待立即停机
+// 服务自身出错（errCh）或附加监听地址故障（failures，可为nil）时不排空，直接返回错误
+func waitAndDrain(signals <-chan os.Signal, errCh chan error, failures <-chan error, markDraining func(), drainDelay time.Duration) error {
+	select {
+	case sig := <-signals:
+		hlog.Infof("Received signal: %s, draining for %s before shutdown", sig, drainDelay)
+	case err := <-errCh:
+		return err
+	case err := <-failures:
+		return err
+	}
+
+	markDraining()
+	timer := time.NewTimer(drainDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case sig := <-signals:
+		hlog.Warnf("Received signal: %s while draining, shutting down now", sig)
+	case err := <-errCh:
+		return err
+	case err := <-failures:
+		return err
+	}
+	return nil
+}
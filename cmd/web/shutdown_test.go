@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWaitAndDrainMarksBeforeDelayedShutdown(t *testing.T) {
+	signals := make(chan os.Signal, 1)
+	errCh := make(chan error, 1)
+	var markedAt atomic.Int64
+
+	const delay = 50 * time.Millisecond
+	done := make(chan error, 1)
+	go func() {
+		done <- waitAndDrain(signals, errCh, nil, func() { markedAt.Store(time.Now().UnixNano()) }, delay)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Expected waiter to block until a signal arrives")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if markedAt.Load() != 0 {
+		t.Fatal("Expected readiness untouched before a signal")
+	}
+
+	sentAt := time.Now()
+	signals <- syscall.SIGTERM
+	if err := <-done; err != nil {
+		t.Fatalf("Expected nil so the server shuts down gracefully, got %v", err)
+	}
+	returnedAt := time.Now()
+
+	marked := time.Unix(0, markedAt.Load())
+	if markedAt.Load() == 0 || marked.Before(sentAt) {
+		t.Fatal("Expected readiness to be marked after the signal")
+	}
+	if returnedAt.Sub(marked) < delay {
+		t.Fatalf("Expected shutdown to wait the drain delay after marking, waited %v", returnedAt.Sub(marked))
+	}
+}
+
+func TestWaitAndDrainSecondSignalSkipsDelay(t *testing.T) {
+	signals := make(chan os.Signal, 2)
+	signals <- syscall.SIGTERM
+	signals <- syscall.SIGINT
+
+	start := time.Now()
+	if err := waitAndDrain(signals, make(chan error), nil, func() {}, time.Minute); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected second signal to cut the drain short, took %v", elapsed)
+	}
+}
+
+func TestWaitAndDrainServerErrorSkipsDrain(t *testing.T) {
+	errCh := make(chan error, 1)
+	errCh <- errors.New("listen failed")
+
+	marked := false
+	if err := waitAndDrain(make(chan os.Signal), errCh, nil, func() { marked = true }, time.Minute); err == nil {
+		t.Fatal("Expected server error to be returned")
+	}
+	if marked {
+		t.Fatal("Expected no drain when the server itself failed")
+	}
+}
+
+func TestWaitAndDrainListenerFailureSkipsDrain(t *testing.T) {
+	failures := make(chan error, 1)
+	failures <- errors.New("listener on :8081 stopped")
+
+	marked := false
+	if err := waitAndDrain(make(chan os.Signal), make(chan error), failures, func() { marked = true }, time.Minute); err == nil {
+		t.Fatal("Expected listener failure to be returned")
+	}
+	if marked {
+		t.Fatal("Expected no drain when an additional listener failed")
+	}
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/golang-jwt/jwt/v5"
+	"my-digital-home/pkg/common/config"
+)
+
+// ErrInvalidToken 令牌无效（签名、算法、签发方或有效期校验失败）
+var ErrInvalidToken = errors.New("invalid token")
+
+// JWTService 访问令牌的签发与校验，签名算法、签发方、受众与有效期均取自配置
+type JWTService struct {
+	key      []byte
+	method   jwt.SigningMethod
+	issuer   string
+	audience string
+	ttl      time.Duration
+	leeway   time.Duration
+}
+
+// supportedMethods 可配置的签名算法（共享密钥，仅HMAC系列）
+var supportedMethods = map[string]jwt.SigningMethod{
+	"HS256": jwt.SigningMethodHS256,
+	"HS384": jwt.SigningMethodHS384,
+	"HS512": jwt.SigningMethodHS512,
+}
+
+// SigningMethod 将配置中的算法名映射为签名算法，不支持的取值返回错误
+func SigningMethod(name string) (jwt.SigningMethod, error) {
+	method, ok := supportedMethods[strings.ToUpper(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("unsupported JWT signing method %q (supported: HS256, HS384, HS512)", name)
+	}
+	return method, nil
+}
+
+func NewJWTService(cfg config.JWTAuthConfig) (*JWTService, error) {
+	method, err := SigningMethod(cfg.SigningMethod)
+	if err != nil {
+		return nil, err
+	}
+	return &JWTService{
+		key:      []byte(cfg.Secret),
+		method:   method,
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		ttl:      cfg.ExpireDuration,
+		leeway:   cfg.Leeway,
+	}, nil
+}
+
+// TTL 令牌有效期
+func (s *JWTService) TTL() time.Duration {
+	return s.ttl
+}
+
+// Issue 签发令牌，统一补充iss、aud、iat、exp与唯一的jti声明
+func (s *JWTService) Issue(claims jwt.MapClaims) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", fmt.Errorf("generate token id failed: %w", err)
+	}
+	now := time.Now()
+	payload := make(jwt.MapClaims, len(claims)+5)
+	for k, v := range claims {
+		payload[k] = v
+	}
+	payload["iss"] = s.issuer
+	payload["iat"] = now.Unix()
+	payload["exp"] = now.Add(s.ttl).Unix()
+	payload["jti"] = jti
+	if s.audience != "" {
+		payload["aud"] = s.audience
+	}
+
+	signed, err := jwt.NewWithClaims(s.method, payload).SignedString(s.key)
+	if err != nil {
+		return "", fmt.Errorf("sign token failed: %w", err)
+	}
+	return signed, nil
+}
+
+// Parse 校验令牌并返回声明：算法、签发方与受众（已配置时）须与配置一致，exp、iat与jti不可缺少；
+// exp、nbf与iat按配置的Leeway容忍时钟偏差
+func (s *JWTService) Parse(tokenStr string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{s.method.Alg()}),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(s.leeway),
+	}
+	if s.audience != "" {
+		opts = append(opts, jwt.WithAudience(s.audience))
+	}
+	if _, err := jwt.ParseWithClaims(tokenStr, claims, PinnedKeyFunc(s.method, s.key), opts...); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	// WithIssuedAt只校验iat不晚于当前时间，缺失时需单独拒绝
+	if iat, err := claims.GetIssuedAt(); err != nil || iat == nil {
+		return nil, fmt.Errorf("%w: missing iat claim", ErrInvalidToken)
+	}
+	if jti, _ := claims["jti"].(string); jti == "" {
+		return nil, fmt.Errorf("%w: missing jti claim", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+// newTokenID 生成16字节随机数的十六进制jti
+func newTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// PinnedKeyFunc 仅当令牌算法与预期完全一致时返回密钥，拒绝alg=none及算法混淆
+// 所有解析令牌的位置都应使用它，而不是无条件返回密钥
+func PinnedKeyFunc(method jwt.SigningMethod, key []byte) jwt.Keyfunc {
+	return func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok || t.Method.Alg() != method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %v, expected %s", t.Header["alg"], method.Alg())
+		}
+		return key, nil
+	}
+}
+
+// ClaimsKey JWT中间件校验通过后写入请求上下文的声明键
+const ClaimsKey = "jwt_claims"
+
+// ClaimsFromContext 读取JWT中间件写入的声明
+func ClaimsFromContext(c *app.RequestContext) (jwt.MapClaims, bool) {
+	value, exists := c.Get(ClaimsKey)
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(jwt.MapClaims)
+	return claims, ok
+}
@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"my-digital-home/pkg/common/config"
+)
+
+func testJWTConfig() config.JWTAuthConfig {
+	return config.JWTAuthConfig{
+		Secret:         "test-secret",
+		ExpireDuration: time.Hour,
+		Issuer:         "my-digital-home",
+		Audience:       "my-digital-home-api",
+		SigningMethod:  "HS384",
+	}
+}
+
+func newTestService(t *testing.T, cfg config.JWTAuthConfig) *JWTService {
+	t.Helper()
+	svc, err := NewJWTService(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+func TestIssueAndParseRoundTrip(t *testing.T) {
+	svc := newTestService(t, testJWTConfig())
+
+	token, err := svc.Issue(jwt.MapClaims{"user_id": 7, "roles": []string{"admin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := svc.Parse(token)
+	if err != nil {
+		t.Fatalf("Expected token to parse, got %v", err)
+	}
+	if claims["user_id"] != float64(7) || claims["iss"] != "my-digital-home" {
+		t.Fatalf("Unexpected claims: %v", claims)
+	}
+	exp, _ := claims.GetExpirationTime()
+	if d := time.Until(exp.Time); d <= 59*time.Minute || d > time.Hour {
+		t.Fatalf("Expected expiry from config, got %v", d)
+	}
+}
+
+func TestParseRejectsForeignIssuerAndExpiredTokens(t *testing.T) {
+	svc := newTestService(t, testJWTConfig())
+
+	other := testJWTConfig()
+	other.Issuer = "someone-else"
+	foreign, _ := newTestService(t, other).Issue(jwt.MapClaims{"user_id": 7})
+	if _, err := svc.Parse(foreign); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Expected foreign issuer to be rejected, got %v", err)
+	}
+
+	expiredCfg := testJWTConfig()
+	expiredCfg.ExpireDuration = -time.Minute
+	expired, _ := newTestService(t, expiredCfg).Issue(jwt.MapClaims{"user_id": 7})
+	if _, err := svc.Parse(expired); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Expected expired token to be rejected, got %v", err)
+	}
+}
+
+func TestIssueAddsUniqueIDAndAudience(t *testing.T) {
+	svc := newTestService(t, testJWTConfig())
+
+	first, _ := svc.Issue(jwt.MapClaims{"user_id": 7})
+	second, _ := svc.Issue(jwt.MapClaims{"user_id": 7})
+	a, err := svc.Parse(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := svc.Parse(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a["jti"] == "" || a["jti"] == b["jti"] {
+		t.Fatalf("Expected distinct jti per token, got %v and %v", a["jti"], b["jti"])
+	}
+	if aud, _ := a.GetAudience(); len(aud) != 1 || aud[0] != "my-digital-home-api" {
+		t.Fatalf("Expected configured audience, got %v", aud)
+	}
+}
+
+func TestParseRejectsWrongAudienceAndMissingClaims(t *testing.T) {
+	cfg := testJWTConfig()
+	svc := newTestService(t, cfg)
+
+	other := testJWTConfig()
+	other.Audience = "another-service"
+	foreign, _ := newTestService(t, other).Issue(jwt.MapClaims{"user_id": 7})
+	if _, err := svc.Parse(foreign); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Expected foreign audience to be rejected, got %v", err)
+	}
+
+	now := time.Now()
+	complete := jwt.MapClaims{
+		"user_id": 7,
+		"iss":     cfg.Issuer,
+		"aud":     cfg.Audience,
+		"iat":     now.Unix(),
+		"exp":     now.Add(time.Hour).Unix(),
+		"jti":     "token-1",
+	}
+	sign := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS384, claims).SignedString([]byte(cfg.Secret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	if _, err := svc.Parse(sign(complete)); err != nil {
+		t.Fatalf("Expected complete token to parse, got %v", err)
+	}
+	for _, claim := range []string{"iss", "aud", "iat", "exp", "jti"} {
+		claims := jwt.MapClaims{}
+		for k, v := range complete {
+			if k != claim {
+				claims[k] = v
+			}
+		}
+		if _, err := svc.Parse(sign(claims)); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("Expected token without %s to be rejected, got %v", claim, err)
+		}
+	}
+}
+
+func TestParseToleratesConfiguredClockSkew(t *testing.T) {
+	// 签发方时钟快了2秒：令牌在本机看来刚刚过期
+	expiredCfg := testJWTConfig()
+	expiredCfg.ExpireDuration = -2 * time.Second
+	token, _ := newTestService(t, expiredCfg).Issue(jwt.MapClaims{"user_id": 7})
+
+	if _, err := newTestService(t, testJWTConfig()).Parse(token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Expected strict validation by default, got %v", err)
+	}
+
+	lenient := testJWTConfig()
+	lenient.Leeway = 5 * time.Second
+	if _, err := newTestService(t, lenient).Parse(token); err != nil {
+		t.Fatalf("Expected token within leeway to parse, got %v", err)
+	}
+}
+
+func TestSigningMethodFromConfig(t *testing.T) {
+	for name, want := range map[string]jwt.SigningMethod{
+		"HS256": jwt.SigningMethodHS256,
+		"hs384": jwt.SigningMethodHS384,
+		"HS512": jwt.SigningMethodHS512,
+	} {
+		if got, err := SigningMethod(name); err != nil || got != want {
+			t.Fatalf("%s: expected %v, got %v err=%v", name, want.Alg(), got, err)
+		}
+	}
+
+	for _, name := range []string{"RS256", "none", ""} {
+		cfg := testJWTConfig()
+		cfg.SigningMethod = name
+		if _, err := NewJWTService(cfg); err == nil || !strings.Contains(err.Error(), "unsupported JWT signing method") {
+			t.Fatalf("%q: expected unsupported method error, got %v", name, err)
+		}
+	}
+}
+
+func TestIssueUsesConfiguredAlgorithm(t *testing.T) {
+	token, err := newTestService(t, testJWTConfig()).Issue(jwt.MapClaims{"user_id": 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil || parsed.Method.Alg() != "HS384" {
+		t.Fatalf("Expected HS384 token, got %v err=%v", parsed.Method.Alg(), err)
+	}
+}
+
+func TestParseRejectsNoneAndMismatchedAlgorithms(t *testing.T) {
+	cfg := testJWTConfig() // HS384
+	svc := newTestService(t, cfg)
+	claims := jwt.MapClaims{
+		"user_id": 7,
+		"iss":     cfg.Issuer,
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	}
+
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同一密钥、不同HMAC变体签名
+	otherHS, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString([]byte(cfg.Secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{"alg none": none, "HS512": otherHS} {
+		if _, err := svc.Parse(token); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("%s: expected token to be rejected, got %v", name, err)
+		}
+	}
+}
+
+func TestPinnedKeyFuncChecksAlgorithm(t *testing.T) {
+	keyFunc := PinnedKeyFunc(jwt.SigningMethodHS256, []byte("k"))
+
+	if _, err := keyFunc(&jwt.Token{Method: jwt.SigningMethodHS256}); err != nil {
+		t.Fatalf("Expected matching method to be accepted, got %v", err)
+	}
+	for _, method := range []jwt.SigningMethod{jwt.SigningMethodNone, jwt.SigningMethodHS512, jwt.SigningMethodRS256} {
+		token := &jwt.Token{Method: method, Header: map[string]interface{}{"alg": method.Alg()}}
+		if _, err := keyFunc(token); err == nil {
+			t.Fatalf("Expected %s to be rejected", method.Alg())
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package auth
+
+import "strings"
+
+// 权限名格式为"<资源>:<操作>"；"<资源>:*"表示该资源的全部操作，"*"表示全部权限
+const (
+	PermUsersRead     = "users:read"      // 查询用户
+	PermUsersWrite    = "users:write"     // 删除、恢复、导入用户
+	PermAPIKeysManage = "api_keys:manage" // 创建、吊销API Key
+	PermSystemManage  = "system:manage"   // 维护模式、数据库日志级别等运维开关
+	PermAll           = "*"
+)
+
+// defaultRolePermissions 内置角色的默认权限，配置中出现的角色整体替换对应条目
+// 普通用户只访问自己的资源，由JWT认证保护，不需要额外权限
+var defaultRolePermissions = map[string][]string{
+	"user":  {},
+	"admin": {PermUsersRead, PermUsersWrite, PermAPIKeysManage, PermSystemManage},
+}
+
+// RolePermissions 角色到权限列表的映射
+type RolePermissions map[string][]string
+
+// NewRolePermissions 以默认映射为基础合并配置：overrides中的角色替换默认条目，新角色直接加入
+func NewRolePermissions(overrides map[string][]string) RolePermissions {
+	roles := make(RolePermissions, len(defaultRolePermissions)+len(overrides))
+	for role, perms := range defaultRolePermissions {
+		roles[role] = perms
+	}
+	for role, perms := range overrides {
+		roles[role] = perms
+	}
+	return roles
+}
+
+// Grant 将roles拥有的权限并入set，未知角色忽略
+func (r RolePermissions) Grant(set PermissionSet, roles ...string) {
+	for _, role := range roles {
+		for _, perm := range r[role] {
+			set[perm] = true
+		}
+	}
+}
+
+// PermissionSet 调用方的有效权限
+type PermissionSet map[string]bool
+
+// Has 判断是否拥有perm，支持"*"与"<资源>:*"通配
+func (s PermissionSet) Has(perm string) bool {
+	if s[perm] || s[PermAll] {
+		return true
+	}
+	resource, _, ok := strings.Cut(perm, ":")
+	return ok && s[resource+":*"]
+}
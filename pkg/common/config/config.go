@@ -3,15 +3,18 @@ package config
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	logger2 "github.com/bytedance/gopkg/util/logger"
 	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol"
 	mysql2 "github.com/go-sql-driver/mysql" // 显式引入MySQL驱动包
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 	"io/ioutil"
+	"maps"
 	"net/url"
 	"os"
 	"strconv"
@@ -20,13 +23,87 @@ import (
 )
 
 type ServerConfig struct {
-	Address string `json:"address"`
+	Address    string `json:"address"`    // host:port，或unix:/path/to.sock监听Unix域套接字；逗号分隔多个地址时同时监听，第一个为主地址
+	SocketMode string `json:"socketMode"` // Unix套接字文件权限（八进制），如0660允许同组的nginx连接
+	// 连接级超时，防御慢速请求（slowloris）；与中间件的RequestTimeout（处理耗时）相互独立
+	ReadTimeout  time.Duration `json:"readTimeout"`  // 读取完整请求的最长时间
+	WriteTimeout time.Duration `json:"writeTimeout"` // 写出响应的最长时间，应大于RequestTimeout
+	IdleTimeout  time.Duration `json:"idleTimeout"`  // keep-alive连接的最长空闲时间
+	TLS          TLSConfig     `json:"tls"`
+	// 收到SIGHUP时重新加载可热更新的配置（限流、CORS、日志级别、维护模式），关闭时SIGHUP按Hertz默认行为优雅停机
+	ReloadOnSIGHUP bool `json:"reloadOnSIGHUP"`
+	// 收到停机信号后先让就绪探针失败，等待该时长让负载均衡摘除流量，再关闭监听与连接
+	ShutdownDrainDelay time.Duration `json:"shutdownDrainDelay"`
+}
+
+// unixAddrPrefix Address使用该前缀时监听Unix域套接字
+const unixAddrPrefix = "unix:"
+
+// ListenAddr 解析后的监听地址
+type ListenAddr struct {
+	Network string // tcp或unix
+	Addr    string
+}
+
+// Listen 解析主监听地址，返回网络类型（tcp/unix）与地址
+func (c ServerConfig) Listen() (network, addr string) {
+	primary := c.Listeners()[0]
+	return primary.Network, primary.Addr
+}
+
+// Listeners 解析Address中逗号分隔的全部监听地址，忽略空项；至少返回一项，第一项为主地址
+func (c ServerConfig) Listeners() []ListenAddr {
+	var listeners []ListenAddr
+	for _, address := range strings.Split(c.Address, ",") {
+		address = strings.TrimSpace(address)
+		if address == "" {
+			continue
+		}
+		if path, ok := strings.CutPrefix(address, unixAddrPrefix); ok {
+			listeners = append(listeners, ListenAddr{Network: "unix", Addr: path})
+		} else {
+			listeners = append(listeners, ListenAddr{Network: "tcp", Addr: address})
+		}
+	}
+	if len(listeners) == 0 {
+		// 与单地址时一致，交由Hertz按空地址处理
+		listeners = append(listeners, ListenAddr{Network: "tcp"})
+	}
+	return listeners
+}
+
+// SocketFileMode 解析SocketMode，未配置或格式错误时返回0表示保持默认权限
+func (c ServerConfig) SocketFileMode() os.FileMode {
+	mode, err := strconv.ParseUint(c.SocketMode, 8, 32)
+	if err != nil {
+		return 0
+	}
+	return os.FileMode(mode)
 }
 
 type SecurityConfig struct {
 	MaxBodySize    int64    `json:"maxBodySize"` // 单位：字节
 	AllowedHosts   []string `json:"allowedHosts"`
 	AllowedMethods []string `json:"allowedMethods"`
+	// 按路由模板（如/api/v1/users/login）覆盖MaxBodySize，单位：字节
+	RouteBodyLimits map[string]int64 `json:"routeBodyLimits"`
+	// 敏感操作（修改密码、注销账户）要求令牌在认证后的时间窗口内签发
+	RequireFreshAuth bool          `json:"requireFreshAuth"`
+	FreshAuthWindow  time.Duration `json:"freshAuthWindow"`
+	// 可信反向代理（CIDR），仅来自这些地址的X-Forwarded-For会被采信
+	TrustedProxies []string `json:"trustedProxies"`
+	// 密码哈希的bcrypt代价，调高后旧哈希在用户下次登录时自动升级
+	BcryptCost int `json:"bcryptCost"`
+}
+
+// MaxRequestBodySize 全局与各路由请求体上限中的最大值，作为Hertz服务端的请求体上限，
+// 避免Hertz默认的4MB先于中间件拒绝合法的大请求
+func (c SecurityConfig) MaxRequestBodySize() int64 {
+	size := c.MaxBodySize
+	for _, limit := range c.RouteBodyLimits {
+		size = max(size, limit)
+	}
+	return size
 }
 
 type TimeoutConfig struct {
@@ -46,9 +123,30 @@ type CORSConfig struct {
 type JWTAuthConfig struct {
 	Secret         string        `json:"secret"`
 	ExpireDuration time.Duration `json:"expireDuration"`
+	RefreshTTL     time.Duration `json:"refreshTTL"` // 刷新令牌（登录会话）有效期，不随刷新顺延
 	Issuer         string        `json:"issuer"`
+	Audience       string        `json:"audience"` // 令牌受众（aud），为空时不签发也不校验
 	SigningMethod  string        `json:"signingMethod"`
 	Realm          string        `json:"realm"` // JWT领域标识
+	// 校验exp、nbf时容忍的时钟偏差，用于多台服务器时钟略有差异的集群部署；0表示严格校验
+	Leeway time.Duration `json:"leeway"`
+	// 浏览器客户端：登录时下发httpOnly Cookie，认证时除请求头外也从Cookie读取令牌
+	CookieEnabled  bool   `json:"cookieEnabled"`
+	CookieName     string `json:"cookieName"`
+	CookieSecure   bool   `json:"cookieSecure"`
+	CookieSameSite string `json:"cookieSameSite"` // lax/strict/none
+}
+
+// SameSiteMode 将配置的SameSite取值转换为Hertz的Cookie属性，无法识别时按Lax处理
+func (c JWTAuthConfig) SameSiteMode() protocol.CookieSameSite {
+	switch strings.ToLower(c.CookieSameSite) {
+	case "strict":
+		return protocol.CookieSameSiteStrictMode
+	case "none":
+		return protocol.CookieSameSiteNoneMode
+	default:
+		return protocol.CookieSameSiteLaxMode
+	}
 }
 
 type RateLimitConfig struct {
@@ -56,12 +154,257 @@ type RateLimitConfig struct {
 	Interval time.Duration `json:"interval"`
 }
 
+// LoginRateLimitConfig 登录接口限流：每个来源IP、每个登录标识在Interval内最多尝试Rate次，Rate为0时不限制该维度
+type LoginRateLimitConfig struct {
+	PerIP      RateLimitConfig `json:"perIP"`
+	PerAccount RateLimitConfig `json:"perAccount"`
+}
+
+type MetricsConfig struct {
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path"` // 指标暴露路径
+	// 业务结果标签白名单，Handler上报的取值不在其中时统一记为other
+	AllowedOutcomes []string `json:"allowedOutcomes"`
+	// 活跃用户数指标的刷新间隔，为0时不采集
+	ActiveUsersInterval time.Duration `json:"activeUsersInterval"`
+	// 数据库连接池指标的采样间隔，为0时不采集
+	DBStatsInterval time.Duration `json:"dbStatsInterval"`
+}
+
+// IPFilterConfig 来源IP黑白名单（CIDR或单个IP），黑名单优先，白名单为空表示不限制
+type IPFilterConfig struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+// CSRFConfig 双提交Cookie防护，仅在令牌存放于Cookie时需要开启
+type CSRFConfig struct {
+	Enabled      bool   `json:"enabled"`
+	CookieName   string `json:"cookieName"`
+	HeaderName   string `json:"headerName"`
+	CookieSecure bool   `json:"cookieSecure"` // 仅通过HTTPS发送
+}
+
 type MiddlewareConfig struct {
 	Security  SecurityConfig  `json:"security"`
 	JWT       JWTAuthConfig   `json:"jwt"`
 	Timeout   TimeoutConfig   `json:"timeout"`
 	CORS      CORSConfig      `json:"cors"`
 	RateLimit RateLimitConfig `json:"rateLimit"`
+	Metrics   MetricsConfig   `json:"metrics"`
+	CSRF      CSRFConfig      `json:"csrf"`
+	IPFilter  IPFilterConfig  `json:"ipFilter"`      // 全局
+	AdminIP   IPFilterConfig  `json:"adminIPFilter"` // 仅管理员接口
+	Recovery  RecoveryConfig  `json:"recovery"`
+	SkipPaths SkipPathsConfig `json:"skipPaths"`
+}
+
+// SkipPathsConfig 各全局中间件额外跳过的路径前缀（如Webhook接收端），健康探针等内置路径始终跳过
+// 取注册路由时的值，修改后需重启
+type SkipPathsConfig struct {
+	Security  []string `json:"security"`
+	RateLimit []string `json:"rateLimit"`
+	Timeout   []string `json:"timeout"`
+}
+
+// RecoveryConfig panic捕获配置
+type RecoveryConfig struct {
+	// 写日志、返回开发环境响应前，对错误信息与堆栈执行的脱敏正则
+	// 匹配内容替换为"${1}***${2}"，即只保留第一、二个捕获组（如字段名与分隔符）
+	RedactPatterns []string `json:"redactPatterns"`
+}
+
+// MailConfig SMTP发信配置，Host为空时仅输出到日志
+type MailConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+	BaseURL  string `json:"baseURL"` // 邮件中链接的前缀，如 https://home.example.com
+}
+
+// RedisConfig Redis连接配置，Addr为空表示未启用Redis
+type RedisConfig struct {
+	Addr     string `json:"addr"` // host:port
+	Password string `json:"password"`
+}
+
+// HTTPClientConfig 调用外部服务的共享HTTP客户端
+type HTTPClientConfig struct {
+	ConnectTimeout time.Duration `json:"connectTimeout"` // 建立TCP连接与TLS握手的超时
+	RequestTimeout time.Duration `json:"requestTimeout"` // 单次尝试从发送到读完响应体的超时
+	MaxRetries     int           `json:"maxRetries"`     // 幂等请求失败后的最多重试次数，0表示不重试
+	RetryBackoff   time.Duration `json:"retryBackoff"`   // 首次重试前的等待，之后每次翻倍
+}
+
+// WebhookConfig 第三方回调（支付、邮件服务商等）的验签配置
+type WebhookConfig struct {
+	// 各接入方的签名密钥，键为接入方名称（对应/webhooks/{name}），只有配置了密钥的接入方才会注册
+	Secrets map[string]string `json:"secrets"`
+	// 签名时间戳与服务器时间的最大偏差，超出视为重放
+	Tolerance time.Duration `json:"tolerance"`
+}
+
+// GoogleIssuer Google的OIDC签发方，GOOGLE_*环境变量配置的提供方使用该地址
+const GoogleIssuer = "https://accounts.google.com"
+
+// OAuthConfig 第三方登录（OIDC授权码流程），未配置的提供方不开放登录入口
+type OAuthConfig struct {
+	// 键为提供方名称，对应/api/v1/auth/{name}/login与/api/v1/auth/{name}/callback
+	Providers map[string]OIDCProviderConfig `json:"providers"`
+	// 授权跳转到回调之间state Cookie的有效期
+	StateTTL time.Duration `json:"stateTTL"`
+}
+
+// OIDCProviderConfig 一个OIDC提供方（Keycloak、Google等），端点与签名公钥由签发方的发现文档获得
+type OIDCProviderConfig struct {
+	Issuer       string   `json:"issuer"` // 如https://sso.example.com/realms/main，须与ID令牌的iss一致
+	ClientID     string   `json:"clientId"`
+	ClientSecret string   `json:"clientSecret"`
+	RedirectURL  string   `json:"redirectUrl"` // 须与提供方登记的回调地址一致
+	Scopes       []string `json:"scopes"`      // 为空时使用openid email profile
+	// 信任提供方返回的全部邮箱，不要求email_verified（仅用于由管理员维护账户的内部身份系统）
+	TrustEmail bool `json:"trustEmail"`
+}
+
+// Enabled 签发方、客户端凭证与回调地址均已配置时启用
+func (c OIDCProviderConfig) Enabled() bool {
+	return c.Issuer != "" && c.ClientID != "" && c.ClientSecret != "" && c.RedirectURL != ""
+}
+
+type APIConfig struct {
+	// 批量接口部分成功时是否返回207 Multi-Status，关闭后返回200并仅在响应体中区分
+	UseMultiStatus bool `json:"useMultiStatus"`
+	// 携带Idempotency-Key的注册等请求，响应在该时长内可被重复请求复用
+	IdempotencyTTL time.Duration `json:"idempotencyTTL"`
+	// 实时通知WebSocket连接
+	WebSocket WebSocketConfig `json:"webSocket"`
+}
+
+// WebSocketConfig 实时通知连接的保活与缓冲设置
+type WebSocketConfig struct {
+	PingInterval   time.Duration `json:"pingInterval"`   // 服务端发送ping的间隔
+	PongTimeout    time.Duration `json:"pongTimeout"`    // 超过该时长未收到客户端任何帧（含pong）即断开，应大于PingInterval
+	SendBuffer     int           `json:"sendBuffer"`     // 每个连接待发送消息的缓冲条数，写满时丢弃新消息
+	MaxMessageSize int64         `json:"maxMessageSize"` // 客户端单条消息的字节上限
+}
+
+// StaticConfig 随二进制一起部署的前端静态文件，Dir为空时不提供
+type StaticConfig struct {
+	Dir       string `json:"dir"`       // 前端构建产物目录（含index.html）
+	URLPrefix string `json:"urlPrefix"` // 挂载路径，默认"/"
+}
+
+// StorageConfig 上传文件的存储位置
+type StorageConfig struct {
+	Backend   string          `json:"backend"`   // local（默认）或s3
+	Dir       string          `json:"dir"`       // local：存储根目录
+	URLPrefix string          `json:"urlPrefix"` // local：本服务提供已上传文件访问的路径前缀
+	S3        S3StorageConfig `json:"s3"`
+}
+
+// S3StorageConfig S3兼容对象存储（AWS S3、MinIO等），按路径风格访问 {Endpoint}/{Bucket}/{key}
+type S3StorageConfig struct {
+	Endpoint  string `json:"endpoint"` // 如 https://s3.us-east-1.amazonaws.com、http://minio:9000
+	Region    string `json:"region"`
+	Bucket    string `json:"bucket"`
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+	PublicURL string `json:"publicURL"` // 返回给客户端的地址前缀（如CDN），为空时使用 {Endpoint}/{Bucket}
+}
+
+// UploadConfig 上传文件的校验规则
+type UploadConfig struct {
+	AvatarMaxSize int64    `json:"avatarMaxSize"` // 头像文件字节上限，需小于Security.MaxBodySize
+	AvatarTypes   []string `json:"avatarTypes"`   // 允许的头像媒体类型，文件内容须与声明的类型一致
+}
+
+// DebugConfig 诊断工具配置，默认全部关闭
+type DebugConfig struct {
+	EnablePprof bool   `json:"enablePprof"`
+	PprofAddr   string `json:"pprofAddr"` // pprof独立监听地址，默认仅本机可访问
+	// 以debug级别记录请求/响应体，密码与令牌字段会被脱敏
+	LogBodies          bool `json:"logBodies"`
+	LogBodyMaxSize     int  `json:"logBodyMaxSize"`     // 单个请求/响应体最多记录的字节数
+	LogSensitiveBodies bool `json:"logSensitiveBodies"` // 是否记录登录、注册、密码相关接口的请求体，默认不记录
+}
+
+// MaintenanceConfig 维护模式，启动后可通过管理员接口切换
+type MaintenanceConfig struct {
+	Enabled    bool          `json:"enabled"`    // 启动时是否处于维护模式
+	RetryAfter time.Duration `json:"retryAfter"` // 503响应中的Retry-After
+}
+
+// AdminConfig 内部运维端口
+type AdminConfig struct {
+	// 设置后探针、指标、pprof与配置导出只在该地址提供，业务端口仅保留业务接口；为空时沿用单端口
+	Address string `json:"address"`
+}
+
+// AuthzConfig 细粒度权限配置
+type AuthzConfig struct {
+	// 角色到权限列表的映射，列出的角色整体替换内置默认值（见auth.NewRolePermissions），未列出的角色沿用默认
+	RolePermissions map[string][]string `json:"rolePermissions"`
+}
+
+// AuditConfig 审计日志异步写入配置
+type AuditConfig struct {
+	BufferSize int `json:"bufferSize"` // 待写入队列长度，写满时丢弃并告警
+}
+
+// BootstrapConfig 首次启动时创建的管理员账户，用户名或密码为空时不执行
+type BootstrapConfig struct {
+	AdminUsername string `json:"adminUsername"`
+	AdminEmail    string `json:"adminEmail"`
+	AdminPassword string `json:"adminPassword"`
+}
+
+// 已注销账户身份（用户名/邮箱）的处理策略
+const (
+	DeletedIdentityBlock   = "block"   // 禁止复用，注册时返回明确的冲突提示
+	DeletedIdentityRelease = "release" // 允许复用，注册前释放已注销账户占用的唯一值
+)
+
+// 用户名大小写策略
+const (
+	UsernameCaseLower    = "lower"    // 不区分大小写，统一按小写存储与比较
+	UsernameCasePreserve = "preserve" // 区分大小写，保留原样
+)
+
+// PasswordPolicyConfig 密码复杂度策略，零值表示不做任何限制
+type PasswordPolicyConfig struct {
+	MinLength      int      `json:"minLength"`
+	MaxLength      int      `json:"maxLength"` // 0表示不限制
+	RequireUpper   bool     `json:"requireUpper"`
+	RequireLower   bool     `json:"requireLower"`
+	RequireLetter  bool     `json:"requireLetter"` // 任意大小写字母
+	RequireDigit   bool     `json:"requireDigit"`
+	RequireSpecial bool     `json:"requireSpecial"`
+	Denylist       []string `json:"denylist"` // 常见弱密码，比较时不区分大小写
+	// 注册、修改与重置密码时查询泄露密码库，依赖外部服务，默认关闭
+	BreachCheck BreachCheckConfig `json:"breachCheck"`
+}
+
+// BreachCheckConfig 泄露密码检查（HaveIBeenPwned范围接口），接口不可用时放行并告警
+type BreachCheckConfig struct {
+	Enabled   bool          `json:"enabled"`
+	Threshold int           `json:"threshold"` // 泄露次数超过该值时拒绝，0表示出现过即拒绝
+	APIURL    string        `json:"apiURL"`    // 范围接口地址，请求时追加5位哈希前缀
+	Timeout   time.Duration `json:"timeout"`   // 单次检查（含重试）的总时限，避免拖慢注册
+}
+
+type AccountConfig struct {
+	DeletedIdentityPolicy string `json:"deletedIdentityPolicy"`
+	UsernameCase          string `json:"usernameCase"` // 邮箱始终不区分大小写
+	// 邮箱验证
+	RequireEmailVerification bool                 `json:"requireEmailVerification"` // 未验证邮箱的账户禁止登录
+	VerificationTokenTTL     time.Duration        `json:"verificationTokenTTL"`
+	ResetTokenTTL            time.Duration        `json:"resetTokenTTL"`  // 密码重置令牌有效期
+	EmailRateLimit           RateLimitConfig      `json:"emailRateLimit"` // 触发发信的接口（重发验证、找回密码）限流
+	LoginRateLimit           LoginRateLimitConfig `json:"loginRateLimit"` // 防暴力破解，在校验凭证前拦截
+	PasswordPolicy           PasswordPolicyConfig `json:"passwordPolicy"`
+	ImportBatchSize          int                  `json:"importBatchSize"` // 批量导入时每条INSERT的行数
 }
 
 // 新增数据库配置类型
@@ -75,49 +418,109 @@ type DatabaseConfig struct {
 	MinPoolSize int    `json:"minPoolSize"` // 连接池最小连接数
 	MaxPoolSize int    `json:"maxPoolSize"` // 连接池最大连接数
 	LogLevel    string `json:"logLevel"`    // GORM日志级别
+	SchemaCheck string `json:"schemaCheck"` // 启动时表结构校验：strict/warn/off，为空时按环境决定
+	AutoMigrate *bool  `json:"autoMigrate"` // 启动时自动建表/补字段，未配置时非生产环境开启；生产环境使用 migrate子命令执行版本化迁移
+	// 降级启动：启动时连接失败仍提供探针与指标接口，业务接口返回503，后台按RetryInterval重连
+	DegradedStart bool          `json:"degradedStart"`
+	RetryInterval time.Duration `json:"retryInterval"`
+	// 只读副本的完整DSN，配置后部分查询路由到副本，为空时只使用主库
+	Replicas []string `json:"replicas"`
+	// 执行时间超过SlowThreshold的SQL以warn级别记录（附带请求ID），0表示不单独记录慢查询
+	SlowThreshold time.Duration `json:"slowThreshold"`
+	// 日志中的SQL是否代入参数值，关闭时保留占位符，避免记录邮箱、密码哈希等数据
+	LogParams bool `json:"logParams"`
+	// 缓存预编译语句，省去重复解析SQL的开销；缓存按连接保存，内存随连接数与不同SQL数增长，
+	// 运行中会修改表结构的库（在线DDL等）应保持关闭，否则缓存的语句可能失效
+	PrepareStmt bool `json:"prepareStmt"`
+	// 单条写操作不再包裹默认事务，写入更快；仓储中需要原子性的多步操作仍显式使用事务
+	SkipDefaultTransaction bool `json:"skipDefaultTransaction"`
 }
 
+// 表结构校验模式
+const (
+	SchemaCheckStrict = "strict" // 不一致时拒绝启动
+	SchemaCheckWarn   = "warn"   // 不一致时仅告警
+	SchemaCheckOff    = "off"
+)
+
 type Config struct {
-	Server     ServerConfig     `json:"server"`
-	Database   DatabaseConfig   `json:"database"` // 新增数据库配置节点
-	Middleware MiddlewareConfig `json:"middleware"`
-	Env        string           `json:"env"` // 环境标识
+	Server      ServerConfig      `json:"server"`
+	Database    DatabaseConfig    `json:"database"` // 新增数据库配置节点
+	Middleware  MiddlewareConfig  `json:"middleware"`
+	Account     AccountConfig     `json:"account"`
+	API         APIConfig         `json:"api"`
+	Static      StaticConfig      `json:"static"`
+	Storage     StorageConfig     `json:"storage"`
+	Upload      UploadConfig      `json:"upload"`
+	Mail        MailConfig        `json:"mail"`
+	Redis       RedisConfig       `json:"redis"`
+	Webhook     WebhookConfig     `json:"webhook"`
+	OAuth       OAuthConfig       `json:"oauth"`
+	HTTPClient  HTTPClientConfig  `json:"httpClient"`
+	Debug       DebugConfig       `json:"debug"`
+	Maintenance MaintenanceConfig `json:"maintenance"`
+	Bootstrap   BootstrapConfig   `json:"bootstrap"`
+	Audit       AuditConfig       `json:"audit"`
+	Admin       AdminConfig       `json:"admin"`
+	Authz       AuthzConfig       `json:"authz"`
+	LogLevel    string            `json:"logLevel"` // 应用日志级别：trace/debug/info/notice/warn/error/fatal，为空时使用Hertz默认
+	Env         string            `json:"env"`      // 环境标识
 }
 
 var defaultConfig = Config{
 	Server: ServerConfig{
-		Address: ":8080",
+		Address:      ":8080",
+		SocketMode:   "0660",
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  60 * time.Second,
+		// 覆盖Kubernetes摘除Endpoints的传播时间
+		ShutdownDrainDelay: 5 * time.Second,
 	},
 	Database: DatabaseConfig{
-		Host:        "localhost",
-		Port:        3306,
-		Username:    "root",
-		Password:    "root",
-		DBName:      "app",
-		UseUnixSock: false,
-		MinPoolSize: 5,
-		MaxPoolSize: 50,
-		LogLevel:    "warn",
+		Host:          "localhost",
+		Port:          3306,
+		Username:      "root",
+		Password:      "root",
+		DBName:        "app",
+		UseUnixSock:   false,
+		MinPoolSize:   5,
+		MaxPoolSize:   50,
+		LogLevel:      "warn",
+		SlowThreshold: 200 * time.Millisecond,
+		RetryInterval: 5 * time.Second,
 	},
 	Middleware: MiddlewareConfig{
 		Security: SecurityConfig{
-			MaxBodySize:    10 << 20, // 10MB
-			AllowedMethods: []string{"GET", "POST", "PUT"},
+			MaxBodySize:     10 << 20, // 10MB
+			AllowedMethods:  []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+			FreshAuthWindow: 5 * time.Minute,
+			BcryptCost:      bcrypt.DefaultCost,
+			// 登录、注册只提交少量JSON字段
+			RouteBodyLimits: map[string]int64{
+				"/api/v1/users/login":    16 << 10,
+				"/api/v1/users/register": 16 << 10,
+			},
 		},
 		JWT: JWTAuthConfig{ // JWT默认配置
 			Secret:         "dev-secret-change-me-in-production", // 开发环境默认密钥
 			ExpireDuration: 24 * time.Hour,
+			RefreshTTL:     30 * 24 * time.Hour,
 			Issuer:         "my-digital-home",
+			Audience:       "my-digital-home-api",
 			SigningMethod:  "HS256",
+			CookieName:     "jwt",
+			CookieSecure:   true,
+			CookieSameSite: "lax",
 		},
 		Timeout: TimeoutConfig{
 			RequestTimeout: 15,
 		},
 		CORS: CORSConfig{
 			AllowOrigins:     []string{"http://localhost:3000"},
-			AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			AllowHeaders:     []string{"Content-Type", "Authorization", "X-Requested-With"},
-			ExposeHeaders:    []string{"Content-Length"},
+			AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowHeaders:     []string{"Content-Type", "Authorization", "X-Requested-With", "X-CSRF-Token", "Idempotency-Key", "If-None-Match"},
+			ExposeHeaders:    []string{"Content-Length", "ETag", "X-Request-ID", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
 			AllowCredentials: true,
 			MaxAge:           12 * time.Hour,
 			TrustedDomains:   []string{".dev.your-company.com"},
@@ -126,6 +529,107 @@ var defaultConfig = Config{
 			Rate:     10,
 			Interval: time.Second,
 		},
+		CSRF: CSRFConfig{
+			CookieName:   "csrf_token",
+			HeaderName:   "X-CSRF-Token",
+			CookieSecure: true,
+		},
+		Recovery: RecoveryConfig{
+			RedactPatterns: []string{
+				`(?i)((?:password|passwd|pwd|secret|token|api[_-]?key)["']?\s*[=:]\s*["']?)[^\s"'&,;)]+`,
+				`(?i)(authorization["']?\s*[=:]\s*["']?)(?:(?:bearer|basic)\s+)?[^\s"',;)]+`,
+				`([\w.%-]+:)[^@\s/]+(@)`, // DSN中的账号密码，如 root:pass@tcp(...)
+			},
+		},
+		Metrics: MetricsConfig{
+			Enabled:             true,
+			Path:                "/metrics",
+			ActiveUsersInterval: time.Minute,
+			DBStatsInterval:     15 * time.Second,
+			AllowedOutcomes: []string{
+				"login_success",
+				"login_failure",
+				"register_success",
+				"register_failure",
+			},
+		},
+	},
+	Account: AccountConfig{
+		DeletedIdentityPolicy: DeletedIdentityBlock,
+		UsernameCase:          UsernameCaseLower,
+		PasswordPolicy: PasswordPolicyConfig{
+			MinLength:      8,
+			RequireLetter:  true,
+			RequireDigit:   true,
+			RequireSpecial: true,
+			BreachCheck: BreachCheckConfig{
+				APIURL:  "https://api.pwnedpasswords.com/range/",
+				Timeout: 3 * time.Second,
+			},
+		},
+		ImportBatchSize:      100,
+		VerificationTokenTTL: 24 * time.Hour,
+		ResetTokenTTL:        30 * time.Minute,
+		EmailRateLimit: RateLimitConfig{
+			Rate:     5,
+			Interval: time.Minute,
+		},
+		LoginRateLimit: LoginRateLimitConfig{
+			PerIP:      RateLimitConfig{Rate: 20, Interval: time.Minute}, // 多人共用出口IP时需放宽
+			PerAccount: RateLimitConfig{Rate: 5, Interval: time.Minute},
+		},
+	},
+	API: APIConfig{
+		UseMultiStatus: true,
+		IdempotencyTTL: 24 * time.Hour,
+		WebSocket: WebSocketConfig{
+			PingInterval:   30 * time.Second,
+			PongTimeout:    60 * time.Second,
+			SendBuffer:     16,
+			MaxMessageSize: 4096,
+		},
+	},
+	Static: StaticConfig{
+		URLPrefix: "/",
+	},
+	Webhook: WebhookConfig{
+		Tolerance: 5 * time.Minute,
+	},
+	OAuth: OAuthConfig{
+		StateTTL: 10 * time.Minute,
+	},
+	HTTPClient: HTTPClientConfig{
+		ConnectTimeout: 5 * time.Second,
+		RequestTimeout: 15 * time.Second,
+		MaxRetries:     2,
+		RetryBackoff:   200 * time.Millisecond,
+	},
+	Storage: StorageConfig{
+		Backend:   "local",
+		Dir:       "./data/uploads",
+		URLPrefix: "/uploads",
+		S3: S3StorageConfig{
+			Region: "us-east-1",
+		},
+	},
+	Upload: UploadConfig{
+		AvatarMaxSize: 2 << 20, // 2MB
+		AvatarTypes:   []string{"image/png", "image/jpeg", "image/gif", "image/webp"},
+	},
+	Mail: MailConfig{
+		Port:    587,
+		From:    "no-reply@my-digital-home.local",
+		BaseURL: "http://localhost:8080",
+	},
+	Debug: DebugConfig{
+		PprofAddr:      "127.0.0.1:6060",
+		LogBodyMaxSize: 2048,
+	},
+	Maintenance: MaintenanceConfig{
+		RetryAfter: 5 * time.Minute,
+	},
+	Audit: AuditConfig{
+		BufferSize: 1024,
 	},
 	Env: "development",
 }
@@ -135,24 +639,109 @@ func (c *Config) IsProd() bool {
 	return c.Env == "production"
 }
 
+// redactedMask 脱敏后的占位值
+const redactedMask = "***"
+
+// Redacted 返回隐藏了密钥与密码的副本，用于日志或诊断接口输出
+// 未配置（为空）的字段保持为空，便于确认是否生效
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	mask := func(s *string) {
+		if *s != "" {
+			*s = redactedMask
+		}
+	}
+	mask(&redacted.Middleware.JWT.Secret)
+	mask(&redacted.Database.Password)
+	mask(&redacted.Mail.Password)
+	mask(&redacted.Redis.Password)
+	mask(&redacted.Bootstrap.AdminPassword)
+	mask(&redacted.Storage.S3.SecretKey)
+	// 复制map避免改动原配置
+	if len(c.Webhook.Secrets) > 0 {
+		redacted.Webhook.Secrets = make(map[string]string, len(c.Webhook.Secrets))
+		for name := range c.Webhook.Secrets {
+			redacted.Webhook.Secrets[name] = redactedMask
+		}
+	}
+	if len(c.OAuth.Providers) > 0 {
+		redacted.OAuth.Providers = make(map[string]OIDCProviderConfig, len(c.OAuth.Providers))
+		for name, provider := range c.OAuth.Providers {
+			mask(&provider.ClientSecret)
+			redacted.OAuth.Providers[name] = provider
+		}
+	}
+	// 副本DSN包含账号密码，整体隐藏；复制切片避免改动原配置
+	if len(c.Database.Replicas) > 0 {
+		redacted.Database.Replicas = make([]string, len(c.Database.Replicas))
+		for i := range redacted.Database.Replicas {
+			redacted.Database.Replicas[i] = redactedMask
+		}
+	}
+	return &redacted
+}
+
+// SchemaCheckMode 表结构校验模式（未显式配置时生产环境严格校验，其余环境仅告警）
+func (c *Config) SchemaCheckMode() string {
+	if c.Database.SchemaCheck != "" {
+		return c.Database.SchemaCheck
+	}
+	if c.IsProd() {
+		return SchemaCheckStrict
+	}
+	return SchemaCheckWarn
+}
+
+// AutoMigrateEnabled 启动时是否执行AutoMigrate（未显式配置时仅非生产环境执行）
+func (c *Config) AutoMigrateEnabled() bool {
+	if c.Database.AutoMigrate != nil {
+		return *c.Database.AutoMigrate
+	}
+	return !c.IsProd()
+}
+
+// Default 返回默认配置的副本，不读取配置文件与环境变量（用于测试）
+func Default() *Config {
+	config := defaultConfig
+	config.Middleware.Security.RouteBodyLimits = maps.Clone(defaultConfig.Middleware.Security.RouteBodyLimits)
+	return &config
+}
+
 // Load 加载配置（优先级：环境变量 > 配置文件 > 默认值）
 func Load() *Config {
+	config, err := loadConfig()
+	if err != nil {
+		// 启动时配置文件不可用仍以默认值与环境变量启动
+		hlog.Warnf("Failed to load config file: %v", err)
+	}
+	return config
+}
+
+// loadConfig 依次合并默认值、配置文件与环境变量；配置文件读取或解析失败时返回错误，
+// 同时返回跳过文件后的结果，由调用方决定是否采用（热更新时拒绝，避免把运行中的配置重置为默认值）
+func loadConfig() (*Config, error) {
 	config := defaultConfig
+	// 配置文件与环境变量会向map中合并键，复制一份避免改动默认值
+	config.Middleware.Security.RouteBodyLimits = maps.Clone(defaultConfig.Middleware.Security.RouteBodyLimits)
 
 	// 1. 尝试从配置文件加载
+	var fileErr error
 	configPath := getConfigPath()
 	logger2.Infof("Config file path: %s", configPath)
 	if configPath != "" {
 		logger2.Infof("Loading config from file: %s", configPath)
 		if err := loadFromFile(&config, configPath); err != nil {
-			hlog.Warnf("Failed to load config file: %v", err)
+			fileErr = fmt.Errorf("%s: %w", configPath, err)
 		}
 	}
 
 	// 2. 从环境变量覆盖
 	loadFromEnv(&config)
 
-	return &config
+	// 3. 校验取值范围
+	validate(&config)
+
+	return &config, fileErr
 }
 
 // getConfigPath 获取配置文件路径
@@ -195,11 +784,281 @@ func loadFromEnv(config *Config) {
 		config.Server.Address = v
 	}
 
+	if v := os.Getenv("RELOAD_ON_SIGHUP"); v != "" {
+		config.Server.ReloadOnSIGHUP = parseBool(v)
+	}
+
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		config.LogLevel = strings.ToLower(v)
+	}
+
+	if v := os.Getenv("SERVER_SOCKET_MODE"); v != "" {
+		config.Server.SocketMode = v
+	}
+
+	if v := os.Getenv("SERVER_READ_TIMEOUT"); v != "" {
+		if timeout, err := time.ParseDuration(v); err == nil {
+			config.Server.ReadTimeout = timeout
+		} else {
+			hlog.Warnf("Invalid SERVER_READ_TIMEOUT format: %v", err)
+		}
+	}
+
+	if v := os.Getenv("SERVER_WRITE_TIMEOUT"); v != "" {
+		if timeout, err := time.ParseDuration(v); err == nil {
+			config.Server.WriteTimeout = timeout
+		} else {
+			hlog.Warnf("Invalid SERVER_WRITE_TIMEOUT format: %v", err)
+		}
+	}
+
+	if v := os.Getenv("SERVER_IDLE_TIMEOUT"); v != "" {
+		if timeout, err := time.ParseDuration(v); err == nil {
+			config.Server.IdleTimeout = timeout
+		} else {
+			hlog.Warnf("Invalid SERVER_IDLE_TIMEOUT format: %v", err)
+		}
+	}
+
+	if v := os.Getenv("SHUTDOWN_DRAIN_DELAY"); v != "" {
+		if delay, err := time.ParseDuration(v); err == nil {
+			config.Server.ShutdownDrainDelay = delay
+		} else {
+			hlog.Warnf("Invalid SHUTDOWN_DRAIN_DELAY format: %v", err)
+		}
+	}
+
+	if v := os.Getenv("TLS_ENABLED"); v != "" {
+		config.Server.TLS.Enabled = parseBool(v)
+	}
+
+	if v := os.Getenv("TLS_CERT_FILE"); v != "" {
+		config.Server.TLS.CertFile = v
+	}
+
+	if v := os.Getenv("TLS_KEY_FILE"); v != "" {
+		config.Server.TLS.KeyFile = v
+	}
+
+	if v := os.Getenv("TLS_MIN_VERSION"); v != "" {
+		config.Server.TLS.MinVersion = v
+	}
+
+	if v := os.Getenv("TLS_CIPHER_SUITES"); v != "" {
+		config.Server.TLS.CipherSuites = splitEnvList(v)
+	}
+
+	if v := os.Getenv("TLS_REDIRECT_ADDR"); v != "" {
+		config.Server.TLS.RedirectAddr = v
+	}
+
 	// 环境配置
 	if v := os.Getenv("APP_ENV"); v != "" {
 		config.Env = v
 	}
 
+	// 账户策略配置
+	if v := os.Getenv("DELETED_IDENTITY_POLICY"); v != "" {
+		switch policy := strings.ToLower(v); policy {
+		case DeletedIdentityBlock, DeletedIdentityRelease:
+			config.Account.DeletedIdentityPolicy = policy
+		default:
+			hlog.Warnf("Unsupported deleted identity policy: %s", v)
+		}
+	}
+
+	if v := os.Getenv("USERNAME_CASE"); v != "" {
+		switch policy := strings.ToLower(v); policy {
+		case UsernameCaseLower, UsernameCasePreserve:
+			config.Account.UsernameCase = policy
+		default:
+			hlog.Warnf("Unsupported username case policy: %s", v)
+		}
+	}
+
+	if v := os.Getenv("REQUIRE_EMAIL_VERIFICATION"); v != "" {
+		config.Account.RequireEmailVerification = parseBool(v)
+	}
+
+	if v := os.Getenv("PASSWORD_MIN_LENGTH"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			config.Account.PasswordPolicy.MinLength = n
+		}
+	}
+
+	if v := os.Getenv("PASSWORD_MAX_LENGTH"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			config.Account.PasswordPolicy.MaxLength = n
+		}
+	}
+
+	if v := os.Getenv("PASSWORD_DENYLIST"); v != "" {
+		config.Account.PasswordPolicy.Denylist = splitEnvList(v)
+	}
+
+	if v := os.Getenv("PASSWORD_BREACH_CHECK"); v != "" {
+		config.Account.PasswordPolicy.BreachCheck.Enabled = parseBool(v)
+	}
+	if v := os.Getenv("PASSWORD_BREACH_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			config.Account.PasswordPolicy.BreachCheck.Threshold = n
+		} else {
+			hlog.Warnf("Invalid PASSWORD_BREACH_THRESHOLD: %s", v)
+		}
+	}
+	if v := os.Getenv("PASSWORD_BREACH_API_URL"); v != "" {
+		config.Account.PasswordPolicy.BreachCheck.APIURL = v
+	}
+	if v := os.Getenv("PASSWORD_BREACH_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			config.Account.PasswordPolicy.BreachCheck.Timeout = d
+		} else {
+			hlog.Warnf("Invalid PASSWORD_BREACH_TIMEOUT format: %v", err)
+		}
+	}
+
+	// 邮件配置
+	if v := os.Getenv("SMTP_HOST"); v != "" {
+		config.Mail.Host = v
+	}
+
+	if v := os.Getenv("SMTP_PORT"); v != "" {
+		if port, err := strconv.Atoi(v); err == nil {
+			config.Mail.Port = port
+		}
+	}
+
+	if v := os.Getenv("SMTP_USER"); v != "" {
+		config.Mail.Username = v
+	}
+
+	if v := os.Getenv("SMTP_PASSWORD"); v != "" {
+		config.Mail.Password = v
+	}
+
+	if v := os.Getenv("MAIL_FROM"); v != "" {
+		config.Mail.From = v
+	}
+
+	if v := os.Getenv("MAIL_BASE_URL"); v != "" {
+		config.Mail.BaseURL = strings.TrimRight(v, "/")
+	}
+
+	if v := os.Getenv("REDIS_ADDR"); v != "" {
+		config.Redis.Addr = v
+	}
+	if v := os.Getenv("REDIS_PASSWORD"); v != "" {
+		config.Redis.Password = v
+	}
+
+	// ROLE_PERMISSIONS_<ROLE>：逗号分隔的权限列表，角色名取后缀的小写形式，如ROLE_PERMISSIONS_SUPPORT=users:read
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if role, ok := strings.CutPrefix(key, "ROLE_PERMISSIONS_"); ok && role != "" {
+			if config.Authz.RolePermissions == nil {
+				config.Authz.RolePermissions = make(map[string][]string)
+			}
+			config.Authz.RolePermissions[strings.ToLower(role)] = splitEnvList(value)
+		}
+	}
+
+	// WEBHOOK_SECRET_<NAME>：接入方名称取后缀的小写形式，如WEBHOOK_SECRET_STRIPE对应/webhooks/stripe
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if name, ok := strings.CutPrefix(key, "WEBHOOK_SECRET_"); ok && name != "" && value != "" {
+			if config.Webhook.Secrets == nil {
+				config.Webhook.Secrets = make(map[string]string)
+			}
+			config.Webhook.Secrets[strings.ToLower(name)] = value
+		}
+	}
+
+	if v := os.Getenv("HTTP_CLIENT_CONNECT_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			config.HTTPClient.ConnectTimeout = d
+		} else {
+			hlog.Warnf("Invalid HTTP_CLIENT_CONNECT_TIMEOUT format: %v", err)
+		}
+	}
+	if v := os.Getenv("HTTP_CLIENT_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			config.HTTPClient.RequestTimeout = d
+		} else {
+			hlog.Warnf("Invalid HTTP_CLIENT_TIMEOUT format: %v", err)
+		}
+	}
+	if v := os.Getenv("HTTP_CLIENT_MAX_RETRIES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			config.HTTPClient.MaxRetries = n
+		}
+	}
+	if v := os.Getenv("HTTP_CLIENT_RETRY_BACKOFF"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			config.HTTPClient.RetryBackoff = d
+		} else {
+			hlog.Warnf("Invalid HTTP_CLIENT_RETRY_BACKOFF format: %v", err)
+		}
+	}
+
+	if v := os.Getenv("WEBHOOK_TOLERANCE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			config.Webhook.Tolerance = d
+		} else {
+			hlog.Warnf("Invalid WEBHOOK_TOLERANCE format: %v", err)
+		}
+	}
+
+	// OIDC_<NAME>_<FIELD>：提供方名称取中间部分的小写形式，如OIDC_KEYCLOAK_ISSUER对应/api/v1/auth/keycloak
+	// GOOGLE_CLIENT_ID等为Google的简写，签发方固定为GoogleIssuer
+	oidcFields := []string{"_ISSUER", "_CLIENT_ID", "_CLIENT_SECRET", "_REDIRECT_URL", "_SCOPES", "_TRUST_EMAIL"}
+	setOIDC := func(name, field, value string) {
+		if config.OAuth.Providers == nil {
+			config.OAuth.Providers = make(map[string]OIDCProviderConfig)
+		}
+		provider := config.OAuth.Providers[name]
+		switch field {
+		case "_ISSUER":
+			provider.Issuer = value
+		case "_CLIENT_ID":
+			provider.ClientID = value
+		case "_CLIENT_SECRET":
+			provider.ClientSecret = value
+		case "_REDIRECT_URL":
+			provider.RedirectURL = value
+		case "_SCOPES":
+			provider.Scopes = splitEnvList(value)
+		case "_TRUST_EMAIL":
+			provider.TrustEmail = parseBool(value)
+		}
+		config.OAuth.Providers[name] = provider
+	}
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if value == "" {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(key, "OIDC_"); ok {
+			for _, field := range oidcFields {
+				if name, ok := strings.CutSuffix(rest, field); ok && name != "" {
+					setOIDC(strings.ToLower(name), field, value)
+					break
+				}
+			}
+		}
+		switch key {
+		case "GOOGLE_CLIENT_ID", "GOOGLE_CLIENT_SECRET", "GOOGLE_REDIRECT_URL":
+			setOIDC("google", "_ISSUER", GoogleIssuer)
+			setOIDC("google", strings.TrimPrefix(key, "GOOGLE"), value)
+		}
+	}
+	if v := os.Getenv("OAUTH_STATE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			config.OAuth.StateTTL = d
+		} else {
+			hlog.Warnf("Invalid OAUTH_STATE_TTL format: %v", err)
+		}
+	}
+
 	// 中间件配置
 	if v := os.Getenv("MAX_BODY_SIZE"); v != "" {
 		if size, err := strconv.ParseInt(v, 10, 64); err == nil {
@@ -207,6 +1066,35 @@ func loadFromEnv(config *Config) {
 		}
 	}
 
+	// ROUTE_BODY_LIMITS：逗号分隔的"路由模板=字节数"，如/api/v1/users/login=16384,/api/v1/users/me/avatar=3145728
+	// 与默认值合并，同一路由以环境变量为准
+	if v := os.Getenv("ROUTE_BODY_LIMITS"); v != "" {
+		for _, item := range splitEnvList(v) {
+			route, limit, _ := strings.Cut(item, "=")
+			size, err := strconv.ParseInt(strings.TrimSpace(limit), 10, 64)
+			if err != nil || size <= 0 {
+				hlog.Warnf("Invalid ROUTE_BODY_LIMITS entry: %s", item)
+				continue
+			}
+			if config.Middleware.Security.RouteBodyLimits == nil {
+				config.Middleware.Security.RouteBodyLimits = make(map[string]int64)
+			}
+			config.Middleware.Security.RouteBodyLimits[strings.TrimSpace(route)] = size
+		}
+	}
+
+	if v := os.Getenv("REQUIRE_FRESH_AUTH"); v != "" {
+		config.Middleware.Security.RequireFreshAuth = parseBool(v)
+	}
+
+	if v := os.Getenv("FRESH_AUTH_WINDOW"); v != "" {
+		if window, err := time.ParseDuration(v); err == nil {
+			config.Middleware.Security.FreshAuthWindow = window
+		} else {
+			hlog.Warnf("Invalid FRESH_AUTH_WINDOW format: %v", err)
+		}
+	}
+
 	if v := os.Getenv("REQUEST_TIMEOUT"); v != "" {
 		if timeout, err := strconv.Atoi(v); err == nil {
 			config.Middleware.Timeout.RequestTimeout = timeout
@@ -219,6 +1107,226 @@ func loadFromEnv(config *Config) {
 		}
 	}
 
+	if v := os.Getenv("SECURITY_SKIP_PATHS"); v != "" {
+		config.Middleware.SkipPaths.Security = splitEnvList(v)
+	}
+	if v := os.Getenv("RATE_LIMIT_SKIP_PATHS"); v != "" {
+		config.Middleware.SkipPaths.RateLimit = splitEnvList(v)
+	}
+	if v := os.Getenv("TIMEOUT_SKIP_PATHS"); v != "" {
+		config.Middleware.SkipPaths.Timeout = splitEnvList(v)
+	}
+
+	if v := os.Getenv("IDEMPOTENCY_TTL"); v != "" {
+		if ttl, err := time.ParseDuration(v); err == nil {
+			config.API.IdempotencyTTL = ttl
+		} else {
+			hlog.Warnf("Invalid IDEMPOTENCY_TTL format: %v", err)
+		}
+	}
+
+	if v := os.Getenv("STATIC_DIR"); v != "" {
+		config.Static.Dir = v
+	}
+	if v := os.Getenv("STATIC_URL_PREFIX"); v != "" {
+		config.Static.URLPrefix = v
+	}
+
+	if v := os.Getenv("STORAGE_DIR"); v != "" {
+		config.Storage.Dir = v
+	}
+	if v := os.Getenv("STORAGE_URL_PREFIX"); v != "" {
+		config.Storage.URLPrefix = v
+	}
+	if v := os.Getenv("STORAGE_BACKEND"); v != "" {
+		config.Storage.Backend = v
+	}
+	if v := os.Getenv("S3_ENDPOINT"); v != "" {
+		config.Storage.S3.Endpoint = v
+	}
+	if v := os.Getenv("S3_REGION"); v != "" {
+		config.Storage.S3.Region = v
+	}
+	if v := os.Getenv("S3_BUCKET"); v != "" {
+		config.Storage.S3.Bucket = v
+	}
+	if v := os.Getenv("S3_ACCESS_KEY"); v != "" {
+		config.Storage.S3.AccessKey = v
+	}
+	if v := os.Getenv("S3_SECRET_KEY"); v != "" {
+		config.Storage.S3.SecretKey = v
+	}
+	if v := os.Getenv("S3_PUBLIC_URL"); v != "" {
+		config.Storage.S3.PublicURL = v
+	}
+	if v := os.Getenv("AVATAR_MAX_SIZE"); v != "" {
+		if size, err := strconv.ParseInt(v, 10, 64); err == nil {
+			config.Upload.AvatarMaxSize = size
+		}
+	}
+	if v := os.Getenv("AVATAR_TYPES"); v != "" {
+		config.Upload.AvatarTypes = splitEnvList(v)
+	}
+
+	if v := os.Getenv("WS_PING_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			config.API.WebSocket.PingInterval = d
+		} else {
+			hlog.Warnf("Invalid WS_PING_INTERVAL format: %v", err)
+		}
+	}
+
+	if v := os.Getenv("WS_PONG_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			config.API.WebSocket.PongTimeout = d
+		} else {
+			hlog.Warnf("Invalid WS_PONG_TIMEOUT format: %v", err)
+		}
+	}
+
+	if v := os.Getenv("LOGIN_RATE_LIMIT_IP"); v != "" {
+		if rate, err := strconv.Atoi(v); err == nil {
+			config.Account.LoginRateLimit.PerIP.Rate = rate
+		}
+	}
+
+	if v := os.Getenv("LOGIN_RATE_LIMIT_ACCOUNT"); v != "" {
+		if rate, err := strconv.Atoi(v); err == nil {
+			config.Account.LoginRateLimit.PerAccount.Rate = rate
+		}
+	}
+
+	if v := os.Getenv("LOGIN_RATE_LIMIT_WINDOW"); v != "" {
+		if window, err := time.ParseDuration(v); err == nil {
+			config.Account.LoginRateLimit.PerIP.Interval = window
+			config.Account.LoginRateLimit.PerAccount.Interval = window
+		} else {
+			hlog.Warnf("Invalid LOGIN_RATE_LIMIT_WINDOW format: %v", err)
+		}
+	}
+
+	if v := os.Getenv("BCRYPT_COST"); v != "" {
+		if cost, err := strconv.Atoi(v); err == nil {
+			config.Middleware.Security.BcryptCost = cost
+		}
+	}
+
+	if v := os.Getenv("TRUSTED_PROXIES"); v != "" {
+		config.Middleware.Security.TrustedProxies = splitEnvList(v)
+	}
+
+	if v := os.Getenv("IP_DENY"); v != "" {
+		config.Middleware.IPFilter.Deny = splitEnvList(v)
+	}
+
+	if v := os.Getenv("ADMIN_IP_ALLOW"); v != "" {
+		config.Middleware.AdminIP.Allow = splitEnvList(v)
+	}
+
+	// 跨域配置
+	if v := os.Getenv("CORS_ALLOW_ORIGINS"); v != "" {
+		config.Middleware.CORS.AllowOrigins = splitEnvList(v)
+	}
+
+	if v := os.Getenv("CORS_TRUSTED_DOMAINS"); v != "" {
+		config.Middleware.CORS.TrustedDomains = splitEnvList(v)
+	}
+
+	if v := os.Getenv("CORS_ALLOW_METHODS"); v != "" {
+		config.Middleware.CORS.AllowMethods = splitEnvList(v)
+	}
+
+	if v := os.Getenv("CORS_ALLOW_HEADERS"); v != "" {
+		config.Middleware.CORS.AllowHeaders = splitEnvList(v)
+	}
+
+	if v := os.Getenv("CORS_EXPOSE_HEADERS"); v != "" {
+		config.Middleware.CORS.ExposeHeaders = splitEnvList(v)
+	}
+
+	if v := os.Getenv("CORS_ALLOW_CREDENTIALS"); v != "" {
+		config.Middleware.CORS.AllowCredentials = parseBool(v)
+	}
+
+	if v := os.Getenv("CORS_MAX_AGE"); v != "" {
+		if maxAge, err := time.ParseDuration(v); err == nil {
+			config.Middleware.CORS.MaxAge = maxAge
+		} else {
+			hlog.Warnf("Invalid CORS_MAX_AGE format: %v", err)
+		}
+	}
+
+	// 逗号分隔，单个正则中不能包含逗号
+	if v := os.Getenv("PANIC_REDACT_PATTERNS"); v != "" {
+		config.Middleware.Recovery.RedactPatterns = splitEnvList(v)
+	}
+
+	if v := os.Getenv("CSRF_ENABLED"); v != "" {
+		config.Middleware.CSRF.Enabled = parseBool(v)
+	}
+
+	if v := os.Getenv("METRICS_ENABLED"); v != "" {
+		config.Middleware.Metrics.Enabled = parseBool(v)
+	}
+
+	if v := os.Getenv("METRICS_DB_STATS_INTERVAL"); v != "" {
+		if interval, err := time.ParseDuration(v); err == nil {
+			config.Middleware.Metrics.DBStatsInterval = interval
+		} else {
+			hlog.Warnf("Invalid METRICS_DB_STATS_INTERVAL format: %v", err)
+		}
+	}
+
+	if v := os.Getenv("MAINTENANCE_MODE"); v != "" {
+		config.Maintenance.Enabled = parseBool(v)
+	}
+
+	// 初始管理员
+	if v := os.Getenv("BOOTSTRAP_ADMIN_USERNAME"); v != "" {
+		config.Bootstrap.AdminUsername = v
+	}
+
+	if v := os.Getenv("BOOTSTRAP_ADMIN_EMAIL"); v != "" {
+		config.Bootstrap.AdminEmail = v
+	}
+
+	if v := os.Getenv("BOOTSTRAP_ADMIN_PASSWORD"); v != "" {
+		config.Bootstrap.AdminPassword = v
+	}
+
+	if v := os.Getenv("AUDIT_BUFFER_SIZE"); v != "" {
+		if size, err := strconv.Atoi(v); err == nil {
+			config.Audit.BufferSize = size
+		}
+	}
+
+	// 诊断配置
+	if v := os.Getenv("ENABLE_PPROF"); v != "" {
+		config.Debug.EnablePprof = parseBool(v)
+	}
+
+	if v := os.Getenv("PPROF_ADDR"); v != "" {
+		config.Debug.PprofAddr = v
+	}
+
+	if v := os.Getenv("ADMIN_ADDR"); v != "" {
+		config.Admin.Address = v
+	}
+
+	if v := os.Getenv("LOG_BODIES"); v != "" {
+		config.Debug.LogBodies = parseBool(v)
+	}
+
+	if v := os.Getenv("LOG_BODY_MAX_SIZE"); v != "" {
+		if size, err := strconv.Atoi(v); err == nil {
+			config.Debug.LogBodyMaxSize = size
+		}
+	}
+
+	if v := os.Getenv("LOG_SENSITIVE_BODIES"); v != "" {
+		config.Debug.LogSensitiveBodies = parseBool(v)
+	}
+
 	/****** JWT 配置 (新增部分) ******/
 	if v := os.Getenv("JWT_SECRET"); v != "" {
 		config.Middleware.JWT.Secret = v
@@ -232,10 +1340,34 @@ func loadFromEnv(config *Config) {
 		}
 	}
 
+	if v := os.Getenv("JWT_REFRESH_TTL"); v != "" {
+		if duration, err := time.ParseDuration(v); err == nil {
+			config.Middleware.JWT.RefreshTTL = duration
+		} else {
+			hlog.Warnf("Invalid JWT_REFRESH_TTL format: %v", err)
+		}
+	}
+
+	if v := os.Getenv("JWT_LEEWAY"); v != "" {
+		if leeway, err := time.ParseDuration(v); err == nil && leeway >= 0 {
+			config.Middleware.JWT.Leeway = leeway
+		} else {
+			hlog.Warnf("Invalid JWT_LEEWAY format: %s", v)
+		}
+	}
+
+	if v := os.Getenv("JWT_COOKIE_ENABLED"); v != "" {
+		config.Middleware.JWT.CookieEnabled = parseBool(v)
+	}
+
 	if v := os.Getenv("JWT_ISSUER"); v != "" {
 		config.Middleware.JWT.Issuer = v
 	}
 
+	if v := os.Getenv("JWT_AUDIENCE"); v != "" {
+		config.Middleware.JWT.Audience = v
+	}
+
 	if v := os.Getenv("JWT_ALGORITHM"); v != "" {
 		// 清理输入算法字符串中的空格
 		algorithm := strings.ReplaceAll(v, " ", "")
@@ -297,14 +1429,77 @@ func loadFromEnv(config *Config) {
 	if v := os.Getenv("DB_LOG_LEVEL"); v != "" {
 		config.Database.LogLevel = strings.ToLower(v)
 	}
+
+	if v := os.Getenv("DB_SLOW_THRESHOLD"); v != "" {
+		if threshold, err := time.ParseDuration(v); err == nil && threshold >= 0 {
+			config.Database.SlowThreshold = threshold
+		} else {
+			hlog.Warnf("Invalid DB_SLOW_THRESHOLD format: %s", v)
+		}
+	}
+
+	if v := os.Getenv("DB_LOG_PARAMS"); v != "" {
+		config.Database.LogParams = parseBool(v)
+	}
+
+	if v := os.Getenv("DB_SCHEMA_CHECK"); v != "" {
+		switch mode := strings.ToLower(v); mode {
+		case SchemaCheckStrict, SchemaCheckWarn, SchemaCheckOff:
+			config.Database.SchemaCheck = mode
+		default:
+			hlog.Warnf("Unsupported schema check mode: %s", v)
+		}
+	}
+
+	if v := os.Getenv("DB_AUTO_MIGRATE"); v != "" {
+		enabled := parseBool(v)
+		config.Database.AutoMigrate = &enabled
+	}
+
+	if v := os.Getenv("DB_REPLICAS"); v != "" {
+		config.Database.Replicas = splitEnvList(v)
+	}
+
+	if v := os.Getenv("DB_DEGRADED_START"); v != "" {
+		config.Database.DegradedStart = parseBool(v)
+	}
+
+	if v := os.Getenv("DB_PREPARE_STMT"); v != "" {
+		config.Database.PrepareStmt = parseBool(v)
+	}
+
+	if v := os.Getenv("DB_SKIP_DEFAULT_TX"); v != "" {
+		config.Database.SkipDefaultTransaction = parseBool(v)
+	}
+
+	if v := os.Getenv("DB_RETRY_INTERVAL"); v != "" {
+		if interval, err := time.ParseDuration(v); err == nil && interval > 0 {
+			config.Database.RetryInterval = interval
+		} else {
+			hlog.Warnf("Invalid DB_RETRY_INTERVAL format: %s", v)
+		}
+	}
+}
+
+// validate 修正超出允许范围的配置项，回退为默认值
+func validate(config *Config) {
+	if cost := config.Middleware.Security.BcryptCost; cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		hlog.Warnf("Invalid bcrypt cost %d (allowed %d-%d), falling back to %d",
+			cost, bcrypt.MinCost, bcrypt.MaxCost, bcrypt.DefaultCost)
+		config.Middleware.Security.BcryptCost = bcrypt.DefaultCost
+	}
 }
 
 // 分割环境变量列表（支持逗号分隔的字符串）
 func splitEnvList(value string) []string {
-	if value == "" {
-		return nil
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		// 忽略逗号两侧的空白与空项，"a, b,"等价于"a,b"
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
-	return strings.Split(value, ",")
+	return items
 }
 
 // 转换字符串为布尔值
@@ -313,7 +1508,18 @@ func parseBool(value string) bool {
 	return value == "true" || value == "1" || value == "yes"
 }
 
+// InitDB 连接数据库，数据库不可用时返回错误
 func (c *Config) InitDB() (*gorm.DB, error) {
+	return c.openDB(false)
+}
+
+// InitDBLazy 不建立连接，首次执行SQL时才连接数据库，用于降级启动
+// 跳过了按服务端版本初始化方言，依赖版本探测的迁移能力会按保守方式处理
+func (c *Config) InitDBLazy() (*gorm.DB, error) {
+	return c.openDB(true)
+}
+
+func (c *Config) openDB(lazy bool) (*gorm.DB, error) {
 	// >>>>> 修复点1：先注册TLS配置 <<<<<
 	rootCertPool := x509.NewCertPool()
 	pem, _ := os.ReadFile("/path/to/ca-cert.pem")
@@ -340,21 +1546,20 @@ func (c *Config) InitDB() (*gorm.DB, error) {
 		c.Database.Port,
 		c.Database.DBName)
 
-	// 配置GORM日志级别
-	gormConfig := &gorm.Config{}
-	switch c.Database.LogLevel {
-	case "silent":
-		gormConfig.Logger = logger.Default.LogMode(logger.Silent)
-	case "error":
-		gormConfig.Logger = logger.Default.LogMode(logger.Error)
-	case "warn":
-		gormConfig.Logger = logger.Default.LogMode(logger.Warn)
-	case "info":
-		gormConfig.Logger = logger.Default.LogMode(logger.Info)
+	// 配置GORM日志级别，运行中可通过DBLoggerOf调整
+	gormConfig := &gorm.Config{
+		Logger:                 NewSwitchableDBLogger(NewRequestDBLogger(c.Database.SlowThreshold, c.Database.LogParams), c.Database.LogLevel),
+		PrepareStmt:            c.Database.PrepareStmt,
+		SkipDefaultTransaction: c.Database.SkipDefaultTransaction,
 	}
 
 	// 初始化数据库连接
-	db, err := gorm.Open(mysql.Open(dsn), gormConfig)
+	dialector := mysql.Open(dsn)
+	if lazy {
+		dialector = mysql.New(mysql.Config{DSN: dsn, SkipInitializeWithVersion: true})
+		gormConfig.DisableAutomaticPing = true
+	}
+	db, err := gorm.Open(dialector, gormConfig)
 	if err != nil {
 		logger2.Infof("Failed to open database: %v, dsn: %s", err, dsn)
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -372,3 +1577,34 @@ func (c *Config) InitDB() (*gorm.DB, error) {
 
 	return db, nil
 }
+
+// InitReplicas 按Replicas连接只读副本，连接池与预编译语句设置与主库一致
+// 日志与主库共用同一实例，运行时调整日志级别对副本同样生效；未配置副本时返回空
+func (c *Config) InitReplicas(primary *gorm.DB) ([]*gorm.DB, error) {
+	replicas := make([]*gorm.DB, 0, len(c.Database.Replicas))
+	for i, dsn := range c.Database.Replicas {
+		db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: primary.Config.Logger, PrepareStmt: c.Database.PrepareStmt})
+		if err == nil {
+			var sqlDB *sql.DB
+			if sqlDB, err = db.DB(); err == nil {
+				sqlDB.SetMaxIdleConns(c.Database.MinPoolSize)
+				sqlDB.SetMaxOpenConns(c.Database.MaxPoolSize)
+				replicas = append(replicas, db)
+				continue
+			}
+		}
+
+		// DSN含密码，错误信息中只给出序号
+		closeAll(replicas)
+		return nil, fmt.Errorf("failed to open replica #%d: %w", i, err)
+	}
+	return replicas, nil
+}
+
+func closeAll(dbs []*gorm.DB) {
+	for _, db := range dbs {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestServerListeners(t *testing.T) {
+	cases := []struct {
+		address string
+		want    []ListenAddr
+	}{
+		{"", []ListenAddr{{Network: "tcp"}}},
+		{":8080", []ListenAddr{{Network: "tcp", Addr: ":8080"}}},
+		{":8080, unix:/run/home.sock,", []ListenAddr{
+			{Network: "tcp", Addr: ":8080"},
+			{Network: "unix", Addr: "/run/home.sock"},
+		}},
+		{" , ", []ListenAddr{{Network: "tcp"}}},
+	}
+	for _, tc := range cases {
+		got := ServerConfig{Address: tc.address}.Listeners()
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Listeners(%q) = %+v, want %+v", tc.address, got, tc.want)
+		}
+	}
+
+	network, addr := ServerConfig{Address: "unix:/run/home.sock,:8080"}.Listen()
+	if network != "unix" || addr != "/run/home.sock" {
+		t.Errorf("Expected the first address to be primary, got %s %s", network, addr)
+	}
+}
+
+func TestMaxRequestBodySize(t *testing.T) {
+	cfg := SecurityConfig{MaxBodySize: 10 << 20}
+	if got := cfg.MaxRequestBodySize(); got != 10<<20 {
+		t.Errorf("Expected the global limit without route overrides, got %d", got)
+	}
+
+	cfg.RouteBodyLimits = map[string]int64{"/api/v1/users/login": 16 << 10, "/api/v1/admin/users/import": 32 << 20}
+	if got := cfg.MaxRequestBodySize(); got != 32<<20 {
+		t.Errorf("Expected the largest route limit, got %d", got)
+	}
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+	"my-digital-home/pkg/common/requestid"
+)
+
+// gormLogLevels 配置中的数据库日志级别名称
+var gormLogLevels = map[string]logger.LogLevel{
+	"silent": logger.Silent,
+	"error":  logger.Error,
+	"warn":   logger.Warn,
+	"info":   logger.Info,
+}
+
+// ValidDBLogLevel 判断数据库日志级别名称是否有效
+func ValidDBLogLevel(name string) bool {
+	_, ok := gormLogLevels[name]
+	return ok
+}
+
+// dbLoggerState 日志级别名称与对应的gorm日志实例，整体替换保证两者一致
+type dbLoggerState struct {
+	name   string
+	logger logger.Interface
+}
+
+// SwitchableDBLogger 可在运行时调整级别的gorm日志，用于排障时临时打开SQL日志
+type SwitchableDBLogger struct {
+	base  logger.Interface
+	state atomic.Pointer[dbLoggerState]
+}
+
+func NewSwitchableDBLogger(base logger.Interface, level string) *SwitchableDBLogger {
+	l := &SwitchableDBLogger{base: base}
+	if !l.SetLevel(level) {
+		l.SetLevel("warn")
+	}
+	return l
+}
+
+// SetLevel 切换日志级别，名称无效时返回false且保持原级别
+func (l *SwitchableDBLogger) SetLevel(name string) bool {
+	level, ok := gormLogLevels[name]
+	if !ok {
+		return false
+	}
+	l.state.Store(&dbLoggerState{name: name, logger: l.base.LogMode(level)})
+	return true
+}
+
+// Level 当前日志级别名称
+func (l *SwitchableDBLogger) Level() string {
+	return l.state.Load().name
+}
+
+// LogMode 供db.Debug()等临时调整使用，返回固定级别的独立实例，不影响全局级别
+func (l *SwitchableDBLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return l.base.LogMode(level)
+}
+
+func (l *SwitchableDBLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	l.state.Load().logger.Info(ctx, msg, data...)
+}
+
+func (l *SwitchableDBLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	l.state.Load().logger.Warn(ctx, msg, data...)
+}
+
+func (l *SwitchableDBLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	l.state.Load().logger.Error(ctx, msg, data...)
+}
+
+func (l *SwitchableDBLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	l.state.Load().logger.Trace(ctx, begin, fc, err)
+}
+
+// ParamsFilter 转交给当前级别的日志实例，使其对SQL参数的处理生效
+func (l *SwitchableDBLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if filter, ok := l.state.Load().logger.(gorm.ParamsFilter); ok {
+		return filter.ParamsFilter(ctx, sql, params...)
+	}
+	return sql, params
+}
+
+// RequestDBLogger 经hlog输出的gorm日志，每条附带上下文中的请求ID（仓储方法以WithContext传入请求上下文）
+// warn级别输出出错与超过慢查询阈值的SQL，info级别输出全部SQL
+type RequestDBLogger struct {
+	level         logger.LogLevel
+	slowThreshold time.Duration
+	logParams     bool
+}
+
+// NewRequestDBLogger slowThreshold为0时不单独记录慢查询；logParams为false时SQL中的参数保留为占位符，避免日志泄露用户数据
+func NewRequestDBLogger(slowThreshold time.Duration, logParams bool) *RequestDBLogger {
+	return &RequestDBLogger{level: logger.Warn, slowThreshold: slowThreshold, logParams: logParams}
+}
+
+func (l *RequestDBLogger) LogMode(level logger.LogLevel) logger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+func (l *RequestDBLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Info {
+		hlog.CtxInfof(ctx, "[db] request_id=%s | "+msg, append([]interface{}{requestid.FromContext(ctx)}, data...)...)
+	}
+}
+
+func (l *RequestDBLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Warn {
+		hlog.CtxWarnf(ctx, "[db] request_id=%s | "+msg, append([]interface{}{requestid.FromContext(ctx)}, data...)...)
+	}
+}
+
+func (l *RequestDBLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Error {
+		hlog.CtxErrorf(ctx, "[db] request_id=%s | "+msg, append([]interface{}{requestid.FromContext(ctx)}, data...)...)
+	}
+}
+
+// Trace 记录单条SQL；记录不存在是仓储查询的正常结果，不作为错误记录
+func (l *RequestDBLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	id := requestid.FromContext(ctx)
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, logger.ErrRecordNotFound):
+		sql, rows := fc()
+		hlog.CtxErrorf(ctx, "[db] %s | request_id=%s | %v | %.3fms | rows=%d | %s",
+			utils.FileWithLineNum(), id, err, float64(elapsed.Microseconds())/1e3, rows, sql)
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= logger.Warn:
+		sql, rows := fc()
+		hlog.CtxWarnf(ctx, "[db] %s | request_id=%s | slow query >= %v | %.3fms | rows=%d | %s",
+			utils.FileWithLineNum(), id, l.slowThreshold, float64(elapsed.Microseconds())/1e3, rows, sql)
+	case l.level >= logger.Info:
+		sql, rows := fc()
+		hlog.CtxInfof(ctx, "[db] %s | request_id=%s | %.3fms | rows=%d | %s",
+			utils.FileWithLineNum(), id, float64(elapsed.Microseconds())/1e3, rows, sql)
+	}
+}
+
+// ParamsFilter gorm据此决定日志中的SQL是否代入参数
+func (l *RequestDBLogger) ParamsFilter(_ context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if !l.logParams {
+		return sql, nil
+	}
+	return sql, params
+}
+
+// DBLoggerOf 取出InitDB安装的可调整日志，db不是由InitDB创建时返回false
+func DBLoggerOf(db *gorm.DB) (*SwitchableDBLogger, bool) {
+	l, ok := db.Config.Logger.(*SwitchableDBLogger)
+	return l, ok
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm/logger"
+	"my-digital-home/pkg/common/requestid"
+)
+
+func TestRequestDBLoggerLogsSlowQueriesWithRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	hlog.SetOutput(&buf)
+	t.Cleanup(func() { hlog.SetOutput(os.Stderr) })
+
+	l := NewRequestDBLogger(10*time.Millisecond, false)
+	ctx := requestid.NewContext(context.Background(), "req-42")
+	sql := func() (string, int64) { return "SELECT * FROM users WHERE id = ?", 1 }
+
+	if _, params := l.ParamsFilter(ctx, "SELECT ?", 7); params != nil {
+		t.Fatalf("Expected params to be dropped when LogParams is off, got %v", params)
+	}
+
+	l.Trace(ctx, time.Now(), sql, nil)
+	if buf.Len() != 0 {
+		t.Fatalf("Expected fast queries to stay out of warn-level logs, got %q", buf.String())
+	}
+
+	l.Trace(ctx, time.Now().Add(-50*time.Millisecond), sql, nil)
+	out := buf.String()
+	if !strings.Contains(out, "request_id=req-42") || !strings.Contains(out, "slow query") || !strings.Contains(out, "WHERE id = ?") {
+		t.Fatalf("Expected slow query log with request id, got %q", out)
+	}
+
+	buf.Reset()
+	l.Trace(ctx, time.Now(), sql, logger.ErrRecordNotFound)
+	if buf.Len() != 0 {
+		t.Fatalf("Expected record-not-found to stay out of error logs, got %q", buf.String())
+	}
+
+	buf.Reset()
+	l.LogMode(logger.Silent).Trace(ctx, time.Now().Add(-50*time.Millisecond), sql, nil)
+	if buf.Len() != 0 {
+		t.Fatalf("Expected silent level to log nothing, got %q", buf.String())
+	}
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// ReloadHook 配置热更新回调，old与updated均为只读快照
+type ReloadHook func(old, updated *Config)
+
+// Reloader 持有当前生效的配置快照，重新加载后原子替换
+// 只有限流、CORS、日志级别（含数据库日志）与维护模式可在运行时变更，其余配置的变化会被忽略并告警
+type Reloader struct {
+	current atomic.Pointer[Config]
+	load    func() (*Config, error)
+
+	mu    sync.Mutex // 串行化重载与回调注册
+	hooks []ReloadHook
+}
+
+func NewReloader(initial *Config) *Reloader {
+	r := &Reloader{load: loadConfig}
+	r.current.Store(initial)
+	return r
+}
+
+// Current 当前生效的配置快照，调用方不得修改
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// OnReload 注册热更新回调，按注册顺序在新快照生效后执行
+func (r *Reloader) OnReload(hook ReloadHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, hook)
+}
+
+// Reload 重新加载配置，校验通过后替换可热更新的配置项
+// 配置文件读取或解析失败、校验失败时保持原配置，不像启动时那样回退到默认值
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.current.Load()
+	loaded, err := r.load()
+	if err != nil {
+		return fmt.Errorf("config reload rejected: %w", err)
+	}
+	if err := validateRuntime(loaded); err != nil {
+		return fmt.Errorf("config reload rejected: %w", err)
+	}
+
+	// 以旧配置为基础，只覆盖可热更新的字段
+	next := *old
+	applyRuntime(&next, loaded)
+	warnImmutableChanges(&next, loaded)
+
+	r.current.Store(&next)
+	for _, hook := range r.hooks {
+		hook(old, &next)
+	}
+	hlog.Infof("config reloaded")
+	return nil
+}
+
+// WatchSIGHUP 收到SIGHUP时重新加载，ctx取消后停止监听
+// 需同时替换Hertz的信号处理，否则SIGHUP会触发优雅停机
+func (r *Reloader) WatchSIGHUP(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				if err := r.Reload(); err != nil {
+					hlog.Errorf("%v", err)
+				}
+			}
+		}
+	}()
+}
+
+// applyRuntime 将可热更新的配置项从src复制到dst
+func applyRuntime(dst, src *Config) {
+	dst.Middleware.RateLimit = src.Middleware.RateLimit
+	dst.Middleware.CORS = src.Middleware.CORS
+	dst.Maintenance.Enabled = src.Maintenance.Enabled
+	dst.LogLevel = src.LogLevel
+	dst.Database.LogLevel = src.Database.LogLevel
+}
+
+// validateRuntime 校验可热更新的配置项，避免错误取值在运行中生效
+func validateRuntime(c *Config) error {
+	if c.Middleware.RateLimit.Rate <= 0 || c.Middleware.RateLimit.Interval <= 0 {
+		return fmt.Errorf("rate limit must be positive, got rate=%d interval=%v",
+			c.Middleware.RateLimit.Rate, c.Middleware.RateLimit.Interval)
+	}
+	if _, ok := c.HlogLevel(); c.LogLevel != "" && !ok {
+		return fmt.Errorf("unsupported log level %q", c.LogLevel)
+	}
+	if !ValidDBLogLevel(c.Database.LogLevel) {
+		return fmt.Errorf("unsupported database log level %q", c.Database.LogLevel)
+	}
+	return nil
+}
+
+// warnImmutableChanges 对需要重启才能生效的变更逐项告警
+func warnImmutableChanges(current, loaded *Config) {
+	if current.Server.Address != loaded.Server.Address {
+		hlog.Warnf("server.address changed (%s -> %s), restart required", current.Server.Address, loaded.Server.Address)
+	}
+	if !reflect.DeepEqual(current.Database, loaded.Database) {
+		hlog.Warnf("database settings changed, restart required")
+	}
+
+	// 其余配置整体比较，只提示存在变化，不逐项列出（可能包含密钥）
+	rest, restLoaded := *current, *loaded
+	rest.Server.Address, rest.Database = "", DatabaseConfig{}
+	restLoaded.Server.Address, restLoaded.Database = "", DatabaseConfig{}
+	if !reflect.DeepEqual(rest, restLoaded) {
+		hlog.Warnf("other non-reloadable settings changed and were ignored, restart required")
+	}
+}
+
+// hlogLevels 配置中的日志级别名称
+var hlogLevels = map[string]hlog.Level{
+	"trace":  hlog.LevelTrace,
+	"debug":  hlog.LevelDebug,
+	"info":   hlog.LevelInfo,
+	"notice": hlog.LevelNotice,
+	"warn":   hlog.LevelWarn,
+	"error":  hlog.LevelError,
+	"fatal":  hlog.LevelFatal,
+}
+
+// HlogLevel 解析LogLevel，未配置或取值无效时返回false
+func (c *Config) HlogLevel() (hlog.Level, bool) {
+	level, ok := hlogLevels[c.LogLevel]
+	return level, ok
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReloadSwapsOnlyRuntimeSettings(t *testing.T) {
+	initial := Default()
+	r := NewReloader(initial)
+
+	loaded := Default()
+	loaded.Middleware.RateLimit = RateLimitConfig{Rate: 5, Interval: time.Second}
+	loaded.Middleware.CORS.AllowOrigins = []string{"https://home.example.com"}
+	loaded.LogLevel = "debug"
+	loaded.Server.Address = ":9090"   // 需重启才能生效
+	loaded.Database.Host = "db.other" // 需重启才能生效
+	r.load = func() (*Config, error) { return loaded, nil }
+
+	var hookOld, hookNew *Config
+	r.OnReload(func(old, updated *Config) { hookOld, hookNew = old, updated })
+
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Expected reload to succeed, got %v", err)
+	}
+
+	cur := r.Current()
+	if cur.Middleware.RateLimit.Rate != 5 || cur.LogLevel != "debug" || len(cur.Middleware.CORS.AllowOrigins) != 1 {
+		t.Fatalf("Expected runtime settings to be applied, got %+v", cur.Middleware)
+	}
+	if cur.Server.Address != initial.Server.Address || cur.Database.Host != initial.Database.Host {
+		t.Fatalf("Expected immutable settings to be kept, got address=%s host=%s", cur.Server.Address, cur.Database.Host)
+	}
+	if hookOld != initial || hookNew != cur {
+		t.Fatal("Expected hook to receive the previous and current snapshots")
+	}
+	if initial.Middleware.RateLimit.Rate == 5 {
+		t.Fatal("Expected the previous snapshot to stay unchanged")
+	}
+}
+
+func TestReloadRejectsInvalidConfig(t *testing.T) {
+	initial := Default()
+	r := NewReloader(initial)
+
+	loaded := Default()
+	loaded.LogLevel = "verbose"
+	r.load = func() (*Config, error) { return loaded, nil }
+
+	called := false
+	r.OnReload(func(_, _ *Config) { called = true })
+
+	if err := r.Reload(); err == nil {
+		t.Fatal("Expected invalid log level to be rejected")
+	}
+	if r.Current() != initial || called {
+		t.Fatal("Expected the current config to be kept and hooks not to run")
+	}
+}
+
+func TestReloadKeepsConfigWhenFileIsBroken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"middleware":{"rateLimit":{"rate":5,"interval":1000000000}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("APP_CONFIG", path)
+
+	initial := Default()
+	initial.Maintenance.Enabled = true
+	r := NewReloader(initial)
+
+	// 文件损坏：不能回退到默认值与环境变量，把维护模式、限流等悄悄重置
+	if err := os.WriteFile(path, []byte(`{"middleware":`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err == nil {
+		t.Fatal("Expected reload of an unparsable file to be rejected")
+	}
+	if r.Current() != initial {
+		t.Fatal("Expected the current config to be kept")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err == nil || r.Current() != initial {
+		t.Fatalf("Expected reload of an unreadable file to be rejected, got %v", err)
+	}
+}
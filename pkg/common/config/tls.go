@@ -0,0 +1,73 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// TLSConfig 进程内终止TLS的配置，关闭时以明文HTTP监听
+type TLSConfig struct {
+	Enabled      bool     `json:"enabled"`
+	CertFile     string   `json:"certFile"`
+	KeyFile      string   `json:"keyFile"`
+	MinVersion   string   `json:"minVersion"`   // "1.2"或"1.3"，为空时使用1.2
+	CipherSuites []string `json:"cipherSuites"` // 按Go标准库名称（如TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256），为空时使用默认安全套件；对TLS 1.3无效
+	RedirectAddr string   `json:"redirectAddr"` // 明文HTTP监听地址，收到的请求308跳转到HTTPS，为空时不启用
+}
+
+// tlsVersions 允许配置的最低协议版本
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Build 加载证书并生成tls.Config，版本或套件名称无效时返回错误
+func (c TLSConfig) Build() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load TLS certificate failed: %w", err)
+	}
+
+	minVersion := uint16(tls.VersionTLS12)
+	if c.MinVersion != "" {
+		v, ok := tlsVersions[strings.TrimSpace(c.MinVersion)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TLS min version %q (supported: 1.2, 1.3)", c.MinVersion)
+		}
+		minVersion = v
+	}
+
+	suites, err := cipherSuiteIDs(c.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   minVersion,
+		CipherSuites: suites,
+	}, nil
+}
+
+// cipherSuiteIDs 将套件名称映射为ID，仅接受标准库认为安全的套件
+func cipherSuiteIDs(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	known := make(map[string]uint16)
+	for _, s := range tls.CipherSuites() {
+		known[s.Name] = s.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported or insecure TLS cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
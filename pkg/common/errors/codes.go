@@ -0,0 +1,46 @@
+package errors
+
+// 业务码：HTTP状态码*1000+序号，同一HTTP状态下细分具体原因
+// 客户端应以业务码而非message判断错误类型，message仅用于展示
+const (
+	CodeOK = 0
+
+	CodePartialSuccess = 207000 // 批量操作部分成功，逐项结果见data.items
+
+	CodeBadRequest       = 400000 // 参数绑定/校验失败
+	CodeWeakPassword     = 400002 // 密码不符合复杂度要求
+	CodeInvalidLink      = 400003 // 验证/重置令牌无效或已过期
+	CodeUnauthorized     = 401000 // 未携带或无效的访问令牌
+	CodeBadCredential    = 401001 // 用户名或密码错误
+	CodeInvalidRefresh   = 401003 // 刷新令牌无效、已过期或会话已撤销
+	CodeBadSignature     = 401004 // Webhook签名无效或时间戳超出允许偏差
+	CodeOAuthFailed      = 401005 // 第三方登录失败（state不匹配、用户取消授权或授权码无效）
+	CodeInvalidAPIKey    = 401006 // 未携带API Key，或Key不存在、已吊销
+	CodeForbidden        = 403000
+	CodeEmailUnverify    = 403002 // 邮箱未验证
+	CodeCSRFMismatch     = 403003 // CSRF令牌缺失或不一致
+	CodeIPDenied         = 403004 // 来源IP被拒绝
+	CodeNoPermission     = 403005 // 缺少接口所需的权限
+	CodeNotFound         = 404000
+	CodeUserNotFound     = 404001
+	CodeSessionNotFound  = 404002 // 会话不存在或已撤销
+	CodeAPIKeyNotFound   = 404003 // API Key不存在或已吊销
+	CodeConflict         = 409000
+	CodeUsernameTaken    = 409001
+	CodeEmailTaken       = 409002
+	CodeIdentityHeld     = 409003 // 用户名/邮箱被已注销账户占用
+	CodeFKConflict       = 409004 // 外键约束冲突
+	CodeVersionConflict  = 409005 // 乐观锁重试耗尽
+	CodeUnverifiedEmail  = 409007 // 邮箱已被未验证的账户注册，第三方登录不自动关联
+	CodeFileTooLarge     = 413002 // 上传文件超过大小限制
+	CodeUnsupportedMedia = 415001 // 请求体媒体类型不受支持
+	CodeFileTypeMismatch = 415002 // 上传文件内容与声明的类型不符
+	CodeAllFailed        = 422000 // 批量操作全部失败，逐项原因见data.items
+	CodeInternal         = 500000
+	CodeDatabase         = 500001
+	CodeUnavailable      = 503000
+	CodeTransient        = 503001 // 瞬时故障（死锁、锁等待超时），可重试
+	CodeCapacity         = 503002 // 数据库连接数耗尽
+	CodeMaintenance      = 503003 // 维护模式
+	CodeDependencyDown   = 503004 // 降级启动中，依赖（数据库）尚未就绪
+)
@@ -37,6 +37,12 @@ func WrapGormError(rawErr error) error {
 			return ErrDuplicateEntry
 		case 1045, 1049, 1146: // 数据库连接、表不存在等错误
 			return fmt.Errorf("%w: %s", dao.ErrDatabaseInternal, mysqlErr.Message)
+		case 1213, 1205: // 死锁、锁等待超时，语句已回滚，可重试
+			return fmt.Errorf("%w: %s", dao.ErrTransient, mysqlErr.Message)
+		case 1451, 1452: // 外键约束冲突
+			return fmt.Errorf("%w: %s", dao.ErrConflict, mysqlErr.Message)
+		case 1040, 1203: // 连接数耗尽
+			return fmt.Errorf("%w: %s", dao.ErrCapacity, mysqlErr.Message)
 		}
 	}
 
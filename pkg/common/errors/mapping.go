@@ -0,0 +1,58 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+
+	dao "my-digital-home/pkg/core/user/repository/dao/impl"
+)
+
+// ErrValidation 请求参数校验失败，由NewValidationError包装具体原因
+var ErrValidation = errors.New("validation failed")
+
+// NewValidationError 将绑定/校验错误标记为参数错误，保留原错误以便提取字段详情
+func NewValidationError(err error) error {
+	return fmt.Errorf("%w: %w", ErrValidation, err)
+}
+
+// errorMapping 领域错误与HTTP状态、业务码的对应关系
+type errorMapping struct {
+	targets    []error
+	httpStatus int
+	bizCode    int
+	msg        string // 为空时使用错误自身的描述
+}
+
+// errorMappings 按顺序匹配，新增错误类型只需在此追加一行
+var errorMappings = []errorMapping{
+	{[]error{ErrValidation}, 400, CodeBadRequest, ""},
+	{[]error{dao.ErrInvalidToken}, 400, CodeInvalidLink, "令牌无效或已过期"},
+	{[]error{ErrUserNotFound, dao.ErrUserNotFound}, 404, CodeUserNotFound, "用户不存在或已注销"},
+	{[]error{dao.ErrSessionNotFound}, 404, CodeSessionNotFound, "会话不存在或已撤销"},
+	{[]error{dao.ErrAPIKeyNotFound}, 404, CodeAPIKeyNotFound, "API Key不存在或已吊销"},
+	{[]error{dao.ErrIdentityHeld}, 409, CodeIdentityHeld, "用户名或邮箱属于已注销账户，暂不可使用"},
+	{[]error{dao.ErrUsernameTaken}, 409, CodeUsernameTaken, "用户名已存在"},
+	{[]error{dao.ErrEmailTaken}, 409, CodeEmailTaken, "邮箱已被注册"},
+	{[]error{ErrDuplicateEntry, dao.ErrDuplicateEntry}, 409, CodeConflict, "用户已存在"},
+	{[]error{dao.ErrConflict}, 409, CodeFKConflict, "关联数据冲突"},
+	{[]error{dao.ErrVersionConflict}, 409, CodeVersionConflict, "数据已被修改，请重试"},
+	{[]error{dao.ErrTransient}, 503, CodeTransient, "服务繁忙，请重试"},
+	{[]error{dao.ErrCapacity}, 503, CodeCapacity, "服务繁忙，请稍后再试"},
+	{[]error{dao.ErrDatabaseInternal}, 500, CodeDatabase, "数据库错误"},
+}
+
+// MapError 将错误映射为HTTP状态码、业务码与对外提示信息
+// 未识别的错误统一返回500，不向客户端暴露内部细节
+func MapError(err error) (httpStatus int, bizCode int, msg string) {
+	for _, m := range errorMappings {
+		for _, target := range m.targets {
+			if errors.Is(err, target) {
+				if m.msg == "" {
+					return m.httpStatus, m.bizCode, err.Error()
+				}
+				return m.httpStatus, m.bizCode, m.msg
+			}
+		}
+	}
+	return 500, CodeInternal, "系统错误"
+}
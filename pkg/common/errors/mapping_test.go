@@ -0,0 +1,45 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	dao "my-digital-home/pkg/core/user/repository/dao/impl"
+)
+
+func TestMapError(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		httpStatus int
+		bizCode    int
+	}{
+		{"validation", NewValidationError(errors.New("username required")), 400, CodeBadRequest},
+		{"hertz user not found", ErrUserNotFound, 404, CodeUserNotFound},
+		{"dao user not found", fmt.Errorf("query: %w", dao.ErrUserNotFound), 404, CodeUserNotFound},
+		{"duplicate from mysql", WrapGormError(&mysql.MySQLError{Number: 1062}), 409, CodeConflict},
+		{"deadlock", WrapGormError(&mysql.MySQLError{Number: 1213}), 503, CodeTransient},
+		{"foreign key", WrapGormError(&mysql.MySQLError{Number: 1452}), 409, CodeFKConflict},
+		{"too many connections", WrapGormError(&mysql.MySQLError{Number: 1040}), 503, CodeCapacity},
+		{"database internal", WrapGormError(errors.New("connection refused")), 500, CodeDatabase},
+		{"unknown", errors.New("boom"), 500, CodeInternal},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, code, msg := MapError(tc.err)
+			if status != tc.httpStatus || code != tc.bizCode {
+				t.Fatalf("Expected %d/%d, got %d/%d (%s)", tc.httpStatus, tc.bizCode, status, code, msg)
+			}
+		})
+	}
+}
+
+func TestMapErrorHidesInternalDetails(t *testing.T) {
+	_, _, msg := MapError(WrapGormError(errors.New("dial tcp 10.0.0.1:3306: connection refused")))
+	if msg != "数据库错误" {
+		t.Fatalf("Expected generic message, got %q", msg)
+	}
+}
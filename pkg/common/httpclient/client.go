@@ -0,0 +1,173 @@
+// Package httpclient 调用外部服务（Webhook投递、OAuth、对象存储等）的共享HTTP客户端
+//
+// 统一连接与请求超时；幂等请求在网络错误、429与502/503/504时按指数退避有限次重试；
+// 上下文中带有请求ID时透传给下游，便于跨服务排查
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/common/requestid"
+)
+
+// RequestIDHeader 透传请求ID使用的请求头
+const RequestIDHeader = requestid.Header
+
+// maxRetryAfter 服务端Retry-After超过该值时不再等待重试，直接返回响应
+const maxRetryAfter = 10 * time.Second
+
+// Doer 发送HTTP请求的最小接口，依赖方以此注入Client或测试替身
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client 带超时与重试的HTTP客户端，可并发使用
+type Client struct {
+	http       *http.Client
+	maxRetries int
+	backoff    time.Duration
+}
+
+func New(cfg config.HTTPClientConfig) *Client {
+	dialer := &net.Dialer{Timeout: cfg.ConnectTimeout, KeepAlive: 30 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.TLSHandshakeTimeout = cfg.ConnectTimeout
+
+	return &Client{
+		http:       &http.Client{Transport: transport, Timeout: cfg.RequestTimeout},
+		maxRetries: max(cfg.MaxRetries, 0),
+		backoff:    cfg.RetryBackoff,
+	}
+}
+
+// HTTP 底层的*http.Client（不含重试），供只接受标准库客户端的第三方库使用
+func (c *Client) HTTP() *http.Client {
+	return c.http
+}
+
+// Do 发送请求。只有幂等方法（GET、HEAD、OPTIONS、PUT、DELETE）或携带Idempotency-Key的请求会重试，
+// 有请求体时须可重放（http.NewRequest对bytes、strings的Reader会自动设置GetBody）
+// 重试用尽后返回最后一次的响应或错误；ctx取消时立即停止
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if id := RequestIDFrom(ctx); id != "" && req.Header.Get(RequestIDHeader) == "" {
+		req = req.Clone(ctx) // 不修改调用方的请求
+		req.Header.Set(RequestIDHeader, id)
+	}
+
+	retries := 0
+	if isRetryable(req) {
+		retries = c.maxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(ctx)
+			attemptReq.Body = body
+		}
+
+		resp, err := c.http.Do(attemptReq)
+		if attempt >= retries || !shouldRetry(ctx, resp, err) {
+			return resp, err
+		}
+
+		wait := c.backoffFor(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp); ok {
+				if after > maxRetryAfter {
+					return resp, nil
+				}
+				wait = after
+			}
+			// 读完并关闭响应体，连接才能复用
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Get 以ctx发送GET请求
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// backoffFor 第attempt次重试前的等待：backoff*2^attempt，叠加±25%抖动避免多个实例同时重试
+func (c *Client) backoffFor(attempt int) time.Duration {
+	d := c.backoff << attempt
+	if d <= 0 {
+		return 0
+	}
+	jitter := time.Duration(rand.Int63n(int64(d)/2+1)) - d/4
+	return d + jitter
+}
+
+func isRetryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// shouldRetry 网络错误（含单次尝试超时）与429、502、503、504可重试；调用方取消或整体超时时不重试
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter 解析以秒为单位的Retry-After
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	secs, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+	return time.Duration(secs) * time.Second, true
+}
+
+// ContextWithRequestID 在上下文中记录请求ID，经Client发出的请求会携带X-Request-ID
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return requestid.NewContext(ctx, id)
+}
+
+// RequestIDFrom 读取上下文中的请求ID（ContextWithRequestID或RequestIDMiddleware记录）
+func RequestIDFrom(ctx context.Context) string {
+	return requestid.FromContext(ctx)
+}
@@ -0,0 +1,140 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"my-digital-home/pkg/common/config"
+)
+
+func testClient(timeout time.Duration) *Client {
+	return New(config.HTTPClientConfig{
+		ConnectTimeout: time.Second,
+		RequestTimeout: timeout,
+		MaxRetries:     2,
+		RetryBackoff:   time.Millisecond,
+	})
+}
+
+func TestRetriesIdempotentRequests(t *testing.T) {
+	var attempts atomic.Int32
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+	c := testClient(time.Second)
+
+	resp, err := c.Get(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 || attempts.Load() != 3 {
+		t.Fatalf("Expected success on the third attempt, got %d after %d attempts", resp.StatusCode, attempts.Load())
+	}
+
+	// POST默认不重试
+	attempts.Store(0)
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+	resp, err = c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 503 || attempts.Load() != 1 {
+		t.Fatalf("Expected a single POST attempt returning 503, got %d after %d attempts", resp.StatusCode, attempts.Load())
+	}
+
+	// 携带Idempotency-Key的POST重试，且每次都重新发送请求体
+	attempts.Store(0)
+	bodies = nil
+	req, _ = http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+	req.Header.Set("Idempotency-Key", "k1")
+	resp, err = c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 || len(bodies) != 3 || bodies[2] != "payload" {
+		t.Fatalf("Expected idempotent POST to be replayed with its body, got %d %q", resp.StatusCode, bodies)
+	}
+}
+
+func TestRequestTimeoutIsRetriedThenFails(t *testing.T) {
+	var attempts atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	start := time.Now()
+	_, err := testClient(50*time.Millisecond).Get(context.Background(), srv.URL)
+	if err == nil {
+		t.Fatal("Expected timeout error")
+	}
+	if attempts.Load() != 3 {
+		t.Fatalf("Expected each timed-out attempt to be retried, got %d attempts", attempts.Load())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected per-attempt timeout to bound the call, took %v", elapsed)
+	}
+}
+
+func TestCanceledContextStopsRetries(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := testClient(time.Second).Get(ctx, srv.URL); err != context.DeadlineExceeded {
+		t.Fatalf("Expected deadline exceeded while waiting for Retry-After, got %v", err)
+	}
+	if attempts.Load() != 1 {
+		t.Fatalf("Expected no attempt after cancellation, got %d", attempts.Load())
+	}
+}
+
+func TestPropagatesRequestID(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(RequestIDHeader)
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequestWithContext(ContextWithRequestID(context.Background(), "req-42"), http.MethodGet, srv.URL, nil)
+	resp, err := testClient(time.Second).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got != "req-42" {
+		t.Fatalf("Expected request ID to be forwarded, got %q", got)
+	}
+	if req.Header.Get(RequestIDHeader) != "" {
+		t.Fatal("Expected caller's request to be left unchanged")
+	}
+}
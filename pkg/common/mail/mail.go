@@ -0,0 +1,35 @@
+package mail
+
+import (
+	"context"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"my-digital-home/pkg/common/config"
+)
+
+// EmailSender 邮件发送抽象，Handler仅依赖此接口，测试中可注入假实现
+type EmailSender interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// NewSender 按配置创建发送器：未配置SMTP主机时退化为日志发送器
+func NewSender(cfg config.MailConfig) EmailSender {
+	if cfg.Host == "" {
+		return LogSender{}
+	}
+	return NewSMTPSender(cfg)
+}
+
+// LogSender 开发环境使用，仅把邮件内容输出到调试日志
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, to, subject, body string) error {
+	hlog.CtxDebugf(ctx, "[MAIL] to=%s subject=%q\n%s", to, subject, body)
+	return nil
+}
+
+// NopSender 丢弃所有邮件
+type NopSender struct{}
+
+func (NopSender) Send(context.Context, string, string, string) error {
+	return nil
+}
@@ -0,0 +1,185 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// 业务结果取值（需同时出现在配置白名单中才会被原样记录）
+const (
+	OutcomeLoginSuccess    = "login_success"
+	OutcomeLoginFailure    = "login_failure"
+	OutcomeRegisterSuccess = "register_success"
+	OutcomeRegisterFailure = "register_failure"
+
+	// OutcomeOther 不在白名单内的取值统一归并，防止标签基数失控
+	OutcomeOther = "other"
+)
+
+// outcomeKey 业务结果在RequestContext中的存储键
+const outcomeKey = "metrics_outcome"
+
+// Registry 应用私有指标注册表（不使用全局默认注册表，避免测试间互相污染）
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequestsTotal 按方法/路由/状态码统计的请求总数
+	HTTPRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests.",
+		},
+		[]string{"method", "path", "status"},
+	)
+
+	// HTTPRequestDuration 请求耗时分布
+	HTTPRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency in seconds.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method", "path"},
+	)
+
+	// BusinessOutcomesTotal 业务维度计数（如登录成功/失败）
+	BusinessOutcomesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "business_outcomes_total",
+			Help: "Total number of business outcomes reported by handlers.",
+		},
+		[]string{"path", "outcome"},
+	)
+
+	// ActiveUsers 活跃用户数，由后台任务定期刷新而非每次抓取时查询数据库
+	ActiveUsers = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "active_users",
+			Help: "Number of active (not deactivated or deleted) users.",
+		},
+	)
+
+	// 数据库连接池状态，由后台任务按间隔采样sql.DBStats
+	DBOpenConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "db_pool_open_connections",
+		Help: "Number of established connections, both in use and idle.",
+	})
+	DBInUseConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "db_pool_in_use_connections",
+		Help: "Number of connections currently in use.",
+	})
+	DBIdleConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "db_pool_idle_connections",
+		Help: "Number of idle connections.",
+	})
+	DBMaxOpenConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "db_pool_max_open_connections",
+		Help: "Maximum number of open connections allowed by the pool.",
+	})
+	DBWaitCount = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "db_pool_wait_count_total",
+		Help: "Total number of connections waited for.",
+	})
+	DBWaitDuration = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "db_pool_wait_duration_seconds_total",
+		Help: "Total time blocked waiting for a new connection.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+		BusinessOutcomesTotal,
+		ActiveUsers,
+		DBOpenConnections,
+		DBInUseConnections,
+		DBIdleConnections,
+		DBMaxOpenConnections,
+		DBWaitCount,
+		DBWaitDuration,
+	)
+}
+
+// SetOutcome 由Handler调用，为当前请求附加业务结果标签
+func SetOutcome(c *app.RequestContext, outcome string) {
+	c.Set(outcomeKey, outcome)
+}
+
+// OutcomeFromContext 读取Handler附加的业务结果标签
+func OutcomeFromContext(c *app.RequestContext) (string, bool) {
+	v, ok := c.Get(outcomeKey)
+	if !ok {
+		return "", false
+	}
+	outcome, ok := v.(string)
+	return outcome, ok && outcome != ""
+}
+
+// StartGaugeRefresher 立即并每隔interval调用load刷新gauge，ctx取消后退出
+// 加载失败时保留上一次的值
+func StartGaugeRefresher(ctx context.Context, gauge prometheus.Gauge, interval time.Duration, load func(ctx context.Context) (int64, error)) {
+	refresh := func() {
+		value, err := load(ctx)
+		if err != nil {
+			hlog.CtxWarnf(ctx, "refresh gauge failed: %v", err)
+			return
+		}
+		gauge.Set(float64(value))
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		refresh()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}()
+}
+
+// StartDBStatsSampler 立即并每隔interval采样连接池状态，ctx取消后退出
+// WaitCount/WaitDuration在sql.DBStats中是累计值，按两次采样的差值累加到计数器
+func StartDBStatsSampler(ctx context.Context, interval time.Duration, stats func() sql.DBStats) {
+	var last sql.DBStats
+	sample := func() {
+		s := stats()
+		DBOpenConnections.Set(float64(s.OpenConnections))
+		DBInUseConnections.Set(float64(s.InUse))
+		DBIdleConnections.Set(float64(s.Idle))
+		DBMaxOpenConnections.Set(float64(s.MaxOpenConnections))
+		if s.WaitCount >= last.WaitCount {
+			DBWaitCount.Add(float64(s.WaitCount - last.WaitCount))
+		}
+		if s.WaitDuration >= last.WaitDuration {
+			DBWaitDuration.Add((s.WaitDuration - last.WaitDuration).Seconds())
+		}
+		last = s
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		sample()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sample()
+			}
+		}
+	}()
+}
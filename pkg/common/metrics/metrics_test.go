@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestGaugeRefresherKeepsLastValueOnError(t *testing.T) {
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_gauge"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int32
+	StartGaugeRefresher(ctx, gauge, 10*time.Millisecond, func(context.Context) (int64, error) {
+		if calls.Add(1) == 1 {
+			return 7, nil
+		}
+		return 0, errors.New("db down")
+	})
+
+	deadline := time.Now().Add(time.Second)
+	for calls.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if calls.Load() < 3 {
+		t.Fatal("Expected gauge to be refreshed periodically")
+	}
+	if got := testutil.ToFloat64(gauge); got != 7 {
+		t.Fatalf("Expected last good value 7 to be kept, got %v", got)
+	}
+}
+
+func TestDBStatsSamplerAddsWaitDeltas(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int32
+	StartDBStatsSampler(ctx, 10*time.Millisecond, func() sql.DBStats {
+		n := calls.Add(1)
+		if n > 2 {
+			n = 2
+		}
+		return sql.DBStats{
+			MaxOpenConnections: 50,
+			OpenConnections:    10,
+			InUse:              8,
+			Idle:               2,
+			WaitCount:          int64(n) * 3,
+			WaitDuration:       time.Duration(n) * time.Second,
+		}
+	})
+
+	deadline := time.Now().Add(time.Second)
+	for calls.Load() < 4 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+
+	// 累计值不应被重复计入：两次采样分别为3和6，计数器应为6
+	if got := testutil.ToFloat64(DBWaitCount); got != 6 {
+		t.Fatalf("Expected wait count 6, got %v", got)
+	}
+	if got := testutil.ToFloat64(DBWaitDuration); got != 2 {
+		t.Fatalf("Expected wait duration 2s, got %v", got)
+	}
+	if got := testutil.ToFloat64(DBInUseConnections); got != 8 {
+		t.Fatalf("Expected 8 in-use connections, got %v", got)
+	}
+}
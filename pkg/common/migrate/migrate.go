@@ -0,0 +1,221 @@
+package migrate
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 迁移文件命名：<版本号>_<名称>.up.sql / .down.sql，版本号递增且不可复用
+// 文件中的语句以分号结尾，字符串字面量中不能包含分号
+//
+//go:embed migrations/*.sql
+var embedded embed.FS
+
+// fileNamePattern 迁移文件名
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// createTableSQL 已执行版本记录表
+const createTableSQL = "CREATE TABLE IF NOT EXISTS `schema_migrations` (" +
+	"`version` bigint NOT NULL, " +
+	"`name` varchar(255) NOT NULL, " +
+	"`applied_at` datetime(3) NOT NULL, " +
+	"PRIMARY KEY (`version`)" +
+	") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+
+// Migration 单个版本的迁移，Down为空表示不可回滚
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load 读取fsys根目录下的迁移文件，按版本升序返回；每个版本必须有up文件
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		m := fileNamePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || m == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = migration
+		} else if migration.Name != m[2] {
+			return nil, fmt.Errorf("migration version %d used by both %q and %q", version, migration.Name, m[2])
+		}
+		if m[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Runner 按版本顺序执行迁移，并在schema_migrations中记录已执行的版本
+type Runner struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewRunner 使用内置的迁移文件
+func NewRunner(db *gorm.DB) (*Runner, error) {
+	sub, err := fs.Sub(embedded, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := Load(sub)
+	if err != nil {
+		return nil, err
+	}
+	return NewRunnerWith(db, migrations), nil
+}
+
+// NewRunnerWith 使用指定的迁移列表（需按版本升序）
+func NewRunnerWith(db *gorm.DB, migrations []Migration) *Runner {
+	return &Runner{db: db, migrations: migrations}
+}
+
+// Pending 尚未执行的迁移，按版本升序
+func (r *Runner) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range r.migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up 依次执行全部未执行的迁移，遇到错误即停止，返回已成功执行的迁移
+func (r *Runner) Up(ctx context.Context) ([]Migration, error) {
+	pending, err := r.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range pending {
+		err := r.apply(ctx, migration, migration.Up, func(tx *gorm.DB) error {
+			return tx.Exec("INSERT INTO `schema_migrations` (`version`, `name`, `applied_at`) VALUES (?, ?, ?)",
+				migration.Version, migration.Name, time.Now()).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s up failed: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down 按版本倒序回滚最近执行的steps个迁移，返回已成功回滚的迁移
+func (r *Runner) Down(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(r.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := r.migrations[i]
+		if !applied[migration.Version] {
+			continue
+		}
+		if strings.TrimSpace(migration.Down) == "" {
+			return done, fmt.Errorf("migration %d_%s is irreversible", migration.Version, migration.Name)
+		}
+		err := r.apply(ctx, migration, migration.Down, func(tx *gorm.DB) error {
+			return tx.Exec("DELETE FROM `schema_migrations` WHERE `version` = ?", migration.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s down failed: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// apply 在事务中执行脚本与版本记录
+// 注意MySQL的DDL会隐式提交，含DDL的脚本失败时可能部分生效，脚本应尽量保持幂等（如IF NOT EXISTS）
+func (r *Runner) apply(ctx context.Context, migration Migration, script string, record func(tx *gorm.DB) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range splitStatements(script) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return record(tx)
+	})
+}
+
+// applied 已执行的版本集合，记录表不存在时先创建
+func (r *Runner) applied(ctx context.Context) (map[int64]bool, error) {
+	db := r.db.WithContext(ctx)
+	if err := db.Exec(createTableSQL).Error; err != nil {
+		return nil, fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var versions []int64
+	if err := db.Raw("SELECT `version` FROM `schema_migrations`").Scan(&versions).Error; err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	applied := make(map[int64]bool, len(versions))
+	for _, version := range versions {
+		applied[version] = true
+	}
+	return applied, nil
+}
+
+// splitStatements 按分号拆分语句，忽略空行与--注释行
+func splitStatements(script string) []string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+			lines = append(lines, line)
+		}
+	}
+
+	var stmts []string
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
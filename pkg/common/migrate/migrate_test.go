@@ -0,0 +1,108 @@
+package migrate
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newMockRunner(t *testing.T, migrations []Migration) (*Runner, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock init failed: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("gorm open failed: %v", err)
+	}
+	return NewRunnerWith(db, migrations), mock
+}
+
+func TestLoadOrdersAndPairsFiles(t *testing.T) {
+	migrations, err := Load(fstest.MapFS{
+		"0002_add_nickname.up.sql":   {Data: []byte("ALTER TABLE t ADD COLUMN nickname varchar(64);")},
+		"0001_init.up.sql":           {Data: []byte("CREATE TABLE t (id bigint);")},
+		"0001_init.down.sql":         {Data: []byte("DROP TABLE t;")},
+		"README.md":                  {Data: []byte("ignored")},
+		"0002_add_nickname.down.sql": {Data: []byte("ALTER TABLE t DROP COLUMN nickname;")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 1 || migrations[1].Name != "add_nickname" || migrations[0].Down == "" {
+		t.Fatalf("Unexpected migrations: %+v", migrations)
+	}
+
+	if _, err := Load(fstest.MapFS{"0003_orphan.down.sql": {Data: []byte("DROP TABLE t;")}}); err == nil {
+		t.Fatal("Expected a down script without up script to be rejected")
+	}
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	runner, err := NewRunner(nil)
+	if err != nil || len(runner.migrations) == 0 || runner.migrations[0].Version != 1 {
+		t.Fatalf("Expected embedded migrations to load, got %v", err)
+	}
+}
+
+func TestUpAppliesOnlyPendingMigrations(t *testing.T) {
+	runner, mock := newMockRunner(t, []Migration{
+		{Version: 1, Name: "init", Up: "CREATE TABLE t (id bigint);"},
+		{Version: 2, Name: "add_nickname", Up: "-- 新增昵称\nALTER TABLE t ADD COLUMN nickname varchar(64);\nUPDATE t SET nickname = '';"},
+	})
+
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS `schema_migrations`")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `version` FROM `schema_migrations`")).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE t ADD COLUMN nickname varchar(64)")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE t SET nickname = ''")).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `schema_migrations`")).
+		WithArgs(2, "add_nickname", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	done, err := runner.Up(context.Background())
+	if err != nil || len(done) != 1 || done[0].Version != 2 {
+		t.Fatalf("Expected only version 2 to be applied, got %+v err=%v", done, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDownStopsAtIrreversibleMigration(t *testing.T) {
+	runner, mock := newMockRunner(t, []Migration{
+		{Version: 1, Name: "init", Up: "CREATE TABLE t (id bigint);", Down: "DROP TABLE t;"},
+		{Version: 2, Name: "backfill", Up: "UPDATE t SET id = id;"},
+	})
+
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS `schema_migrations`")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `version` FROM `schema_migrations`")).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1).AddRow(2))
+
+	done, err := runner.Down(context.Background(), 2)
+	if err == nil || len(done) != 0 {
+		t.Fatalf("Expected irreversible migration to stop rollback, got %+v err=%v", done, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `audit_logs`;
+DROP TABLE IF EXISTS `password_reset_tokens`;
+DROP TABLE IF EXISTS `base_users`;
@@ -0,0 +1,49 @@
+-- 与model.AutoMigrate生成的表结构一致；使用IF NOT EXISTS，已由AutoMigrate建表的库可直接接入
+CREATE TABLE IF NOT EXISTS `base_users` (
+  `id` bigint AUTO_INCREMENT,
+  `username` varchar(100) NOT NULL,
+  `email` varchar(255) NOT NULL,
+  `password_hash` varchar(255) NOT NULL,
+  `is_active` boolean DEFAULT true,
+  `email_verified` boolean NOT NULL DEFAULT false,
+  `roles` varchar(255) NOT NULL DEFAULT 'user',
+  `version` bigint NOT NULL DEFAULT 1,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `last_login_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_base_users_username` (`username`),
+  UNIQUE INDEX `idx_base_users_email` (`email`),
+  INDEX `idx_base_users_is_active` (`is_active`),
+  INDEX `idx_base_users_created_at` (`created_at`),
+  INDEX `idx_base_users_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户基础表';
+
+CREATE TABLE IF NOT EXISTS `password_reset_tokens` (
+  `id` bigint AUTO_INCREMENT,
+  `user_id` bigint NOT NULL,
+  `token_hash` char(64) NOT NULL,
+  `expires_at` datetime(3) NOT NULL,
+  `used_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_password_reset_tokens_user_id` (`user_id`),
+  UNIQUE INDEX `idx_password_reset_tokens_token_hash` (`token_hash`),
+  INDEX `idx_password_reset_tokens_expires_at` (`expires_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='密码重置令牌表';
+
+CREATE TABLE IF NOT EXISTS `audit_logs` (
+  `id` bigint AUTO_INCREMENT,
+  `actor_id` bigint NOT NULL,
+  `action` varchar(64) NOT NULL,
+  `target_id` bigint,
+  `ip` varchar(64),
+  `user_agent` varchar(255),
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_audit_logs_actor_id` (`actor_id`),
+  INDEX `idx_audit_logs_action` (`action`),
+  INDEX `idx_audit_logs_target_id` (`target_id`),
+  INDEX `idx_audit_logs_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='审计日志表';
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS `user_sessions` (
+  `id` bigint AUTO_INCREMENT,
+  `user_id` bigint NOT NULL,
+  `refresh_token_hash` char(64) NOT NULL,
+  `device` varchar(100),
+  `user_agent` varchar(255),
+  `ip` varchar(64),
+  `created_at` datetime(3) NULL,
+  `last_used_at` datetime(3) NOT NULL,
+  `expires_at` datetime(3) NOT NULL,
+  `revoked_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_user_sessions_user_id` (`user_id`),
+  UNIQUE INDEX `idx_user_sessions_refresh_token_hash` (`refresh_token_hash`),
+  INDEX `idx_user_sessions_expires_at` (`expires_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='登录会话表';
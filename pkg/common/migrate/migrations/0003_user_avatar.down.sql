@@ -0,0 +1 @@
+ALTER TABLE `base_users` DROP COLUMN `avatar_url`;
@@ -0,0 +1 @@
+ALTER TABLE `base_users` ADD COLUMN `avatar_url` varchar(512) NOT NULL DEFAULT '';
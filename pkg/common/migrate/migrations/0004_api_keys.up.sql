@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS `api_keys` (
+  `id` bigint AUTO_INCREMENT,
+  `name` varchar(100) NOT NULL,
+  `prefix` varchar(16) NOT NULL,
+  `key_hash` char(64) NOT NULL,
+  `scopes` varchar(512) NOT NULL DEFAULT '',
+  `is_active` boolean NOT NULL DEFAULT true,
+  `created_by` bigint NOT NULL,
+  `created_at` datetime(3) NULL,
+  `last_used_at` datetime(3) NULL,
+  `revoked_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_api_keys_key_hash` (`key_hash`),
+  INDEX `idx_api_keys_is_active` (`is_active`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务API Key表';
@@ -0,0 +1 @@
+ALTER TABLE `audit_logs` ADD COLUMN `detail` varchar(1024) NOT NULL DEFAULT '';
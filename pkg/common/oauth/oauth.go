@@ -0,0 +1,82 @@
+// Package oauth 第三方登录（OIDC授权码流程）的提供方客户端
+//
+// 提供方只负责把授权码换成经核实的身份；查找或创建本地账户、签发本服务令牌由调用方完成
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"my-digital-home/pkg/common/httpclient"
+)
+
+// errorBodyLimit 错误响应体最多读取的字节数
+const errorBodyLimit = 512
+
+// Identity 提供方核实的用户身份
+type Identity struct {
+	Provider      string
+	Subject       string // 提供方内的用户唯一标识
+	Email         string
+	EmailVerified bool // 提供方是否确认了邮箱归属，未确认的邮箱不能用于关联本地账户
+	Name          string
+}
+
+// Provider 一个第三方登录提供方
+type Provider interface {
+	// AuthCodeURL 授权页地址，state原样带回回调
+	AuthCodeURL(ctx context.Context, state string) (string, error)
+	// Identify 以回调收到的授权码换取用户身份
+	Identify(ctx context.Context, code string) (Identity, error)
+}
+
+// tokenResponse 令牌端点的响应（RFC 6749 第5节）
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// exchangeCode 向令牌端点提交授权码；授权码只能使用一次，请求不会重试
+func exchangeCode(ctx context.Context, client httpclient.Doer, tokenURL string, form url.Values) (tokenResponse, error) {
+	var token tokenResponse
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return token, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if err := doJSON(client, req, &token); err != nil {
+		if token.Error != "" {
+			return token, fmt.Errorf("oauth token exchange: %s: %s", token.Error, token.Description)
+		}
+		return token, fmt.Errorf("oauth token exchange: %w", err)
+	}
+	if token.AccessToken == "" {
+		return token, fmt.Errorf("oauth token exchange: response without access_token")
+	}
+	return token, nil
+}
+
+// doJSON 发送请求并解码JSON响应；非2xx时仍尝试解码到out（以便读取错误字段）并返回带状态码的错误
+func doJSON(client httpclient.Doer, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, errorBodyLimit))
+		_ = json.Unmarshal(body, out)
+		return fmt.Errorf("%s %s: status %d", req.Method, req.URL.Host+req.URL.Path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,266 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/common/httpclient"
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+	// jwksMinRefresh 遇到未知kid时重新拉取JWKS的最短间隔，避免伪造kid的令牌放大对提供方的请求
+	jwksMinRefresh = time.Minute
+	// idTokenLeeway 校验exp、nbf、iat时容忍的时钟偏差
+	idTokenLeeway = time.Minute
+)
+
+var (
+	defaultScopes = []string{"openid", "email", "profile"}
+	// idTokenMethods ID令牌允许的签名算法，OIDC要求非对称签名，不接受none与HMAC
+	idTokenMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+)
+
+// discoveryDocument 发现文档中用到的字段
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// idTokenClaims ID令牌中映射到本地用户的声明
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	AuthorizedParty   string       `json:"azp"`
+	Email             string       `json:"email"`
+	EmailVerified     flexibleBool `json:"email_verified"`
+	Name              string       `json:"name"`
+	PreferredUsername string       `json:"preferred_username"`
+}
+
+// flexibleBool 兼容部分提供方把布尔声明编码为字符串"true"
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true":
+		*b = true
+	case "false", "null", "":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean claim %s", data)
+	}
+	return nil
+}
+
+// OIDC 通用OIDC提供方：首次使用时读取签发方的发现文档，以授权码换取ID令牌并用JWKS校验签名
+// 提供方暂时不可用不影响服务启动，发现文档读取成功后缓存
+type OIDC struct {
+	name   string
+	cfg    config.OIDCProviderConfig
+	client httpclient.Doer
+	now    func() time.Time
+
+	mu            sync.Mutex
+	discovery     *discoveryDocument
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+func NewOIDC(name string, cfg config.OIDCProviderConfig, client httpclient.Doer) *OIDC {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = defaultScopes
+	}
+	return &OIDC{name: name, cfg: cfg, client: client, now: time.Now}
+}
+
+func (o *OIDC) AuthCodeURL(ctx context.Context, state string) (string, error) {
+	doc, err := o.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {o.cfg.ClientID},
+		"redirect_uri":  {o.cfg.RedirectURL},
+		"scope":         {strings.Join(o.cfg.Scopes, " ")},
+		"state":         {state},
+	}
+	sep := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return doc.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Identify 身份取自校验过的ID令牌；ID令牌不含邮箱时（提供方按配置只放在userinfo中）再读取userinfo端点
+func (o *OIDC) Identify(ctx context.Context, code string) (Identity, error) {
+	doc, err := o.discover(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+	token, err := exchangeCode(ctx, o.client, doc.TokenEndpoint, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"client_id":     {o.cfg.ClientID},
+		"client_secret": {o.cfg.ClientSecret},
+		"redirect_uri":  {o.cfg.RedirectURL},
+	})
+	if err != nil {
+		return Identity{}, err
+	}
+	if token.IDToken == "" {
+		return Identity{}, errors.New("oidc: token response without id_token, is the openid scope configured?")
+	}
+	claims, err := o.verifyIDToken(ctx, doc, token.IDToken)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	if claims.Email == "" && doc.UserinfoEndpoint != "" {
+		info, err := o.userinfo(ctx, doc.UserinfoEndpoint, token.AccessToken)
+		if err != nil {
+			return Identity{}, err
+		}
+		// userinfo的sub必须与ID令牌一致（OIDC Core 5.3.2）
+		if info.Subject != claims.Subject {
+			return Identity{}, errors.New("oidc: userinfo subject does not match id_token")
+		}
+		claims.Email, claims.EmailVerified = info.Email, info.EmailVerified
+		if claims.Name == "" {
+			claims.Name = info.Name
+		}
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.PreferredUsername
+	}
+	return Identity{
+		Provider:      o.name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified) || o.cfg.TrustEmail,
+		Name:          name,
+	}, nil
+}
+
+// verifyIDToken 校验签名、签发方、受众与有效期（OIDC Core 3.1.3.7）
+func (o *OIDC) verifyIDToken(ctx context.Context, doc *discoveryDocument, raw string) (*idTokenClaims, error) {
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return o.key(ctx, doc.JWKSURI, kid)
+	},
+		jwt.WithValidMethods(idTokenMethods),
+		jwt.WithIssuer(doc.Issuer),
+		jwt.WithAudience(o.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(idTokenLeeway),
+		jwt.WithTimeFunc(o.now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: invalid id_token: %w", err)
+	}
+	// 多个受众时，azp须为本客户端
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != o.cfg.ClientID {
+		return nil, errors.New("oidc: id_token azp does not match client id")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("oidc: id_token without sub")
+	}
+	return claims, nil
+}
+
+func (o *OIDC) userinfo(ctx context.Context, endpoint, accessToken string) (*idTokenClaims, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	info := &idTokenClaims{}
+	if err := doJSON(o.client, req, info); err != nil {
+		return nil, fmt.Errorf("oidc userinfo: %w", err)
+	}
+	return info, nil
+}
+
+// discover 读取并缓存发现文档；失败时不缓存，下次登录重试
+func (o *OIDC) discover(ctx context.Context) (*discoveryDocument, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.discovery != nil {
+		return o.discovery, nil
+	}
+
+	issuer := strings.TrimSuffix(o.cfg.Issuer, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+discoveryPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	var doc discoveryDocument
+	if err := doJSON(o.client, req, &doc); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	// 发现文档中的签发方须与配置一致（OIDC Discovery 4.3），防止被引导到其他签发方
+	if strings.TrimSuffix(doc.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match configured %q", doc.Issuer, o.cfg.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, errors.New("oidc discovery: document lacks authorization, token or jwks endpoint")
+	}
+	o.discovery = &doc
+	return o.discovery, nil
+}
+
+// key 按kid取签名公钥；kid未知时重新拉取JWKS以支持提供方轮换密钥，两次拉取至少间隔jwksMinRefresh
+// 令牌未带kid且密钥集只有一把密钥时使用该密钥
+func (o *OIDC) key(ctx context.Context, jwksURI, kid string) (crypto.PublicKey, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if key := lookupKey(o.keys, kid); key != nil {
+		return key, nil
+	}
+	if o.keys != nil && o.now().Sub(o.keysFetchedAt) < jwksMinRefresh {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set jsonWebKeySet
+	if err := doJSON(o.client, req, &set); err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	o.keys = set.publicKeys()
+	o.keysFetchedAt = o.now()
+
+	if key := lookupKey(o.keys, kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func lookupKey(keys map[string]crypto.PublicKey, kid string) crypto.PublicKey {
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key
+		}
+	}
+	return keys[kid]
+}
@@ -0,0 +1,245 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"my-digital-home/pkg/common/config"
+)
+
+// fakeIdP 最小OIDC提供方：授权码good换出由当前密钥签名的ID令牌
+type fakeIdP struct {
+	t   *testing.T
+	srv *httptest.Server
+
+	mu      sync.Mutex
+	kid     string
+	signer  interface{}
+	method  jwt.SigningMethod
+	jwks    []jsonWebKey
+	claims  func(jwt.MapClaims)
+	fetches int
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	idp := &fakeIdP{t: t}
+	idp.rotateRSA("rsa-1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(discoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discoveryDocument{
+			Issuer:                idp.srv.URL,
+			AuthorizationEndpoint: idp.srv.URL + "/auth",
+			TokenEndpoint:         idp.srv.URL + "/token",
+			UserinfoEndpoint:      idp.srv.URL + "/userinfo",
+			JWKSURI:               idp.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		idp.fetches++
+		json.NewEncoder(w).Encode(jsonWebKeySet{Keys: idp.jwks})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "good" || r.FormValue("client_secret") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "at-1", "id_token": idp.idToken()})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer at-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"sub": "user-1", "email": "info@example.com", "email_verified": true})
+	})
+	idp.srv = httptest.NewServer(mux)
+	t.Cleanup(idp.srv.Close)
+	return idp
+}
+
+func (idp *fakeIdP) provider(cfg config.OIDCProviderConfig) *OIDC {
+	cfg.Issuer = idp.srv.URL
+	cfg.ClientID, cfg.ClientSecret, cfg.RedirectURL = "app", "secret", "https://app.example.com/cb"
+	return NewOIDC("keycloak", cfg, idp.srv.Client())
+}
+
+func (idp *fakeIdP) rotateRSA(kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.kid, idp.signer, idp.method = kid, key, jwt.SigningMethodRS256
+	idp.jwks = []jsonWebKey{{
+		Kty: "RSA", Kid: kid, Use: "sig",
+		N: base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}
+}
+
+func (idp *fakeIdP) idToken() string {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": idp.srv.URL, "aud": "app", "sub": "user-1",
+		"iat": now.Unix(), "exp": now.Add(5 * time.Minute).Unix(),
+		"email": "alice@example.com", "email_verified": "true", "preferred_username": "alice",
+	}
+	if idp.claims != nil {
+		idp.claims(claims)
+	}
+	token := jwt.NewWithClaims(idp.method, claims)
+	token.Header["kid"] = idp.kid
+	signed, err := token.SignedString(idp.signer)
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	return signed
+}
+
+func TestOIDCAuthCodeURLUsesDiscovery(t *testing.T) {
+	idp := newFakeIdP(t)
+	o := idp.provider(config.OIDCProviderConfig{Scopes: []string{"openid", "email"}})
+
+	raw, err := o.AuthCodeURL(context.Background(), "st4te")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(raw)
+	q := u.Query()
+	if u.Path != "/auth" || q.Get("client_id") != "app" || q.Get("state") != "st4te" || q.Get("scope") != "openid email" {
+		t.Fatalf("Unexpected authorization URL %s", raw)
+	}
+}
+
+func TestOIDCIdentifyVerifiesIDToken(t *testing.T) {
+	idp := newFakeIdP(t)
+	o := idp.provider(config.OIDCProviderConfig{})
+	ctx := context.Background()
+
+	id, err := o.Identify(ctx, "good")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Identity{Provider: "keycloak", Subject: "user-1", Email: "alice@example.com", EmailVerified: true, Name: "alice"}
+	if id != want {
+		t.Fatalf("Expected %+v, got %+v", want, id)
+	}
+
+	// 受众、签发方不符或已过期的令牌被拒绝
+	for name, mutate := range map[string]func(jwt.MapClaims){
+		"audience": func(c jwt.MapClaims) { c["aud"] = "other-app" },
+		"issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"expired":  func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"azp":      func(c jwt.MapClaims) { c["aud"] = []string{"app", "other-app"}; c["azp"] = "other-app" },
+	} {
+		idp.claims = mutate
+		if _, err := o.Identify(ctx, "good"); err == nil {
+			t.Errorf("Expected id_token with bad %s to be rejected", name)
+		}
+	}
+	idp.claims = nil
+
+	if _, err := o.Identify(ctx, "bad"); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Fatalf("Expected invalid_grant error, got %v", err)
+	}
+}
+
+func TestOIDCKeyRotation(t *testing.T) {
+	idp := newFakeIdP(t)
+	o := idp.provider(config.OIDCProviderConfig{})
+	now := time.Now()
+	o.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := o.Identify(ctx, "good"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 提供方换用EC密钥：刚拉取过JWKS时不立即重新拉取，超过最短间隔后拉取新密钥
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	idp.mu.Lock()
+	idp.kid, idp.signer, idp.method = "ec-1", ecKey, jwt.SigningMethodES256
+	idp.jwks = []jsonWebKey{{
+		Kty: "EC", Kid: "ec-1", Crv: "P-256",
+		X: base64.RawURLEncoding.EncodeToString(ecKey.X.FillBytes(make([]byte, 32))),
+		Y: base64.RawURLEncoding.EncodeToString(ecKey.Y.FillBytes(make([]byte, 32))),
+	}}
+	idp.mu.Unlock()
+
+	if _, err := o.Identify(ctx, "good"); err == nil {
+		t.Fatal("Expected unknown key to be rejected within the refresh interval")
+	}
+	now = now.Add(jwksMinRefresh)
+	if _, err := o.Identify(ctx, "good"); err != nil {
+		t.Fatalf("Expected rotated key to be fetched, got %v", err)
+	}
+	if idp.fetches != 2 {
+		t.Fatalf("Expected 2 JWKS fetches, got %d", idp.fetches)
+	}
+}
+
+func TestOIDCFallsBackToUserinfoAndTrustEmail(t *testing.T) {
+	idp := newFakeIdP(t)
+	idp.claims = func(c jwt.MapClaims) {
+		delete(c, "email")
+		delete(c, "email_verified")
+	}
+	id, err := idp.provider(config.OIDCProviderConfig{}).Identify(context.Background(), "good")
+	if err != nil || id.Email != "info@example.com" || !id.EmailVerified {
+		t.Fatalf("Expected email from userinfo, got %+v err=%v", id, err)
+	}
+
+	idp.claims = func(c jwt.MapClaims) { c["email_verified"] = false }
+	if id, _ := idp.provider(config.OIDCProviderConfig{}).Identify(context.Background(), "good"); id.EmailVerified {
+		t.Fatal("Expected unverified email by default")
+	}
+	if id, _ := idp.provider(config.OIDCProviderConfig{TrustEmail: true}).Identify(context.Background(), "good"); !id.EmailVerified {
+		t.Fatal("Expected TrustEmail to treat the email as verified")
+	}
+}
+
+func TestOIDCDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	// 发现文档声称的签发方是另一个地址
+	idp := newFakeIdP(t)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, err := idp.srv.Client().Get(idp.srv.URL + r.URL.Path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	}))
+	defer proxy.Close()
+
+	o := NewOIDC("keycloak", config.OIDCProviderConfig{
+		Issuer: proxy.URL, ClientID: "app", ClientSecret: "secret", RedirectURL: "https://app.example.com/cb",
+	}, proxy.Client())
+	if _, err := o.AuthCodeURL(context.Background(), "s"); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("Expected discovery to fail for a different issuer, got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+// Package pagination 列表接口的分页参数与响应元数据
+//
+// 支持两种模式：偏移分页（page/page_size）便于跳页，数据量大时越往后越慢；
+// 游标分页（mode=keyset开始，之后传上一页的next_cursor）按ID递增顺序续读，每页代价固定，但不统计总数、不能跳页
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+)
+
+var (
+	// ErrInvalidCursor 游标无法解析（被篡改或来自其他接口）
+	ErrInvalidCursor = errors.New("invalid pagination cursor")
+	// ErrInvalidMode 分页模式不是offset或keyset
+	ErrInvalidMode = errors.New("invalid pagination mode")
+)
+
+// 分页模式，为空时按是否携带游标决定
+const (
+	ModeOffset = "offset"
+	ModeKeyset = "keyset"
+)
+
+// Pagination 规范化后的分页参数，由Parse构造
+type Pagination struct {
+	Size    int   // 每页条数
+	Page    int   // 偏移分页的页码，从1开始；游标分页时为0
+	AfterID int64 // 游标分页：只返回ID大于该值的记录
+	Keyset  bool  // 是否为游标分页
+}
+
+// Parse 规范化查询参数：size不大于0时取defaultSize，超过maxSize时截断为maxSize
+// cursor非空时使用游标分页并忽略page；mode为keyset且没有cursor时从头开始游标分页（第一页），
+// 此后凭响应中的next_cursor续读；page小于1时视为第1页
+func Parse(page, size int, mode, cursor string, defaultSize, maxSize int) (Pagination, error) {
+	switch {
+	case size <= 0:
+		size = defaultSize
+	case size > maxSize:
+		size = maxSize
+	}
+
+	switch mode {
+	case "", ModeOffset, ModeKeyset:
+	default:
+		return Pagination{}, ErrInvalidMode
+	}
+
+	if cursor != "" {
+		afterID, err := DecodeCursor(cursor)
+		if err != nil {
+			return Pagination{}, err
+		}
+		return Pagination{Size: size, AfterID: afterID, Keyset: true}, nil
+	}
+	if mode == ModeKeyset {
+		return Pagination{Size: size, Keyset: true}, nil
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	return Pagination{Size: size, Page: page}, nil
+}
+
+// Offset 偏移分页应跳过的记录数
+func (p Pagination) Offset() int {
+	if p.Keyset || p.Page < 1 {
+		return 0
+	}
+	return (p.Page - 1) * p.Size
+}
+
+// EncodeCursor 由本页最后一条记录的ID生成下一页游标，对客户端不透明
+func EncodeCursor(lastID int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(lastID, 10)))
+}
+
+// DecodeCursor 解析EncodeCursor生成的游标
+func DecodeCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
+
+// Meta 列表响应中的分页信息，按模式省略不适用的字段
+type Meta struct {
+	Total      *int64 `json:"total,omitempty"` // 仅偏移分页
+	Page       int    `json:"page,omitempty"`  // 仅偏移分页
+	PageSize   int    `json:"page_size"`
+	NextCursor string `json:"next_cursor,omitempty"` // 仅游标分页，没有下一页时省略
+}
+
+// NewMeta 构造分页信息；游标分页时忽略total
+func NewMeta(p Pagination, total int64, nextCursor string) Meta {
+	meta := Meta{PageSize: p.Size, NextCursor: nextCursor}
+	if !p.Keyset {
+		meta.Total = &total
+		meta.Page = p.Page
+	}
+	return meta
+}
@@ -0,0 +1,48 @@
+package pagination
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseCapsSizeAndPicksMode(t *testing.T) {
+	p, err := Parse(0, 500, "", "", 20, 100)
+	if err != nil || p.Keyset || p.Page != 1 || p.Size != 100 || p.Offset() != 0 {
+		t.Fatalf("Expected first offset page capped at 100, got %+v (%v)", p, err)
+	}
+	if p, _ := Parse(3, 0, ModeOffset, "", 20, 100); p.Size != 20 || p.Offset() != 40 {
+		t.Fatalf("Expected default size and offset 40, got %+v", p)
+	}
+
+	p, err = Parse(3, 10, "", EncodeCursor(42), 20, 100)
+	if err != nil || !p.Keyset || p.AfterID != 42 || p.Page != 0 || p.Offset() != 0 {
+		t.Fatalf("Expected keyset page after id 42, got %+v (%v)", p, err)
+	}
+
+	// 没有游标时由mode=keyset开始游标分页
+	p, err = Parse(3, 10, ModeKeyset, "", 20, 100)
+	if err != nil || !p.Keyset || p.AfterID != 0 || p.Page != 0 {
+		t.Fatalf("Expected first keyset page, got %+v (%v)", p, err)
+	}
+	if _, err := Parse(1, 10, "seek", "", 20, 100); !errors.Is(err, ErrInvalidMode) {
+		t.Fatalf("Expected unknown mode to be rejected, got %v", err)
+	}
+
+	for _, cursor := range []string{"!!", EncodeCursor(-1), "YWJj"} {
+		if _, err := Parse(1, 10, "", cursor, 20, 100); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("Expected cursor %q to be rejected, got %v", cursor, err)
+		}
+	}
+}
+
+func TestMetaOmitsFieldsByMode(t *testing.T) {
+	offset := NewMeta(Pagination{Size: 20, Page: 2}, 45, "")
+	if offset.Total == nil || *offset.Total != 45 || offset.Page != 2 {
+		t.Fatalf("Expected total and page for offset mode, got %+v", offset)
+	}
+
+	keyset := NewMeta(Pagination{Size: 20, Keyset: true}, 45, "abc")
+	if keyset.Total != nil || keyset.Page != 0 || keyset.NextCursor != "abc" {
+		t.Fatalf("Expected only cursor for keyset mode, got %+v", keyset)
+	}
+}
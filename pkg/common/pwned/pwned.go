@@ -0,0 +1,84 @@
+// Package pwned 查询HaveIBeenPwned的Pwned Passwords范围接口，判断密码是否出现在已泄露的密码库中
+//
+// 采用k-匿名方式：只发送密码SHA-1的前5位十六进制，在返回的同前缀后缀列表中本地比对，完整哈希不离开本机
+package pwned
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/common/httpclient"
+)
+
+// prefixLen 发送给接口的哈希前缀长度
+const prefixLen = 5
+
+// Checker 密码泄露查询，可并发使用
+type Checker struct {
+	cfg    config.BreachCheckConfig
+	client httpclient.Doer
+}
+
+func New(cfg config.BreachCheckConfig, client httpclient.Doer) *Checker {
+	cfg.APIURL = strings.TrimSuffix(cfg.APIURL, "/") + "/"
+	return &Checker{cfg: cfg, client: client}
+}
+
+// Count 返回password在泄露库中出现的次数，未出现时为0；接口不可用时返回错误，由调用方决定是否放行
+func (c *Checker) Count(ctx context.Context, password string) (int, error) {
+	if c.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.cfg.Timeout)
+		defer cancel()
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:prefixLen], hash[prefixLen:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.cfg.APIURL+prefix, nil)
+	if err != nil {
+		return 0, err
+	}
+	// 填充响应使其长度不随前缀变化，避免旁路观察者据此推测查询的哈希
+	req.Header.Set("Add-Padding", "true")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("pwned passwords range %s: status %d", prefix, resp.StatusCode)
+	}
+
+	// 每行为"<35位后缀>:<次数>"，填充行的次数为0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		candidate, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || !strings.EqualFold(candidate, suffix) {
+			continue
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return 0, fmt.Errorf("pwned passwords range %s: invalid count %q", prefix, count)
+		}
+		return n, nil
+	}
+	return 0, scanner.Err()
+}
+
+// Breached 出现次数超过配置阈值时返回true
+func (c *Checker) Breached(ctx context.Context, password string) (bool, int, error) {
+	n, err := c.Count(ctx, password)
+	if err != nil {
+		return false, 0, err
+	}
+	return n > c.cfg.Threshold, n, nil
+}
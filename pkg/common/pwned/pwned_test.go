@@ -0,0 +1,58 @@
+package pwned
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"my-digital-home/pkg/common/config"
+)
+
+// "password"的SHA-1为5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+func TestBreachedUsesHashPrefixOnly(t *testing.T) {
+	var gotPath, gotPadding string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotPadding = r.URL.Path, r.Header.Get("Add-Padding")
+		fmt.Fprint(w, "0018A45C4D1DEF81644B54AB7F969B88D65:0\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493\r\n")
+	}))
+	defer srv.Close()
+
+	c := New(config.BreachCheckConfig{APIURL: srv.URL + "/range", Threshold: 10}, srv.Client())
+	breached, count, err := c.Breached(context.Background(), "password")
+	if err != nil || !breached || count != 3861493 {
+		t.Fatalf("Expected breached password, got %v %d %v", breached, count, err)
+	}
+	if gotPath != "/range/5BAA6" || gotPadding != "true" {
+		t.Fatalf("Expected only the 5-char prefix with padding, got %s padding=%q", gotPath, gotPadding)
+	}
+
+	// 未出现或次数不超过阈值的密码放行
+	if breached, _, err := c.Breached(context.Background(), "Unlisted#Passw0rd"); err != nil || breached {
+		t.Fatalf("Expected unlisted password to pass, got %v %v", breached, err)
+	}
+	c = New(config.BreachCheckConfig{APIURL: srv.URL + "/range", Threshold: 5000000}, srv.Client())
+	if breached, _, _ := c.Breached(context.Background(), "password"); breached {
+		t.Fatal("Expected count below threshold to pass")
+	}
+}
+
+func TestCountReportsUnavailableAPI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow/5BAA6" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	if _, err := New(config.BreachCheckConfig{APIURL: srv.URL}, srv.Client()).Count(context.Background(), "password"); err == nil {
+		t.Fatal("Expected error for non-200 response")
+	}
+	c := New(config.BreachCheckConfig{APIURL: srv.URL + "/slow", Timeout: 20 * time.Millisecond}, srv.Client())
+	if _, err := c.Count(context.Background(), "password"); err == nil {
+		t.Fatal("Expected timeout error")
+	}
+}
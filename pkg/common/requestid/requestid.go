@@ -0,0 +1,48 @@
+// Package requestid 请求ID在上下文中的传递，访问日志、数据库日志与出站请求据此关联到同一请求
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header 携带请求ID的请求头与响应头
+const Header = "X-Request-ID"
+
+// maxLen 采信上游传入的请求ID的最大长度
+const maxLen = 128
+
+type contextKey struct{}
+
+// NewContext 在上下文中记录请求ID
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext 读取NewContext记录的请求ID，没有时返回空字符串
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// New 生成16字节随机数的十六进制请求ID
+func New() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// Valid 判断上游传入的请求ID能否直接使用：非空、不超过128字节且只含可打印ASCII字符（不含空格），
+// 避免换行等字符伪造日志行
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
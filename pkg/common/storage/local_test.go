@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStoragePutGetDelete(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocalStorage(dir, "/uploads/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url, err := s.Put(context.Background(), "avatars/7/a.png", strings.NewReader("png"), "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "/uploads/avatars/7/a.png" {
+		t.Fatalf("Unexpected url %q", url)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "avatars", "7", "a.png")); err != nil || string(data) != "png" {
+		t.Fatalf("Expected stored content, got %q (%v)", data, err)
+	}
+
+	rc, err := s.Get(context.Background(), "avatars/7/a.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "png" {
+		t.Fatalf("Expected Get to return stored content, got %q", data)
+	}
+
+	if err := s.Delete(context.Background(), "avatars/7/a.png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(context.Background(), "avatars/7/a.png"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "avatars", "7", "a.png")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Expected file to be removed, got %v", err)
+	}
+	// 重复删除不报错
+	if err := s.Delete(context.Background(), "avatars/7/a.png"); err != nil {
+		t.Fatalf("Expected deleting a missing object to succeed, got %v", err)
+	}
+}
+
+func TestLocalStorageRejectsEscapingKeys(t *testing.T) {
+	s, err := NewLocalStorage(t.TempDir(), "/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../secret", `a\b`} {
+		if _, err := s.Put(context.Background(), key, strings.NewReader("x"), "text/plain"); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expected ErrInvalidKey for %q, got %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Package storage 上传文件的存储抽象，处理器只依赖Storage接口，后端可按部署环境替换
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/common/httpclient"
+)
+
+var (
+	// ErrInvalidKey 对象键为空、为绝对路径或试图跳出存储根目录
+	ErrInvalidKey = errors.New("invalid storage key")
+	// ErrNotFound 对象不存在
+	ErrNotFound = errors.New("storage object not found")
+)
+
+// Storage 对象存储，键使用"/"分隔的相对路径（如avatars/42/xxx.png）
+type Storage interface {
+	// Put 写入对象（已存在时覆盖），返回客户端可访问的URL
+	Put(ctx context.Context, key string, r io.Reader, contentType string) (string, error)
+	// Get 读取对象，调用方负责关闭；对象不存在时返回ErrNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除对象，对象不存在时不报错
+	Delete(ctx context.Context, key string) error
+}
+
+// New 按配置创建存储后端：Backend为空或local时使用本地磁盘，s3时使用S3兼容对象存储（经client访问）
+func New(cfg config.StorageConfig, client httpclient.Doer) (Storage, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return NewLocalStorage(cfg.Dir, cfg.URLPrefix)
+	case "s3":
+		return NewS3Storage(cfg.S3, client)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+// cleanKey 规范化对象键并拒绝越界路径
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
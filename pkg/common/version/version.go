@@ -0,0 +1,12 @@
+// Package version 构建信息，编译时通过ldflags注入：
+//
+//	go build -ldflags "-X my-digital-home/pkg/common/version.Version=v1.2.0 \
+//	  -X my-digital-home/pkg/common/version.GitCommit=$(git rev-parse --short HEAD) \
+//	  -X my-digital-home/pkg/common/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package version
+
+var (
+	Version   = "dev"
+	GitCommit = "unknown"
+	BuildTime = "unknown"
+)
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// APIKey 服务间调用使用的API Key，只保存哈希；明文只在创建时返回一次
+type APIKey struct {
+	ID         int64      `gorm:"primaryKey;autoIncrement"`
+	Name       string     `gorm:"type:varchar(100);not null"`            // 调用方服务名，作为请求上下文中的服务身份
+	Prefix     string     `gorm:"type:varchar(16);not null"`             // 明文开头几位，用于在列表中辨认，不足以还原Key
+	KeyHash    string     `gorm:"type:char(64);uniqueIndex;not null"`    // SHA-256十六进制
+	Scopes     string     `gorm:"type:varchar(512);not null;default:''"` // 逗号分隔的授权范围
+	IsActive   bool       `gorm:"default:true;index;not null"`           // 吊销后为false
+	CreatedBy  int64      `gorm:"not null"`                              // 创建该Key的管理员
+	CreatedAt  time.Time  `gorm:"autoCreateTime"`
+	LastUsedAt *time.Time // 最近一次认证成功的时间（有节流）
+	RevokedAt  *time.Time
+}
+
+// ScopeList 解析授权范围
+func (k APIKey) ScopeList() []string {
+	var scopes []string
+	for _, scope := range strings.Split(k.Scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
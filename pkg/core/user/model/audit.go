@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// 审计动作
+const (
+	AuditLogin              = "login"
+	AuditPasswordChange     = "password_change"
+	AuditSessionRevoke      = "session_revoke"
+	AuditUsernameChange     = "username_change"
+	AuditEmailChange        = "email_change"
+	AuditUserImport         = "admin.user_import"
+	AuditUserRestore        = "admin.user_restore"
+	AuditUserPurge          = "admin.user_purge"
+	AuditMaintenanceToggled = "admin.maintenance"
+	AuditDBLogLevel         = "admin.db_log_level"
+	AuditAPIKeyCreate       = "admin.api_key_create"
+	AuditAPIKeyRevoke       = "admin.api_key_revoke"
+)
+
+// AuditLog 敏感操作审计记录，只增不改
+type AuditLog struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement"`
+	ActorID   int64     `gorm:"index;not null"` // 操作者
+	Action    string    `gorm:"type:varchar(64);index;not null"`
+	TargetID  int64     `gorm:"index"` // 被操作的用户，无明确对象时为0
+	IP        string    `gorm:"type:varchar(64)"`
+	UserAgent string    `gorm:"type:varchar(255)"`
+	Detail    string    `gorm:"type:varchar(1024);not null;default:''"` // 补充信息（JSON），如资料变更前后的值
+	CreatedAt time.Time `gorm:"index;autoCreateTime"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
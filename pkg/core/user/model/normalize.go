@@ -0,0 +1,17 @@
+package model
+
+import "strings"
+
+// NormalizeEmail 邮箱统一去除首尾空白并转小写，保证唯一性校验与查询一致
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizeUsername 用户名去除首尾空白，caseInsensitive为true时转小写
+func NormalizeUsername(username string, caseInsensitive bool) string {
+	username = strings.TrimSpace(username)
+	if caseInsensitive {
+		username = strings.ToLower(username)
+	}
+	return username
+}
@@ -0,0 +1,18 @@
+package model
+
+import "testing"
+
+func TestNormalizeEmail(t *testing.T) {
+	if got := NormalizeEmail("  User@Example.COM "); got != "user@example.com" {
+		t.Fatalf("Expected user@example.com, got %q", got)
+	}
+}
+
+func TestNormalizeUsername(t *testing.T) {
+	if got := NormalizeUsername(" Admin ", true); got != "admin" {
+		t.Fatalf("Expected admin, got %q", got)
+	}
+	if got := NormalizeUsername(" Admin ", false); got != "Admin" {
+		t.Fatalf("Expected case to be preserved, got %q", got)
+	}
+}
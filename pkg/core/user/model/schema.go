@@ -0,0 +1,117 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// compatibleTypes 模型字段类型与数据库列类型的兼容关系
+var compatibleTypes = map[schema.DataType][]string{
+	schema.Bool:   {"tinyint", "bit", "bool", "boolean"},
+	schema.Int:    {"tinyint", "smallint", "mediumint", "int", "integer", "bigint"},
+	schema.Uint:   {"tinyint", "smallint", "mediumint", "int", "integer", "bigint"},
+	schema.Float:  {"float", "double", "decimal"},
+	schema.String: {"char", "varchar", "tinytext", "text", "mediumtext", "longtext"},
+	schema.Time:   {"datetime", "timestamp", "date"},
+	schema.Bytes:  {"binary", "varbinary", "blob", "longblob"},
+}
+
+// expectedColumn 模型期望的列定义
+type expectedColumn struct {
+	Name     string
+	DataType schema.DataType
+	SQLType  string // gorm type标签中声明的基础类型（如varchar），未声明时为空
+}
+
+// ValidateSchema 对比User模型与实际数据表，返回差异描述（为空表示一致）
+func ValidateSchema(db *gorm.DB) ([]string, error) {
+	expected, err := expectedColumns(&User{})
+	if err != nil {
+		return nil, err
+	}
+
+	actual, err := db.Migrator().ColumnTypes(&User{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", User{}.TableName(), err)
+	}
+
+	return diffColumns(User{}.TableName(), expected, actual), nil
+}
+
+// expectedColumns 解析模型定义得到期望的列
+func expectedColumns(value interface{}) ([]expectedColumn, error) {
+	s, err := schema.Parse(value, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse model schema: %w", err)
+	}
+
+	columns := make([]expectedColumn, 0, len(s.Fields))
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		columns = append(columns, expectedColumn{
+			Name:     field.DBName,
+			DataType: field.DataType,
+			SQLType:  baseType(field.TagSettings["TYPE"]),
+		})
+	}
+	return columns, nil
+}
+
+// diffColumns 找出缺失的列与类型不兼容的列
+func diffColumns(table string, expected []expectedColumn, actual []gorm.ColumnType) []string {
+	actualTypes := make(map[string]string, len(actual))
+	for _, col := range actual {
+		actualTypes[strings.ToLower(col.Name())] = baseType(col.DatabaseTypeName())
+	}
+
+	var problems []string
+	for _, col := range expected {
+		dbType, ok := actualTypes[col.Name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s.%s: column missing", table, col.Name))
+			continue
+		}
+		if !typeMatches(col, dbType) {
+			problems = append(problems, fmt.Sprintf("%s.%s: expected %s, got %s", table, col.Name, expectedTypeName(col), dbType))
+		}
+	}
+	return problems
+}
+
+func typeMatches(col expectedColumn, dbType string) bool {
+	if col.SQLType != "" {
+		return col.SQLType == dbType
+	}
+	allowed, ok := compatibleTypes[col.DataType]
+	if !ok {
+		return true // 未知类型不做校验
+	}
+	for _, t := range allowed {
+		if t == dbType {
+			return true
+		}
+	}
+	return false
+}
+
+func expectedTypeName(col expectedColumn) string {
+	if col.SQLType != "" {
+		return col.SQLType
+	}
+	return string(col.DataType)
+}
+
+// baseType 去掉长度与修饰，如 "VARCHAR(100) unsigned" -> "varchar"
+func baseType(t string) string {
+	t = strings.ToLower(strings.TrimSpace(t))
+	if i := strings.IndexAny(t, "( "); i >= 0 {
+		t = t[:i]
+	}
+	return t
+}
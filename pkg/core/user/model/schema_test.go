@@ -0,0 +1,82 @@
+package model
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/migrator"
+)
+
+// tableColumns 模拟与模型一致的数据表列
+func tableColumns() map[string]string {
+	return map[string]string{
+		"id":             "bigint",
+		"username":       "varchar",
+		"email":          "varchar",
+		"password_hash":  "varchar",
+		"is_active":      "tinyint",
+		"email_verified": "tinyint",
+		"roles":          "varchar",
+		"version":        "bigint",
+		"created_at":     "datetime",
+		"updated_at":     "datetime",
+		"last_login_at":  "datetime",
+		"avatar_url":     "varchar",
+		"deleted_at":     "datetime",
+	}
+}
+
+func toColumnTypes(columns map[string]string) []gorm.ColumnType {
+	result := make([]gorm.ColumnType, 0, len(columns))
+	for name, dataType := range columns {
+		result = append(result, migrator.ColumnType{
+			NameValue:     sql.NullString{String: name, Valid: true},
+			DataTypeValue: sql.NullString{String: dataType, Valid: true},
+		})
+	}
+	return result
+}
+
+func TestSchemaMatchesModel(t *testing.T) {
+	expected, err := expectedColumns(&User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if problems := diffColumns("base_users", expected, toColumnTypes(tableColumns())); len(problems) != 0 {
+		t.Fatalf("Expected no mismatch, got %v", problems)
+	}
+}
+
+func TestSchemaMissingColumnDetected(t *testing.T) {
+	expected, err := expectedColumns(&User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟手工变更后丢失了version列
+	columns := tableColumns()
+	delete(columns, "version")
+
+	problems := diffColumns("base_users", expected, toColumnTypes(columns))
+	if len(problems) != 1 || !strings.Contains(problems[0], "base_users.version: column missing") {
+		t.Fatalf("Expected missing version column to be reported, got %v", problems)
+	}
+}
+
+func TestSchemaTypeMismatchDetected(t *testing.T) {
+	expected, err := expectedColumns(&User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	columns := tableColumns()
+	columns["email"] = "int"
+
+	problems := diffColumns("base_users", expected, toColumnTypes(columns))
+	if len(problems) != 1 || !strings.Contains(problems[0], "base_users.email: expected varchar, got int") {
+		t.Fatalf("Expected email type mismatch to be reported, got %v", problems)
+	}
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// Session 登录会话（每次登录一条），持有刷新令牌的哈希；访问令牌通过sid声明关联会话
+type Session struct {
+	ID               int64      `gorm:"primaryKey;autoIncrement"`
+	UserID           int64      `gorm:"index;not null"`
+	RefreshTokenHash string     `gorm:"type:char(64);uniqueIndex;not null"` // SHA-256十六进制，刷新时轮换
+	Device           string     `gorm:"type:varchar(100)"`                  // 客户端自报的设备名
+	UserAgent        string     `gorm:"type:varchar(255)"`
+	IP               string     `gorm:"type:varchar(64)"`
+	CreatedAt        time.Time  `gorm:"autoCreateTime"` // 即密码认证时间
+	LastUsedAt       time.Time  `gorm:"not null"`
+	ExpiresAt        time.Time  `gorm:"index;not null"` // 刷新令牌到期时间
+	RevokedAt        *time.Time // 撤销（登出）时间
+}
+
+func (Session) TableName() string {
+	return "user_sessions"
+}
@@ -2,19 +2,41 @@ package model
 
 import (
 	"gorm.io/gorm"
+	"strings"
 	"time"
 )
 
+// 内置角色
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
-	ID           int64          `gorm:"primaryKey;autoIncrement"`
-	Username     string         `gorm:"type:varchar(100);uniqueIndex;not null"`
-	Email        string         `gorm:"type:varchar(255);uniqueIndex;not null"`
-	PasswordHash string         `gorm:"type:varchar(255);not null"`
-	IsActive     bool           `gorm:"default:true;index"`
-	Version      int            `gorm:"default:1;not null"` // 新增乐观锁配置
-	CreatedAt    time.Time      `gorm:"index;autoCreateTime"`
-	UpdatedAt    time.Time      `gorm:"autoUpdateTime"`
-	DeletedAt    gorm.DeletedAt `gorm:"index"` // 软删除标记
+	ID            int64          `gorm:"primaryKey;autoIncrement"`
+	Username      string         `gorm:"type:varchar(100);uniqueIndex;not null"`
+	Email         string         `gorm:"type:varchar(255);uniqueIndex;not null"`
+	PasswordHash  string         `gorm:"type:varchar(255);not null"`
+	IsActive      bool           `gorm:"default:true;index"`
+	EmailVerified bool           `gorm:"default:false;not null"`
+	Roles         string         `gorm:"type:varchar(255);default:'user';not null"` // 逗号分隔的角色列表
+	Version       int            `gorm:"default:1;not null"`                        // 新增乐观锁配置
+	CreatedAt     time.Time      `gorm:"index;autoCreateTime"`
+	UpdatedAt     time.Time      `gorm:"autoUpdateTime"`
+	LastLoginAt   *time.Time     // 最近登录时间，独立写入，不递增Version
+	AvatarURL     string         `gorm:"type:varchar(512);not null;default:''"` // 头像地址，未上传时为空
+	DeletedAt     gorm.DeletedAt `gorm:"index"`                                 // 软删除标记
+}
+
+// RoleList 解析角色列表
+func (u User) RoleList() []string {
+	var roles []string
+	for _, role := range strings.Split(u.Roles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
 }
 
 // TableName 定义映射表名
@@ -22,7 +44,37 @@ func (User) TableName() string {
 	return "base_users" // 更清晰的表名
 }
 
+// PasswordResetToken 密码重置令牌（仅存储哈希，单次有效）
+type PasswordResetToken struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement"`
+	UserID    int64      `gorm:"index;not null"`
+	TokenHash string     `gorm:"type:char(64);uniqueIndex;not null"` // SHA-256十六进制
+	ExpiresAt time.Time  `gorm:"index;not null"`
+	UsedAt    *time.Time // 使用或作废时间
+	CreatedAt time.Time  `gorm:"autoCreateTime"`
+}
+
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
+
 func AutoMigrate(db *gorm.DB) error {
-	return db.Set("gorm:table_options", "COMMENT='用户基础表'").
-		AutoMigrate(&User{})
+	if err := db.Set("gorm:table_options", "COMMENT='用户基础表'").
+		AutoMigrate(&User{}); err != nil {
+		return err
+	}
+	if err := db.Set("gorm:table_options", "COMMENT='密码重置令牌表'").
+		AutoMigrate(&PasswordResetToken{}); err != nil {
+		return err
+	}
+	if err := db.Set("gorm:table_options", "COMMENT='审计日志表'").
+		AutoMigrate(&AuditLog{}); err != nil {
+		return err
+	}
+	if err := db.Set("gorm:table_options", "COMMENT='登录会话表'").
+		AutoMigrate(&Session{}); err != nil {
+		return err
+	}
+	return db.Set("gorm:table_options", "COMMENT='服务API Key表'").
+		AutoMigrate(&APIKey{})
 }
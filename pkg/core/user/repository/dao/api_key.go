@@ -0,0 +1,17 @@
+package dao
+
+import (
+	"context"
+	"my-digital-home/pkg/core/user/model"
+	"time"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key model.APIKey) (int64, error) // 返回Key的ID
+	// 按哈希查找未吊销的Key，并刷新最近使用时间（有节流）；不存在或已吊销时返回ErrAPIKeyNotFound
+	AuthenticateAPIKey(ctx context.Context, keyHash string, at time.Time) (model.APIKey, error)
+	// 全部Key（含已吊销），不含哈希，最新创建的在前
+	ListAPIKeys(ctx context.Context) ([]model.APIKey, error)
+	// 吊销后立即失效，不存在或已吊销时返回ErrAPIKeyNotFound
+	RevokeAPIKey(ctx context.Context, id int64) error
+}
@@ -0,0 +1,10 @@
+package dao
+
+import (
+	"context"
+	"my-digital-home/pkg/core/user/model"
+)
+
+type AuditRepository interface {
+	CreateAuditLog(ctx context.Context, entry model.AuditLog) error
+}
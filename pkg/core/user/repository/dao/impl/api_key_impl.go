@@ -0,0 +1,85 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"my-digital-home/pkg/core/user/model"
+	"my-digital-home/pkg/core/user/repository/dao"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+type GormAPIKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository builds a gorm-backed APIKeyRepository on db
+func NewAPIKeyRepository(db *gorm.DB) dao.APIKeyRepository {
+	return &GormAPIKeyRepository{
+		db: db.Model(&model.APIKey{}).Session(&gorm.Session{}),
+	}
+}
+
+// Store a new key and return its id
+func (r *GormAPIKeyRepository) CreateAPIKey(ctx context.Context, key model.APIKey) (int64, error) {
+	if err := r.db.WithContext(ctx).Create(&key).Error; err != nil {
+		return 0, fmt.Errorf("%w: api key creation failed", wrapGormError(err))
+	}
+	return key.ID, nil
+}
+
+// Look up an active key by hash, bumping last_used_at only when the stored
+// value is older than touchInterval
+func (r *GormAPIKeyRepository) AuthenticateAPIKey(ctx context.Context, keyHash string, at time.Time) (model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).
+		Select("id", "name", "prefix", "scopes", "is_active", "created_by", "created_at", "last_used_at").
+		Where("key_hash = ? AND is_active = ?", keyHash, true).
+		First(&key).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return model.APIKey{}, ErrAPIKeyNotFound
+	case err != nil:
+		return model.APIKey{}, fmt.Errorf("%w: api key lookup failed", wrapGormError(err))
+	}
+
+	if key.LastUsedAt == nil || at.Sub(*key.LastUsedAt) >= touchInterval {
+		if err := r.db.WithContext(ctx).Where("id = ?", key.ID).
+			UpdateColumn("last_used_at", at).Error; err != nil {
+			return model.APIKey{}, fmt.Errorf("%w: api key touch failed", wrapGormError(err))
+		}
+		key.LastUsedAt = &at
+	}
+	return key, nil
+}
+
+// List every key, newest first, without hashes
+func (r *GormAPIKeyRepository) ListAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := r.db.WithContext(ctx).
+		Select("id", "name", "prefix", "scopes", "is_active", "created_by", "created_at", "last_used_at", "revoked_at").
+		Order("id DESC").
+		Find(&keys).Error
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to list api keys", wrapGormError(err))
+	}
+	return keys, nil
+}
+
+// Deactivate a key; requests carrying it are rejected from now on
+func (r *GormAPIKeyRepository) RevokeAPIKey(ctx context.Context, id int64) error {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND is_active = ?", id, true).
+		UpdateColumns(map[string]interface{}{"is_active": false, "revoked_at": time.Now()})
+	if result.Error != nil {
+		return fmt.Errorf("%w: api key revocation failed", wrapGormError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestAuthenticateAPIKeyOnlyMatchesActiveKeys(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewAPIKeyRepository(db)
+	now := time.Now()
+
+	lookup := regexp.QuoteMeta("SELECT `id`,`name`,`prefix`,`scopes`,`is_active`,`created_by`,`created_at`,`last_used_at` FROM `api_keys` WHERE key_hash = ? AND is_active = ?")
+	columns := []string{"id", "name", "prefix", "scopes", "is_active", "created_by", "created_at", "last_used_at"}
+	// 刚使用过：只校验不写库
+	mock.ExpectQuery(lookup).WithArgs("hash", true, 1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(3, "billing", "mdh_abcdefgh", "users:read", true, 1, now, now.Add(-time.Second)))
+	// 已吊销或不存在
+	mock.ExpectQuery(lookup).WithArgs("revoked", true, 1).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	key, err := repo.AuthenticateAPIKey(context.Background(), "hash", now)
+	if err != nil || key.ID != 3 || len(key.ScopeList()) != 1 {
+		t.Fatalf("Expected key 3 with one scope, got %+v (%v)", key, err)
+	}
+	if _, err := repo.AuthenticateAPIKey(context.Background(), "revoked", now); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Fatalf("Expected ErrAPIKeyNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
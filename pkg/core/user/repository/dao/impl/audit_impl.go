@@ -0,0 +1,29 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"my-digital-home/pkg/core/user/model"
+	"my-digital-home/pkg/core/user/repository/dao"
+
+	"gorm.io/gorm"
+)
+
+type GormAuditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository builds a gorm-backed AuditRepository on db
+func NewAuditRepository(db *gorm.DB) dao.AuditRepository {
+	return &GormAuditRepository{
+		db: db.Model(&model.AuditLog{}).Session(&gorm.Session{}),
+	}
+}
+
+// Append a single audit entry
+func (r *GormAuditRepository) CreateAuditLog(ctx context.Context, entry model.AuditLog) error {
+	if err := r.db.WithContext(ctx).Create(&entry).Error; err != nil {
+		return fmt.Errorf("%w: failed to write audit log", wrapGormError(err))
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"my-digital-home/pkg/core/user/model"
+	"my-digital-home/pkg/core/user/repository/dao"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// touchInterval throttles last_used_at writes so authenticated requests don't each hit the primary
+const touchInterval = time.Minute
+
+type GormSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository builds a gorm-backed SessionRepository on db
+func NewSessionRepository(db *gorm.DB) dao.SessionRepository {
+	return &GormSessionRepository{
+		db: db.Model(&model.Session{}).Session(&gorm.Session{}),
+	}
+}
+
+// Store a new session and return its id
+func (r *GormSessionRepository) CreateSession(ctx context.Context, session model.Session) (int64, error) {
+	if err := r.db.WithContext(ctx).Create(&session).Error; err != nil {
+		return 0, fmt.Errorf("%w: session creation failed", wrapGormError(err))
+	}
+	return session.ID, nil
+}
+
+// List the user's sessions that are neither revoked nor expired, most recently used first
+func (r *GormSessionRepository) ListActiveSessions(ctx context.Context, userID int64) ([]model.Session, error) {
+	var sessions []model.Session
+	err := r.db.WithContext(ctx).
+		Select("id", "user_id", "device", "user_agent", "ip", "created_at", "last_used_at", "expires_at").
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to list sessions", wrapGormError(err))
+	}
+	return sessions, nil
+}
+
+// Report whether the session is still live for the user, bumping last_used_at
+// only when the stored value is older than touchInterval
+func (r *GormSessionRepository) TouchSession(ctx context.Context, userID, sessionID int64, at time.Time) (bool, error) {
+	var session model.Session
+	err := r.db.WithContext(ctx).Select("id", "last_used_at").
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, userID, at).
+		First(&session).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("%w: session lookup failed", wrapGormError(err))
+	}
+
+	if at.Sub(session.LastUsedAt) >= touchInterval {
+		if err := r.db.WithContext(ctx).Where("id = ?", sessionID).
+			UpdateColumn("last_used_at", at).Error; err != nil {
+			return false, fmt.Errorf("%w: session touch failed", wrapGormError(err))
+		}
+	}
+	return true, nil
+}
+
+// Swap the refresh token of a live session; the old hash stops working immediately
+func (r *GormSessionRepository) RotateRefreshToken(ctx context.Context, oldHash, newHash string, at time.Time) (model.Session, error) {
+	var session model.Session
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("refresh_token_hash = ? AND revoked_at IS NULL AND expires_at > ?", oldHash, at).
+			First(&session).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidToken
+		}
+		if err != nil {
+			return fmt.Errorf("%w: refresh token lookup failed", wrapGormError(err))
+		}
+
+		if err := tx.Where("id = ?", session.ID).UpdateColumns(map[string]interface{}{
+			"refresh_token_hash": newHash,
+			"last_used_at":       at,
+		}).Error; err != nil {
+			return fmt.Errorf("%w: refresh token rotation failed", wrapGormError(err))
+		}
+		session.RefreshTokenHash = newHash
+		session.LastUsedAt = at
+		return nil
+	})
+	if err != nil {
+		return model.Session{}, err
+	}
+	return session, nil
+}
+
+// Revoke one of the user's live sessions
+func (r *GormSessionRepository) RevokeSession(ctx context.Context, userID, sessionID int64) error {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		UpdateColumn("revoked_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("%w: session revocation failed", wrapGormError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// Revoke every live session of the user except keepID (0 revokes all)
+func (r *GormSessionRepository) RevokeOtherSessions(ctx context.Context, userID, keepID int64) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepID).
+		UpdateColumn("revoked_at", time.Now())
+	if result.Error != nil {
+		return 0, fmt.Errorf("%w: session revocation failed", wrapGormError(result.Error))
+	}
+	return result.RowsAffected, nil
+}
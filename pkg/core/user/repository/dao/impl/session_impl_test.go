@@ -0,0 +1,46 @@
+package dao
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestTouchSessionThrottlesLastUsedWrites(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewSessionRepository(db)
+	now := time.Now()
+
+	lookup := regexp.QuoteMeta("SELECT `id`,`last_used_at` FROM `user_sessions` WHERE id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?")
+	// 刚使用过：只校验不写库
+	mock.ExpectQuery(lookup).WithArgs(10, 1, now, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "last_used_at"}).AddRow(10, now.Add(-time.Second)))
+	// 超过节流间隔：刷新最近使用时间
+	mock.ExpectQuery(lookup).WithArgs(10, 1, now, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "last_used_at"}).AddRow(10, now.Add(-time.Hour)))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `user_sessions` SET `last_used_at`=? WHERE id = ?")).
+		WithArgs(now, 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	// 已撤销：无匹配行
+	mock.ExpectQuery(lookup).WithArgs(11, 1, now, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "last_used_at"}))
+
+	for _, tc := range []struct {
+		sid    int64
+		active bool
+	}{{10, true}, {10, true}, {11, false}} {
+		active, err := repo.TouchSession(context.Background(), 1, tc.sid, now)
+		if err != nil || active != tc.active {
+			t.Fatalf("session %d: expected active=%v, got %v (%v)", tc.sid, tc.active, active, err)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
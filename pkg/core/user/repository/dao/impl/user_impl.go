@@ -1,11 +1,16 @@
 package dao
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"gorm.io/gorm/clause"
+	"math/rand"
+	"my-digital-home/pkg/common/pagination"
 	"my-digital-home/pkg/core/user/model"
 	"my-digital-home/pkg/core/user/repository/dao"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
@@ -16,17 +21,54 @@ var (
 	ErrUserNotFound     = errors.New("user not found")
 	ErrDuplicateEntry   = errors.New("duplicate user entry")
 	ErrDatabaseInternal = errors.New("database internal error")
+	ErrInvalidToken     = errors.New("invalid or expired token")
+	ErrTransient        = errors.New("transient database error, retry may succeed") // deadlock / lock wait timeout
+	ErrConflict         = errors.New("foreign key constraint conflict")
+	ErrCapacity         = errors.New("database connection capacity exhausted")
+	ErrVersionConflict  = errors.New("concurrent modification, version conflict persisted")
+
+	// Duplicates on a known unique column; all of them still match ErrDuplicateEntry
+	ErrUsernameTaken = fmt.Errorf("%w: username", ErrDuplicateEntry)
+	ErrEmailTaken    = fmt.Errorf("%w: email", ErrDuplicateEntry)
+	ErrIdentityHeld  = fmt.Errorf("%w: held by a deleted account", ErrDuplicateEntry)
 )
 
 type GormUserRepository struct {
 	db *gorm.DB
+
+	// Read replicas for lookups that tolerate replication lag, used round-robin.
+	// Routing is opt-in per method via reader() rather than gorm's dbresolver plugin:
+	// dbresolver sends every SELECT to a replica unless the query opts out, which would
+	// silently move version re-reads, pre-write checks and credential lookups onto lagging
+	// copies; here anything not explicitly lag-tolerant stays on the primary.
+	replicas []*gorm.DB
+	next     atomic.Uint64
 }
 
+// publicColumns are safe to expose to handlers (no password hash)
+var publicColumns = []string{"id", "username", "email", "email_verified", "roles", "avatar_url", "created_at", "updated_at", "last_login_at", "version"}
+
 // User查询方法实现（优化版本）
-func (r *GormUserRepository) QueryByID(id int64) (model.User, error) {
+func (r *GormUserRepository) QueryByID(ctx context.Context, id int64) (model.User, error) {
+	return r.queryActiveUser(ctx, "id = ?", id)
+}
+
+// Query an active user by username
+func (r *GormUserRepository) QueryByUsername(ctx context.Context, username string) (model.User, error) {
+	return r.queryActiveUser(ctx, "username = ?", username)
+}
+
+// Query an active user by email
+func (r *GormUserRepository) QueryByEmail(ctx context.Context, email string) (model.User, error) {
+	return r.queryActiveUser(ctx, "email = ?", email)
+}
+
+// queryActiveUser loads public columns of the single active user matching cond
+func (r *GormUserRepository) queryActiveUser(ctx context.Context, cond string, arg interface{}) (model.User, error) {
 	var user model.User
-	err := r.db.Select("id", "username", "email", "created_at", "updated_at", "version").
-		Where("id = ? AND is_active = ?", id, true).
+	err := r.reader(ctx).WithContext(ctx).Select(publicColumns).
+		Where(cond, arg).
+		Where("is_active = ?", true).
 		First(&user).
 		Error
 
@@ -40,18 +82,75 @@ func (r *GormUserRepository) QueryByID(id int64) (model.User, error) {
 	}
 }
 
-var DefaultUserRepo dao.UserRepository
+// Search active users whose username starts with prefix (matched literally), ordered by username
+func (r *GormUserRepository) SearchByUsername(ctx context.Context, prefix string, limit int) ([]model.User, error) {
+	users := make([]model.User, 0, limit)
+	err := r.db.WithContext(ctx).Select(publicColumns).
+		Where("username LIKE ? AND is_active = ?", escapeLike(prefix)+"%", true).
+		Order("username ASC").
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to search users", wrapGormError(err))
+	}
+	return users, nil
+}
+
+// Count active users; the model scope already excludes soft-deleted rows
+func (r *GormUserRepository) CountActiveUsers(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Where("is_active = ?", true).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("%w: failed to count active users", wrapGormError(err))
+	}
+	return count, nil
+}
+
+// NewUserRepository builds a gorm-backed UserRepository on db.
+// Single-row lookups are routed to replicas when any are given; writes always go to db.
+func NewUserRepository(db *gorm.DB, replicas ...*gorm.DB) dao.UserRepository {
+	r := &GormUserRepository{db: userSession(db)}
+	for _, replica := range replicas {
+		r.replicas = append(r.replicas, userSession(replica))
+	}
+	return r
+}
+
+// userSession starts a new session so each chained query starts from a clean statement
+func userSession(db *gorm.DB) *gorm.DB {
+	return db.Model(&model.User{}).Session(&gorm.Session{})
+}
+
+// Run fn in one transaction; the repository handed to fn is bound to it and never reads
+// from replicas, so fn sees its own writes. Transient failures re-run fn via RetryTransaction.
+// fn's error is returned unchanged
+func (r *GormUserRepository) WithTx(ctx context.Context, fn func(tx dao.UserRepository) error) error {
+	var fnErr error
+	err := RetryTransaction(ctx, r.db, func(tx *gorm.DB) error {
+		fnErr = fn(&GormUserRepository{db: tx})
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return fmt.Errorf("%w: transaction failed", wrapGormError(err))
+	}
+	return nil
+}
 
-func NewUserRepository(db *gorm.DB) {
-	DefaultUserRepo = &GormUserRepository{
-		db: db.Model(&model.User{}),
+// reader picks the connection for a lag-tolerant read: the primary when no replicas
+// are configured or the caller asked for read-after-write consistency via dao.WithPrimary
+func (r *GormUserRepository) reader(ctx context.Context) *gorm.DB {
+	if len(r.replicas) == 0 || dao.PrimaryForced(ctx) {
+		return r.db
 	}
+	return r.replicas[r.next.Add(1)%uint64(len(r.replicas))]
 }
 
 // Check username existence with active status
-func (r *GormUserRepository) IsUsernameExists(username string) (bool, error) {
+func (r *GormUserRepository) IsUsernameExists(ctx context.Context, username string) (bool, error) {
 	var count int64
-	err := r.db.Where("username = ? AND is_active = ?", username, true).
+	err := r.reader(ctx).WithContext(ctx).Where("username = ? AND is_active = ?", username, true).
 		Count(&count).Error
 	if err != nil {
 		return false, fmt.Errorf("%w: failed to check username", wrapGormError(err))
@@ -60,33 +159,112 @@ func (r *GormUserRepository) IsUsernameExists(username string) (bool, error) {
 }
 
 // Check email existence with active status
-func (r *GormUserRepository) IsEmailExists(email string) (bool, error) {
+func (r *GormUserRepository) IsEmailExists(ctx context.Context, email string) (bool, error) {
 	var count int64
-	err := r.db.Where("email = ? AND is_active = ?", email, true).Count(&count).Error
+	err := r.reader(ctx).WithContext(ctx).Where("email = ? AND is_active = ?", email, true).Count(&count).Error
 	if err != nil {
 		return false, fmt.Errorf("%w: failed to check email", wrapGormError(err))
 	}
 	return count > 0, nil
 }
 
-// Create new user with transaction
-func (r *GormUserRepository) CreateUser(user model.User) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+// Create new user with transaction. The unique indexes cover soft-deleted and deactivated
+// rows too, so a duplicate is reported as ErrIdentityHeld when a deleted account holds the
+// value, otherwise as ErrUsernameTaken / ErrEmailTaken.
+func (r *GormUserRepository) CreateUser(ctx context.Context, user model.User) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(&user).Error; err != nil {
 			if isDuplicateError(err) {
-				return ErrDuplicateEntry
+				return duplicateColumnError(err)
 			}
 			return fmt.Errorf("%w: user creation failed", wrapGormError(err))
 		}
 		return nil
 	})
+	if errors.Is(err, ErrDuplicateEntry) {
+		return r.classifyDuplicate(ctx, user, err)
+	}
+	return err
+}
+
+// classifyDuplicate checks whether the colliding username/email belongs to a deleted
+// account; lookups that fail leave the original duplicate error in place
+func (r *GormUserRepository) classifyDuplicate(ctx context.Context, user model.User, dup error) error {
+	if !errors.Is(dup, ErrEmailTaken) {
+		if held, err := r.IsUsernameHeldByDeleted(ctx, user.Username); err == nil && held {
+			return ErrIdentityHeld
+		}
+	}
+	if !errors.Is(dup, ErrUsernameTaken) {
+		if held, err := r.IsEmailHeldByDeleted(ctx, user.Email); err == nil && held {
+			return ErrIdentityHeld
+		}
+	}
+	return dup
+}
+
+// Create users in a single transaction, batchSize rows per INSERT. A batch that hits a
+// duplicate is rolled back to its savepoint and retried row by row, so the bad rows are
+// reported while the rest are still imported. Any other error aborts the whole import.
+func (r *GormUserRepository) CreateUsers(ctx context.Context, users []model.User, batchSize int) ([]dao.CreateFailure, error) {
+	if batchSize <= 0 {
+		batchSize = len(users)
+	}
+
+	var failures []dao.CreateFailure
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(users); start += batchSize {
+			batch := users[start:min(start+batchSize, len(users))]
+
+			err := tx.Transaction(func(sp *gorm.DB) error {
+				return sp.CreateInBatches(batch, batchSize).Error
+			})
+			if err == nil {
+				continue
+			}
+			if !isDuplicateError(err) {
+				return fmt.Errorf("%w: batch user creation failed", wrapGormError(err))
+			}
+
+			// Fall back to one row per savepoint to isolate the duplicates
+			for i := range batch {
+				err := tx.Transaction(func(sp *gorm.DB) error {
+					return sp.Create(&batch[i]).Error
+				})
+				switch {
+				case err == nil:
+				case isDuplicateError(err):
+					failures = append(failures, dao.CreateFailure{Index: start + i, Err: duplicateColumnError(err)})
+				default:
+					return fmt.Errorf("%w: batch user creation failed", wrapGormError(err))
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return failures, nil
 }
 
 // Get user credentials with Optimistic Lock check
-func (r *GormUserRepository) GetPasswordHash(username string) (string, int64, error) {
+func (r *GormUserRepository) GetPasswordHash(ctx context.Context, username string) (string, int64, error) {
+	return r.passwordHashBy(ctx, "username = ?", username)
+}
+
+// Get the password hash of an active user by email
+func (r *GormUserRepository) GetPasswordHashByEmail(ctx context.Context, email string) (string, int64, error) {
+	return r.passwordHashBy(ctx, "email = ?", email)
+}
+
+// Credentials are always read from the primary: on a lagging replica a password that was
+// just changed or reset would keep working
+func (r *GormUserRepository) passwordHashBy(ctx context.Context, cond string, arg interface{}) (string, int64, error) {
 	var user model.User
-	err := r.db.Select("password_hash", "id", "version").
-		Where("username = ? AND is_active = ?", username, true).
+	err := r.db.WithContext(ctx).Select("password_hash", "id", "version").
+		Where(cond, arg).
+		Where("is_active = ?", true).
 		First(&user).Error
 
 	switch {
@@ -100,27 +278,385 @@ func (r *GormUserRepository) GetPasswordHash(username string) (string, int64, er
 }
 
 // Update password with version control
-func (r *GormUserRepository) UpdatePassword(userID uint, newPwdHash string) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+func (r *GormUserRepository) UpdatePassword(ctx context.Context, userID uint, newPwdHash string) error {
+	err := r.retryOnVersionConflict(ctx, int64(userID), func(db *gorm.DB, user model.User) (int64, error) {
+		var affected int64
+		// A deadlock rolls the update back, so re-running it against the same version is safe
+		err := RetryTransaction(ctx, db, func(tx *gorm.DB) error {
+			result := tx.Where("id = ? AND version = ?", user.ID, user.Version).
+				Updates(map[string]interface{}{
+					"password_hash": newPwdHash,
+					"version":       user.Version + 1,
+					"updated_at":    time.Now(),
+				})
+			affected = result.RowsAffected
+			return result.Error
+		})
+		return affected, err
+	})
+	if err != nil && !errors.Is(err, ErrUserNotFound) && !errors.Is(err, ErrVersionConflict) {
+		return fmt.Errorf("%w: password update failed", err)
+	}
+	return err
+}
+
+// Replace the avatar URL; like other profile changes it bumps the version
+func (r *GormUserRepository) UpdateAvatar(ctx context.Context, userID int64, avatarURL string) error {
+	err := r.retryOnVersionConflict(ctx, userID, func(tx *gorm.DB, user model.User) (int64, error) {
+		result := tx.Where("id = ? AND version = ?", user.ID, user.Version).
+			Updates(map[string]interface{}{
+				"avatar_url": avatarURL,
+				"version":    user.Version + 1,
+				"updated_at": time.Now(),
+			})
+		return result.RowsAffected, result.Error
+	})
+	if err != nil && !errors.Is(err, ErrUserNotFound) && !errors.Is(err, ErrVersionConflict) {
+		return fmt.Errorf("%w: avatar update failed", err)
+	}
+	return err
+}
+
+// Change username and/or email, guarded by the version the caller read in current
+// rather than retried: the caller audits and notifies based on the values it saw.
+// A changed email starts unverified; collisions are classified like CreateUser.
+func (r *GormUserRepository) UpdateIdentity(ctx context.Context, current model.User, username, email string) error {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND version = ? AND is_active = ?", current.ID, current.Version, true).
+		Updates(map[string]interface{}{
+			"username":       username,
+			"email":          email,
+			"email_verified": current.EmailVerified && email == current.Email,
+			"version":        current.Version + 1,
+			"updated_at":     time.Now(),
+		})
+	if err := result.Error; err != nil {
+		if isDuplicateError(err) {
+			return r.classifyDuplicate(ctx, model.User{Username: username, Email: email}, duplicateColumnError(err))
+		}
+		return fmt.Errorf("%w: identity update failed", wrapGormError(err))
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// maxVersionRetries bounds how often a versioned update is retried after losing a race
+const maxVersionRetries = 3
+
+// versionedUpdate applies an update guarded by the version read into user and reports rows affected
+type versionedUpdate func(tx *gorm.DB, user model.User) (int64, error)
+
+// retryOnVersionConflict re-reads the row and retries update while the optimistic lock keeps losing.
+// A missing row is a genuine ErrUserNotFound; exhausting the retries returns ErrVersionConflict.
+func (r *GormUserRepository) retryOnVersionConflict(ctx context.Context, userID int64, update versionedUpdate) error {
+	for attempt := 0; attempt < maxVersionRetries; attempt++ {
+		// Stop retrying once the caller gave up (e.g. request timeout)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		var user model.User
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		if err := r.db.WithContext(ctx).Select("id", "version").
 			Where("id = ? AND is_active = ?", userID, true).
 			First(&user).Error; err != nil {
 			return wrapGormError(err)
 		}
 
+		affected, err := update(r.db.WithContext(ctx), user)
+		if err != nil {
+			return wrapGormError(err)
+		}
+		if affected > 0 {
+			return nil
+		}
+		// Row was present but the version moved on: re-read and try again
+	}
+	return ErrVersionConflict
+}
+
+// maxTransientRetries bounds how often a transaction is run when it keeps hitting deadlocks
+const maxTransientRetries = 3
+
+// transientBackoff is the wait before the first re-run; it doubles per attempt, with jitter
+// so the transactions that deadlocked each other don't collide again
+const transientBackoff = 20 * time.Millisecond
+
+// RetryTransaction runs fn in a transaction on db and re-runs the whole transaction while it
+// fails with a transient error (deadlock, lock wait timeout), at most maxTransientRetries times.
+// MySQL has already rolled the transaction back by then, so fn must not have effects outside tx.
+// The last error is returned as fn produced it; ctx cancellation stops the retries.
+func RetryTransaction(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	var err error
+	for attempt := 0; attempt < maxTransientRetries; attempt++ {
+		if attempt > 0 {
+			wait := transientBackoff << (attempt - 1)
+			wait += time.Duration(rand.Int63n(int64(wait)))
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+
+		err = db.WithContext(ctx).Transaction(fn)
+		if err == nil || !IsRetryable(wrapGormError(err)) {
+			return err
+		}
+	}
+	return err
+}
+
+// Mark the email verified, only if it still matches and is not verified yet
+func (r *GormUserRepository) MarkEmailVerified(ctx context.Context, userID int64, email string) error {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND email = ? AND is_active = ? AND email_verified = ?", userID, email, true, false).
+		Update("email_verified", true)
+	if result.Error != nil {
+		return fmt.Errorf("%w: email verification failed", wrapGormError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// Record a successful login. UpdateColumn skips the version bump and updated_at
+// so frequent logins don't churn the optimistic lock or the "last modified" time.
+func (r *GormUserRepository) UpdateLastLogin(ctx context.Context, userID int64, at time.Time) error {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND is_active = ?", userID, true).
+		UpdateColumn("last_login_at", at)
+	if result.Error != nil {
+		return fmt.Errorf("%w: last login update failed", wrapGormError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// Store a hashed password reset token
+func (r *GormUserRepository) CreatePasswordResetToken(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error {
+	token := model.PasswordResetToken{
+		UserID:    userID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	}
+	if err := r.db.WithContext(ctx).Model(&token).Create(&token).Error; err != nil {
+		return fmt.Errorf("%w: reset token creation failed", wrapGormError(err))
+	}
+	return nil
+}
+
+// Consume a reset token and update the password in one transaction
+func (r *GormUserRepository) ResetPasswordWithToken(ctx context.Context, tokenHash, newPwdHash string) (int64, error) {
+	var userID int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var token model.PasswordResetToken
+		err := tx.Model(&model.PasswordResetToken{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+			First(&token).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidToken
+		}
+		if err != nil {
+			return fmt.Errorf("%w: reset token lookup failed", wrapGormError(err))
+		}
+
 		result := tx.Model(&model.User{}).
-			Where("id = ? AND version = ?", userID, user.Version).
+			Where("id = ? AND is_active = ?", token.UserID, true).
 			Updates(map[string]interface{}{
 				"password_hash": newPwdHash,
-				"version":       user.Version + 1,
-				"updated_at":    time.Now(),
+				"version":       gorm.Expr("version + 1"),
+				"updated_at":    now,
 			})
+		if result.Error != nil {
+			return fmt.Errorf("%w: password reset failed", wrapGormError(result.Error))
+		}
+		if result.RowsAffected == 0 {
+			return ErrUserNotFound
+		}
+
+		// Burn this token and every other outstanding one for the user
+		if err := tx.Model(&model.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", now).Error; err != nil {
+			return fmt.Errorf("%w: reset token invalidation failed", wrapGormError(err))
+		}
+
+		userID = token.UserID
+		return nil
+	})
+	return userID, err
+}
+
+// List users in id order with optional filters. Offset pages also report the total count;
+// keyset pages skip the count and fetch one extra row to tell whether a next page exists.
+func (r *GormUserRepository) ListUsers(ctx context.Context, page pagination.Pagination, filter dao.ListFilter) (dao.UserPage, error) {
+	query := r.db.WithContext(ctx)
+	if filter.UsernamePrefix != "" {
+		query = query.Where("username LIKE ?", escapeLike(filter.UsernamePrefix)+"%")
+	}
+	if filter.IsActive != nil {
+		query = query.Where("is_active = ?", *filter.IsActive)
+	}
+	// Count and Find must not share one statement
+	query = query.Session(&gorm.Session{})
+
+	result := dao.UserPage{Users: make([]model.User, 0, page.Size)}
+	if !page.Keyset {
+		if err := query.Count(&result.Total).Error; err != nil {
+			return dao.UserPage{}, fmt.Errorf("%w: failed to count users", wrapGormError(err))
+		}
+		if result.Total == 0 {
+			return result, nil
+		}
+	}
+
+	find := query.Select("id", "username", "email", "is_active", "roles", "avatar_url", "created_at", "updated_at").
+		Order("id ASC")
+	if page.Keyset {
+		find = find.Where("id > ?", page.AfterID).Limit(page.Size + 1)
+	} else {
+		find = find.Offset(page.Offset()).Limit(page.Size)
+	}
+	if err := find.Find(&result.Users).Error; err != nil {
+		return dao.UserPage{}, fmt.Errorf("%w: failed to list users", wrapGormError(err))
+	}
+
+	if page.Keyset && len(result.Users) > page.Size {
+		result.Users = result.Users[:page.Size]
+		result.NextCursor = pagination.EncodeCursor(result.Users[page.Size-1].ID)
+	}
+	return result, nil
+}
+
+// deletedHolderCond matches rows that still occupy the unique index although
+// they are invisible to the active-user checks above
+const deletedHolderCond = "(deleted_at IS NOT NULL OR is_active = ?)"
+
+// Check whether a soft-deleted or deactivated account still holds the username
+func (r *GormUserRepository) IsUsernameHeldByDeleted(ctx context.Context, username string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("username = ? AND "+deletedHolderCond, username, false).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("%w: failed to check deleted username", wrapGormError(err))
+	}
+	return count > 0, nil
+}
+
+// Check whether a soft-deleted or deactivated account still holds the email
+func (r *GormUserRepository) IsEmailHeldByDeleted(ctx context.Context, email string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("email = ? AND "+deletedHolderCond, email, false).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("%w: failed to check deleted email", wrapGormError(err))
+	}
+	return count > 0, nil
+}
 
+// Rewrite username/email of deleted holders to tombstone values so the
+// unique indexes accept a new registration. Run it in the same WithTx as the
+// CreateUser it makes room for, so a failed registration leaves the holders intact
+func (r *GormUserRepository) ReleaseDeletedIdentity(ctx context.Context, username, email string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().
+			Where("username = ? AND "+deletedHolderCond, username, false).
+			Update("username", tombstoneExpr("username", usernameWidth))
 		if result.Error != nil {
-			return fmt.Errorf("%w: password update failed", wrapGormError(result.Error))
+			return fmt.Errorf("%w: failed to release username", wrapGormError(result.Error))
 		}
 
+		result = tx.Unscoped().
+			Where("email = ? AND "+deletedHolderCond, email, false).
+			Update("email", tombstoneExpr("email", emailWidth))
+		if result.Error != nil {
+			return fmt.Errorf("%w: failed to release email", wrapGormError(result.Error))
+		}
+		return nil
+	})
+}
+
+// Restore a soft-deleted or deactivated user, undoing any tombstone rewrite of its identity.
+// Fails with ErrDuplicateEntry when an active user took the username or email in the meantime,
+// or when the identity was clipped on release and the original can no longer be recovered.
+func (r *GormUserRepository) RestoreUser(ctx context.Context, userID int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user model.User
+		err := tx.Unscoped().Select("id", "username", "email").
+			Where("id = ? AND "+deletedHolderCond, userID, false).
+			First(&user).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return ErrUserNotFound
+		case err != nil:
+			return fmt.Errorf("%w: failed to load deleted user", wrapGormError(err))
+		}
+
+		if isClipped(user.Username, userID) || isClipped(user.Email, userID) {
+			// The original value is gone; an admin has to pick a new identity for the account
+			return fmt.Errorf("%w: released identity was clipped and cannot be restored", ErrDuplicateEntry)
+		}
+		username := stripTombstone(user.Username, userID)
+		email := stripTombstone(user.Email, userID)
+
+		var taken int64
+		err = tx.Where("(username = ? OR email = ?) AND is_active = ? AND id <> ?", username, email, true, userID).
+			Count(&taken).Error
+		if err != nil {
+			return fmt.Errorf("%w: failed to check identity collision", wrapGormError(err))
+		}
+		if taken > 0 {
+			return fmt.Errorf("%w: identity taken since deletion", ErrDuplicateEntry)
+		}
+
+		err = tx.Unscoped().Where("id = ?", userID).Updates(map[string]interface{}{
+			"username":   username,
+			"email":      email,
+			"is_active":  true,
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		}).Error
+		if isDuplicateError(err) {
+			// Another deleted account holds the original identity
+			return fmt.Errorf("%w: identity held by another account", ErrDuplicateEntry)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: failed to restore user", wrapGormError(err))
+		}
+		return nil
+	})
+}
+
+// Permanently remove a user row, soft-deleted or not, together with its reset tokens and sessions
+func (r *GormUserRepository) PurgeUser(ctx context.Context, userID int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.PasswordResetToken{}).
+			Where("user_id = ?", userID).
+			Delete(&model.PasswordResetToken{}).Error; err != nil {
+			return fmt.Errorf("%w: failed to purge reset tokens", wrapGormError(err))
+		}
+		if err := tx.Model(&model.Session{}).
+			Where("user_id = ?", userID).
+			Delete(&model.Session{}).Error; err != nil {
+			return fmt.Errorf("%w: failed to purge sessions", wrapGormError(err))
+		}
+
+		result := tx.Unscoped().Where("id = ?", userID).Delete(&model.User{})
+		if result.Error != nil {
+			return fmt.Errorf("%w: failed to purge user", wrapGormError(result.Error))
+		}
 		if result.RowsAffected == 0 {
 			return ErrUserNotFound
 		}
@@ -128,7 +664,62 @@ func (r *GormUserRepository) UpdatePassword(userID uint, newPwdHash string) erro
 	})
 }
 
+// tombstoneExpr builds "<value>#deleted#<id>"; when that would overflow the column the
+// value is cut short and marked with clippedTombstoneSep instead, so the row still fits
+// and stays unique by id
+func tombstoneExpr(column string, width int) clause.Expr {
+	return gorm.Expr(fmt.Sprintf(
+		"CASE WHEN CHAR_LENGTH(%[1]s) + CHAR_LENGTH(id) + ? <= ? THEN CONCAT(%[1]s, ?, id) "+
+			"ELSE CONCAT(LEFT(%[1]s, ? - CHAR_LENGTH(id)), ?, id) END", column),
+		len(tombstoneSep), width, tombstoneSep, width-len(clippedTombstoneSep), clippedTombstoneSep)
+}
+
+// stripTombstone recovers the original value from a tombstone written by ReleaseDeletedIdentity
+func stripTombstone(value string, id int64) string {
+	return strings.TrimSuffix(value, fmt.Sprintf("%s%d", tombstoneSep, id))
+}
+
+// isClipped reports whether the tombstone lost part of the original value
+func isClipped(value string, id int64) bool {
+	return strings.HasSuffix(value, fmt.Sprintf("%s%d", clippedTombstoneSep, id))
+}
+
 // Error handling utils
+
+// tombstoneSep separates the original value from the row id in released identities;
+// clippedTombstoneSep marks a tombstone whose original value was cut to fit the column
+const (
+	tombstoneSep        = "#deleted#"
+	clippedTombstoneSep = "#clipped#"
+)
+
+// Column widths of model.User, which tombstones must not exceed
+const (
+	usernameWidth = 100
+	emailWidth    = 255
+)
+
+// escapeLike escapes LIKE wildcards so user input is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// duplicateColumnError names the unique index a duplicate-key error hit, based on the
+// MySQL message ("Duplicate entry 'x' for key 'base_users.idx_base_users_email'")
+func duplicateColumnError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		_, key, _ := strings.Cut(mysqlErr.Message, " for key ")
+		switch {
+		case strings.Contains(key, "username"):
+			return ErrUsernameTaken
+		case strings.Contains(key, "email"):
+			return ErrEmailTaken
+		}
+	}
+	return ErrDuplicateEntry
+}
+
 func isDuplicateError(err error) bool {
 	var mysqlErr *mysql.MySQLError
 	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
@@ -137,6 +728,11 @@ func isDuplicateError(err error) bool {
 	return errors.Is(err, gorm.ErrDuplicatedKey)
 }
 
+// IsRetryable reports whether err is a transient failure worth retrying
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrTransient)
+}
+
 func wrapGormError(err error) error {
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return ErrUserNotFound
@@ -149,6 +745,12 @@ func wrapGormError(err error) error {
 			return ErrDuplicateEntry
 		case 1048, 1044, 1146: // Common MySQL operation errors
 			return ErrDatabaseInternal
+		case 1213, 1205: // Deadlock / lock wait timeout: the statement was rolled back and can be retried
+			return fmt.Errorf("%w: %s", ErrTransient, mysqlErr.Message)
+		case 1451, 1452: // Foreign key constraint on delete/update or insert
+			return fmt.Errorf("%w: %s", ErrConflict, mysqlErr.Message)
+		case 1040, 1203: // Too many connections (server-wide / per user)
+			return fmt.Errorf("%w: %s", ErrCapacity, mysqlErr.Message)
 		}
 	}
 
@@ -1 +1,674 @@
 package dao
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	mysqldrv "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"my-digital-home/pkg/common/pagination"
+	"my-digital-home/pkg/core/user/model"
+	"my-digital-home/pkg/core/user/repository/dao"
+)
+
+// newMockRepo 基于sqlmock构造仓储，断言实际下发的SQL
+func newMockRepo(t *testing.T) (*GormUserRepository, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock := newMockDB(t)
+	return &GormUserRepository{db: userSession(db)}, mock
+}
+
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock init failed: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("gorm open failed: %v", err)
+	}
+	return db, mock
+}
+
+func TestLookupsRouteToReplicaUnlessPrimaryForced(t *testing.T) {
+	primary, primaryMock := newMockDB(t)
+	replica, replicaMock := newMockDB(t)
+	repo := NewUserRepository(primary, replica)
+
+	query := regexp.QuoteMeta("SELECT `id`,`username`,`email`")
+	row := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "username", "email"}).AddRow(7, "alice", "alice@example.com")
+	}
+	replicaMock.ExpectQuery(query).WillReturnRows(row())
+	primaryMock.ExpectQuery(query).WillReturnRows(row())
+	primaryMock.ExpectBegin()
+	primaryMock.ExpectExec(regexp.QuoteMeta("UPDATE `base_users` SET `last_login_at`=")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	primaryMock.ExpectCommit()
+
+	if _, err := repo.QueryByID(context.Background(), 7); err != nil {
+		t.Fatalf("replica lookup failed: %v", err)
+	}
+	if _, err := repo.QueryByID(dao.WithPrimary(context.Background()), 7); err != nil {
+		t.Fatalf("forced primary lookup failed: %v", err)
+	}
+	// 写操作始终走主库
+	if err := repo.UpdateLastLogin(context.Background(), 7, time.Now()); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	if err := replicaMock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	if err := primaryMock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPasswordHashIsReadFromPrimary(t *testing.T) {
+	primary, primaryMock := newMockDB(t)
+	replica, replicaMock := newMockDB(t)
+	repo := NewUserRepository(primary, replica)
+
+	// 副本存在复制延迟，刚修改或重置的旧密码不能在副本上继续生效
+	query := regexp.QuoteMeta("SELECT `password_hash`,`id`,`version`")
+	primaryMock.ExpectQuery(query).
+		WillReturnRows(sqlmock.NewRows([]string{"password_hash", "id", "version"}).AddRow("hash", 7, 1))
+	primaryMock.ExpectQuery(query).
+		WillReturnRows(sqlmock.NewRows([]string{"password_hash", "id", "version"}).AddRow("hash", 7, 1))
+
+	if _, _, err := repo.GetPasswordHash(context.Background(), "alice"); err != nil {
+		t.Fatalf("password lookup failed: %v", err)
+	}
+	if _, _, err := repo.GetPasswordHashByEmail(context.Background(), "alice@example.com"); err != nil {
+		t.Fatalf("password lookup by email failed: %v", err)
+	}
+
+	if err := primaryMock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	if err := replicaMock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithTxCommitsOrRollsBackAsOneUnit(t *testing.T) {
+	primary, primaryMock := newMockDB(t)
+	replica, replicaMock := newMockDB(t)
+	repo := NewUserRepository(primary, replica)
+
+	insert := regexp.QuoteMeta("INSERT INTO `base_users`")
+	lookup := regexp.QuoteMeta("SELECT `id`,`username`,`email`")
+
+	// 事务内的写入与查询都走主库上的同一事务，CreateUser自带的事务以保存点嵌套
+	primaryMock.ExpectBegin()
+	primaryMock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	primaryMock.ExpectExec(insert).WillReturnResult(sqlmock.NewResult(7, 1))
+	primaryMock.ExpectQuery(lookup).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email"}).AddRow(7, "alice", "alice@example.com"))
+	primaryMock.ExpectCommit()
+
+	err := repo.WithTx(context.Background(), func(tx dao.UserRepository) error {
+		if err := tx.CreateUser(context.Background(), model.User{Username: "alice", Email: "alice@example.com"}); err != nil {
+			return err
+		}
+		_, err := tx.QueryByID(context.Background(), 7)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Expected transaction to commit, got %v", err)
+	}
+
+	// fn出错时回滚，错误原样返回
+	errAbort := errors.New("abort")
+	primaryMock.ExpectBegin()
+	primaryMock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	primaryMock.ExpectExec(insert).WillReturnResult(sqlmock.NewResult(8, 1))
+	primaryMock.ExpectRollback()
+
+	err = repo.WithTx(context.Background(), func(tx dao.UserRepository) error {
+		if err := tx.CreateUser(context.Background(), model.User{Username: "bob", Email: "bob@example.com"}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if err != errAbort {
+		t.Fatalf("Expected fn error to be returned unchanged, got %v", err)
+	}
+
+	if err := primaryMock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	if err := replicaMock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmailOfSoftDeletedUserIsHeld(t *testing.T) {
+	repo, mock := newMockRepo(t)
+
+	// 活跃用户检查看不到已注销账户
+	mock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT count(*) FROM `base_users` WHERE (email = ? AND is_active = ?) AND `base_users`.`deleted_at` IS NULL")).
+		WithArgs("old@example.com", true).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	// 但它仍占用唯一索引（查询需包含软删除行）
+	mock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT count(*) FROM `base_users` WHERE email = ? AND (deleted_at IS NOT NULL OR is_active = ?)")).
+		WithArgs("old@example.com", false).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	exists, err := repo.IsEmailExists(context.Background(), "old@example.com")
+	if err != nil || exists {
+		t.Fatalf("Expected email to be free for active check, got exists=%v err=%v", exists, err)
+	}
+
+	held, err := repo.IsEmailHeldByDeleted(context.Background(), "old@example.com")
+	if err != nil || !held {
+		t.Fatalf("Expected email to be held by deleted user, got held=%v err=%v", held, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReleaseDeletedIdentityTombstonesHolders(t *testing.T) {
+	repo, mock := newMockRepo(t)
+
+	// 墓碑值超出列宽时截短原值并改用clippedTombstoneSep，保证改写不会因超长失败
+	tombstone := func(column string) string {
+		return "CASE WHEN CHAR_LENGTH(" + column + ") + CHAR_LENGTH(id) + ? <= ? THEN CONCAT(" + column + ", ?, id) " +
+			"ELSE CONCAT(LEFT(" + column + ", ? - CHAR_LENGTH(id)), ?, id) END"
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(
+		"UPDATE `base_users` SET `username`="+tombstone("username")+",`updated_at`=? WHERE username = ? AND (deleted_at IS NOT NULL OR is_active = ?)")).
+		WithArgs(len(tombstoneSep), usernameWidth, tombstoneSep, usernameWidth-len(clippedTombstoneSep), clippedTombstoneSep,
+			sqlmock.AnyArg(), "olduser", false).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(
+		"UPDATE `base_users` SET `email`="+tombstone("email")+",`updated_at`=? WHERE email = ? AND (deleted_at IS NOT NULL OR is_active = ?)")).
+		WithArgs(len(tombstoneSep), emailWidth, tombstoneSep, emailWidth-len(clippedTombstoneSep), clippedTombstoneSep,
+			sqlmock.AnyArg(), "old@example.com", false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.ReleaseDeletedIdentity(context.Background(), "olduser", "old@example.com"); err != nil {
+		t.Fatalf("Expected release to succeed, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReleaseAndCreateShareOneTransaction(t *testing.T) {
+	repo, mock := newMockRepo(t)
+
+	// 释放与创建以保存点嵌套在同一事务中，创建失败时一并回滚
+	for _, commit := range []bool{true, false} {
+		mock.ExpectBegin()
+		mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `base_users` SET `username`=")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `base_users` SET `email`=")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+		if commit {
+			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `base_users`")).WillReturnResult(sqlmock.NewResult(9, 1))
+			mock.ExpectCommit()
+			continue
+		}
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `base_users`")).WillReturnError(errors.New("connection reset"))
+		mock.ExpectExec("ROLLBACK TO SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+	}
+
+	register := func() error {
+		return repo.WithTx(context.Background(), func(tx dao.UserRepository) error {
+			if err := tx.ReleaseDeletedIdentity(context.Background(), "olduser", "new@example.com"); err != nil {
+				return err
+			}
+			return tx.CreateUser(context.Background(), model.User{Username: "olduser", Email: "new@example.com"})
+		})
+	}
+	if err := register(); err != nil {
+		t.Fatalf("Expected re-registration to commit, got %v", err)
+	}
+	if err := register(); err == nil {
+		t.Fatal("Expected failed insert to roll back the release")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRestoreUserRejectsClippedIdentity(t *testing.T) {
+	repo, mock := newMockRepo(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`username`,`email` FROM `base_users`")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email"}).
+			AddRow(7, "olduser"+tombstoneSep+"7", "very-long-addr"+clippedTombstoneSep+"7"))
+	mock.ExpectRollback()
+
+	if err := repo.RestoreUser(context.Background(), 7); !errors.Is(err, ErrDuplicateEntry) {
+		t.Fatalf("Expected ErrDuplicateEntry for a clipped identity, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRestoreUserRejectsIdentityTakenSinceDeletion(t *testing.T) {
+	repo, mock := newMockRepo(t)
+
+	mock.ExpectBegin()
+	// 已注销账户的用户名曾被改写为墓碑值
+	mock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT `id`,`username`,`email` FROM `base_users` WHERE id = ? AND (deleted_at IS NOT NULL OR is_active = ?) ORDER BY `base_users`.`id` LIMIT ?")).
+		WithArgs(7, false, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email"}).
+			AddRow(7, "olduser"+tombstoneSep+"7", "old@example.com"))
+	// 注销期间已有活跃用户注册了同名账户
+	mock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT count(*) FROM `base_users` WHERE ((username = ? OR email = ?) AND is_active = ? AND id <> ?) AND `base_users`.`deleted_at` IS NULL")).
+		WithArgs("olduser", "old@example.com", true, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	err := repo.RestoreUser(context.Background(), 7)
+	if !errors.Is(err, ErrDuplicateEntry) {
+		t.Fatalf("Expected ErrDuplicateEntry, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPurgeUserRemovesTokensSessionsAndRow(t *testing.T) {
+	repo, mock := newMockRepo(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `password_reset_tokens` WHERE user_id = ?")).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `user_sessions` WHERE user_id = ?")).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	// 硬删除不能带deleted_at条件，否则已软删除的行无法清除
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `base_users` WHERE id = ?")).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.PurgeUser(context.Background(), 7); err != nil {
+		t.Fatalf("Expected purge to succeed, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListUsersKeysetSkipsCountAndReturnsCursor(t *testing.T) {
+	repo, mock := newMockRepo(t)
+
+	// 多取一行判断是否还有下一页，且不执行COUNT
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE id > ? AND `base_users`.`deleted_at` IS NULL ORDER BY id ASC LIMIT ?")).
+		WithArgs(5, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).
+			AddRow(6, "a").AddRow(8, "b").AddRow(9, "c"))
+
+	page, err := repo.ListUsers(context.Background(), pagination.Pagination{Size: 2, AfterID: 5, Keyset: true}, dao.ListFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Users) != 2 || page.NextCursor != pagination.EncodeCursor(8) {
+		t.Fatalf("Expected 2 users and a cursor after id 8, got %+v", page)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateUserReportsDuplicateColumnAndDeletedHolder(t *testing.T) {
+	dupEmail := &mysqldrv.MySQLError{Number: 1062, Message: "Duplicate entry 'a@example.com' for key 'base_users.idx_base_users_email'"}
+	user := model.User{Username: "alice", Email: "a@example.com"}
+
+	for name, tc := range map[string]struct {
+		deletedHolders int
+		want           error
+	}{
+		"active holder":  {0, ErrEmailTaken},
+		"deleted holder": {1, ErrIdentityHeld},
+	} {
+		repo, mock := newMockRepo(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `base_users`").WillReturnError(dupEmail)
+		mock.ExpectRollback()
+		// 冲突在邮箱上，只需确认邮箱的占用者是否已注销
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `base_users` WHERE email = ? AND (deleted_at IS NOT NULL OR is_active = ?)")).
+			WithArgs("a@example.com", false).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tc.deletedHolders))
+
+		err := repo.CreateUser(context.Background(), user)
+		if !errors.Is(err, tc.want) || !errors.Is(err, ErrDuplicateEntry) {
+			t.Fatalf("%s: expected %v, got %v", name, tc.want, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestWrapGormErrorClassifiesMySQLErrors(t *testing.T) {
+	cases := []struct {
+		number uint16
+		target error
+	}{
+		{1213, ErrTransient},
+		{1205, ErrTransient},
+		{1451, ErrConflict},
+		{1452, ErrConflict},
+		{1040, ErrCapacity},
+		{1203, ErrCapacity},
+		{1062, ErrDuplicateEntry},
+	}
+
+	for _, tc := range cases {
+		err := wrapGormError(&mysqldrv.MySQLError{Number: tc.number, Message: "test"})
+		if !errors.Is(err, tc.target) {
+			t.Fatalf("MySQL error %d: expected %v, got %v", tc.number, tc.target, err)
+		}
+	}
+
+	if !IsRetryable(wrapGormError(&mysqldrv.MySQLError{Number: 1213})) {
+		t.Fatal("Expected deadlock to be retryable")
+	}
+	if IsRetryable(wrapGormError(&mysqldrv.MySQLError{Number: 1452})) {
+		t.Fatal("Expected foreign key conflict not to be retryable")
+	}
+}
+
+const (
+	selectVersionSQL  = "SELECT `id`,`version` FROM `base_users` WHERE (id = ? AND is_active = ?) AND `base_users`.`deleted_at` IS NULL"
+	updatePasswordSQL = "UPDATE `base_users` SET `password_hash`=?,`updated_at`=?,`version`=? WHERE (id = ? AND version = ?) AND `base_users`.`deleted_at` IS NULL"
+)
+
+func TestUpdatePasswordRetriesLostVersionRace(t *testing.T) {
+	repo, mock := newMockRepo(t)
+
+	// 第一次：读到version=1，但更新前已被并发修改
+	mock.ExpectQuery(regexp.QuoteMeta(selectVersionSQL)).
+		WithArgs(7, true, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version"}).AddRow(7, 1))
+	mock.ExpectBegin() // gorm默认为写操作开启事务
+	mock.ExpectExec(regexp.QuoteMeta(updatePasswordSQL)).
+		WithArgs("hash", sqlmock.AnyArg(), 2, 7, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	// 重新读取后以新版本重试成功
+	mock.ExpectQuery(regexp.QuoteMeta(selectVersionSQL)).
+		WithArgs(7, true, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version"}).AddRow(7, 2))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(updatePasswordSQL)).
+		WithArgs("hash", sqlmock.AnyArg(), 3, 7, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.UpdatePassword(context.Background(), 7, "hash"); err != nil {
+		t.Fatalf("Expected retry to succeed, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdatePasswordRetriesDeadlockedTransaction(t *testing.T) {
+	repo, mock := newMockRepo(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(selectVersionSQL)).
+		WithArgs(7, true, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version"}).AddRow(7, 1))
+	// 第一次更新遇到死锁被回滚，重新执行同一版本的更新后成功
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(updatePasswordSQL)).
+		WithArgs("hash", sqlmock.AnyArg(), 2, 7, 1).
+		WillReturnError(&mysqldrv.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"})
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(updatePasswordSQL)).
+		WithArgs("hash", sqlmock.AnyArg(), 2, 7, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.UpdatePassword(context.Background(), 7, "hash"); err != nil {
+		t.Fatalf("Expected deadlock retry to succeed, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRetryTransactionGivesUpOnPersistentDeadlock(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	for i := 0; i < maxTransientRetries; i++ {
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+	}
+	attempts := 0
+	err := RetryTransaction(context.Background(), db, func(tx *gorm.DB) error {
+		attempts++
+		return wrapGormError(&mysqldrv.MySQLError{Number: 1213})
+	})
+	if !IsRetryable(err) || attempts != maxTransientRetries {
+		t.Fatalf("Expected transient error after %d attempts, got %v after %d", maxTransientRetries, err, attempts)
+	}
+
+	// 非暂时性错误不重试
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	attempts = 0
+	err = RetryTransaction(context.Background(), db, func(tx *gorm.DB) error {
+		attempts++
+		return ErrUserNotFound
+	})
+	if !errors.Is(err, ErrUserNotFound) || attempts != 1 {
+		t.Fatalf("Expected a single attempt for a permanent error, got %v after %d", err, attempts)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateAvatarBumpsVersion(t *testing.T) {
+	repo, mock := newMockRepo(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(selectVersionSQL)).
+		WithArgs(7, true, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version"}).AddRow(7, 4))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `base_users` SET `avatar_url`=?,`updated_at`=?,`version`=? WHERE (id = ? AND version = ?) AND `base_users`.`deleted_at` IS NULL")).
+		WithArgs("/uploads/avatars/7/a.png", sqlmock.AnyArg(), 5, 7, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.UpdateAvatar(context.Background(), 7, "/uploads/avatars/7/a.png"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateIdentityResetsVerificationOnEmailChange(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	current := model.User{ID: 7, Username: "alice", Email: "a@example.com", EmailVerified: true, Version: 4}
+	updateSQL := regexp.QuoteMeta("UPDATE `base_users` SET `email`=?,`email_verified`=?,`updated_at`=?,`username`=?,`version`=? WHERE (id = ? AND version = ? AND is_active = ?) AND `base_users`.`deleted_at` IS NULL")
+
+	// 只改用户名：保留验证状态
+	mock.ExpectBegin()
+	mock.ExpectExec(updateSQL).
+		WithArgs("a@example.com", true, sqlmock.AnyArg(), "alice2", 5, 7, 4, true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	// 改邮箱：重置验证状态；版本已变化时不重试
+	mock.ExpectBegin()
+	mock.ExpectExec(updateSQL).
+		WithArgs("b@example.com", false, sqlmock.AnyArg(), "alice", 5, 7, 4, true).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	if err := repo.UpdateIdentity(context.Background(), current, "alice2", "a@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.UpdateIdentity(context.Background(), current, "alice", "b@example.com"); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("Expected ErrVersionConflict, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdatePasswordDistinguishesNotFoundFromConflict(t *testing.T) {
+	repo, mock := newMockRepo(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(selectVersionSQL)).
+		WithArgs(7, true, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version"}))
+
+	if err := repo.UpdatePassword(context.Background(), 7, "hash"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("Expected ErrUserNotFound for missing row, got %v", err)
+	}
+
+	// 每次都输掉竞争，重试耗尽后返回版本冲突
+	for i := 0; i < maxVersionRetries; i++ {
+		mock.ExpectQuery(regexp.QuoteMeta(selectVersionSQL)).
+			WithArgs(7, true, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "version"}).AddRow(7, i+1))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(updatePasswordSQL)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+	}
+
+	if err := repo.UpdatePassword(context.Background(), 7, "hash"); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("Expected ErrVersionConflict after exhausting retries, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateLastLoginLeavesVersionAlone(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// 只写last_login_at：不递增version，也不刷新updated_at
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(
+		"UPDATE `base_users` SET `last_login_at`=? WHERE (id = ? AND is_active = ?) AND `base_users`.`deleted_at` IS NULL")).
+		WithArgs(at, 7, true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.UpdateLastLogin(context.Background(), 7, at); err != nil {
+		t.Fatalf("Expected last login update to succeed, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateUsersIsolatesDuplicateRows(t *testing.T) {
+	repo, mock := newMockRepo(t)
+	duplicate := &mysqldrv.MySQLError{Number: 1062, Message: "Duplicate entry"}
+
+	mock.ExpectBegin()
+	// 第一批（2行）命中重复：回滚到保存点后逐行重试
+	mock.ExpectExec("SAVEPOINT sp").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `base_users`").WillReturnError(duplicate)
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT sp").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT sp").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `base_users`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("SAVEPOINT sp").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `base_users`").WillReturnError(duplicate)
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT sp").WillReturnResult(sqlmock.NewResult(0, 0))
+	// 第二批正常写入
+	mock.ExpectExec("SAVEPOINT sp").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `base_users`").WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+
+	users := []model.User{
+		{Username: "alice", Email: "alice@example.com"},
+		{Username: "taken", Email: "taken@example.com"},
+		{Username: "carol", Email: "carol@example.com"},
+	}
+	failures, err := repo.CreateUsers(context.Background(), users, 2)
+	if err != nil {
+		t.Fatalf("Expected import to commit, got %v", err)
+	}
+	if len(failures) != 1 || failures[0].Index != 1 || !errors.Is(failures[0].Err, ErrDuplicateEntry) {
+		t.Fatalf("Expected only row 1 to fail as duplicate, got %+v", failures)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCountActiveUsersExcludesSoftDeleted(t *testing.T) {
+	repo, mock := newMockRepo(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT count(*) FROM `base_users` WHERE is_active = ? AND `base_users`.`deleted_at` IS NULL")).
+		WithArgs(true).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+	count, err := repo.CountActiveUsers(context.Background())
+	if err != nil || count != 42 {
+		t.Fatalf("Expected 42 active users, got %d err=%v", count, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSearchByUsernameEscapesWildcards(t *testing.T) {
+	repo, mock := newMockRepo(t)
+
+	// 输入中的%和_按字面匹配，只在末尾追加前缀通配符
+	mock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT `id`,`username`,`email`,`email_verified`,`roles`,`avatar_url`,`created_at`,`updated_at`,`last_login_at`,`version` FROM `base_users` WHERE (username LIKE ? AND is_active = ?) AND `base_users`.`deleted_at` IS NULL ORDER BY username ASC LIMIT ?")).
+		WithArgs(`a\%\_%`, true, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(1, "a%_x"))
+
+	users, err := repo.SearchByUsername(context.Background(), "a%_", 5)
+	if err != nil || len(users) != 1 || users[0].Username != "a%_x" {
+		t.Fatalf("Expected one literal match, got %+v err=%v", users, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,17 @@
+package dao
+
+import "context"
+
+type forcePrimaryKey struct{}
+
+// WithPrimary 标记后续查询强制走主库，用于写后立即读（如验证邮箱后登录签发令牌）
+// 未配置只读副本时没有影响
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcePrimaryKey{}, true)
+}
+
+// PrimaryForced ctx是否经WithPrimary标记
+func PrimaryForced(ctx context.Context) bool {
+	forced, _ := ctx.Value(forcePrimaryKey{}).(bool)
+	return forced
+}
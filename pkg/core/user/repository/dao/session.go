@@ -0,0 +1,21 @@
+package dao
+
+import (
+	"context"
+	"my-digital-home/pkg/core/user/model"
+	"time"
+)
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session model.Session) (int64, error) // 返回会话ID
+	// 未撤销且未过期的会话，最近使用的在前
+	ListActiveSessions(ctx context.Context, userID int64) ([]model.Session, error)
+	// 会话属于该用户且仍有效时返回true，并刷新最近使用时间（有节流，不会每次请求都写库）
+	TouchSession(ctx context.Context, userID, sessionID int64, at time.Time) (bool, error)
+	// 以旧刷新令牌换新令牌，返回轮换后的会话；令牌不存在、已过期或会话已撤销时返回ErrInvalidToken
+	RotateRefreshToken(ctx context.Context, oldHash, newHash string, at time.Time) (model.Session, error)
+	// 撤销单个会话，不存在、已撤销或不属于该用户时返回ErrSessionNotFound
+	RevokeSession(ctx context.Context, userID, sessionID int64) error
+	// 撤销该用户除keepID外的全部会话（keepID为0时全部撤销），返回撤销数量
+	RevokeOtherSessions(ctx context.Context, userID, keepID int64) (int64, error)
+}
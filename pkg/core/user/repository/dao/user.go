@@ -1,14 +1,68 @@
 package dao
 
 import (
+	"context"
+	"my-digital-home/pkg/common/pagination"
 	"my-digital-home/pkg/core/user/model"
+	"time"
 )
 
+// ListFilter 用户列表可选过滤条件（零值表示不过滤）
+type ListFilter struct {
+	UsernamePrefix string // 用户名前缀
+	IsActive       *bool  // 账号状态
+}
+
+// UserPage 用户列表的一页
+type UserPage struct {
+	Users      []model.User
+	Total      int64  // 满足过滤条件的总数，仅偏移分页统计
+	NextCursor string // 仅游标分页，没有下一页时为空
+}
+
+// CreateFailure 批量创建中未能写入的行
+type CreateFailure struct {
+	Index int   // 在入参切片中的下标
+	Err   error // 失败原因，如ErrDuplicateEntry
+}
+
 type UserRepository interface {
-	QueryByID(id int64) (model.User, error)
-	IsUsernameExists(username string) (bool, error)
-	IsEmailExists(email string) (bool, error)
-	CreateUser(user model.User) error
-	GetPasswordHash(username string) (string, int64, error) // 返回哈希和用户ID
-	UpdatePassword(userID uint, newPwdHash string) error
+	QueryByID(ctx context.Context, id int64) (model.User, error)
+	QueryByUsername(ctx context.Context, username string) (model.User, error)
+	QueryByEmail(ctx context.Context, email string) (model.User, error)
+	// 仅检查活跃用户；唯一索引同样覆盖已注销账户，需配合IsUsernameHeldByDeleted/IsEmailHeldByDeleted
+	IsUsernameExists(ctx context.Context, username string) (bool, error)
+	IsEmailExists(ctx context.Context, email string) (bool, error)
+	// 重复时返回ErrUsernameTaken/ErrEmailTaken，被已注销账户占用时返回ErrIdentityHeld（均可用ErrDuplicateEntry判断）
+	CreateUser(ctx context.Context, user model.User) error
+	// 单个事务内按批写入；重复等行级错误逐行报告而不中止整体，其余错误回滚全部
+	CreateUsers(ctx context.Context, users []model.User, batchSize int) ([]CreateFailure, error)
+	GetPasswordHash(ctx context.Context, username string) (string, int64, error) // 返回哈希和用户ID
+	GetPasswordHashByEmail(ctx context.Context, email string) (string, int64, error)
+	UpdatePassword(ctx context.Context, userID uint, newPwdHash string) error
+	UpdateAvatar(ctx context.Context, userID int64, avatarURL string) error  // 递增Version
+	MarkEmailVerified(ctx context.Context, userID int64, email string) error // 邮箱已变更或已验证时返回ErrUserNotFound
+	UpdateLastLogin(ctx context.Context, userID int64, at time.Time) error   // 轻量写入，不触碰Version与UpdatedAt
+	// 修改用户名/邮箱，以current.Version做乐观锁（不符时返回ErrVersionConflict）；邮箱变化时重置验证状态
+	UpdateIdentity(ctx context.Context, current model.User, username, email string) error
+	// 密码重置：令牌仅以哈希形式存储
+	CreatePasswordResetToken(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error
+	// 消费令牌并更新密码（递增Version），同时作废该用户其余未使用的令牌，返回用户ID
+	ResetPasswordWithToken(ctx context.Context, tokenHash, newPwdHash string) (int64, error)
+	SearchByUsername(ctx context.Context, prefix string, limit int) ([]model.User, error)           // 活跃用户按用户名前缀匹配
+	CountActiveUsers(ctx context.Context) (int64, error)                                            // 不含软删除与停用账户
+	ListUsers(ctx context.Context, page pagination.Pagination, filter ListFilter) (UserPage, error) // 按ID升序
+	// 已注销（软删除或停用）账户是否仍占用该用户名/邮箱的唯一索引
+	IsUsernameHeldByDeleted(ctx context.Context, username string) (bool, error)
+	IsEmailHeldByDeleted(ctx context.Context, email string) (bool, error)
+	// 将已注销账户占用的用户名/邮箱改写为墓碑值，释放唯一索引
+	ReleaseDeletedIdentity(ctx context.Context, username, email string) error
+	// 恢复已注销账户（含被改写为墓碑值的身份），身份已被活跃用户占用时返回ErrDuplicateEntry
+	RestoreUser(ctx context.Context, userID int64) error
+	// 永久删除用户（含已软删除的）及其密码重置令牌与登录会话，不可恢复
+	PurgeUser(ctx context.Context, userID int64) error
+	// 在同一事务中执行fn：经tx调用的方法（含查询）都使用该事务，自带事务的方法以保存点嵌套；
+	// fn返回错误或panic时整体回滚，fn的错误原样返回。遇到死锁等暂时性错误时整个事务重新执行，
+	// 因此fn不应有事务之外的副作用（如发送邮件）
+	WithTx(ctx context.Context, fn func(tx UserRepository) error) error
 }
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"my-digital-home/pkg/core/user/model"
+	"my-digital-home/pkg/core/user/repository/dao"
+)
+
+// auditWriteTimeout 单条审计记录的写库超时，避免数据库故障时后台协程无限阻塞
+const auditWriteTimeout = 5 * time.Second
+
+// AuditRecorder 审计日志异步写入：请求路径只做入队，由后台协程落库
+// 队列写满时丢弃并告警，不阻塞请求
+type AuditRecorder struct {
+	repo    dao.AuditRepository
+	entries chan model.AuditLog
+	done    chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewAuditRecorder 创建并启动审计写入协程，停机时需调用Close
+func NewAuditRecorder(repo dao.AuditRepository, bufferSize int) *AuditRecorder {
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+	r := &AuditRecorder{
+		repo:    repo,
+		entries: make(chan model.AuditLog, bufferSize),
+		done:    make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// Record 提交一条审计记录，不等待写入完成；nil接收者或已关闭时直接忽略
+func (r *AuditRecorder) Record(entry model.AuditLog) {
+	if r == nil {
+		return
+	}
+	// 以发生时间为准，而非落库时间
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		hlog.Warnf("audit recorder closed, entry dropped: action=%s actor=%d target=%d", entry.Action, entry.ActorID, entry.TargetID)
+		return
+	}
+	select {
+	case r.entries <- entry:
+	default:
+		hlog.Warnf("audit buffer full, entry dropped: action=%s actor=%d target=%d", entry.Action, entry.ActorID, entry.TargetID)
+	}
+}
+
+// Close 停止接收新记录，并在ctx到期前尽量写完队列中剩余的记录
+func (r *AuditRecorder) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.entries)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *AuditRecorder) run() {
+	defer close(r.done)
+	for entry := range r.entries {
+		ctx, cancel := context.WithTimeout(context.Background(), auditWriteTimeout)
+		if err := r.repo.CreateAuditLog(ctx, entry); err != nil {
+			hlog.Warnf("write audit log failed: action=%s actor=%d target=%d err=%v", entry.Action, entry.ActorID, entry.TargetID, err)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"my-digital-home/pkg/core/user/model"
+)
+
+// fakeAuditRepo 在release关闭前阻塞写入，用于模拟数据库变慢
+type fakeAuditRepo struct {
+	release chan struct{}
+
+	mu      sync.Mutex
+	written []model.AuditLog
+}
+
+func (r *fakeAuditRepo) CreateAuditLog(_ context.Context, entry model.AuditLog) error {
+	<-r.release
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.written = append(r.written, entry)
+	return nil
+}
+
+func TestAuditRecorderDropsWhenBufferFull(t *testing.T) {
+	repo := &fakeAuditRepo{release: make(chan struct{})}
+	recorder := NewAuditRecorder(repo, 2)
+
+	// 第一条被后台协程取出并阻塞在写库，随后两条占满队列，其余丢弃
+	recorder.Record(model.AuditLog{Action: model.AuditLogin, ActorID: 1})
+	deadline := time.Now().Add(time.Second)
+	for len(recorder.entries) != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	for i := int64(2); i <= 5; i++ {
+		recorder.Record(model.AuditLog{Action: model.AuditLogin, ActorID: i})
+	}
+
+	close(repo.release)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := recorder.Close(ctx); err != nil {
+		t.Fatalf("Expected buffered entries to be flushed, got %v", err)
+	}
+
+	if len(repo.written) != 3 {
+		t.Fatalf("Expected 3 entries written, got %d", len(repo.written))
+	}
+	for i, entry := range repo.written {
+		if entry.ActorID != int64(i+1) || entry.CreatedAt.IsZero() {
+			t.Fatalf("Unexpected entry %d: %+v", i, entry)
+		}
+	}
+
+	// 关闭后的记录直接忽略，不应panic
+	recorder.Record(model.AuditLog{Action: model.AuditLogin})
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	netmail "net/mail"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"golang.org/x/crypto/bcrypt"
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/core/user/model"
+	"my-digital-home/pkg/core/user/repository/dao"
+	dao2 "my-digital-home/pkg/core/user/repository/dao/impl"
+)
+
+// ErrBadCredential 用户不存在或密码错误，两者不做区分以避免账户枚举
+var ErrBadCredential = errors.New("invalid username or password")
+
+// Authenticator 统一的凭证校验，登录接口与JWT中间件共用
+type Authenticator struct {
+	repo            dao.UserRepository
+	cost            int
+	caseInsensitive bool
+
+	dummyOnce sync.Once
+	dummy     []byte
+}
+
+func NewAuthenticator(repo dao.UserRepository, cfg *config.Config) *Authenticator {
+	cost := cfg.Middleware.Security.BcryptCost
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	a := &Authenticator{
+		repo:            repo,
+		cost:            cost,
+		caseInsensitive: cfg.Account.UsernameCase != config.UsernameCasePreserve,
+	}
+	// 后台预先生成占位哈希：否则首个不存在用户的登录要多做一次哈希生成，耗时约为正常失败的两倍
+	go a.dummyHash()
+	return a
+}
+
+// Authenticate 校验登录标识（用户名或邮箱）与密码，成功时返回用户资料
+// 凭证错误返回ErrBadCredential，其余为数据访问错误
+func (a *Authenticator) Authenticate(ctx context.Context, identifier, password string) (model.User, error) {
+	var storedHash string
+	var userID int64
+	var err error
+	if IsEmail(identifier) {
+		storedHash, userID, err = a.repo.GetPasswordHashByEmail(ctx, model.NormalizeEmail(identifier))
+	} else {
+		storedHash, userID, err = a.repo.GetPasswordHash(ctx, model.NormalizeUsername(identifier, a.caseInsensitive))
+	}
+	if err != nil && !errors.Is(err, dao2.ErrUserNotFound) {
+		return model.User{}, err
+	}
+
+	// 用户不存在时同样执行一次bcrypt比较，使两种失败耗时接近
+	if err != nil {
+		storedHash = string(a.dummyHash())
+	}
+	if cmpErr := bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(password)); err != nil || cmpErr != nil {
+		return model.User{}, ErrBadCredential
+	}
+
+	// 旧哈希代价低于当前配置时顺带升级，失败不影响本次登录
+	a.rehashIfNeeded(ctx, userID, storedHash, password)
+
+	// 令牌中的角色与邮箱验证状态须是最新的（如刚完成验证），从主库读取
+	return a.repo.QueryByID(dao.WithPrimary(ctx), userID)
+}
+
+// rehashIfNeeded 在密码校验通过后，用当前代价重新哈希并保存
+func (a *Authenticator) rehashIfNeeded(ctx context.Context, userID int64, storedHash, password string) {
+	cost, err := bcrypt.Cost([]byte(storedHash))
+	if err != nil || cost >= a.cost {
+		return
+	}
+
+	newHash, err := bcrypt.GenerateFromPassword([]byte(password), a.cost)
+	if err != nil {
+		hlog.CtxWarnf(ctx, "rehash password failed: user=%d err=%v", userID, err)
+		return
+	}
+	if err := a.repo.UpdatePassword(ctx, uint(userID), string(newHash)); err != nil {
+		hlog.CtxWarnf(ctx, "store upgraded password hash failed: user=%d err=%v", userID, err)
+		return
+	}
+	hlog.CtxInfof(ctx, "password hash upgraded: user=%d cost %d -> %d", userID, cost, a.cost)
+}
+
+// dummyHash 与真实哈希代价相同的占位哈希，构造时在后台生成，生成完成前的调用会等待
+func (a *Authenticator) dummyHash() []byte {
+	a.dummyOnce.Do(func() {
+		a.dummy, _ = bcrypt.GenerateFromPassword([]byte("dummy-password-for-timing"), a.cost)
+	})
+	return a.dummy
+}
+
+// IsEmail 判断登录标识是否为邮箱地址
+func IsEmail(identifier string) bool {
+	addr, err := netmail.ParseAddress(strings.TrimSpace(identifier))
+	return err == nil && addr.Address == strings.TrimSpace(identifier)
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/core/user/model"
+	dao2 "my-digital-home/pkg/core/user/repository/dao/impl"
+)
+
+func (r *fakeUserRepo) find(match func(model.User) bool) (string, int64, error) {
+	for _, u := range r.users {
+		if match(u) {
+			return u.PasswordHash, u.ID, nil
+		}
+	}
+	return "", 0, dao2.ErrUserNotFound
+}
+
+func (r *fakeUserRepo) GetPasswordHash(_ context.Context, username string) (string, int64, error) {
+	return r.find(func(u model.User) bool { return u.Username == username })
+}
+
+func (r *fakeUserRepo) GetPasswordHashByEmail(_ context.Context, email string) (string, int64, error) {
+	return r.find(func(u model.User) bool { return u.Email == email })
+}
+
+func (r *fakeUserRepo) QueryByID(_ context.Context, id int64) (model.User, error) {
+	for _, u := range r.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return model.User{}, dao2.ErrUserNotFound
+}
+
+func newTestAuthenticator(t *testing.T) *Authenticator {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("Passw0rd!"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &fakeUserRepo{users: []model.User{{
+		ID:           7,
+		Username:     "alice",
+		Email:        "alice@example.com",
+		PasswordHash: string(hash),
+		Roles:        model.RoleUser + "," + model.RoleAdmin,
+	}}}
+
+	cfg := config.Default()
+	cfg.Middleware.Security.BcryptCost = bcrypt.MinCost
+	return NewAuthenticator(repo, cfg)
+}
+
+func TestAuthenticateByUsernameOrEmail(t *testing.T) {
+	auth := newTestAuthenticator(t)
+
+	for _, identifier := range []string{"Alice", "ALICE@example.com"} {
+		user, err := auth.Authenticate(context.Background(), identifier, "Passw0rd!")
+		if err != nil || user.ID != 7 || len(user.RoleList()) != 2 {
+			t.Fatalf("Expected %s to authenticate with roles, got %+v err=%v", identifier, user, err)
+		}
+	}
+}
+
+func TestAuthenticateRejectsBadCredentials(t *testing.T) {
+	auth := newTestAuthenticator(t)
+
+	for _, tc := range []struct{ identifier, password string }{
+		{"alice", "Wrong0rd!"},
+		{"nobody", "Passw0rd!"},
+	} {
+		if _, err := auth.Authenticate(context.Background(), tc.identifier, tc.password); !errors.Is(err, ErrBadCredential) {
+			t.Fatalf("Expected ErrBadCredential for %s, got %v", tc.identifier, err)
+		}
+	}
+}
+
+func TestDummyHashMatchesConfiguredCost(t *testing.T) {
+	auth := newTestAuthenticator(t)
+
+	// 占位哈希的代价与真实哈希不同会重新暴露用户是否存在
+	if cost, err := bcrypt.Cost(auth.dummyHash()); err != nil || cost != auth.cost {
+		t.Fatalf("Expected dummy hash with cost %d, got %d (%v)", auth.cost, cost, err)
+	}
+}
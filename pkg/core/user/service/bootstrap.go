@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"golang.org/x/crypto/bcrypt"
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/common/pagination"
+	"my-digital-home/pkg/core/user/model"
+	"my-digital-home/pkg/core/user/repository/dao"
+	dao2 "my-digital-home/pkg/core/user/repository/dao/impl"
+)
+
+// BootstrapAdmin 数据库中没有任何用户时创建初始管理员，可重复执行
+// 返回是否创建了账户；未配置用户名或密码时直接跳过
+func BootstrapAdmin(ctx context.Context, repo dao.UserRepository, cfg *config.Config) (bool, error) {
+	bootstrap := cfg.Bootstrap
+	if bootstrap.AdminUsername == "" || bootstrap.AdminPassword == "" {
+		return false, nil
+	}
+
+	page, err := repo.ListUsers(ctx, pagination.Pagination{Size: 1, Page: 1}, dao.ListFilter{})
+	if err != nil {
+		return false, fmt.Errorf("count users failed: %w", err)
+	}
+	if page.Total > 0 {
+		hlog.CtxInfof(ctx, "bootstrap admin skipped: %d users already exist", page.Total)
+		return false, nil
+	}
+
+	admin, err := CreateAdmin(ctx, repo, cfg, bootstrap.AdminUsername, bootstrap.AdminEmail, bootstrap.AdminPassword)
+	switch {
+	case errors.Is(err, dao2.ErrDuplicateEntry):
+		// 多实例同时启动时，另一个实例已完成创建
+		hlog.CtxInfof(ctx, "bootstrap admin skipped: %s already exists", admin.Username)
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("create bootstrap admin failed: %w", err)
+	}
+
+	hlog.CtxInfof(ctx, "bootstrap admin created: username=%s", admin.Username)
+	return true, nil
+}
+
+// CreateAdmin 创建已激活、邮箱已验证的管理员账户，用户名或邮箱已存在时返回dao2.ErrDuplicateEntry
+// 密码同样须满足配置的密码策略，不满足时返回ErrWeakPassword（附未满足的规则）
+// 返回的用户包含规范化后的用户名与邮箱
+func CreateAdmin(ctx context.Context, repo dao.UserRepository, cfg *config.Config, username, email, password string) (model.User, error) {
+	now := time.Now()
+	admin := model.User{
+		Username:      model.NormalizeUsername(username, cfg.Account.UsernameCase == config.UsernameCaseLower),
+		Email:         model.NormalizeEmail(email),
+		IsActive:      true,
+		EmailVerified: true, // 运维配置的邮箱，无需再走验证流程
+		Roles:         model.RoleUser + "," + model.RoleAdmin,
+		Version:       1,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := ValidatePasswordStrength(cfg.Account.PasswordPolicy, password); err != nil {
+		return admin, fmt.Errorf("%w: %v", ErrWeakPassword, err)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cfg.Middleware.Security.BcryptCost)
+	if err != nil {
+		return admin, fmt.Errorf("hash admin password failed: %w", err)
+	}
+	admin.PasswordHash = string(hash)
+	return admin, repo.CreateUser(ctx, admin)
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/common/pagination"
+	"my-digital-home/pkg/core/user/model"
+	"my-digital-home/pkg/core/user/repository/dao"
+)
+
+// fakeUserRepo 内存用户表，仅实现引导所需方法
+type fakeUserRepo struct {
+	dao.UserRepository
+	users []model.User
+}
+
+func (r *fakeUserRepo) ListUsers(_ context.Context, _ pagination.Pagination, _ dao.ListFilter) (dao.UserPage, error) {
+	return dao.UserPage{Users: r.users, Total: int64(len(r.users))}, nil
+}
+
+func (r *fakeUserRepo) CreateUser(_ context.Context, user model.User) error {
+	r.users = append(r.users, user)
+	return nil
+}
+
+func bootstrapConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Middleware.Security.BcryptCost = bcrypt.MinCost
+	cfg.Bootstrap = config.BootstrapConfig{
+		AdminUsername: "Root",
+		AdminEmail:    "Root@Example.com",
+		AdminPassword: "Passw0rd!",
+	}
+	return cfg
+}
+
+func TestBootstrapAdminIsIdempotent(t *testing.T) {
+	repo := &fakeUserRepo{}
+	cfg := bootstrapConfig()
+
+	created, err := BootstrapAdmin(context.Background(), repo, cfg)
+	if err != nil || !created {
+		t.Fatalf("Expected admin to be created, got created=%v err=%v", created, err)
+	}
+
+	admin := repo.users[0]
+	if admin.Username != "root" || admin.Email != "root@example.com" {
+		t.Fatalf("Expected normalized identity, got %s %s", admin.Username, admin.Email)
+	}
+	if !strings.Contains(admin.Roles, model.RoleAdmin) {
+		t.Fatalf("Expected admin role, got %s", admin.Roles)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(admin.PasswordHash), []byte("Passw0rd!")) != nil {
+		t.Fatal("Expected password to be stored as bcrypt hash")
+	}
+
+	// 再次执行时已有用户，不重复创建
+	if created, err := BootstrapAdmin(context.Background(), repo, cfg); err != nil || created || len(repo.users) != 1 {
+		t.Fatalf("Expected second run to skip, got created=%v err=%v users=%d", created, err, len(repo.users))
+	}
+}
+
+func TestBootstrapAdminDisabledWithoutCredentials(t *testing.T) {
+	repo := &fakeUserRepo{}
+	cfg := bootstrapConfig()
+	cfg.Bootstrap.AdminPassword = ""
+
+	if created, err := BootstrapAdmin(context.Background(), repo, cfg); err != nil || created || len(repo.users) != 0 {
+		t.Fatalf("Expected bootstrap to be skipped, got created=%v err=%v", created, err)
+	}
+}
+
+func TestCreateAdminIgnoresExistingUsers(t *testing.T) {
+	repo := &fakeUserRepo{users: []model.User{{Username: "alice"}}}
+
+	admin, err := CreateAdmin(context.Background(), repo, bootstrapConfig(), "Ops", "Ops@Example.com", "Passw0rd!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.users) != 2 || admin.Username != "ops" || admin.Email != "ops@example.com" || !admin.EmailVerified {
+		t.Fatalf("Expected a second, normalized admin account, got %+v", admin)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(admin.PasswordHash), []byte("Passw0rd!")) != nil {
+		t.Fatal("Expected password to be stored as bcrypt hash")
+	}
+}
+
+func TestCreateAdminEnforcesPasswordPolicy(t *testing.T) {
+	repo := &fakeUserRepo{}
+	cfg := bootstrapConfig()
+	cfg.Bootstrap.AdminPassword = "admin"
+
+	// 命令行创建与首次启动的引导共用同一校验
+	if _, err := CreateAdmin(context.Background(), repo, cfg, "ops", "ops@example.com", "admin"); !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("Expected ErrWeakPassword from create-admin, got %v", err)
+	}
+	if created, err := BootstrapAdmin(context.Background(), repo, cfg); !errors.Is(err, ErrWeakPassword) || created {
+		t.Fatalf("Expected bootstrap to fail with ErrWeakPassword, got created=%v err=%v", created, err)
+	}
+	if len(repo.users) != 0 {
+		t.Fatalf("Expected no admin to be created, got %d", len(repo.users))
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"golang.org/x/crypto/bcrypt"
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/common/oauth"
+	"my-digital-home/pkg/core/user/model"
+	"my-digital-home/pkg/core/user/repository/dao"
+	dao2 "my-digital-home/pkg/core/user/repository/dao/impl"
+)
+
+// ErrUnverifiedIdentity 提供方未确认邮箱归属，不能据此关联或创建账户
+var ErrUnverifiedIdentity = errors.New("oauth identity has no verified email")
+
+// ErrUnverifiedAccount 邮箱对应的本地账户尚未验证邮箱，不自动关联
+// 该账户可能由他人抢先以同一邮箱注册并设置了密码（预劫持），关联后对方仍可凭密码登录
+var ErrUnverifiedAccount = errors.New("local account with this email is not verified")
+
+// 由邮箱生成用户名时的长度约束，与注册接口一致
+const (
+	oauthUsernameMin = 4
+	oauthUsernameMax = 20
+	// oauthUsernameAttempts 用户名冲突时追加随机后缀的尝试次数
+	oauthUsernameAttempts = 5
+)
+
+// OAuthAccounts 将第三方身份映射为本地账户：已核实邮箱与邮箱已验证的现有账户一致时直接关联，无账户时创建新账户
+type OAuthAccounts struct {
+	repo            dao.UserRepository
+	cost            int
+	caseInsensitive bool
+}
+
+func NewOAuthAccounts(repo dao.UserRepository, cfg *config.Config) *OAuthAccounts {
+	cost := cfg.Middleware.Security.BcryptCost
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	return &OAuthAccounts{
+		repo:            repo,
+		cost:            cost,
+		caseInsensitive: cfg.Account.UsernameCase != config.UsernameCasePreserve,
+	}
+}
+
+// Resolve 返回身份对应的本地用户，created表示本次新建了账户
+// 邮箱被停用或已注销账户占用时返回dao2.ErrDuplicateEntry，被邮箱未验证的账户占用时返回ErrUnverifiedAccount
+func (a *OAuthAccounts) Resolve(ctx context.Context, id oauth.Identity) (user model.User, created bool, err error) {
+	if !id.EmailVerified || id.Email == "" {
+		return model.User{}, false, ErrUnverifiedIdentity
+	}
+	email := model.NormalizeEmail(id.Email)
+
+	// 读主库：刚注册的账户可能尚未同步到从库
+	user, err = a.repo.QueryByEmail(dao.WithPrimary(ctx), email)
+	switch {
+	case err == nil:
+		// 本地账户未证明邮箱归属：由邮箱所有者先完成验证或经找回密码（同时撤销全部会话）接管后再关联
+		if !user.EmailVerified {
+			hlog.CtxWarnf(ctx, "refuse to link unverified account via %s: user=%d", id.Provider, user.ID)
+			return model.User{}, false, ErrUnverifiedAccount
+		}
+		return user, false, nil
+	case !errors.Is(err, dao2.ErrUserNotFound):
+		return model.User{}, false, err
+	}
+
+	username, err := a.availableUsername(ctx, email)
+	if err != nil {
+		return model.User{}, false, err
+	}
+	// 随机密码不告知任何人，账户只能经第三方登录，需要密码登录时可走找回密码流程设置
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return model.User{}, false, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(secret)), a.cost)
+	if err != nil {
+		return model.User{}, false, err
+	}
+
+	now := time.Now()
+	if err := a.repo.CreateUser(ctx, model.User{
+		Username:      username,
+		Email:         email,
+		PasswordHash:  string(hash),
+		IsActive:      true,
+		EmailVerified: true,
+		Roles:         model.RoleUser,
+		Version:       1,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}); err != nil {
+		return model.User{}, false, err
+	}
+	hlog.CtxInfof(ctx, "user created via %s login: username=%s", id.Provider, username)
+
+	user, err = a.repo.QueryByEmail(dao.WithPrimary(ctx), email)
+	return user, err == nil, err
+}
+
+// availableUsername 以邮箱本地部分为基础生成未被占用的用户名，冲突时追加随机后缀
+func (a *OAuthAccounts) availableUsername(ctx context.Context, email string) (string, error) {
+	base := usernameFromEmail(email)
+	candidate := model.NormalizeUsername(base, a.caseInsensitive)
+	for i := 0; i < oauthUsernameAttempts; i++ {
+		if i > 0 {
+			suffix := make([]byte, 2)
+			if _, err := rand.Read(suffix); err != nil {
+				return "", err
+			}
+			candidate = model.NormalizeUsername(base+"_"+hex.EncodeToString(suffix), a.caseInsensitive)
+		}
+		exists, err := a.repo.IsUsernameExists(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no available username derived from %s", email)
+}
+
+// usernameFromEmail 取邮箱@之前的字母、数字、下划线、点与短横线，截断后预留后缀空间，过短时加前缀
+func usernameFromEmail(email string) string {
+	local, _, _ := strings.Cut(email, "@")
+	var b strings.Builder
+	for _, r := range local {
+		if r < 128 && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.' || r == '-') {
+			b.WriteRune(r)
+		}
+	}
+	name := b.String()
+	if maxBase := oauthUsernameMax - 5; len(name) > maxBase {
+		name = name[:maxBase]
+	}
+	if len(name) < oauthUsernameMin {
+		name = "user_" + name
+	}
+	return name
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"my-digital-home/pkg/common/oauth"
+	"my-digital-home/pkg/core/user/model"
+	dao2 "my-digital-home/pkg/core/user/repository/dao/impl"
+)
+
+func (r *fakeUserRepo) QueryByEmail(_ context.Context, email string) (model.User, error) {
+	for i, u := range r.users {
+		if u.Email == email {
+			u.ID = int64(i + 1)
+			return u, nil
+		}
+	}
+	return model.User{}, dao2.ErrUserNotFound
+}
+
+func (r *fakeUserRepo) IsUsernameExists(_ context.Context, username string) (bool, error) {
+	for _, u := range r.users {
+		if u.Username == username {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestOAuthAccountsLinkAndCreate(t *testing.T) {
+	repo := &fakeUserRepo{users: []model.User{
+		{Username: "alice", Email: "alice@example.com", IsActive: true, EmailVerified: true},
+	}}
+	accounts := NewOAuthAccounts(repo, bootstrapConfig())
+	ctx := context.Background()
+
+	// 已核实邮箱与邮箱已验证的现有账户一致：直接关联
+	user, created, err := accounts.Resolve(ctx, oauth.Identity{Provider: "google", Email: "Alice@Example.com", EmailVerified: true})
+	if err != nil || created || user.ID != 1 || !user.EmailVerified {
+		t.Fatalf("Expected existing account to be linked, got %+v created=%v err=%v", user, created, err)
+	}
+
+	// 新邮箱：创建账户，用户名由邮箱生成，冲突时追加后缀
+	user, created, err = accounts.Resolve(ctx, oauth.Identity{Provider: "google", Email: "alice@other.org", EmailVerified: true})
+	if err != nil || !created || user.Email != "alice@other.org" || !user.EmailVerified {
+		t.Fatalf("Expected a new verified account, got %+v created=%v err=%v", user, created, err)
+	}
+	if user.Username == "alice" || len(user.Username) != len("alice_0000") || user.Roles != model.RoleUser {
+		t.Fatalf("Expected suffixed username and user role, got %q %q", user.Username, user.Roles)
+	}
+
+	// 未核实的邮箱既不关联也不创建
+	if _, _, err := accounts.Resolve(ctx, oauth.Identity{Provider: "google", Email: "alice@example.com"}); !errors.Is(err, ErrUnverifiedIdentity) {
+		t.Fatalf("Expected ErrUnverifiedIdentity, got %v", err)
+	}
+	if len(repo.users) != 2 {
+		t.Fatalf("Expected 2 users, got %d", len(repo.users))
+	}
+}
+
+func TestOAuthAccountsRefuseToLinkUnverifiedAccount(t *testing.T) {
+	// 他人抢先以受害者邮箱注册并设置密码，账户邮箱未验证
+	squatter := model.User{Username: "squatter", Email: "victim@example.com", PasswordHash: "attacker-hash", IsActive: true}
+	repo := &fakeUserRepo{users: []model.User{squatter}}
+	accounts := NewOAuthAccounts(repo, bootstrapConfig())
+
+	_, created, err := accounts.Resolve(context.Background(), oauth.Identity{Provider: "google", Email: "victim@example.com", EmailVerified: true})
+	if !errors.Is(err, ErrUnverifiedAccount) || created {
+		t.Fatalf("Expected ErrUnverifiedAccount, got created=%v err=%v", created, err)
+	}
+	if len(repo.users) != 1 || repo.users[0] != squatter {
+		t.Fatalf("Expected the unverified account to be left untouched, got %+v", repo.users)
+	}
+}
+
+func TestUsernameFromEmail(t *testing.T) {
+	cases := map[string]string{
+		"john.doe@example.com":                  "john.doe",
+		"ab+x@example.com":                      "user_abx",
+		"very.long.local.part.name@example.com": "very.long.local",
+		"张三@example.com":                        "user_",
+	}
+	for email, want := range cases {
+		if got := usernameFromEmail(email); got != want {
+			t.Errorf("usernameFromEmail(%q) = %q, want %q", email, got, want)
+		}
+	}
+}
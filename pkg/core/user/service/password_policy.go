@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"my-digital-home/pkg/common/config"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrWeakPassword 密码不满足配置的密码策略
+var ErrWeakPassword = errors.New("password does not meet the password policy")
+
+// ValidatePasswordStrength 按策略校验密码，返回所有未满足的规则
+// 注册、改密、导入与创建管理员共用，保证所有入口执行同一套密码策略
+func ValidatePasswordStrength(policy config.PasswordPolicyConfig, password string) error {
+	var failed []string
+
+	length := utf8.RuneCountInString(password)
+	if length < policy.MinLength {
+		failed = append(failed, fmt.Sprintf("密码至少%d位", policy.MinLength))
+	}
+	if policy.MaxLength > 0 && length > policy.MaxLength {
+		failed = append(failed, fmt.Sprintf("密码最多%d位", policy.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasLetter, hasNumber, hasSpecial bool
+	for _, c := range password {
+		switch {
+		case unicode.IsNumber(c):
+			hasNumber = true
+		case unicode.IsLetter(c):
+			hasLetter = true
+			hasUpper = hasUpper || unicode.IsUpper(c)
+			hasLower = hasLower || unicode.IsLower(c)
+		case unicode.IsSymbol(c) || unicode.IsPunct(c):
+			hasSpecial = true
+		}
+	}
+
+	if policy.RequireUpper && !hasUpper {
+		failed = append(failed, "需包含大写字母")
+	}
+	if policy.RequireLower && !hasLower {
+		failed = append(failed, "需包含小写字母")
+	}
+	if policy.RequireLetter && !hasLetter {
+		failed = append(failed, "需包含字母")
+	}
+	if policy.RequireDigit && !hasNumber {
+		failed = append(failed, "需包含数字")
+	}
+	if policy.RequireSpecial && !hasSpecial {
+		failed = append(failed, "需包含特殊字符")
+	}
+
+	for _, weak := range policy.Denylist {
+		if strings.EqualFold(strings.TrimSpace(weak), password) {
+			failed = append(failed, "密码过于常见")
+			break
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "；"))
+	}
+	return nil
+}
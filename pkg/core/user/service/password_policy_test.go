@@ -0,0 +1,45 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"my-digital-home/pkg/common/config"
+)
+
+func TestPasswordPolicyDefaults(t *testing.T) {
+	policy := config.Default().Account.PasswordPolicy
+
+	if err := ValidatePasswordStrength(policy, "Passw0rd!"); err != nil {
+		t.Fatalf("Expected default policy to accept Passw0rd!, got %v", err)
+	}
+	if err := ValidatePasswordStrength(policy, "password"); err == nil {
+		t.Fatal("Expected default policy to reject a password without digits and symbols")
+	}
+}
+
+func TestPasswordPolicyReportsEachFailedRule(t *testing.T) {
+	policy := config.PasswordPolicyConfig{
+		MinLength:    10,
+		RequireUpper: true,
+		RequireDigit: true,
+	}
+
+	err := ValidatePasswordStrength(policy, "short")
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+	for _, want := range []string{"密码至少10位", "需包含大写字母", "需包含数字"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %q", want, err.Error())
+		}
+	}
+}
+
+func TestPasswordPolicyDenylist(t *testing.T) {
+	policy := config.PasswordPolicyConfig{Denylist: []string{"Password123!"}}
+
+	if err := ValidatePasswordStrength(policy, "password123!"); err == nil || !strings.Contains(err.Error(), "密码过于常见") {
+		t.Fatalf("Expected denylisted password to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+// Package debug 诊断工具，仅在配置开启时使用
+package debug
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/adaptor"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// NewPprofMux 注册/debug/pprof下的全部分析接口
+func NewPprofMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// PprofHandler 将pprof挂载到Hertz实例（如内部运维端口），路由需覆盖/debug/pprof/下的全部路径
+func PprofHandler() app.HandlerFunc {
+	mux := NewPprofMux()
+
+	return func(c context.Context, ctx *app.RequestContext) {
+		req, err := adaptor.GetCompatRequest(&ctx.Request)
+		if err != nil {
+			hlog.CtxErrorf(c, "pprof request convert failed: %v", err)
+			ctx.AbortWithStatus(500)
+			return
+		}
+		mux.ServeHTTP(adaptor.GetCompatResponseWriter(&ctx.Response), req.WithContext(c))
+	}
+}
+
+// StartPprofServer 在独立端口启动pprof，与业务端口隔离，避免对外暴露
+// 返回的Server用于停机时关闭
+func StartPprofServer(addr string) *http.Server {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           NewPprofMux(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		hlog.Infof("pprof listening on %s", addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			hlog.Errorf("pprof server stopped: %v", err)
+		}
+	}()
+	return srv
+}
@@ -0,0 +1,40 @@
+// Package docs 手写的OpenAPI 3规范及Swagger UI
+// 修改pkg/web/model中的请求/响应结构时需同步更新openapi.json
+package docs
+
+import (
+	"context"
+	_ "embed"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+// swaggerUI 通过CDN加载Swagger UI，指向/openapi.json
+const swaggerUI = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>My Digital Home API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = () => { SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" }); };
+  </script>
+</body>
+</html>`
+
+// OpenAPIHandler 输出OpenAPI规范
+func OpenAPIHandler(ctx context.Context, c *app.RequestContext) {
+	c.Data(200, "application/json; charset=utf-8", spec)
+}
+
+// SwaggerUIHandler 输出Swagger UI页面
+func SwaggerUIHandler(ctx context.Context, c *app.RequestContext) {
+	c.Data(200, "text/html; charset=utf-8", []byte(swaggerUI))
+}
@@ -0,0 +1,88 @@
+package docs
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"my-digital-home/pkg/web/model"
+)
+
+type openAPISpec struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+func loadSpec(t *testing.T) openAPISpec {
+	t.Helper()
+	var s openAPISpec
+	if err := json.Unmarshal(spec, &s); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+	return s
+}
+
+func TestSpecDocumentsUserRoutes(t *testing.T) {
+	s := loadSpec(t)
+	for path, method := range map[string]string{
+		"/api/v1/users/register":      "post",
+		"/api/v1/users/login":         "post",
+		"/api/v1/users/password":      "put",
+		"/api/v1/users/me":            "get",
+		"/api/v1/users/token/refresh": "post",
+		"/api/v1/users/me/sessions":   "get",
+	} {
+		if _, ok := s.Paths[path][method]; !ok {
+			t.Errorf("Expected %s %s to be documented", strings.ToUpper(method), path)
+		}
+	}
+}
+
+// 规范中的字段须与模型的json标签一致，防止模型修改后文档过期
+func TestSpecSchemasMatchModels(t *testing.T) {
+	s := loadSpec(t)
+	for name, value := range map[string]interface{}{
+		"RegisterReq":  model.RegisterReq{},
+		"LoginReq":     model.LoginReq{},
+		"LoginRes":     model.LoginRes{},
+		"ChangePwdReq": model.ChangePwdReq{},
+		"UserRes":      model.UserRes{},
+		"RefreshReq":   model.RefreshReq{},
+		"SessionRes":   model.SessionRes{},
+		"AvatarRes":    model.AvatarRes{},
+	} {
+		schema, ok := s.Components.Schemas[name]
+		if !ok {
+			t.Errorf("Schema %s missing from spec", name)
+			continue
+		}
+
+		var documented []string
+		for prop := range schema.Properties {
+			documented = append(documented, prop)
+		}
+		sort.Strings(documented)
+
+		if fields := jsonFields(reflect.TypeOf(value)); !reflect.DeepEqual(fields, documented) {
+			t.Errorf("Schema %s documents %v, model has %v", name, documented, fields)
+		}
+	}
+}
+
+func jsonFields(typ reflect.Type) []string {
+	var fields []string
+	for i := 0; i < typ.NumField(); i++ {
+		name := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
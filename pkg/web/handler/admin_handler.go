@@ -0,0 +1,398 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/cloudwego/hertz/pkg/app"
+	"golang.org/x/crypto/bcrypt"
+	"my-digital-home/pkg/common/config"
+	errors2 "my-digital-home/pkg/common/errors"
+	"my-digital-home/pkg/common/pagination"
+	dao_model "my-digital-home/pkg/core/user/model"
+	"my-digital-home/pkg/core/user/repository/dao"
+	"my-digital-home/pkg/core/user/service"
+	"my-digital-home/pkg/web/model"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 分页参数默认值与上限
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// 用户搜索返回条数默认值与上限
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+)
+
+// maxImportUsers 单次导入的用户数上限
+// 明文密码的哈希按CPU数并行计算，仍可能超过全局请求超时，路由层对导入接口免除超时
+const maxImportUsers = 500
+
+// MaintenanceSwitch 维护模式开关
+type MaintenanceSwitch interface {
+	Enabled() bool
+	SetEnabled(enabled bool)
+}
+
+type AdminHandler struct {
+	UserRepo       dao.UserRepository
+	Maintenance    MaintenanceSwitch
+	AccountCfg     config.AccountConfig
+	BcryptCost     int
+	UseMultiStatus bool                   // 批量接口部分成功时返回207
+	Audit          *service.AuditRecorder // 为nil时不记录审计日志
+	EffectiveCfg   *config.Config         // 已脱敏的生效配置，启动后不再变化
+	DBLogger       *config.SwitchableDBLogger
+	APIKeys        dao.APIKeyRepository // 为nil时不支持管理API Key
+}
+
+func NewAdminHandler(cfg *config.Config, userRepo dao.UserRepository, maintenance MaintenanceSwitch) *AdminHandler {
+	return &AdminHandler{
+		UserRepo:       userRepo,
+		Maintenance:    maintenance,
+		AccountCfg:     cfg.Account,
+		BcryptCost:     cfg.Middleware.Security.BcryptCost,
+		UseMultiStatus: cfg.API.UseMultiStatus,
+		EffectiveCfg:   cfg.Redacted(),
+	}
+}
+
+// GetConfig 输出脱敏后的生效配置，用于确认环境变量/配置文件/默认值的覆盖结果
+func (h *AdminHandler) GetConfig(ctx context.Context, c *app.RequestContext) {
+	c.JSON(200, h.EffectiveCfg)
+}
+
+// GetMaintenance 查询维护模式状态
+func (h *AdminHandler) GetMaintenance(ctx context.Context, c *app.RequestContext) {
+	respondOK(c, model.MaintenanceRes{Enabled: h.Maintenance.Enabled()})
+}
+
+// SetMaintenance 切换维护模式
+func (h *AdminHandler) SetMaintenance(ctx context.Context, c *app.RequestContext) {
+	var req model.MaintenanceReq
+	if err := c.BindAndValidate(&req); err != nil || req.Enabled == nil {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误: 缺少enabled")
+		return
+	}
+
+	h.Maintenance.SetEnabled(*req.Enabled)
+	h.Audit.Record(newAuditEntry(c, dao_model.AuditMaintenanceToggled, 0, 0))
+	respondOK(c, model.MaintenanceRes{Enabled: h.Maintenance.Enabled()})
+}
+
+// GetDBLogLevel 查询数据库日志级别
+func (h *AdminHandler) GetDBLogLevel(ctx context.Context, c *app.RequestContext) {
+	if h.DBLogger == nil {
+		respondErr(c, 404, errors2.CodeNotFound, "数据库日志不支持运行时调整")
+		return
+	}
+	respondOK(c, model.DBLogLevelRes{Level: h.DBLogger.Level()})
+}
+
+// SetDBLogLevel 运行时切换数据库日志级别，排障时临时打开SQL日志，无需重启
+func (h *AdminHandler) SetDBLogLevel(ctx context.Context, c *app.RequestContext) {
+	if h.DBLogger == nil {
+		respondErr(c, 404, errors2.CodeNotFound, "数据库日志不支持运行时调整")
+		return
+	}
+	var req model.DBLogLevelReq
+	if err := bindAndValidate(c, &req); err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+	if !h.DBLogger.SetLevel(strings.ToLower(req.Level)) {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误: level须为silent/error/warn/info")
+		return
+	}
+
+	h.Audit.Record(newAuditEntry(c, dao_model.AuditDBLogLevel, 0, 0))
+	respondOK(c, model.DBLogLevelRes{Level: h.DBLogger.Level()})
+}
+
+// SearchUsers 按用户名前缀搜索活跃用户，供输入联想使用
+func (h *AdminHandler) SearchUsers(ctx context.Context, c *app.RequestContext) {
+	var req model.SearchUsersReq
+	if err := c.BindAndValidate(&req); err != nil {
+		respondMappedErr(c, errors2.NewValidationError(err))
+		return
+	}
+
+	prefix := strings.TrimSpace(req.Q)
+	if prefix == "" {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误: 缺少q")
+		return
+	}
+	switch {
+	case req.Limit <= 0:
+		req.Limit = defaultSearchLimit
+	case req.Limit > maxSearchLimit:
+		req.Limit = maxSearchLimit
+	}
+
+	// 用户名按配置规范化存储，前缀需同样处理才能匹配
+	prefix = dao_model.NormalizeUsername(prefix, h.AccountCfg.UsernameCase != config.UsernameCasePreserve)
+	users, err := h.UserRepo.SearchByUsername(ctx, prefix, req.Limit)
+	if err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+
+	items := make([]model.UserRes, 0, len(users))
+	for _, u := range users {
+		items = append(items, toUserRes(u))
+	}
+	respondOK(c, items)
+}
+
+// RestoreUser 恢复已注销账户
+func (h *AdminHandler) RestoreUser(ctx context.Context, c *app.RequestContext) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误: 无效的用户ID")
+		return
+	}
+
+	if err := h.UserRepo.RestoreUser(ctx, userID); err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+	h.Audit.Record(newAuditEntry(c, dao_model.AuditUserRestore, 0, userID))
+	respondMsg(c, 200, "账户已恢复")
+}
+
+// DeleteUser 永久删除用户（数据擦除请求），需带purge=true且confirm与用户ID一致
+// 登录会话随用户一并删除，关联会话的访问令牌立即失效；不含会话的旧令牌到期前仍可通过签名校验，但用户记录已不存在
+func (h *AdminHandler) DeleteUser(ctx context.Context, c *app.RequestContext) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误: 无效的用户ID")
+		return
+	}
+	if c.Query("purge") != "true" {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误: 仅支持purge=true永久删除")
+		return
+	}
+	// 二次确认，防止误操作删除其他用户
+	if c.Query("confirm") != c.Param("id") {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误: confirm须与用户ID一致")
+		return
+	}
+
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	if actorID == userID {
+		respondErr(c, 400, errors2.CodeBadRequest, "不能删除当前登录的管理员账户")
+		return
+	}
+
+	if err := h.UserRepo.PurgeUser(ctx, userID); err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+	h.Audit.Record(newAuditEntry(c, dao_model.AuditUserPurge, actorID, userID))
+	respondMsg(c, 200, "用户已永久删除")
+}
+
+// Stats 管理后台统计数据
+func (h *AdminHandler) Stats(ctx context.Context, c *app.RequestContext) {
+	count, err := h.UserRepo.CountActiveUsers(ctx)
+	if err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+	respondOK(c, model.StatsRes{ActiveUsers: count})
+}
+
+// ListUsers 分页查询用户列表（支持用户名前缀与状态过滤），mode=keyset开始游标分页，传cursor时按游标续读
+func (h *AdminHandler) ListUsers(ctx context.Context, c *app.RequestContext) {
+	var req model.ListUsersReq
+	if err := c.BindAndValidate(&req); err != nil {
+		respondMappedErr(c, errors2.NewValidationError(err))
+		return
+	}
+
+	page, err := pagination.Parse(req.Page, req.PageSize, req.Mode, req.Cursor, defaultPageSize, maxPageSize)
+	if errors.Is(err, pagination.ErrInvalidMode) {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误: mode只能为offset或keyset")
+		return
+	}
+	if err != nil {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误: 无效的cursor")
+		return
+	}
+
+	result, err := h.UserRepo.ListUsers(ctx, page, dao.ListFilter{
+		UsernamePrefix: req.Username,
+		IsActive:       req.IsActive,
+	})
+	if err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+
+	items := make([]model.UserRes, 0, len(result.Users))
+	for _, u := range result.Users {
+		items = append(items, toUserRes(u))
+	}
+
+	respondOK(c, model.ListUsersRes{
+		Items: items,
+		Meta:  pagination.NewMeta(page, result.Total, result.NextCursor),
+	})
+}
+
+// ImportUsers 批量导入用户（JSON数组），逐项报告结果，单项失败不影响其余用户
+func (h *AdminHandler) ImportUsers(ctx context.Context, c *app.RequestContext) {
+	var reqs []model.ImportUserReq
+	if err := json.Unmarshal(c.Request.Body(), &reqs); err != nil {
+		respondMappedErr(c, errors2.NewValidationError(err))
+		return
+	}
+	if len(reqs) == 0 || len(reqs) > maxImportUsers {
+		respondErr(c, 400, errors2.CodeBadRequest, fmt.Sprintf("导入数量须在1-%d之间", maxImportUsers))
+		return
+	}
+
+	// 先逐项校验，只把合法的用户交给仓储
+	items := make([]model.ItemResult, len(reqs))
+	users := make([]dao_model.User, 0, len(reqs))
+	indexes := make([]int, 0, len(reqs))
+	for i, req := range reqs {
+		user, item := h.buildImportUser(req)
+		item.Index = i
+		items[i] = item
+		if item.Status != model.ItemFailed {
+			users = append(users, user)
+			indexes = append(indexes, i)
+		}
+	}
+
+	users, indexes = h.hashImportPasswords(reqs, users, indexes, items)
+
+	if len(users) > 0 {
+		failures, err := h.UserRepo.CreateUsers(ctx, users, h.AccountCfg.ImportBatchSize)
+		if err != nil {
+			respondMappedErr(c, err)
+			return
+		}
+		for _, f := range failures {
+			_, code, msg := errors2.MapError(f.Err)
+			i := indexes[f.Index]
+			items[i] = model.ItemResult{Index: i, Key: items[i].Key, Status: model.ItemFailed, Code: code, Message: msg}
+		}
+	}
+
+	var result model.PartialResult
+	for _, item := range items {
+		result.Add(item)
+	}
+	h.Audit.Record(newAuditEntry(c, dao_model.AuditUserImport, 0, 0))
+	respondPartial(c, &result, h.UseMultiStatus)
+}
+
+// hashImportPasswords 为使用明文密码的行计算哈希，并发数不超过GOMAXPROCS
+// 哈希失败的行在items中标记为失败，并从返回的users与indexes中剔除
+func (h *AdminHandler) hashImportPasswords(reqs []model.ImportUserReq, users []dao_model.User, indexes []int, items []model.ItemResult) ([]dao_model.User, []int) {
+	errs := make([]error, len(users))
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	for k, i := range indexes {
+		if reqs[i].PasswordHash != "" {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(k int, password string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost())
+			users[k].PasswordHash, errs[k] = string(hashed), err
+		}(k, reqs[i].Password)
+	}
+	wg.Wait()
+
+	hashedUsers, hashedIndexes := users[:0], indexes[:0]
+	for k, i := range indexes {
+		if errs[k] != nil {
+			items[i] = model.ItemResult{Index: i, Key: items[i].Key, Status: model.ItemFailed, Code: errors2.CodeInternal, Message: "密码加密失败"}
+			continue
+		}
+		hashedUsers, hashedIndexes = append(hashedUsers, users[k]), append(hashedIndexes, i)
+	}
+	return hashedUsers, hashedIndexes
+}
+
+// buildImportUser 校验并构造待导入用户，返回的结果项尚未设置Index；明文密码只校验强度，哈希由hashImportPasswords计算
+func (h *AdminHandler) buildImportUser(req model.ImportUserReq) (dao_model.User, model.ItemResult) {
+	username := dao_model.NormalizeUsername(req.Username, h.AccountCfg.UsernameCase != config.UsernameCasePreserve)
+	item := model.ItemResult{Key: username, Status: model.ItemSucceeded}
+	fail := func(code int, msg string) (dao_model.User, model.ItemResult) {
+		item.Status, item.Code, item.Message = model.ItemFailed, code, msg
+		return dao_model.User{}, item
+	}
+
+	if username == "" || !service.IsEmail(req.Email) {
+		return fail(errors2.CodeBadRequest, "用户名或邮箱无效")
+	}
+
+	roles := req.Roles
+	if len(roles) == 0 {
+		roles = []string{dao_model.RoleUser}
+	}
+	for _, role := range roles {
+		if role != dao_model.RoleUser && role != dao_model.RoleAdmin {
+			return fail(errors2.CodeBadRequest, "未知角色: "+role)
+		}
+	}
+
+	// 旧系统的bcrypt哈希直接沿用，登录时会按当前代价自动升级
+	hash := req.PasswordHash
+	switch {
+	case hash != "":
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return fail(errors2.CodeBadRequest, "密码哈希不是有效的bcrypt格式")
+		}
+	case req.Password != "":
+		if err := service.ValidatePasswordStrength(h.AccountCfg.PasswordPolicy, req.Password); err != nil {
+			return fail(errors2.CodeWeakPassword, err.Error())
+		}
+	default:
+		return fail(errors2.CodeBadRequest, "缺少密码")
+	}
+
+	if username != req.Username {
+		item.Status, item.Message = model.ItemWarning, "用户名已规范化"
+	}
+
+	now := time.Now()
+	return dao_model.User{
+		Username:     username,
+		Email:        dao_model.NormalizeEmail(req.Email),
+		PasswordHash: hash,
+		IsActive:     true,
+		Roles:        strings.Join(roles, ","),
+		Version:      1,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}, item
+}
+
+func (h *AdminHandler) bcryptCost() int {
+	if h.BcryptCost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return h.BcryptCost
+}
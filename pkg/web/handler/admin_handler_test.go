@@ -0,0 +1,343 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm/logger"
+	"my-digital-home/pkg/common/auth"
+	"my-digital-home/pkg/common/config"
+	errors2 "my-digital-home/pkg/common/errors"
+	"my-digital-home/pkg/common/pagination"
+	dao_model "my-digital-home/pkg/core/user/model"
+	"my-digital-home/pkg/core/user/repository/dao"
+	dao2 "my-digital-home/pkg/core/user/repository/dao/impl"
+	"my-digital-home/pkg/web/model"
+)
+
+// fakeImportRepo 用户名为taken的行按重复处理
+type fakeImportRepo struct {
+	dao.UserRepository
+	created []dao_model.User
+	purged  []int64
+}
+
+func (r *fakeImportRepo) CreateUsers(_ context.Context, users []dao_model.User, _ int) ([]dao.CreateFailure, error) {
+	var failures []dao.CreateFailure
+	for i, u := range users {
+		if u.Username == "taken" {
+			failures = append(failures, dao.CreateFailure{Index: i, Err: dao2.ErrDuplicateEntry})
+			continue
+		}
+		r.created = append(r.created, u)
+	}
+	return failures, nil
+}
+
+func (r *fakeImportRepo) PurgeUser(_ context.Context, userID int64) error {
+	r.purged = append(r.purged, userID)
+	return nil
+}
+
+func (r *fakeImportRepo) CountActiveUsers(context.Context) (int64, error) {
+	return 3, nil
+}
+
+// fakeListRepo 按ID升序的内存用户表，游标语义与GormUserRepository.ListUsers一致
+type fakeListRepo struct {
+	dao.UserRepository
+	users []dao_model.User
+	err   error
+}
+
+func (r *fakeListRepo) ListUsers(_ context.Context, page pagination.Pagination, _ dao.ListFilter) (dao.UserPage, error) {
+	if r.err != nil {
+		return dao.UserPage{}, r.err
+	}
+	var result dao.UserPage
+	if !page.Keyset {
+		result.Total = int64(len(r.users))
+		end := min(page.Offset()+page.Size, len(r.users))
+		result.Users = r.users[min(page.Offset(), end):end]
+		return result, nil
+	}
+	for _, u := range r.users {
+		if u.ID > page.AfterID {
+			result.Users = append(result.Users, u)
+		}
+	}
+	if len(result.Users) > page.Size {
+		result.Users = result.Users[:page.Size]
+		result.NextCursor = pagination.EncodeCursor(result.Users[page.Size-1].ID)
+	}
+	return result, nil
+}
+
+func TestListUsersMapsRepositoryErrors(t *testing.T) {
+	h := NewAdminHandler(fastHashConfig(), &fakeListRepo{err: dao2.ErrTransient}, nil)
+	srv := server.New()
+	srv.GET("/users", h.ListUsers)
+
+	w := ut.PerformRequest(srv.Engine, "GET", "/users", nil)
+	var resp model.Response[model.ListUsersRes]
+	_ = json.Unmarshal(w.Result().Body(), &resp)
+	if w.Result().StatusCode() != 503 || resp.Code != errors2.CodeTransient {
+		t.Fatalf("Expected transient errors to map to 503/%d, got %d: %s", errors2.CodeTransient, w.Result().StatusCode(), w.Result().Body())
+	}
+}
+
+func TestListUsersWalksKeysetPages(t *testing.T) {
+	repo := &fakeListRepo{users: []dao_model.User{{ID: 1}, {ID: 2}, {ID: 5}}}
+	h := NewAdminHandler(fastHashConfig(), repo, nil)
+	srv := server.New()
+	srv.GET("/users", h.ListUsers)
+
+	list := func(query string) (int, model.Response[model.ListUsersRes]) {
+		w := ut.PerformRequest(srv.Engine, "GET", "/users?"+query, nil)
+		var resp model.Response[model.ListUsersRes]
+		_ = json.Unmarshal(w.Result().Body(), &resp)
+		return w.Result().StatusCode(), resp
+	}
+
+	// 第一页：没有游标，由mode=keyset进入游标分页并拿到next_cursor
+	status, first := list("mode=keyset&page_size=2")
+	if status != 200 || len(first.Data.Items) != 2 || first.Data.NextCursor == "" || first.Data.Total != nil {
+		t.Fatalf("Expected first keyset page with a cursor, got %d %+v", status, first.Data)
+	}
+	// 第二页：凭next_cursor续读，没有更多数据时不再返回游标
+	status, second := list("page_size=2&cursor=" + first.Data.NextCursor)
+	if status != 200 || len(second.Data.Items) != 1 || second.Data.Items[0].ID != 5 || second.Data.NextCursor != "" {
+		t.Fatalf("Expected last page with the remaining user, got %d %+v", status, second.Data)
+	}
+
+	if status, _ := list("mode=seek"); status != 400 {
+		t.Fatalf("Expected unknown mode to be rejected, got %d", status)
+	}
+}
+
+func TestStatsReturnsActiveUsers(t *testing.T) {
+	h := NewAdminHandler(fastHashConfig(), &fakeImportRepo{}, nil)
+	srv := server.New()
+	srv.GET("/stats", h.Stats)
+
+	w := ut.PerformRequest(srv.Engine, "GET", "/stats", nil)
+	var resp model.Response[model.StatsRes]
+	if err := json.Unmarshal(w.Result().Body(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if w.Result().StatusCode() != 200 || resp.Data.ActiveUsers != 3 {
+		t.Fatalf("Expected 3 active users, got %d: %s", w.Result().StatusCode(), w.Result().Body())
+	}
+}
+
+func TestGetConfigMasksSecrets(t *testing.T) {
+	cfg := fastHashConfig()
+	cfg.Middleware.JWT.Secret = "jwt-secret-value"
+	cfg.Database.Password = "db-password-value"
+	cfg.Mail.Password = ""
+	h := NewAdminHandler(cfg, &fakeImportRepo{}, nil)
+	srv := server.New()
+	srv.GET("/debug/config", h.GetConfig)
+
+	w := ut.PerformRequest(srv.Engine, "GET", "/debug/config", nil)
+	body := string(w.Result().Body())
+	if strings.Contains(body, "jwt-secret-value") || strings.Contains(body, "db-password-value") {
+		t.Fatalf("Expected secrets to be masked, got %s", body)
+	}
+
+	var got config.Config
+	if err := json.Unmarshal(w.Result().Body(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Middleware.JWT.Secret != "***" || got.Database.Password != "***" || got.Mail.Password != "" {
+		t.Fatalf("Unexpected redaction: jwt=%q db=%q mail=%q", got.Middleware.JWT.Secret, got.Database.Password, got.Mail.Password)
+	}
+	if cfg.Middleware.JWT.Secret != "jwt-secret-value" {
+		t.Fatal("Expected original config to be left untouched")
+	}
+}
+
+func TestSetDBLogLevelSwitchesAtRuntime(t *testing.T) {
+	h := NewAdminHandler(fastHashConfig(), &fakeImportRepo{}, nil)
+	h.DBLogger = config.NewSwitchableDBLogger(logger.Default, "warn")
+	srv := server.New()
+	srv.PUT("/db-log-level", h.SetDBLogLevel)
+
+	put := func(body string) int {
+		w := ut.PerformRequest(srv.Engine, "PUT", "/db-log-level",
+			&ut.Body{Body: strings.NewReader(body), Len: len(body)},
+			ut.Header{Key: "Content-Type", Value: "application/json"})
+		return w.Result().StatusCode()
+	}
+
+	if status := put(`{"level":"INFO"}`); status != 200 || h.DBLogger.Level() != "info" {
+		t.Fatalf("Expected level info, got %d %s", status, h.DBLogger.Level())
+	}
+	if status := put(`{"level":"verbose"}`); status != 400 || h.DBLogger.Level() != "info" {
+		t.Fatalf("Expected invalid level to be rejected and kept at info, got %d %s", status, h.DBLogger.Level())
+	}
+}
+
+func TestDeleteUserRequiresConfirmation(t *testing.T) {
+	repo := &fakeImportRepo{}
+	h := NewAdminHandler(fastHashConfig(), repo, nil)
+	srv := server.New()
+	srv.DELETE("/users/:id", func(c context.Context, ctx *app.RequestContext) {
+		ctx.Set(auth.ClaimsKey, jwt.MapClaims{"user_id": float64(1)})
+		ctx.Next(c)
+	}, h.DeleteUser)
+
+	cases := []struct {
+		query  string
+		status int
+	}{
+		{"", 400},                      // 未声明purge
+		{"?purge=true", 400},           // 缺少确认
+		{"?purge=true&confirm=8", 400}, // 确认与目标不一致
+		{"?purge=true&confirm=7", 200},
+	}
+	for _, tc := range cases {
+		w := ut.PerformRequest(srv.Engine, "DELETE", "/users/7"+tc.query, nil)
+		if w.Result().StatusCode() != tc.status {
+			t.Fatalf("%q: expected %d, got %d: %s", tc.query, tc.status, w.Result().StatusCode(), w.Result().Body())
+		}
+	}
+	if len(repo.purged) != 1 || repo.purged[0] != 7 {
+		t.Fatalf("Expected exactly user 7 to be purged, got %v", repo.purged)
+	}
+
+	// 管理员不能删除自己
+	if w := ut.PerformRequest(srv.Engine, "DELETE", "/users/1?purge=true&confirm=1", nil); w.Result().StatusCode() != 400 {
+		t.Fatalf("Expected self purge to be rejected, got %d", w.Result().StatusCode())
+	}
+}
+
+func TestImportUsersReportsPerRowResults(t *testing.T) {
+	repo := &fakeImportRepo{}
+	h := NewAdminHandler(fastHashConfig(), repo, nil)
+	srv := server.New()
+	srv.POST("/import", h.ImportUsers)
+
+	legacyHash, _ := bcrypt.GenerateFromPassword([]byte("legacy"), bcrypt.MinCost)
+	body, _ := json.Marshal([]model.ImportUserReq{
+		{Username: "alice", Email: "alice@example.com", Password: "Passw0rd!"},
+		{Username: "taken", Email: "taken@example.com", Password: "Passw0rd!"},
+		{Username: "bob", Email: "not-an-email", Password: "Passw0rd!"},
+		{Username: "Carol", Email: "carol@example.com", PasswordHash: string(legacyHash), Roles: []string{"admin"}},
+	})
+	w := ut.PerformRequest(srv.Engine, "POST", "/import",
+		&ut.Body{Body: bytes.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"})
+	if w.Result().StatusCode() != 207 {
+		t.Fatalf("Expected 207, got %d: %s", w.Result().StatusCode(), w.Result().Body())
+	}
+
+	var resp model.Response[model.PartialResult]
+	if err := json.Unmarshal(w.Result().Body(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != errors2.CodePartialSuccess {
+		t.Fatalf("Expected code %d, got %d", errors2.CodePartialSuccess, resp.Code)
+	}
+	result := resp.Data
+	if result.Total != 4 || result.Succeeded != 2 || result.Warnings != 1 || result.Failed != 2 || len(result.Items) != 4 {
+		t.Fatalf("Unexpected summary: %+v", result)
+	}
+	want := []struct {
+		status string
+		code   int
+	}{
+		{model.ItemSucceeded, 0},
+		{model.ItemFailed, errors2.CodeConflict},
+		{model.ItemFailed, errors2.CodeBadRequest},
+		{model.ItemWarning, 0},
+	}
+	for i, item := range result.Items {
+		if item.Index != i || item.Status != want[i].status || item.Code != want[i].code {
+			t.Fatalf("Item %d: expected %+v, got %+v", i, want[i], item)
+		}
+	}
+
+	if len(repo.created) != 2 || repo.created[1].PasswordHash != string(legacyHash) || repo.created[1].Roles != "admin" {
+		t.Fatalf("Expected alice and carol to be created with legacy hash kept, got %+v", repo.created)
+	}
+}
+
+func TestImportUsersAllFailed(t *testing.T) {
+	repo := &fakeImportRepo{}
+	h := NewAdminHandler(fastHashConfig(), repo, nil)
+	srv := server.New()
+	srv.POST("/import", h.ImportUsers)
+
+	body, _ := json.Marshal([]model.ImportUserReq{
+		{Username: "bob", Email: "not-an-email", Password: "Passw0rd!"},
+	})
+	w := ut.PerformRequest(srv.Engine, "POST", "/import",
+		&ut.Body{Body: bytes.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"})
+	if w.Result().StatusCode() != 422 {
+		t.Fatalf("Expected 422, got %d: %s", w.Result().StatusCode(), w.Result().Body())
+	}
+	var resp model.Response[model.PartialResult]
+	if err := json.Unmarshal(w.Result().Body(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != errors2.CodeAllFailed || resp.Data.Failed != 1 || len(resp.Data.Items) != 1 {
+		t.Fatalf("Expected all-failed envelope, got %s", w.Result().Body())
+	}
+}
+
+func TestImportUsersHashesPasswordsInParallel(t *testing.T) {
+	repo := &fakeImportRepo{}
+	h := NewAdminHandler(fastHashConfig(), repo, nil)
+	srv := server.New()
+	srv.POST("/import", h.ImportUsers)
+
+	var reqs []model.ImportUserReq
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("user%02d", i)
+		reqs = append(reqs, model.ImportUserReq{Username: name, Email: name + "@example.com", Password: "Passw0rd!" + name})
+	}
+	// 超过bcrypt的72字节上限，哈希失败只影响本行
+	reqs[5].Password = "Passw0rd!" + strings.Repeat("x", 80)
+	body, _ := json.Marshal(reqs)
+	w := ut.PerformRequest(srv.Engine, "POST", "/import",
+		&ut.Body{Body: bytes.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"})
+
+	var resp model.Response[model.PartialResult]
+	if err := json.Unmarshal(w.Result().Body(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	result := resp.Data
+	if len(result.Items) != len(reqs) {
+		t.Fatalf("Expected %d items, got %d: %s", len(reqs), len(result.Items), w.Result().Body())
+	}
+	for i, item := range result.Items {
+		wantFailed := i == 5
+		if item.Index != i || (item.Status == model.ItemFailed) != wantFailed {
+			t.Fatalf("Item %d: unexpected result %+v", i, item)
+		}
+	}
+	if result.Items[5].Code != errors2.CodeInternal {
+		t.Fatalf("Expected the unhashable row to fail with %d, got %+v", errors2.CodeInternal, result.Items[5])
+	}
+
+	if len(repo.created) != 19 {
+		t.Fatalf("Expected 19 users to be created, got %d", len(repo.created))
+	}
+	for _, u := range repo.created {
+		if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte("Passw0rd!"+u.Username)); err != nil {
+			t.Fatalf("Expected %s to get the hash of its own password: %v", u.Username, err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"my-digital-home/pkg/common/auth"
+	errors2 "my-digital-home/pkg/common/errors"
+	dao_model "my-digital-home/pkg/core/user/model"
+	"my-digital-home/pkg/web/model"
+)
+
+// API Key名称与授权范围的长度约束，与api_keys表的列宽一致
+const (
+	maxAPIKeyName   = 100
+	maxAPIKeyScopes = 512
+)
+
+// CreateAPIKey 创建服务API Key，明文只在本次响应中返回，之后只保存哈希
+func (h *AdminHandler) CreateAPIKey(ctx context.Context, c *app.RequestContext) {
+	var req model.CreateAPIKeyReq
+	if err := bindAndValidate(c, &req); err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxAPIKeyName {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误: name须为1-100个字符")
+		return
+	}
+	// 授权范围以逗号分隔存储，不能包含逗号与空白
+	for _, scope := range req.Scopes {
+		if scope == "" || strings.ContainsAny(scope, ", \t\n") {
+			respondErr(c, 400, errors2.CodeBadRequest, "参数错误: 无效的scope "+strconv.Quote(scope))
+			return
+		}
+	}
+	scopes := strings.Join(req.Scopes, ",")
+	if len(scopes) > maxAPIKeyScopes {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误: scopes过长")
+		return
+	}
+
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		respondErr(c, 500, errors2.CodeInternal, "系统错误")
+		return
+	}
+	apiKey := dao_model.APIKey{
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    scopes,
+		IsActive:  true,
+		CreatedBy: actorID,
+		CreatedAt: time.Now(),
+	}
+	if apiKey.ID, err = h.APIKeys.CreateAPIKey(ctx, apiKey); err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+
+	hlog.CtxInfof(ctx, "api key created: id=%d name=%s prefix=%s by=%d", apiKey.ID, name, prefix, actorID)
+	h.Audit.Record(newAuditEntry(c, dao_model.AuditAPIKeyCreate, actorID, 0))
+	c.JSON(201, model.Response[model.CreatedAPIKeyRes]{
+		Code:    errors2.CodeOK,
+		Message: "success",
+		Data:    model.CreatedAPIKeyRes{APIKeyRes: toAPIKeyRes(apiKey), Key: key},
+	})
+}
+
+// ListAPIKeys 列出全部API Key（含已吊销），不返回明文
+func (h *AdminHandler) ListAPIKeys(ctx context.Context, c *app.RequestContext) {
+	keys, err := h.APIKeys.ListAPIKeys(ctx)
+	if err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+	items := make([]model.APIKeyRes, 0, len(keys))
+	for _, k := range keys {
+		items = append(items, toAPIKeyRes(k))
+	}
+	respondOK(c, items)
+}
+
+// RevokeAPIKey 吊销API Key，携带该Key的请求立即被拒绝
+func (h *AdminHandler) RevokeAPIKey(ctx context.Context, c *app.RequestContext) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误: 无效的API Key ID")
+		return
+	}
+	if err := h.APIKeys.RevokeAPIKey(ctx, id); err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+	h.Audit.Record(newAuditEntry(c, dao_model.AuditAPIKeyRevoke, 0, 0))
+	respondMsg(c, 200, "API Key已吊销")
+}
+
+func toAPIKeyRes(k dao_model.APIKey) model.APIKeyRes {
+	scopes := k.ScopeList()
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return model.APIKeyRes{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     scopes,
+		Active:     k.IsActive,
+		CreatedBy:  k.CreatedBy,
+		CreatedAt:  k.CreatedAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+	}
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/golang-jwt/jwt/v5"
+	"my-digital-home/pkg/common/auth"
+	dao_model "my-digital-home/pkg/core/user/model"
+	dao2 "my-digital-home/pkg/core/user/repository/dao/impl"
+	"my-digital-home/pkg/web/model"
+)
+
+// fakeAPIKeyRepo 内存中的API Key表
+type fakeAPIKeyRepo struct {
+	keys []dao_model.APIKey
+}
+
+func (r *fakeAPIKeyRepo) CreateAPIKey(_ context.Context, key dao_model.APIKey) (int64, error) {
+	key.ID = int64(len(r.keys) + 1)
+	r.keys = append(r.keys, key)
+	return key.ID, nil
+}
+
+func (r *fakeAPIKeyRepo) AuthenticateAPIKey(_ context.Context, keyHash string, _ time.Time) (dao_model.APIKey, error) {
+	for _, k := range r.keys {
+		if k.KeyHash == keyHash && k.IsActive {
+			return k, nil
+		}
+	}
+	return dao_model.APIKey{}, dao2.ErrAPIKeyNotFound
+}
+
+func (r *fakeAPIKeyRepo) ListAPIKeys(context.Context) ([]dao_model.APIKey, error) {
+	return r.keys, nil
+}
+
+func (r *fakeAPIKeyRepo) RevokeAPIKey(_ context.Context, id int64) error {
+	for i := range r.keys {
+		if r.keys[i].ID == id && r.keys[i].IsActive {
+			r.keys[i].IsActive = false
+			return nil
+		}
+	}
+	return dao2.ErrAPIKeyNotFound
+}
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	repo := &fakeAPIKeyRepo{}
+	h := NewAdminHandler(fastHashConfig(), &fakeImportRepo{}, nil)
+	h.APIKeys = repo
+	srv := server.New()
+	asAdmin := func(c context.Context, ctx *app.RequestContext) {
+		ctx.Set(auth.ClaimsKey, jwt.MapClaims{"user_id": float64(1)})
+		ctx.Next(c)
+	}
+	srv.POST("/api-keys", asAdmin, h.CreateAPIKey)
+	srv.GET("/api-keys", asAdmin, h.ListAPIKeys)
+	srv.DELETE("/api-keys/:id", asAdmin, h.RevokeAPIKey)
+
+	post := func(body string) (int, []byte) {
+		w := ut.PerformRequest(srv.Engine, "POST", "/api-keys",
+			&ut.Body{Body: strings.NewReader(body), Len: len(body)},
+			ut.Header{Key: "Content-Type", Value: "application/json"})
+		return w.Result().StatusCode(), w.Result().Body()
+	}
+
+	if status, _ := post(`{"name":"billing","scopes":["users:read","bad scope"]}`); status != 400 {
+		t.Fatalf("Expected scope containing whitespace to be rejected, got %d", status)
+	}
+
+	status, body := post(`{"name":"billing","scopes":["users:read"]}`)
+	var created model.Response[model.CreatedAPIKeyRes]
+	if status != 201 || json.Unmarshal(body, &created) != nil {
+		t.Fatalf("Expected 201, got %d: %s", status, body)
+	}
+	key := created.Data.Key
+	if !strings.HasPrefix(key, created.Data.Prefix) || created.Data.CreatedBy != 1 {
+		t.Fatalf("Unexpected created key %+v", created.Data)
+	}
+	// 只保存哈希
+	if stored := repo.keys[0]; stored.KeyHash != auth.HashAPIKey(key) || strings.Contains(stored.KeyHash, key) {
+		t.Fatalf("Expected only the key hash to be stored, got %+v", stored)
+	}
+
+	w := ut.PerformRequest(srv.Engine, "GET", "/api-keys", nil)
+	if strings.Contains(string(w.Result().Body()), key) || strings.Contains(string(w.Result().Body()), repo.keys[0].KeyHash) {
+		t.Fatalf("Expected list to omit the key and its hash, got %s", w.Result().Body())
+	}
+
+	if w := ut.PerformRequest(srv.Engine, "DELETE", "/api-keys/1", nil); w.Result().StatusCode() != 200 {
+		t.Fatalf("Expected revoke to succeed, got %d", w.Result().StatusCode())
+	}
+	if _, err := repo.AuthenticateAPIKey(context.Background(), auth.HashAPIKey(key), time.Now()); err == nil {
+		t.Fatal("Expected revoked key to be rejected")
+	}
+	if w := ut.PerformRequest(srv.Engine, "DELETE", "/api-keys/1", nil); w.Result().StatusCode() != 404 {
+		t.Fatalf("Expected revoking twice to return 404, got %d", w.Result().StatusCode())
+	}
+}
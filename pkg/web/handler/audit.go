@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"github.com/cloudwego/hertz/pkg/app"
+	"my-digital-home/pkg/common/auth"
+	dao_model "my-digital-home/pkg/core/user/model"
+)
+
+// maxAuditUserAgent 与audit_logs.user_agent列宽一致，超长部分截断
+const maxAuditUserAgent = 255
+
+// newAuditEntry 由请求上下文构造审计记录；actorID为0时取JWT声明中的当前用户
+func newAuditEntry(c *app.RequestContext, action string, actorID, targetID int64) dao_model.AuditLog {
+	if actorID == 0 {
+		if claims, ok := auth.ClaimsFromContext(c); ok {
+			if id, ok := claims["user_id"].(float64); ok {
+				actorID = int64(id)
+			}
+		}
+	}
+
+	userAgent := string(c.UserAgent())
+	if len(userAgent) > maxAuditUserAgent {
+		userAgent = userAgent[:maxAuditUserAgent]
+	}
+	return dao_model.AuditLog{
+		ActorID:   actorID,
+		Action:    action,
+		TargetID:  targetID,
+		IP:        c.ClientIP(),
+		UserAgent: userAgent,
+	}
+}
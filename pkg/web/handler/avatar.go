@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	errors2 "my-digital-home/pkg/common/errors"
+	"my-digital-home/pkg/web/model"
+)
+
+// avatarFormField 头像上传的表单字段名
+const avatarFormField = "avatar"
+
+// sniffLen 内容类型识别所需的文件头长度（与http.DetectContentType一致）
+const sniffLen = 512
+
+// avatarExtensions 头像文件的存储扩展名，同时限定可识别的图片类型
+var avatarExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// UploadAvatar 上传当前用户的头像（multipart/form-data，字段avatar），返回存储后的访问地址
+// 校验大小与声明的类型，并按文件头识别实际类型，二者不一致时拒绝
+func (h *UserHandler) UploadAvatar(ctx context.Context, c *app.RequestContext) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	if h.Storage == nil {
+		respondErr(c, 404, errors2.CodeNotFound, "未启用文件上传")
+		return
+	}
+
+	file, err := c.FormFile(avatarFormField)
+	if err != nil {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误: 缺少头像文件")
+		return
+	}
+	if file.Size > h.UploadCfg.AvatarMaxSize {
+		respondErr(c, 413, errors2.CodeFileTooLarge,
+			fmt.Sprintf("头像文件不能超过%d字节", h.UploadCfg.AvatarMaxSize))
+		return
+	}
+	declared, _, err := mime.ParseMediaType(file.Header.Get("Content-Type"))
+	ext, known := avatarExtensions[declared]
+	if err != nil || !known || !h.avatarTypeAllowed(declared) {
+		respondErr(c, 415, errors2.CodeUnsupportedMedia, "不支持的头像文件类型")
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误: 无法读取头像文件")
+		return
+	}
+	defer src.Close()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误: 无法读取头像文件")
+		return
+	}
+	head = head[:n]
+	if detected := http.DetectContentType(head); detected != declared {
+		respondErr(c, 415, errors2.CodeFileTypeMismatch, "头像文件内容与声明的类型不符")
+		return
+	}
+
+	// 每次上传使用新的对象键，客户端与CDN不会命中旧头像的缓存
+	name := make([]byte, 8)
+	if _, err := rand.Read(name); err != nil {
+		respondErr(c, 500, errors2.CodeInternal, "系统错误")
+		return
+	}
+	key := fmt.Sprintf("avatars/%d/%s%s", userID, hex.EncodeToString(name), ext)
+	url, err := h.Storage.Put(ctx, key, io.MultiReader(bytes.NewReader(head), src), declared)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "store avatar failed: user=%d err=%v", userID, err)
+		respondErr(c, 500, errors2.CodeInternal, "头像保存失败")
+		return
+	}
+
+	if err := h.UserRepo.UpdateAvatar(ctx, userID, url); err != nil {
+		if delErr := h.Storage.Delete(ctx, key); delErr != nil {
+			hlog.CtxWarnf(ctx, "remove orphaned avatar failed: key=%s err=%v", key, delErr)
+		}
+		respondMappedErr(c, err)
+		return
+	}
+	respondOK(c, model.AvatarRes{AvatarURL: url})
+}
+
+func (h *UserHandler) avatarTypeAllowed(contentType string) bool {
+	for _, t := range h.UploadCfg.AvatarTypes {
+		if t == contentType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"my-digital-home/pkg/common/auth"
+	errors2 "my-digital-home/pkg/common/errors"
+	"my-digital-home/pkg/common/storage"
+	"my-digital-home/pkg/core/user/repository/dao"
+	dao2 "my-digital-home/pkg/core/user/repository/dao/impl"
+	"my-digital-home/pkg/web/model"
+)
+
+// pngHeader 最小的PNG文件头，足以被识别为image/png
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type fakeAvatarRepo struct {
+	dao.UserRepository
+	avatars map[int64]string
+}
+
+func (r *fakeAvatarRepo) UpdateAvatar(_ context.Context, userID int64, avatarURL string) error {
+	r.avatars[userID] = avatarURL
+	return nil
+}
+
+func uploadAvatar(t *testing.T, h *UserHandler, contentType string, content []byte) (int, model.Response[model.AvatarRes]) {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="avatar"; filename="me.png"`)
+	header.Set("Content-Type", contentType)
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	mw.Close()
+
+	srv := server.New()
+	srv.POST("/me/avatar", func(c context.Context, ctx *app.RequestContext) {
+		ctx.Set(auth.ClaimsKey, jwt.MapClaims{"user_id": float64(7)})
+		ctx.Next(c)
+	}, h.UploadAvatar)
+	w := ut.PerformRequest(srv.Engine, "POST", "/me/avatar",
+		&ut.Body{Body: &body, Len: body.Len()},
+		ut.Header{Key: "Content-Type", Value: mw.FormDataContentType()})
+
+	var resp model.Response[model.AvatarRes]
+	_ = json.Unmarshal(w.Result().Body(), &resp)
+	return w.Result().StatusCode(), resp
+}
+
+func newAvatarHandler(t *testing.T) (*UserHandler, *fakeAvatarRepo, string) {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := storage.NewLocalStorage(dir, "/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &fakeAvatarRepo{avatars: map[int64]string{}}
+	h := newLoginHandler(t, fastHashConfig(), repo)
+	h.Storage = store
+	return h, repo, dir
+}
+
+func TestUploadAvatarStoresFileAndRecordsURL(t *testing.T) {
+	h, repo, dir := newAvatarHandler(t)
+
+	status, resp := uploadAvatar(t, h, "image/png", pngHeader)
+	if status != 200 || !strings.HasPrefix(resp.Data.AvatarURL, "/uploads/avatars/7/") || !strings.HasSuffix(resp.Data.AvatarURL, ".png") {
+		t.Fatalf("Expected stored avatar url, got %d: %+v", status, resp)
+	}
+	if repo.avatars[7] != resp.Data.AvatarURL {
+		t.Fatalf("Expected avatar url recorded on the user, got %q", repo.avatars[7])
+	}
+	stored, err := os.ReadFile(filepath.Join(dir, strings.TrimPrefix(resp.Data.AvatarURL, "/uploads/")))
+	if err != nil || !bytes.Equal(stored, pngHeader) {
+		t.Fatalf("Expected uploaded bytes on disk, got %q (%v)", stored, err)
+	}
+}
+
+func TestUploadAvatarValidatesSizeAndType(t *testing.T) {
+	h, repo, _ := newAvatarHandler(t)
+	h.UploadCfg.AvatarMaxSize = 64
+	h.UploadCfg.AvatarTypes = []string{"image/png", "image/jpeg"}
+
+	cases := []struct {
+		name        string
+		contentType string
+		content     []byte
+		status      int
+		code        int
+	}{
+		{"too large", "image/png", append(pngHeader, make([]byte, 64)...), 413, errors2.CodeFileTooLarge},
+		{"type not allowed", "image/gif", []byte("GIF89a"), 415, errors2.CodeUnsupportedMedia},
+		{"not an image", "application/pdf", []byte("%PDF-1.4"), 415, errors2.CodeUnsupportedMedia},
+		{"magic bytes mismatch", "image/jpeg", pngHeader, 415, errors2.CodeFileTypeMismatch},
+		{"html disguised as png", "image/png", []byte("<html><script>alert(1)</script>"), 415, errors2.CodeFileTypeMismatch},
+	}
+	for _, tc := range cases {
+		if status, resp := uploadAvatar(t, h, tc.contentType, tc.content); status != tc.status || resp.Code != tc.code {
+			t.Errorf("%s: expected %d/%d, got %d/%d", tc.name, tc.status, tc.code, status, resp.Code)
+		}
+	}
+	if len(repo.avatars) != 0 {
+		t.Fatalf("Expected rejected uploads not to be recorded, got %v", repo.avatars)
+	}
+}
+
+func TestProfileReturnsUploadedAvatar(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 经真实仓储写入头像，再经GET /users/me读回，确认资料查询包含avatar_url列
+	h, _, _ := newAvatarHandler(t)
+	h.UserRepo = dao2.NewUserRepository(db)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`version` FROM `base_users`")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version"}).AddRow(7, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `base_users` SET `avatar_url`=?")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	status, uploaded := uploadAvatar(t, h, "image/png", pngHeader)
+	if status != 200 || uploaded.Data.AvatarURL == "" {
+		t.Fatalf("Expected upload to succeed, got %d: %+v", status, uploaded)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT `id`,`username`,`email`,`email_verified`,`roles`,`avatar_url`,`created_at`,`updated_at`,`last_login_at`,`version` FROM `base_users`")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "roles", "avatar_url", "version"}).
+			AddRow(7, "alice", "alice@example.com", "user", uploaded.Data.AvatarURL, 2))
+
+	srv := server.New()
+	srv.GET("/users/me", func(c context.Context, ctx *app.RequestContext) {
+		ctx.Set(auth.ClaimsKey, jwt.MapClaims{"user_id": float64(7)})
+		ctx.Next(c)
+	}, h.Profile)
+	w := ut.PerformRequest(srv.Engine, "GET", "/users/me", nil)
+
+	var profile model.Response[model.UserRes]
+	if err := json.Unmarshal(w.Result().Body(), &profile); err != nil {
+		t.Fatal(err)
+	}
+	if w.Result().StatusCode() != 200 || profile.Data.AvatarURL != uploaded.Data.AvatarURL {
+		t.Fatalf("Expected profile to return avatar %q, got %d: %s", uploaded.Data.AvatarURL, w.Result().StatusCode(), w.Result().Body())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/golang-jwt/jwt/v5"
+	"my-digital-home/pkg/common/auth"
+	errors2 "my-digital-home/pkg/common/errors"
+	dao_model "my-digital-home/pkg/core/user/model"
+	dao2 "my-digital-home/pkg/core/user/repository/dao/impl"
+	"my-digital-home/pkg/web/model"
+	"net/url"
+	"time"
+)
+
+// 验证令牌用途标识，签名密钥与访问令牌隔离，避免互相冒用
+const purposeVerifyEmail = "verify_email"
+
+// mailSendTimeout 单封邮件发送超时
+const mailSendTimeout = 30 * time.Second
+
+// VerifyEmail 校验邮件中的令牌并标记邮箱已验证
+func (h *UserHandler) VerifyEmail(ctx context.Context, c *app.RequestContext) {
+	var req model.VerifyEmailReq
+	if err := c.BindAndValidate(&req); err != nil || req.Token == "" {
+		respondErr(c, 400, errors2.CodeBadRequest, "缺少验证令牌")
+		return
+	}
+
+	userID, email, err := h.parseVerificationToken(req.Token)
+	if err != nil {
+		respondErr(c, 400, errors2.CodeInvalidLink, "验证链接无效或已过期")
+		return
+	}
+
+	// 令牌绑定邮箱且仅在未验证时生效，验证成功后即失效
+	if err := h.UserRepo.MarkEmailVerified(ctx, userID, email); err != nil {
+		if errors.Is(err, dao2.ErrUserNotFound) {
+			respondErr(c, 400, errors2.CodeInvalidLink, "验证链接无效或已使用")
+		} else {
+			respondErr(c, 500, errors2.CodeDatabase, "邮箱验证失败")
+		}
+		return
+	}
+
+	respondMsg(c, 200, "邮箱验证成功")
+}
+
+// ResendVerification 重新发送验证邮件（无论邮箱是否存在均返回成功，避免账户枚举）
+func (h *UserHandler) ResendVerification(ctx context.Context, c *app.RequestContext) {
+	var req model.ResendVerificationReq
+	if err := c.BindAndValidate(&req); err != nil || req.Email == "" {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误")
+		return
+	}
+
+	user, err := h.UserRepo.QueryByEmail(ctx, dao_model.NormalizeEmail(req.Email))
+	switch {
+	case err == nil && !user.EmailVerified:
+		h.sendVerification(ctx, user)
+	case err != nil && !errors.Is(err, dao2.ErrUserNotFound):
+		hlog.CtxErrorf(ctx, "resend verification lookup failed: %v", err)
+	}
+
+	respondMsg(c, 200, "如果该邮箱已注册且未验证，验证邮件已发送")
+}
+
+// sendVerification 签发验证令牌并投递
+func (h *UserHandler) sendVerification(ctx context.Context, user dao_model.User) {
+	token, err := h.issueVerificationToken(user.ID, user.Email)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "issue verification token failed: user=%d err=%v", user.ID, err)
+		return
+	}
+
+	link := h.MailBaseURL + "/api/v1/users/verify?token=" + url.QueryEscape(token)
+	h.sendMail(ctx, user.Email, "请验证您的邮箱",
+		fmt.Sprintf("您好 %s：\n\n请点击以下链接完成邮箱验证（%s内有效）：\n%s\n\n如非本人操作请忽略本邮件。",
+			user.Username, h.AccountCfg.VerificationTokenTTL, link))
+}
+
+// sendMail 异步发信：不阻塞请求，也避免发信耗时暴露账户是否存在
+func (h *UserHandler) sendMail(ctx context.Context, to, subject, body string) {
+	go func() {
+		sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailSendTimeout)
+		defer cancel()
+		if err := h.Mailer.Send(sendCtx, to, subject, body); err != nil {
+			hlog.CtxErrorf(sendCtx, "send mail failed: to=%s subject=%q err=%v", to, subject, err)
+		}
+	}()
+}
+
+func (h *UserHandler) issueVerificationToken(userID int64, email string) (string, error) {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":     fmt.Sprint(userID),
+		"email":   email,
+		"purpose": purposeVerifyEmail,
+		"iat":     now.Unix(),
+		"exp":     now.Add(h.AccountCfg.VerificationTokenTTL).Unix(),
+	})
+	return token.SignedString(h.verificationKey())
+}
+
+func (h *UserHandler) parseVerificationToken(tokenStr string) (int64, string, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenStr, claims,
+		auth.PinnedKeyFunc(jwt.SigningMethodHS256, h.verificationKey()),
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if purpose, _ := claims["purpose"].(string); purpose != purposeVerifyEmail {
+		return 0, "", errors.New("token purpose mismatch")
+	}
+	email, _ := claims["email"].(string)
+	sub, _ := claims["sub"].(string)
+
+	var userID int64
+	if _, err := fmt.Sscan(sub, &userID); err != nil || email == "" {
+		return 0, "", errors.New("malformed verification token")
+	}
+	return userID, email, nil
+}
+
+func (h *UserHandler) verificationKey() []byte {
+	return []byte(h.JWTSecret + ":" + purposeVerifyEmail)
+}
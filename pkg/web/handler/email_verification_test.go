@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"my-digital-home/pkg/common/config"
+)
+
+func TestVerificationTokenRejectsForeignAlgorithms(t *testing.T) {
+	cfg := config.Default()
+	h := NewUserHandler(cfg, nil, nil, nil)
+
+	token, err := h.issueVerificationToken(7, "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, email, err := h.parseVerificationToken(token); err != nil || id != 7 || email != "alice@example.com" {
+		t.Fatalf("Expected own token to parse, got id=%d email=%s err=%v", id, email, err)
+	}
+
+	claims := jwt.MapClaims{
+		"sub":     fmt.Sprint(7),
+		"email":   "alice@example.com",
+		"purpose": purposeVerifyEmail,
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	}
+	none, _ := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	otherHS, _ := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString(h.verificationKey())
+
+	for name, forged := range map[string]string{"alg none": none, "HS512": otherHS} {
+		if _, _, err := h.parseVerificationToken(forged); err == nil {
+			t.Fatalf("%s: expected verification token to be rejected", name)
+		}
+	}
+}
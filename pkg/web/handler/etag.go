@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// weakETag 由资源标识与版本信息生成弱ETag
+// 弱ETag只表示语义等价，响应经压缩等编码改变字节后仍可用于协商
+func weakETag(parts ...interface{}) string {
+	values := make([]string, len(parts))
+	for i, part := range parts {
+		switch v := part.(type) {
+		case time.Time:
+			values[i] = fmt.Sprint(v.UnixNano())
+		case *time.Time:
+			if v != nil {
+				values[i] = fmt.Sprint(v.UnixNano())
+			}
+		default:
+			values[i] = fmt.Sprint(v)
+		}
+	}
+	return `W/"` + strings.Join(values, "-") + `"`
+}
+
+// notModified 设置ETag响应头；请求的If-None-Match与之匹配时写入304并返回true，调用方不应再写响应体
+func notModified(c *app.RequestContext, etag string) bool {
+	if !etagMatches(string(c.GetHeader("If-None-Match")), etag) {
+		c.Response.Header.Set("ETag", etag)
+		return false
+	}
+	c.NotModified()
+	c.Response.Header.Set("ETag", etag)
+	return true
+}
+
+// etagMatches 按弱比较判断If-None-Match（逗号分隔的列表或*）是否包含etag
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
@@ -1,21 +1,133 @@
 package handler
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol/http1/resp"
+	"gorm.io/gorm"
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/common/version"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// dbPingTimeout 数据库探活超时
+const dbPingTimeout = 2 * time.Second
+
+// redisPingTimeout Redis探活超时，Redis正常时应在毫秒级响应
+const redisPingTimeout = 500 * time.Millisecond
+
+// defaultCheckTimeout 外部注册的检查项未指定超时时使用
+const defaultCheckTimeout = 2 * time.Second
+
+// componentCheck 单个依赖的检查项，各自拥有独立的超时；check与report二选一
+type componentCheck struct {
+	name    string
+	isCore  bool
+	timeout time.Duration
+	check   func(ctx context.Context) error
+	report  func(ctx context.Context) ComponentStatus
+	details func() interface{} // 可选，/health中附带的组件详情
+}
+
+// ComponentCheck 由各功能注册的检查项（如SMTP、对象存储），无需修改本处理器
+// Check返回的Status为空时视为ok，Name、IsCore与Latency由注册信息与实际耗时填充
+type ComponentCheck struct {
+	Name    string
+	Core    bool          // 为true时状态非ok即整体降级
+	Timeout time.Duration // 为0时使用defaultCheckTimeout
+	Check   func(ctx context.Context) ComponentStatus
+}
+
+// defaultStreamInterval 健康状态事件流的默认推送间隔
+const defaultStreamInterval = 5 * time.Second
+
 type HealthCheckHandler struct {
+	checks       []componentCheck
+	shuttingDown atomic.Bool
+
+	StreamInterval time.Duration // 事件流推送间隔，为0时使用defaultStreamInterval
 }
 
-func NewHealthCheckHandler() *HealthCheckHandler {
-	return &HealthCheckHandler{}
+// NewHealthCheckHandler db为nil时不检查数据库，extra为各功能注册的检查项，按注册顺序排在数据库之后
+func NewHealthCheckHandler(db *gorm.DB, extra ...ComponentCheck) *HealthCheckHandler {
+	h := &HealthCheckHandler{}
+	if db != nil {
+		h.checks = append(h.checks, componentCheck{
+			name:    "database",
+			isCore:  true,
+			timeout: dbPingTimeout,
+			check:   pingDatabase(db),
+			details: databasePoolStats(db),
+		})
+	}
+	for _, chk := range extra {
+		timeout := chk.Timeout
+		if timeout <= 0 {
+			timeout = defaultCheckTimeout
+		}
+		h.checks = append(h.checks, componentCheck{
+			name:    chk.Name,
+			isCore:  chk.Core,
+			timeout: timeout,
+			report:  chk.Check,
+		})
+	}
+	return h
+}
+
+// AddRedisCheck 追加Redis的PING检查，报告ok/timeout，失败时核心检查报告critical、非核心报告warning
+// core为true时Redis异常导致整体降级，应仅在有功能依赖Redis时开启；需在开始处理请求前调用
+func (h *HealthCheckHandler) AddRedisCheck(cfg config.RedisConfig, core bool) {
+	ping := pingRedis(cfg)
+	failed := "warning"
+	if core {
+		failed = "critical"
+	}
+	h.checks = append(h.checks, componentCheck{
+		name:    "redis",
+		isCore:  core,
+		timeout: redisPingTimeout,
+		report: func(ctx context.Context) ComponentStatus {
+			if err := ping(ctx); err != nil {
+				return ComponentStatus{Status: failed, Error: err.Error()}
+			}
+			return ComponentStatus{}
+		},
+	})
+}
+
+// RequireReady 追加核心检查项，ready返回false期间就绪探针失败（如降级启动时数据库尚未初始化）
+// 需在开始处理请求前调用
+func (h *HealthCheckHandler) RequireReady(name string, ready func() bool) {
+	h.checks = append(h.checks, componentCheck{
+		name:    name,
+		isCore:  true,
+		timeout: dbPingTimeout,
+		check: func(ctx context.Context) error {
+			if !ready() {
+				return errors.New("not initialized")
+			}
+			return nil
+		},
+	})
 }
 
 type HealthStatus struct {
 	Status     string            `json:"status"`
 	Timestamp  time.Time         `json:"timestamp"`
+	Uptime     string            `json:"uptime"`
+	Version    string            `json:"version"`
+	GitCommit  string            `json:"git_commit"`
+	BuildTime  string            `json:"build_time"`
 	Components []ComponentStatus `json:"components,omitempty"`
 }
 
@@ -26,31 +138,277 @@ type ComponentStatus struct {
 	IsCore  bool          `json:"is_core"` // 新增关键组件标识
 	Latency time.Duration `json:"latency,omitempty"`
 	Error   string        `json:"error,omitempty"`
+	Details interface{}   `json:"details,omitempty"`
+}
+
+// DBPoolStats 连接池概况，InUse接近MaxOpen时说明连接池即将耗尽
+type DBPoolStats struct {
+	MaxOpen      int    `json:"max_open"`
+	Open         int    `json:"open"`
+	InUse        int    `json:"in_use"`
+	Idle         int    `json:"idle"`
+	WaitCount    int64  `json:"wait_count"`
+	WaitDuration string `json:"wait_duration"`
 }
 
 var startupTime = time.Now()
 
-// AdvancedHealthCheck 增强的健康检查接口
-func (h *HealthCheckHandler) AdvancedHealthCheck(ctx context.Context, c *app.RequestContext) {
-	status := HealthStatus{
-		Status:     "healthy",
-		Timestamp:  time.Now().UTC(),
-		Components: []ComponentStatus{
-			//checkDatabase(),
-			//checkRedis(),
-			//checkExternalService(),
-		},
+// newHealthStatus 填充公共字段：时间戳、运行时长与构建信息
+func newHealthStatus(status string) HealthStatus {
+	return HealthStatus{
+		Status:    status,
+		Timestamp: time.Now().UTC(),
+		Uptime:    time.Since(startupTime).Truncate(time.Second).String(),
+		Version:   version.Version,
+		GitCommit: version.GitCommit,
+		BuildTime: version.BuildTime,
+	}
+}
+
+// MarkShuttingDown 进入优雅停机：就绪探针立即失败，让流量先行摘除
+func (h *HealthCheckHandler) MarkShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Livez 存活探针：只反映进程状态，不检查外部依赖
+// 停机排空期间仍返回200，否则编排系统会重启实例而非等待排空
+func (h *HealthCheckHandler) Livez(ctx context.Context, c *app.RequestContext) {
+	c.JSON(200, newHealthStatus("alive"))
+}
+
+// Readyz 就绪探针：核心依赖可用且未处于停机流程时才返回200
+// 只执行核心检查项：非核心项不影响就绪结果，探针频繁调用时也不必每次访问SMTP等外部服务
+func (h *HealthCheckHandler) Readyz(ctx context.Context, c *app.RequestContext) {
+	if h.shuttingDown.Load() {
+		c.JSON(503, newHealthStatus("shutting_down"))
+		return
 	}
 
+	status := newHealthStatus("ready")
+	status.Components = h.checkComponents(ctx, true)
 	if hasCriticalErrors(status.Components) {
-		status.Status = "degraded"
+		status.Status = "not_ready"
 		c.JSON(503, status)
 		return
 	}
+	c.JSON(200, status)
+}
 
+// AdvancedHealthCheck 增强的健康检查接口
+func (h *HealthCheckHandler) AdvancedHealthCheck(ctx context.Context, c *app.RequestContext) {
+	status := h.healthReport(ctx)
+	if status.Status != "healthy" {
+		c.JSON(503, status)
+		return
+	}
 	c.JSON(200, status)
 }
 
+// healthReport 执行全部检查项并附带组件详情，核心依赖异常时状态为degraded
+func (h *HealthCheckHandler) healthReport(ctx context.Context) HealthStatus {
+	status := newHealthStatus("healthy")
+	status.Components = h.checkComponents(ctx, false)
+	for i, chk := range h.checks {
+		if chk.details != nil {
+			status.Components[i].Details = chk.details()
+		}
+	}
+	if hasCriticalErrors(status.Components) {
+		status.Status = "degraded"
+	}
+	return status
+}
+
+// StreamHealth 以Server-Sent Events定时推送/health的检查结果，供运维面板实时展示
+// 客户端断开（写入失败或请求上下文取消）或进入停机流程时结束推送
+func (h *HealthCheckHandler) StreamHealth(ctx context.Context, c *app.RequestContext) {
+	interval := h.StreamInterval
+	if interval <= 0 {
+		interval = defaultStreamInterval
+	}
+
+	c.SetContentType("text/event-stream; charset=utf-8")
+	c.Response.Header.Set("Cache-Control", "no-cache")
+	c.Response.Header.Set("X-Accel-Buffering", "no") // 避免反向代理缓冲事件
+	c.Response.HijackWriter(resp.NewChunkedBodyWriter(&c.Response, c.GetWriter()))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if h.shuttingDown.Load() {
+			return
+		}
+		data, err := json.Marshal(h.healthReport(ctx))
+		if err != nil {
+			hlog.CtxErrorf(ctx, "encode health event failed: %v", err)
+			return
+		}
+		if _, err := c.Write([]byte("event: health\ndata: " + string(data) + "\n\n")); err != nil {
+			return
+		}
+		if err := c.Flush(); err != nil {
+			hlog.CtxDebugf(ctx, "health stream closed: %v", err)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkComponents 并发执行各检查项，结果按注册顺序返回；coreOnly为true时跳过非核心项
+func (h *HealthCheckHandler) checkComponents(ctx context.Context, coreOnly bool) []ComponentStatus {
+	checks := h.checks
+	if coreOnly {
+		checks = nil
+		for _, chk := range h.checks {
+			if chk.isCore {
+				checks = append(checks, chk)
+			}
+		}
+	}
+	components := make([]ComponentStatus, len(checks))
+
+	var wg sync.WaitGroup
+	for i, chk := range checks {
+		wg.Add(1)
+		go func(i int, chk componentCheck) {
+			defer wg.Done()
+			components[i] = runCheck(ctx, chk)
+		}(i, chk)
+	}
+	wg.Wait()
+	return components
+}
+
+// runCheck 在独立的deadline内执行检查，超时即返回timeout而不等待检查函数结束
+func runCheck(ctx context.Context, chk componentCheck) ComponentStatus {
+	status := ComponentStatus{
+		Name:   chk.name,
+		IsCore: chk.isCore,
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, chk.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan ComponentStatus, 1) // 带缓冲，超时后检查函数返回也不会阻塞
+	go func() {
+		if chk.report != nil {
+			done <- chk.report(checkCtx)
+			return
+		}
+		var result ComponentStatus
+		if err := chk.check(checkCtx); err != nil {
+			result.Status = "critical"
+			result.Error = err.Error()
+		}
+		done <- result
+	}()
+
+	select {
+	case result := <-done:
+		status.Status = result.Status
+		status.Error = result.Error
+		status.Details = result.Details
+		status.Latency = result.Latency
+		if status.Status == "" {
+			status.Status = "ok"
+		}
+		if status.Latency == 0 {
+			status.Latency = time.Since(start)
+		}
+	case <-checkCtx.Done():
+		status.Latency = time.Since(start)
+		status.Status = "timeout"
+		status.Error = checkCtx.Err().Error()
+	}
+	return status
+}
+
+// pingDatabase 数据库连通性检查
+func pingDatabase(db *gorm.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// pingRedis 以RESP协议发送AUTH（配置了密码时）与PING，期望收到+PONG；每次检查新建连接，不占用业务连接池
+func pingRedis(cfg config.RedisConfig) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", cfg.Addr)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+
+		var cmds strings.Builder
+		if cfg.Password != "" {
+			fmt.Fprintf(&cmds, "*2\r\n$4\r\nAUTH\r\n$%d\r\n%s\r\n", len(cfg.Password), cfg.Password)
+		}
+		cmds.WriteString("*1\r\n$4\r\nPING\r\n")
+		if _, err := conn.Write([]byte(cmds.String())); err != nil {
+			return err
+		}
+
+		r := bufio.NewReader(conn)
+		if cfg.Password != "" {
+			if line, err := readRedisLine(r); err != nil {
+				return err
+			} else if line != "+OK" {
+				return fmt.Errorf("redis auth failed: %s", line)
+			}
+		}
+		line, err := readRedisLine(r)
+		if err != nil {
+			return err
+		}
+		if line != "+PONG" {
+			return fmt.Errorf("unexpected redis reply: %s", line)
+		}
+		return nil
+	}
+}
+
+// readRedisLine 读取一行简单回复（+OK、-ERR ...），去掉结尾的CRLF
+func readRedisLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// databasePoolStats 读取连接池统计，不访问数据库
+func databasePoolStats(db *gorm.DB) func() interface{} {
+	return func() interface{} {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil
+		}
+		stats := sqlDB.Stats()
+		return DBPoolStats{
+			MaxOpen:      stats.MaxOpenConnections,
+			Open:         stats.OpenConnections,
+			InUse:        stats.InUse,
+			Idle:         stats.Idle,
+			WaitCount:    stats.WaitCount,
+			WaitDuration: stats.WaitDuration.String(),
+		}
+	}
+}
+
 func hasCriticalErrors(components []ComponentStatus) bool {
 	for _, comp := range components {
 		// 核心组件状态异常或任意组件发生严重错误
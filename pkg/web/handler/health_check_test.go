@@ -0,0 +1,344 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"my-digital-home/pkg/common/config"
+)
+
+func TestHealthCheckComponentTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	h := &HealthCheckHandler{checks: []componentCheck{
+		{
+			name:    "fast",
+			timeout: time.Second,
+			check:   func(ctx context.Context) error { return nil },
+		},
+		{
+			// 模拟挂起且不响应ctx取消的依赖
+			name:    "hanging",
+			isCore:  true,
+			timeout: 50 * time.Millisecond,
+			check: func(ctx context.Context) error {
+				<-block
+				return nil
+			},
+		},
+	}}
+
+	start := time.Now()
+	components := h.checkComponents(context.Background(), false)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected check to return near the component timeout, took %v", elapsed)
+	}
+
+	if components[0].Status != "ok" {
+		t.Fatalf("Expected fast component ok, got %+v", components[0])
+	}
+	if components[1].Status != "timeout" || components[1].Latency < 50*time.Millisecond {
+		t.Fatalf("Expected hanging component to time out with latency recorded, got %+v", components[1])
+	}
+	if !hasCriticalErrors(components) {
+		t.Fatal("Expected core component timeout to degrade overall status")
+	}
+}
+
+func TestHealthCheckDegradedOnCoreTimeout(t *testing.T) {
+	handler := &HealthCheckHandler{checks: []componentCheck{{
+		name:    "database",
+		isCore:  true,
+		timeout: 10 * time.Millisecond,
+		check: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}}}
+
+	h := server.New()
+	h.GET("/health", handler.AdvancedHealthCheck)
+
+	w := ut.PerformRequest(h.Engine, "GET", "/health", nil)
+	if w.Result().StatusCode() != 503 {
+		t.Fatalf("Expected 503, got %d: %s", w.Result().StatusCode(), w.Result().Body())
+	}
+}
+
+func TestShuttingDownFailsReadinessButNotLiveness(t *testing.T) {
+	handler := &HealthCheckHandler{}
+	handler.MarkShuttingDown()
+
+	h := server.New()
+	h.GET("/livez", handler.Livez)
+	h.GET("/readyz", handler.Readyz)
+
+	if w := ut.PerformRequest(h.Engine, "GET", "/livez", nil); w.Result().StatusCode() != 200 {
+		t.Fatalf("Expected livez 200 while draining, got %d", w.Result().StatusCode())
+	}
+	if w := ut.PerformRequest(h.Engine, "GET", "/readyz", nil); w.Result().StatusCode() != 503 {
+		t.Fatalf("Expected readyz 503 while draining, got %d", w.Result().StatusCode())
+	}
+}
+
+func TestHealthCheckIncludesComponentDetails(t *testing.T) {
+	handler := &HealthCheckHandler{checks: []componentCheck{{
+		name:    "database",
+		isCore:  true,
+		timeout: time.Second,
+		check:   func(ctx context.Context) error { return nil },
+		details: func() interface{} {
+			return DBPoolStats{MaxOpen: 50, Open: 49, InUse: 48, Idle: 1}
+		},
+	}}}
+
+	h := server.New()
+	h.GET("/health", handler.AdvancedHealthCheck)
+
+	w := ut.PerformRequest(h.Engine, "GET", "/health", nil)
+	if w.Result().StatusCode() != 200 {
+		t.Fatalf("Expected 200, got %d: %s", w.Result().StatusCode(), w.Result().Body())
+	}
+	if body := string(w.Result().Body()); !strings.Contains(body, `"in_use":48`) || !strings.Contains(body, `"max_open":50`) {
+		t.Fatalf("Expected pool stats in database component, got %s", body)
+	}
+}
+
+func TestStreamHealthEmitsEventsUntilClientDisconnects(t *testing.T) {
+	handler := &HealthCheckHandler{
+		StreamInterval: 5 * time.Millisecond,
+		checks: []componentCheck{{
+			name:    "database",
+			isCore:  true,
+			timeout: time.Second,
+			check:   func(ctx context.Context) error { return nil },
+		}},
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	stopped := make(chan struct{})
+	h := server.New(server.WithHostPorts(addr))
+	h.GET("/events/health", func(c context.Context, ctx *app.RequestContext) {
+		handler.StreamHealth(c, ctx)
+		close(stopped)
+	})
+	go h.Run()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = h.Shutdown(ctx)
+	}()
+
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = http.Get("http://" + addr + "/events/health"); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("Expected event stream content type, got %q", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	for events := 0; events < 3; {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(line, "data: ") {
+			if !strings.Contains(line, `"status":"healthy"`) {
+				t.Fatalf("Expected health report in event, got %q", line)
+			}
+			events++
+		}
+	}
+
+	// 客户端断开后推送协程应退出
+	resp.Body.Close()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected stream handler to return after client disconnected")
+	}
+}
+
+// fakeRedis 应答AUTH与PING的最小RESP服务端，password为空时不要求认证
+func fakeRedis(t *testing.T, password string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				authed := password == ""
+				for {
+					// 读取一条数组命令：*N后跟N组$len与参数
+					header, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					var args []string
+					for n := int(header[1] - '0'); n > 0; n-- {
+						_, _ = r.ReadString('\n')
+						arg, _ := r.ReadString('\n')
+						args = append(args, strings.TrimRight(arg, "\r\n"))
+					}
+					switch {
+					case args[0] == "AUTH" && args[1] == password:
+						authed = true
+						conn.Write([]byte("+OK\r\n"))
+					case args[0] == "AUTH":
+						conn.Write([]byte("-WRONGPASS invalid password\r\n"))
+					case !authed:
+						conn.Write([]byte("-NOAUTH Authentication required.\r\n"))
+					default:
+						conn.Write([]byte("+PONG\r\n"))
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestRedisCheck(t *testing.T) {
+	addr := fakeRedis(t, "secret")
+
+	h := &HealthCheckHandler{}
+	h.AddRedisCheck(config.RedisConfig{Addr: addr, Password: "secret"}, true)
+	h.AddRedisCheck(config.RedisConfig{Addr: addr, Password: "wrong"}, false)
+	components := h.checkComponents(context.Background(), false)
+
+	if c := components[0]; c.Name != "redis" || c.Status != "ok" || !c.IsCore || c.Latency <= 0 {
+		t.Fatalf("Expected redis ok with latency recorded, got %+v", c)
+	}
+	// 非核心的Redis异常只报告warning，不使就绪探针失败
+	if c := components[1]; c.Status != "warning" || c.IsCore || !strings.Contains(c.Error, "WRONGPASS") {
+		t.Fatalf("Expected warning status on auth failure, got %+v", c)
+	}
+	if hasCriticalErrors(components) {
+		t.Fatal("Expected optional redis failure not to degrade overall status")
+	}
+
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	closedAddr := ln.Addr().String()
+	ln.Close()
+	h = &HealthCheckHandler{}
+	h.AddRedisCheck(config.RedisConfig{Addr: closedAddr}, false)
+	h.AddRedisCheck(config.RedisConfig{Addr: closedAddr}, true)
+	components = h.checkComponents(context.Background(), false)
+	if c := components[0]; c.Status != "warning" {
+		t.Fatalf("Expected warning status when optional redis is unreachable, got %+v", c)
+	}
+	if c := components[1]; c.Status != "critical" || !hasCriticalErrors(components) {
+		t.Fatalf("Expected core redis failure to degrade overall status, got %+v", c)
+	}
+}
+
+func TestRegisteredComponentChecks(t *testing.T) {
+	h := NewHealthCheckHandler(nil,
+		ComponentCheck{
+			Name:  "storage",
+			Check: func(ctx context.Context) ComponentStatus { return ComponentStatus{Details: "bucket=media"} },
+		},
+		ComponentCheck{
+			Name: "smtp",
+			Check: func(ctx context.Context) ComponentStatus {
+				return ComponentStatus{Name: "ignored", Status: "warning", Error: "connection refused"}
+			},
+		},
+		ComponentCheck{
+			Name:    "search",
+			Core:    true,
+			Timeout: 20 * time.Millisecond,
+			Check: func(ctx context.Context) ComponentStatus {
+				<-ctx.Done()
+				return ComponentStatus{}
+			},
+		},
+	)
+
+	report := h.healthReport(context.Background())
+	c := report.Components
+	if len(c) != 3 {
+		t.Fatalf("Expected 3 components, got %+v", c)
+	}
+	if c[0].Name != "storage" || c[0].Status != "ok" || c[0].Details != "bucket=media" || c[0].Latency <= 0 {
+		t.Fatalf("Expected storage ok with details and latency, got %+v", c[0])
+	}
+	if c[1].Name != "smtp" || c[1].Status != "warning" || c[1].IsCore {
+		t.Fatalf("Expected registered name and non-core warning, got %+v", c[1])
+	}
+	if c[2].Status != "timeout" || !c[2].IsCore {
+		t.Fatalf("Expected core component to time out, got %+v", c[2])
+	}
+	if report.Status != "degraded" {
+		t.Fatalf("Expected degraded status, got %s", report.Status)
+	}
+
+	// 非核心组件的warning不影响整体状态
+	if hasCriticalErrors(c[:2]) {
+		t.Fatal("Expected non-core warning not to degrade overall status")
+	}
+}
+
+func TestReadyzRunsOnlyCoreChecks(t *testing.T) {
+	var smtpCalls atomic.Int32
+	handler := NewHealthCheckHandler(nil,
+		ComponentCheck{
+			Name: "smtp",
+			Check: func(ctx context.Context) ComponentStatus {
+				smtpCalls.Add(1)
+				return ComponentStatus{Status: "warning", Error: "connection refused"}
+			},
+		},
+	)
+	handler.RequireReady("database_init", func() bool { return true })
+	h := server.New()
+	h.GET("/readyz", handler.Readyz)
+	h.GET("/health", handler.AdvancedHealthCheck)
+
+	for i := 0; i < 3; i++ {
+		if w := ut.PerformRequest(h.Engine, "GET", "/readyz", nil); w.Result().StatusCode() != 200 {
+			t.Fatalf("Expected readyz 200, got %d", w.Result().StatusCode())
+		}
+	}
+	if n := smtpCalls.Load(); n != 0 {
+		t.Fatalf("Expected readiness probes not to run non-core checks, ran %d times", n)
+	}
+
+	// 详细健康检查仍包含全部检查项
+	ut.PerformRequest(h.Engine, "GET", "/health", nil)
+	if n := smtpCalls.Load(); n != 1 {
+		t.Fatalf("Expected /health to run the smtp check once, ran %d times", n)
+	}
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	errors2 "my-digital-home/pkg/common/errors"
+	"my-digital-home/pkg/web/ws"
+)
+
+// 推送给客户端的事件类型
+const (
+	EventPasswordChanged = "password_changed"
+	EventSessionRevoked  = "session_revoked"
+)
+
+// NotificationHandler 实时通知的WebSocket入口
+type NotificationHandler struct {
+	Hub *ws.Hub
+}
+
+func NewNotificationHandler(hub *ws.Hub) *NotificationHandler {
+	return &NotificationHandler{Hub: hub}
+}
+
+// Connect 完成WebSocket握手并将连接登记到当前用户名下（需挂载在JWT认证之后）
+// 令牌只在握手时校验，之后撤销会话不会断开已建立的连接，客户端应处理session_revoked事件
+func (h *NotificationHandler) Connect(ctx context.Context, c *app.RequestContext) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	err := ws.Upgrade(c, []string{ws.BearerSubprotocol}, func(conn *ws.Conn) {
+		h.Hub.Serve(userID, conn)
+	})
+	if err != nil {
+		respondErr(c, 400, errors2.CodeBadRequest, "需要WebSocket握手请求")
+	}
+}
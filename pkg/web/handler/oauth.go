@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	errors2 "my-digital-home/pkg/common/errors"
+	"my-digital-home/pkg/common/metrics"
+	dao2 "my-digital-home/pkg/core/user/repository/dao/impl"
+	"my-digital-home/pkg/core/user/service"
+)
+
+// oauthStateCookiePrefix state Cookie名前缀，后接提供方名称，不同提供方的登录流程互不覆盖
+const oauthStateCookiePrefix = "oauth_state_"
+
+// OAuthLogin 生成state写入Cookie后跳转到提供方授权页
+func (h *UserHandler) OAuthLogin(ctx context.Context, c *app.RequestContext) {
+	name := c.Param("provider")
+	provider, ok := h.OAuthProviders[name]
+	if !ok {
+		respondErr(c, 404, errors2.CodeNotFound, "不支持的登录方式")
+		return
+	}
+
+	state, _, err := newResetToken()
+	if err != nil {
+		respondErr(c, 500, errors2.CodeInternal, "系统错误")
+		return
+	}
+	authURL, err := provider.AuthCodeURL(ctx, state)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "oauth provider unavailable: provider=%s err=%v", name, err)
+		respondErr(c, 503, errors2.CodeUnavailable, "登录服务暂不可用")
+		return
+	}
+	// 回调是从提供方发起的顶级跳转，SameSite=Lax下Cookie仍会携带
+	c.SetCookie(oauthStateCookiePrefix+name, state, int(h.OAuthCfg.StateTTL.Seconds()), "/", "",
+		protocol.CookieSameSiteLaxMode, h.JWTCfg.CookieSecure, true)
+	c.Redirect(302, []byte(authURL))
+}
+
+// OAuthCallback 校验state、以授权码换取身份并关联本地账户，成功时与密码登录返回相同的响应
+func (h *UserHandler) OAuthCallback(ctx context.Context, c *app.RequestContext) {
+	name := c.Param("provider")
+	provider, ok := h.OAuthProviders[name]
+	if !ok {
+		respondErr(c, 404, errors2.CodeNotFound, "不支持的登录方式")
+		return
+	}
+
+	// state只能使用一次，无论结果如何都清除
+	cookieName := oauthStateCookiePrefix + name
+	expected := c.Cookie(cookieName)
+	c.SetCookie(cookieName, "", -1, "/", "", protocol.CookieSameSiteLaxMode, h.JWTCfg.CookieSecure, true)
+
+	state := c.Query("state")
+	if len(expected) == 0 || subtle.ConstantTimeCompare(expected, []byte(state)) != 1 {
+		metrics.SetOutcome(c, metrics.OutcomeLoginFailure)
+		respondErr(c, 401, errors2.CodeOAuthFailed, "登录请求已失效，请重新登录")
+		return
+	}
+	if reason := c.Query("error"); reason != "" {
+		metrics.SetOutcome(c, metrics.OutcomeLoginFailure)
+		respondErr(c, 401, errors2.CodeOAuthFailed, "第三方登录已取消")
+		return
+	}
+
+	identity, err := provider.Identify(ctx, c.Query("code"))
+	if err != nil {
+		hlog.CtxWarnf(ctx, "oauth identify failed: provider=%s err=%v", name, err)
+		metrics.SetOutcome(c, metrics.OutcomeLoginFailure)
+		respondErr(c, 401, errors2.CodeOAuthFailed, "第三方登录失败")
+		return
+	}
+
+	user, _, err := h.OAuthAccounts.Resolve(ctx, identity)
+	switch {
+	case errors.Is(err, service.ErrUnverifiedIdentity):
+		metrics.SetOutcome(c, metrics.OutcomeLoginFailure)
+		respondErr(c, 403, errors2.CodeEmailUnverify, "第三方账户的邮箱未验证")
+		return
+	case errors.Is(err, service.ErrUnverifiedAccount):
+		metrics.SetOutcome(c, metrics.OutcomeLoginFailure)
+		respondErr(c, 409, errors2.CodeUnverifiedEmail, "该邮箱已注册但未验证，请先验证邮箱或通过找回密码登录")
+		return
+	case errors.Is(err, dao2.ErrDuplicateEntry):
+		// 邮箱属于停用或已注销的账户
+		metrics.SetOutcome(c, metrics.OutcomeLoginFailure)
+		respondErr(c, 409, errors2.CodeIdentityHeld, "邮箱对应的账户不可用，请联系管理员")
+		return
+	case err != nil:
+		respondMappedErr(c, err)
+		return
+	}
+
+	h.completeLogin(ctx, c, user, "")
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	errors2 "my-digital-home/pkg/common/errors"
+	"my-digital-home/pkg/common/oauth"
+	dao_model "my-digital-home/pkg/core/user/model"
+	dao2 "my-digital-home/pkg/core/user/repository/dao/impl"
+	"my-digital-home/pkg/web/model"
+)
+
+// fakeProvider 授权码good换出已核实邮箱alice@example.com的身份
+type fakeProvider struct{}
+
+func (fakeProvider) AuthCodeURL(_ context.Context, state string) (string, error) {
+	return "https://idp.example.com/auth?state=" + url.QueryEscape(state), nil
+}
+
+func (fakeProvider) Identify(_ context.Context, code string) (oauth.Identity, error) {
+	if code != "good" {
+		return oauth.Identity{}, errors.New("invalid_grant")
+	}
+	return oauth.Identity{Provider: "fake", Subject: "1", Email: "alice@example.com", EmailVerified: true}, nil
+}
+
+// fakeOAuthRepo 在fakeLoginRepo的基础上按邮箱查询已有用户
+type fakeOAuthRepo struct {
+	fakeLoginRepo
+	unverified bool // 已有账户的邮箱未验证
+}
+
+func (r *fakeOAuthRepo) QueryByEmail(ctx context.Context, email string) (dao_model.User, error) {
+	if email != "alice@example.com" {
+		return dao_model.User{}, dao2.ErrUserNotFound
+	}
+	user, _ := r.QueryByID(ctx, 7)
+	user.EmailVerified = !r.unverified
+	return user, nil
+}
+
+func TestOAuthLoginAndCallback(t *testing.T) {
+	h := newLoginHandler(t, fastHashConfig(), &fakeOAuthRepo{})
+	h.OAuthProviders = map[string]oauth.Provider{"fake": fakeProvider{}}
+	srv := server.New()
+	srv.GET("/auth/:provider/login", h.OAuthLogin)
+	srv.GET("/auth/:provider/callback", h.OAuthCallback)
+
+	w := ut.PerformRequest(srv.Engine, "GET", "/auth/fake/login", nil)
+	location, _ := url.Parse(string(w.Result().Header.Peek("Location")))
+	state := location.Query().Get("state")
+	cookie := string(w.Result().Header.Peek("Set-Cookie"))
+	if w.Result().StatusCode() != 302 || state == "" || !strings.Contains(cookie, "oauth_state_fake="+state) || !strings.Contains(cookie, "HttpOnly") {
+		t.Fatalf("Expected redirect with state cookie, got %d %q %q", w.Result().StatusCode(), location, cookie)
+	}
+
+	callback := func(query, cookieState string) (int, model.Response[model.LoginRes]) {
+		w := ut.PerformRequest(srv.Engine, "GET", "/auth/fake/callback?"+query, nil,
+			ut.Header{Key: "Cookie", Value: "oauth_state_fake=" + cookieState})
+		var resp model.Response[model.LoginRes]
+		_ = json.Unmarshal(w.Result().Body(), &resp)
+		return w.Result().StatusCode(), resp
+	}
+
+	if status, resp := callback("code=good&state=forged", state); status != 401 || resp.Code != errors2.CodeOAuthFailed {
+		t.Fatalf("Expected state mismatch to be rejected, got %d %+v", status, resp)
+	}
+	if status, resp := callback("error=access_denied&state="+state, state); status != 401 || resp.Code != errors2.CodeOAuthFailed {
+		t.Fatalf("Expected denied authorization to be rejected, got %d %+v", status, resp)
+	}
+	if status, resp := callback("code=bad&state="+state, state); status != 401 || resp.Code != errors2.CodeOAuthFailed {
+		t.Fatalf("Expected invalid code to be rejected, got %d %+v", status, resp)
+	}
+	status, resp := callback("code=good&state="+state, state)
+	if status != 200 || resp.Data.UserID != 7 || resp.Data.Token == "" {
+		t.Fatalf("Expected login as the linked account, got %d %+v", status, resp)
+	}
+
+	if w := ut.PerformRequest(srv.Engine, "GET", "/auth/github/login", nil); w.Result().StatusCode() != 404 {
+		t.Fatalf("Expected 404 for an unconfigured provider, got %d", w.Result().StatusCode())
+	}
+}
+
+func TestOAuthCallbackRejectsUnverifiedAccount(t *testing.T) {
+	h := newLoginHandler(t, fastHashConfig(), &fakeOAuthRepo{unverified: true})
+	h.OAuthProviders = map[string]oauth.Provider{"fake": fakeProvider{}}
+	srv := server.New()
+	srv.GET("/auth/:provider/callback", h.OAuthCallback)
+
+	w := ut.PerformRequest(srv.Engine, "GET", "/auth/fake/callback?code=good&state=s", nil,
+		ut.Header{Key: "Cookie", Value: "oauth_state_fake=s"})
+	var resp model.Response[model.LoginRes]
+	_ = json.Unmarshal(w.Result().Body(), &resp)
+	if w.Result().StatusCode() != 409 || resp.Code != errors2.CodeUnverifiedEmail || resp.Data.Token != "" {
+		t.Fatalf("Expected unverified account not to be linked, got %d %+v", w.Result().StatusCode(), resp)
+	}
+}
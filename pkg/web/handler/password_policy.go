@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// errBreachedPassword 密码出现在公开泄露的密码库中
+var errBreachedPassword = errors.New("该密码已出现在公开泄露的密码库中，请更换")
+
+// checkPasswordBreached 未启用泄露检查时直接通过；查询失败时放行并告警，不因外部服务故障阻断注册或改密
+func (h *UserHandler) checkPasswordBreached(ctx context.Context, password string) error {
+	if h.Breach == nil {
+		return nil
+	}
+	breached, count, err := h.Breach.Breached(ctx, password)
+	if err != nil {
+		hlog.CtxWarnf(ctx, "password breach check unavailable, allowing: %v", err)
+		return nil
+	}
+	if breached {
+		hlog.CtxInfof(ctx, "password rejected: found in %d breaches", count)
+		return errBreachedPassword
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"my-digital-home/pkg/common/config"
+	errors2 "my-digital-home/pkg/common/errors"
+	"my-digital-home/pkg/common/pwned"
+	"my-digital-home/pkg/web/model"
+)
+
+func TestRegisterRejectsBreachedPasswordAndFailsOpen(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// "Passw0rd!"的SHA-1为F4A69973E7B0BF9D160F9F60E3C3ACD2494BEB0D，其余前缀视为服务故障
+		if r.URL.Path != "/F4A69" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "973E7B0BF9D160F9F60E3C3ACD2494BEB0D:12\r\n")
+	}))
+	defer api.Close()
+
+	h := NewUserHandler(&config.Config{}, fakeRegisterRepo{}, &fakeSender{sent: make(chan sentMail, 1)}, nil)
+	srv := server.New()
+	srv.POST("/register", h.Register)
+	register := func(password string) *model.Response[any] {
+		body := `{"username":"taken","email":"taken@example.com","password":"` + password + `"}`
+		w := ut.PerformRequest(srv.Engine, "POST", "/register",
+			&ut.Body{Body: bytes.NewBufferString(body), Len: len(body)},
+			ut.Header{Key: "Content-Type", Value: "application/json"})
+		var resp model.Response[any]
+		if err := json.Unmarshal(w.Result().Body(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return &resp
+	}
+
+	// 用户名已占用，通过密码检查后返回409
+	h.Breach = pwned.New(config.BreachCheckConfig{APIURL: api.URL}, api.Client())
+	if resp := register("Passw0rd!"); resp.Code != errors2.CodeWeakPassword || !strings.Contains(resp.Message, "泄露") {
+		t.Fatalf("Expected breached password to be rejected, got %+v", resp)
+	}
+	if resp := register("Other#Passw0rd"); resp.Code != errors2.CodeUsernameTaken {
+		t.Fatalf("Expected unavailable breach API to fail open, got %+v", resp)
+	}
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	errors2 "my-digital-home/pkg/common/errors"
+	dao_model "my-digital-home/pkg/core/user/model"
+	dao2 "my-digital-home/pkg/core/user/repository/dao/impl"
+	"my-digital-home/pkg/core/user/service"
+	"my-digital-home/pkg/web/model"
+	"time"
+)
+
+// ForgotPassword 申请密码重置（始终返回200，避免账户枚举）
+func (h *UserHandler) ForgotPassword(ctx context.Context, c *app.RequestContext) {
+	var req model.ForgotPasswordReq
+	if err := c.BindAndValidate(&req); err != nil || req.Email == "" {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误")
+		return
+	}
+
+	if user, err := h.UserRepo.QueryByEmail(ctx, dao_model.NormalizeEmail(req.Email)); err == nil {
+		token, tokenHash, err := newResetToken()
+		if err != nil {
+			hlog.CtxErrorf(ctx, "generate reset token failed: %v", err)
+		} else if err := h.UserRepo.CreatePasswordResetToken(ctx, user.ID, tokenHash,
+			time.Now().Add(h.AccountCfg.ResetTokenTTL)); err != nil {
+			hlog.CtxErrorf(ctx, "store reset token failed: user=%d err=%v", user.ID, err)
+		} else {
+			h.sendMail(ctx, user.Email, "重置您的密码",
+				fmt.Sprintf("您好 %s：\n\n您的密码重置令牌为：\n%s\n\n令牌%s内有效且只能使用一次。如非本人操作请忽略本邮件。",
+					user.Username, token, h.AccountCfg.ResetTokenTTL))
+		}
+	} else if !errors.Is(err, dao2.ErrUserNotFound) {
+		hlog.CtxErrorf(ctx, "forgot password lookup failed: %v", err)
+	}
+
+	respondMsg(c, 200, "如果该邮箱已注册，重置邮件已发送")
+}
+
+// ResetPassword 使用重置令牌设置新密码
+func (h *UserHandler) ResetPassword(ctx context.Context, c *app.RequestContext) {
+	var req model.ResetPasswordReq
+	if err := bindAndValidate(c, &req); err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+
+	if err := service.ValidatePasswordStrength(h.AccountCfg.PasswordPolicy, req.NewPassword); err != nil {
+		respondErr(c, 400, errors2.CodeWeakPassword, err.Error())
+		return
+	}
+	if err := h.checkPasswordBreached(ctx, req.NewPassword); err != nil {
+		respondErr(c, 400, errors2.CodeWeakPassword, err.Error())
+		return
+	}
+
+	newHash, err := h.hashPassword(req.NewPassword)
+	if err != nil {
+		respondErr(c, 500, errors2.CodeInternal, "系统错误")
+		return
+	}
+
+	// 令牌与密码在同一事务中更新，成功后该用户其余重置令牌一并作废
+	userID, err := h.UserRepo.ResetPasswordWithToken(ctx, hashResetToken(req.Token), string(newHash))
+	if err != nil {
+		if errors.Is(err, dao2.ErrInvalidToken) || errors.Is(err, dao2.ErrUserNotFound) {
+			respondErr(c, 400, errors2.CodeInvalidLink, "重置链接无效或已过期")
+		} else {
+			respondErr(c, 500, errors2.CodeDatabase, "密码重置失败")
+		}
+		return
+	}
+
+	// 密码可能已泄露，撤销全部登录会话
+	h.revokeSessions(ctx, userID, 0)
+
+	hlog.CtxInfof(ctx, "password reset completed: user=%d", userID)
+	respondMsg(c, 200, "密码重置成功")
+}
+
+// newResetToken 生成随机令牌，返回明文（发给用户）与哈希（入库）
+func newResetToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashResetToken(token), nil
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
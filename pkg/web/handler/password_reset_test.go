@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"my-digital-home/pkg/common/config"
+	dao_model "my-digital-home/pkg/core/user/model"
+	"my-digital-home/pkg/core/user/repository/dao"
+	dao2 "my-digital-home/pkg/core/user/repository/dao/impl"
+)
+
+// sentMail 假发送器捕获的邮件
+type sentMail struct {
+	to, subject, body string
+}
+
+type fakeSender struct {
+	sent chan sentMail
+}
+
+func (f *fakeSender) Send(_ context.Context, to, subject, body string) error {
+	f.sent <- sentMail{to: to, subject: subject, body: body}
+	return nil
+}
+
+// fakeResetRepo 仅实现找回密码涉及的方法，其余方法调用会panic
+type fakeResetRepo struct {
+	dao.UserRepository
+	tokenHash string
+}
+
+func (r *fakeResetRepo) QueryByEmail(_ context.Context, email string) (dao_model.User, error) {
+	if email != "alice@example.com" {
+		return dao_model.User{}, dao2.ErrUserNotFound
+	}
+	return dao_model.User{ID: 7, Username: "alice", Email: email}, nil
+}
+
+func (r *fakeResetRepo) CreatePasswordResetToken(_ context.Context, _ int64, tokenHash string, _ time.Time) error {
+	r.tokenHash = tokenHash
+	return nil
+}
+
+func newForgotPasswordServer(repo dao.UserRepository, sender *fakeSender) *server.Hertz {
+	h := &UserHandler{
+		UserRepo:   repo,
+		AccountCfg: config.AccountConfig{ResetTokenTTL: 30 * time.Minute},
+		Mailer:     sender,
+	}
+	srv := server.New()
+	srv.POST("/forgot-password", h.ForgotPassword)
+	return srv
+}
+
+func TestForgotPasswordSendsResetMail(t *testing.T) {
+	repo := &fakeResetRepo{}
+	sender := &fakeSender{sent: make(chan sentMail, 1)}
+	srv := newForgotPasswordServer(repo, sender)
+
+	body := `{"email":"alice@example.com"}`
+	w := ut.PerformRequest(srv.Engine, "POST", "/forgot-password",
+		&ut.Body{Body: bytes.NewBufferString(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"})
+	if w.Result().StatusCode() != 200 {
+		t.Fatalf("Expected 200, got %d", w.Result().StatusCode())
+	}
+
+	select {
+	case msg := <-sender.sent:
+		if msg.to != "alice@example.com" {
+			t.Fatalf("Expected mail to alice@example.com, got %s", msg.to)
+		}
+		// 邮件中的明文令牌哈希后应与入库值一致
+		lines := strings.Split(msg.body, "\n")
+		if len(lines) < 4 || hashResetToken(strings.TrimSpace(lines[3])) != repo.tokenHash {
+			t.Fatalf("Mail body does not carry the stored token: %q", msg.body)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a reset mail to be sent")
+	}
+}
+
+func TestForgotPasswordUnknownEmailSendsNothing(t *testing.T) {
+	sender := &fakeSender{sent: make(chan sentMail, 1)}
+	srv := newForgotPasswordServer(&fakeResetRepo{}, sender)
+
+	body := `{"email":"nobody@example.com"}`
+	w := ut.PerformRequest(srv.Engine, "POST", "/forgot-password",
+		&ut.Body{Body: bytes.NewBufferString(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"})
+
+	// 未注册邮箱同样返回200，避免账户枚举
+	if w.Result().StatusCode() != 200 {
+		t.Fatalf("Expected 200, got %d", w.Result().StatusCode())
+	}
+
+	select {
+	case msg := <-sender.sent:
+		t.Fatalf("Expected no mail, got one to %s", msg.to)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	errors2 "my-digital-home/pkg/common/errors"
+	dao_model "my-digital-home/pkg/core/user/model"
+	"my-digital-home/pkg/core/user/repository/dao"
+	"my-digital-home/pkg/web/model"
+)
+
+// UpdateProfile 修改当前用户的用户名或邮箱
+// 新邮箱需重新验证；邮箱变更同时通知原邮箱，账户被盗用时用户能及时发现
+func (h *UserHandler) UpdateProfile(ctx context.Context, c *app.RequestContext) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req model.UpdateProfileReq
+	if err := bindAndValidate(c, &req); err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+	if req.Username == nil && req.Email == nil {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误: 需提供username或email")
+		return
+	}
+
+	// 以主库上的当前值为准，审计与通知中的旧值与乐观锁版本一致
+	user, err := h.UserRepo.QueryByID(dao.WithPrimary(ctx), userID)
+	if err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+	username, email := user.Username, user.Email
+	if req.Username != nil {
+		username = h.normalizeUsername(*req.Username)
+	}
+	if req.Email != nil {
+		email = dao_model.NormalizeEmail(*req.Email)
+	}
+	if username == user.Username && email == user.Email {
+		respondOK(c, toUserRes(user))
+		return
+	}
+
+	if err := h.UserRepo.UpdateIdentity(ctx, user, username, email); err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+
+	if username != user.Username {
+		h.Audit.Record(newChangeAuditEntry(c, dao_model.AuditUsernameChange, userID, user.Username, username))
+	}
+	updated, err := h.UserRepo.QueryByID(dao.WithPrimary(ctx), userID)
+	if err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+	if email != user.Email {
+		h.Audit.Record(newChangeAuditEntry(c, dao_model.AuditEmailChange, userID, user.Email, email))
+		h.notifyEmailChanged(ctx, user, email)
+		h.sendVerification(ctx, updated)
+	}
+	respondOK(c, toUserRes(updated))
+}
+
+// notifyEmailChanged 通知原邮箱其账户邮箱已被修改；未配置邮件服务时跳过
+func (h *UserHandler) notifyEmailChanged(ctx context.Context, previous dao_model.User, newEmail string) {
+	if !h.MailEnabled {
+		hlog.CtxInfof(ctx, "mail not configured, email change notice skipped: user=%d", previous.ID)
+		return
+	}
+	h.sendMail(ctx, previous.Email, "您的账户邮箱已修改",
+		fmt.Sprintf("您好 %s：\n\n您账户的邮箱已修改为 %s。\n\n如非本人操作，您的账户可能已被盗用，请立即联系管理员。",
+			previous.Username, maskEmail(newEmail)))
+}
+
+// newChangeAuditEntry 资料变更的审计记录，Detail中记录变更前后的值
+func newChangeAuditEntry(c *app.RequestContext, action string, userID int64, oldValue, newValue string) dao_model.AuditLog {
+	entry := newAuditEntry(c, action, userID, userID)
+	detail, _ := json.Marshal(map[string]string{"old": oldValue, "new": newValue})
+	entry.Detail = string(detail)
+	return entry
+}
+
+// maskEmail 只保留本地部分的首字符与域名，如a***@example.com
+func maskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return "***"
+	}
+	_, size := utf8.DecodeRuneInString(local)
+	return local[:size] + "***@" + domain
+}
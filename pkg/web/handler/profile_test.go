@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/golang-jwt/jwt/v5"
+	"my-digital-home/pkg/common/auth"
+	"my-digital-home/pkg/common/config"
+	dao_model "my-digital-home/pkg/core/user/model"
+	"my-digital-home/pkg/core/user/repository/dao"
+	dao2 "my-digital-home/pkg/core/user/repository/dao/impl"
+	"my-digital-home/pkg/core/user/service"
+)
+
+// fakeProfileRepo 内存中的单个用户，UpdateIdentity按版本号校验
+type fakeProfileRepo struct {
+	dao.UserRepository
+	user dao_model.User
+}
+
+func (r *fakeProfileRepo) QueryByID(_ context.Context, id int64) (dao_model.User, error) {
+	if id != r.user.ID {
+		return dao_model.User{}, dao2.ErrUserNotFound
+	}
+	return r.user, nil
+}
+
+func (r *fakeProfileRepo) UpdateIdentity(_ context.Context, current dao_model.User, username, email string) error {
+	if current.Version != r.user.Version {
+		return dao2.ErrVersionConflict
+	}
+	r.user.EmailVerified = r.user.EmailVerified && email == r.user.Email
+	r.user.Username, r.user.Email = username, email
+	r.user.Version++
+	return nil
+}
+
+// fakeAuditRepo 收集写入的审计记录
+type fakeAuditRepo struct {
+	entries chan dao_model.AuditLog
+}
+
+func (r *fakeAuditRepo) CreateAuditLog(_ context.Context, entry dao_model.AuditLog) error {
+	r.entries <- entry
+	return nil
+}
+
+func TestUpdateProfileEmailChangeNotifiesAndAudits(t *testing.T) {
+	repo := &fakeProfileRepo{user: dao_model.User{ID: 7, Username: "alice", Email: "alice@example.com", EmailVerified: true, Version: 3}}
+	sender := &fakeSender{sent: make(chan sentMail, 2)}
+	audits := &fakeAuditRepo{entries: make(chan dao_model.AuditLog, 2)}
+	cfg := config.Default()
+	cfg.Mail.Host = "smtp.example.com"
+	h := NewUserHandler(cfg, repo, sender, nil)
+	h.Audit = service.NewAuditRecorder(audits, 4)
+
+	srv := server.New()
+	srv.PATCH("/me", func(c context.Context, ctx *app.RequestContext) {
+		ctx.Set(auth.ClaimsKey, jwt.MapClaims{"user_id": float64(7)})
+		ctx.Next(c)
+	}, h.UpdateProfile)
+	body := `{"email":"Mallory@Example.com"}`
+	w := ut.PerformRequest(srv.Engine, "PATCH", "/me",
+		&ut.Body{Body: bytes.NewBufferString(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"})
+	if w.Result().StatusCode() != 200 {
+		t.Fatalf("Expected 200, got %d: %s", w.Result().StatusCode(), w.Result().Body())
+	}
+	if repo.user.Email != "mallory@example.com" || repo.user.EmailVerified {
+		t.Fatalf("Expected normalized unverified email, got %+v", repo.user)
+	}
+
+	// 原邮箱收到变更通知，新邮箱收到验证邮件（异步发送，顺序不定）
+	recipients := map[string]sentMail{}
+	for i := 0; i < 2; i++ {
+		select {
+		case m := <-sender.sent:
+			recipients[m.to] = m
+		case <-time.After(time.Second):
+			t.Fatalf("Expected two mails, got %v", recipients)
+		}
+	}
+	notice, ok := recipients["alice@example.com"]
+	if !ok || !strings.Contains(notice.body, "m***@example.com") || strings.Contains(notice.body, "mallory@") {
+		t.Fatalf("Expected masked change notice to the old address, got %+v", recipients)
+	}
+	if _, ok := recipients["mallory@example.com"]; !ok {
+		t.Fatalf("Expected verification mail to the new address, got %+v", recipients)
+	}
+
+	select {
+	case entry := <-audits.entries:
+		var detail map[string]string
+		if entry.Action != dao_model.AuditEmailChange || entry.TargetID != 7 ||
+			json.Unmarshal([]byte(entry.Detail), &detail) != nil ||
+			detail["old"] != "alice@example.com" || detail["new"] != "mallory@example.com" {
+			t.Fatalf("Unexpected audit entry %+v", entry)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected email change to be audited")
+	}
+}
+
+func TestUpdateProfileSkipsNoticeWithoutMail(t *testing.T) {
+	repo := &fakeProfileRepo{user: dao_model.User{ID: 7, Username: "alice", Email: "alice@example.com", Version: 1}}
+	sender := &fakeSender{sent: make(chan sentMail, 2)}
+	h := NewUserHandler(config.Default(), repo, sender, nil)
+
+	srv := server.New()
+	srv.PATCH("/me", func(c context.Context, ctx *app.RequestContext) {
+		ctx.Set(auth.ClaimsKey, jwt.MapClaims{"user_id": float64(7)})
+		ctx.Next(c)
+	}, h.UpdateProfile)
+	body := `{"email":"bob@example.com"}`
+	w := ut.PerformRequest(srv.Engine, "PATCH", "/me",
+		&ut.Body{Body: bytes.NewBufferString(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"})
+	if w.Result().StatusCode() != 200 {
+		t.Fatalf("Expected 200, got %d: %s", w.Result().StatusCode(), w.Result().Body())
+	}
+
+	// 只有发往新邮箱的验证邮件
+	select {
+	case m := <-sender.sent:
+		if m.to != "bob@example.com" {
+			t.Fatalf("Expected only the verification mail, got one to %s", m.to)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected verification mail")
+	}
+	select {
+	case m := <-sender.sent:
+		t.Fatalf("Expected no change notice without mail configured, got one to %s", m.to)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"my-digital-home/pkg/common/auth"
+	errors2 "my-digital-home/pkg/common/errors"
+	dao_model "my-digital-home/pkg/core/user/model"
+	dao2 "my-digital-home/pkg/core/user/repository/dao/impl"
+	"my-digital-home/pkg/web/model"
+	"strconv"
+	"time"
+)
+
+// maxSessionUserAgent 与user_sessions.user_agent列宽一致，超长部分截断
+const maxSessionUserAgent = 255
+
+// startSession 为本次登录创建会话，返回明文刷新令牌（仅下发一次，库中只存哈希）与会话ID
+func (h *UserHandler) startSession(ctx context.Context, c *app.RequestContext, userID int64, device string, now time.Time) (string, int64, error) {
+	refreshToken, tokenHash, err := newResetToken()
+	if err != nil {
+		return "", 0, err
+	}
+
+	userAgent := string(c.UserAgent())
+	if len(userAgent) > maxSessionUserAgent {
+		userAgent = userAgent[:maxSessionUserAgent]
+	}
+	sessionID, err := h.Sessions.CreateSession(ctx, dao_model.Session{
+		UserID:           userID,
+		RefreshTokenHash: tokenHash,
+		Device:           device,
+		UserAgent:        userAgent,
+		IP:               c.ClientIP(),
+		CreatedAt:        now,
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(h.JWTCfg.RefreshTTL),
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return refreshToken, sessionID, nil
+}
+
+// RefreshToken 以刷新令牌换取新的访问令牌，刷新令牌同时轮换，旧令牌立即失效
+// 会话有效期自登录起计算，不随刷新顺延；auth_time保持为登录时间，刷新不能满足敏感操作的新鲜度要求
+func (h *UserHandler) RefreshToken(ctx context.Context, c *app.RequestContext) {
+	if h.Sessions == nil {
+		respondErr(c, 404, errors2.CodeNotFound, "未启用登录会话")
+		return
+	}
+
+	var req model.RefreshReq
+	if err := bindAndValidate(c, &req); err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+
+	refreshToken, tokenHash, err := newResetToken()
+	if err != nil {
+		respondErr(c, 500, errors2.CodeInternal, "令牌生成失败")
+		return
+	}
+	session, err := h.Sessions.RotateRefreshToken(ctx, hashResetToken(req.RefreshToken), tokenHash, time.Now())
+	if errors.Is(err, dao2.ErrInvalidToken) {
+		respondErr(c, 401, errors2.CodeInvalidRefresh, "刷新令牌无效或已过期")
+		return
+	}
+	if err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+
+	// 重新读取用户以带上最新角色；账户已注销时一并撤销该会话
+	user, err := h.UserRepo.QueryByID(ctx, session.UserID)
+	if errors.Is(err, dao2.ErrUserNotFound) {
+		h.revokeSessions(ctx, session.UserID, 0)
+		respondErr(c, 401, errors2.CodeInvalidRefresh, "刷新令牌无效或已过期")
+		return
+	}
+	if err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+
+	signedToken, err := h.issueAccessToken(c, user, session.ID, session.CreatedAt)
+	if err != nil {
+		respondErr(c, 500, errors2.CodeInternal, "令牌生成失败")
+		return
+	}
+	respondOK(c, model.LoginRes{
+		Token:        signedToken,
+		RefreshToken: refreshToken,
+		SessionID:    session.ID,
+		UserID:       user.ID,
+		Username:     user.Username,
+		Roles:        user.RoleList(),
+	})
+}
+
+// ListSessions 列出当前用户的有效登录会话，标记发起请求的会话
+func (h *UserHandler) ListSessions(ctx context.Context, c *app.RequestContext) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	if h.Sessions == nil {
+		respondOK(c, []model.SessionRes{})
+		return
+	}
+
+	sessions, err := h.Sessions.ListActiveSessions(ctx, userID)
+	if err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+
+	current := currentSessionID(c)
+	items := make([]model.SessionRes, 0, len(sessions))
+	for _, s := range sessions {
+		items = append(items, model.SessionRes{
+			ID:         s.ID,
+			Device:     s.Device,
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    s.ID == current,
+		})
+	}
+	respondOK(c, items)
+}
+
+// RevokeSession 撤销当前用户的指定会话（可以是当前会话，即登出）
+func (h *UserHandler) RevokeSession(ctx context.Context, c *app.RequestContext) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	sessionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || sessionID <= 0 {
+		respondErr(c, 400, errors2.CodeBadRequest, "参数错误: 无效的会话ID")
+		return
+	}
+	if h.Sessions == nil {
+		respondMappedErr(c, dao2.ErrSessionNotFound)
+		return
+	}
+
+	if err := h.Sessions.RevokeSession(ctx, userID, sessionID); err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+
+	h.Audit.Record(newAuditEntry(c, dao_model.AuditSessionRevoke, userID, userID))
+	h.Notifier.Notify(userID, EventSessionRevoked, map[string]int64{"session_id": sessionID})
+	respondMsg(c, 200, "会话已撤销")
+}
+
+// RevokeOtherSessions 撤销当前会话以外的全部会话（“退出其他设备”）
+func (h *UserHandler) RevokeOtherSessions(ctx context.Context, c *app.RequestContext) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	if h.Sessions == nil {
+		respondOK(c, model.RevokeSessionsRes{})
+		return
+	}
+
+	revoked, err := h.Sessions.RevokeOtherSessions(ctx, userID, currentSessionID(c))
+	if err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+
+	if revoked > 0 {
+		h.Audit.Record(newAuditEntry(c, dao_model.AuditSessionRevoke, userID, userID))
+	}
+	respondOK(c, model.RevokeSessionsRes{Revoked: revoked})
+}
+
+// revokeSessions 撤销用户除keepID外的全部会话（keepID为0时全部撤销），失败只记录日志
+func (h *UserHandler) revokeSessions(ctx context.Context, userID, keepID int64) {
+	if h.Sessions == nil {
+		return
+	}
+	if _, err := h.Sessions.RevokeOtherSessions(ctx, userID, keepID); err != nil {
+		hlog.CtxWarnf(ctx, "revoke sessions failed: user=%d err=%v", userID, err)
+	}
+}
+
+// currentSessionID 当前访问令牌关联的会话，不含sid声明的旧令牌返回0
+func currentSessionID(c *app.RequestContext) int64 {
+	claims, _ := auth.ClaimsFromContext(c)
+	sid, _ := claims["sid"].(float64)
+	return int64(sid)
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"golang.org/x/crypto/bcrypt"
+	"my-digital-home/pkg/common/auth"
+	errors2 "my-digital-home/pkg/common/errors"
+	dao_model "my-digital-home/pkg/core/user/model"
+	"my-digital-home/pkg/core/user/repository/dao"
+	dao2 "my-digital-home/pkg/core/user/repository/dao/impl"
+	"my-digital-home/pkg/web/model"
+)
+
+// fakeSessionRepo 内存会话存储
+type fakeSessionRepo struct {
+	dao.SessionRepository
+	sessions []dao_model.Session
+}
+
+func (r *fakeSessionRepo) CreateSession(_ context.Context, s dao_model.Session) (int64, error) {
+	s.ID = int64(len(r.sessions) + 1)
+	r.sessions = append(r.sessions, s)
+	return s.ID, nil
+}
+
+func (r *fakeSessionRepo) ListActiveSessions(_ context.Context, userID int64) ([]dao_model.Session, error) {
+	var active []dao_model.Session
+	for _, s := range r.sessions {
+		if s.UserID == userID && s.RevokedAt == nil {
+			active = append(active, s)
+		}
+	}
+	return active, nil
+}
+
+func (r *fakeSessionRepo) RotateRefreshToken(_ context.Context, oldHash, newHash string, at time.Time) (dao_model.Session, error) {
+	for i, s := range r.sessions {
+		if s.RefreshTokenHash == oldHash && s.RevokedAt == nil {
+			r.sessions[i].RefreshTokenHash = newHash
+			r.sessions[i].LastUsedAt = at
+			return r.sessions[i], nil
+		}
+	}
+	return dao_model.Session{}, dao2.ErrInvalidToken
+}
+
+func (r *fakeSessionRepo) RevokeOtherSessions(_ context.Context, userID, keepID int64) (int64, error) {
+	var revoked int64
+	now := time.Now()
+	for i, s := range r.sessions {
+		if s.UserID == userID && s.ID != keepID && s.RevokedAt == nil {
+			r.sessions[i].RevokedAt = &now
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func newSessionHandler(t *testing.T) (*UserHandler, *fakeSessionRepo) {
+	t.Helper()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("Passw0rd!"), bcrypt.MinCost)
+	h := newLoginHandler(t, fastHashConfig(), &fakeLoginRepo{hash: string(hash)})
+	sessions := &fakeSessionRepo{}
+	h.Sessions = sessions
+	return h, sessions
+}
+
+func loginWithSession(t *testing.T, h *UserHandler, device string) model.LoginRes {
+	t.Helper()
+	srv := server.New()
+	srv.POST("/login", h.Login)
+
+	body := `{"username":"alice","password":"Passw0rd!","device":"` + device + `"}`
+	w := ut.PerformRequest(srv.Engine, "POST", "/login",
+		&ut.Body{Body: bytes.NewBufferString(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"})
+
+	var resp model.Response[model.LoginRes]
+	if err := json.Unmarshal(w.Result().Body(), &resp); err != nil || resp.Code != errors2.CodeOK {
+		t.Fatalf("Expected login to succeed, got %s", w.Result().Body())
+	}
+	return resp.Data
+}
+
+func TestRefreshRotatesTokenAndKeepsAuthTime(t *testing.T) {
+	h, sessions := newSessionHandler(t)
+	login := loginWithSession(t, h, "laptop")
+	if login.RefreshToken == "" || login.SessionID != 1 || sessions.sessions[0].Device != "laptop" {
+		t.Fatalf("Expected login to start a session, got %+v / %+v", login, sessions.sessions)
+	}
+
+	srv := server.New()
+	srv.POST("/refresh", h.RefreshToken)
+	refresh := func(token string) (int, model.Response[model.LoginRes]) {
+		body := `{"refresh_token":"` + token + `"}`
+		w := ut.PerformRequest(srv.Engine, "POST", "/refresh",
+			&ut.Body{Body: bytes.NewBufferString(body), Len: len(body)},
+			ut.Header{Key: "Content-Type", Value: "application/json"})
+		var resp model.Response[model.LoginRes]
+		_ = json.Unmarshal(w.Result().Body(), &resp)
+		return w.Result().StatusCode(), resp
+	}
+
+	status, resp := refresh(login.RefreshToken)
+	if status != 200 || resp.Data.RefreshToken == "" || resp.Data.RefreshToken == login.RefreshToken {
+		t.Fatalf("Expected a rotated refresh token, got %d: %+v", status, resp)
+	}
+	claims, err := h.JWT.Parse(resp.Data.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims["sid"] != float64(1) || int64(claims["auth_time"].(float64)) != sessions.sessions[0].CreatedAt.Unix() {
+		t.Fatalf("Expected sid and original auth_time in refreshed token, got %v", claims)
+	}
+
+	// 旧刷新令牌已轮换，不能再次使用
+	if status, resp := refresh(login.RefreshToken); status != 401 || resp.Code != errors2.CodeInvalidRefresh {
+		t.Fatalf("Expected reused refresh token to be rejected, got %d: %+v", status, resp)
+	}
+}
+
+func TestSessionListingAndRevokeOthersKeepCurrent(t *testing.T) {
+	h, sessions := newSessionHandler(t)
+	loginWithSession(t, h, "laptop")
+	current := loginWithSession(t, h, "phone")
+
+	claims, err := h.JWT.Parse(current.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withClaims := func(c context.Context, ctx *app.RequestContext) {
+		ctx.Set(auth.ClaimsKey, claims)
+		ctx.Next(c)
+	}
+	srv := server.New()
+	srv.GET("/me/sessions", withClaims, h.ListSessions)
+	srv.POST("/me/sessions/revoke-others", withClaims, h.RevokeOtherSessions)
+
+	w := ut.PerformRequest(srv.Engine, "GET", "/me/sessions", nil)
+	var listed model.Response[[]model.SessionRes]
+	if err := json.Unmarshal(w.Result().Body(), &listed); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed.Data) != 2 || listed.Data[0].Current || !listed.Data[1].Current {
+		t.Fatalf("Expected two sessions with the phone marked current, got %s", w.Result().Body())
+	}
+
+	w = ut.PerformRequest(srv.Engine, "POST", "/me/sessions/revoke-others", nil)
+	var revoked model.Response[model.RevokeSessionsRes]
+	if err := json.Unmarshal(w.Result().Body(), &revoked); err != nil || revoked.Data.Revoked != 1 {
+		t.Fatalf("Expected one session revoked, got %s", w.Result().Body())
+	}
+	if sessions.sessions[0].RevokedAt == nil || sessions.sessions[1].RevokedAt != nil {
+		t.Fatalf("Expected only the laptop session to be revoked, got %+v", sessions.sessions)
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"my-digital-home/pkg/common/config"
+	errors2 "my-digital-home/pkg/common/errors"
+)
+
+// spaIndex 单页应用入口，未匹配到文件的前端路由均返回该页面
+const spaIndex = "index.html"
+
+// NewStaticHandler 从cfg.Dir提供前端静态文件，挂在NoRoute上，已注册的接口路由始终优先
+// URLPrefix下找不到文件的路径回退到index.html，由前端路由处理；/api/下的未知路径仍返回404
+func NewStaticHandler(cfg config.StaticConfig) app.HandlerFunc {
+	prefix := "/" + strings.Trim(cfg.URLPrefix, "/")
+	index := filepath.Join(cfg.Dir, spaIndex)
+
+	serveIndex := func(c context.Context, ctx *app.RequestContext) {
+		// 入口页不缓存，前端发版后客户端才能拿到新的资源清单
+		ctx.Response.Header.Set("Cache-Control", "no-cache")
+		ctx.File(index)
+	}
+
+	fs := &app.FS{
+		Root:         cfg.Dir,
+		IndexNames:   []string{spaIndex},
+		PathNotFound: serveIndex,
+	}
+	if prefix != "/" {
+		fs.PathRewrite = func(ctx *app.RequestContext) []byte {
+			if p := bytes.TrimPrefix(ctx.Path(), []byte(prefix)); len(p) > 0 {
+				return p
+			}
+			return []byte("/")
+		}
+	}
+	serveFile := fs.NewRequestHandler()
+
+	return func(c context.Context, ctx *app.RequestContext) {
+		reqPath := string(ctx.Path())
+		if !(ctx.IsGet() || ctx.IsHead()) || strings.HasPrefix(reqPath, "/api/") || !hasPathPrefix(reqPath, prefix) {
+			respondErr(ctx, 404, errors2.CodeNotFound, "资源不存在")
+			return
+		}
+		// 前端路由不对应文件，直接返回入口页，避免文件处理器为每次回退记录错误日志
+		rel := strings.TrimPrefix(reqPath, strings.TrimSuffix(prefix, "/"))
+		if _, err := os.Stat(filepath.Join(cfg.Dir, filepath.FromSlash(path.Clean("/"+rel)))); err != nil {
+			serveIndex(c, ctx)
+			return
+		}
+		serveFile(c, ctx)
+	}
+}
+
+// hasPathPrefix 按路径段匹配前缀，/app不匹配/apple
+func hasPathPrefix(path, prefix string) bool {
+	if prefix == "/" {
+		return true
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"my-digital-home/pkg/common/config"
+)
+
+func newStaticDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "assets"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"index.html":    "<html>spa</html>",
+		"assets/app.js": "console.log('app')",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestStaticHandlerServesFilesWithSPAFallback(t *testing.T) {
+	srv := server.New()
+	srv.GET("/health", func(c context.Context, ctx *app.RequestContext) {
+		ctx.String(200, "ok")
+	})
+	srv.NoRoute(NewStaticHandler(config.StaticConfig{Dir: newStaticDir(t), URLPrefix: "/"}))
+
+	cases := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/", 200, "<html>spa</html>"},
+		{"/assets/app.js", 200, "console.log('app')"},
+		{"/settings/profile", 200, "<html>spa</html>"},
+		{"/health", 200, "ok"},
+		{"/api/v1/unknown", 404, `"code":404000`},
+	}
+	for _, tc := range cases {
+		w := ut.PerformRequest(srv.Engine, "GET", tc.path, nil)
+		if got := w.Result(); got.StatusCode() != tc.status || !strings.Contains(string(got.Body()), tc.body) {
+			t.Errorf("GET %s: expected %d containing %q, got %d %s", tc.path, tc.status, tc.body, got.StatusCode(), got.Body())
+		}
+	}
+}
+
+func TestStaticHandlerHonoursURLPrefix(t *testing.T) {
+	srv := server.New()
+	srv.NoRoute(NewStaticHandler(config.StaticConfig{Dir: newStaticDir(t), URLPrefix: "/app/"}))
+
+	cases := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/app", 200, "<html>spa</html>"},
+		{"/app/assets/app.js", 200, "console.log('app')"},
+		{"/app/devices/1", 200, "<html>spa</html>"},
+		{"/apple", 404, `"code":404000`},
+		{"/", 404, `"code":404000`},
+	}
+	for _, tc := range cases {
+		w := ut.PerformRequest(srv.Engine, "GET", tc.path, nil)
+		if got := w.Result(); got.StatusCode() != tc.status || !strings.Contains(string(got.Body()), tc.body) {
+			t.Errorf("GET %s: expected %d containing %q, got %d %s", tc.path, tc.status, tc.body, got.StatusCode(), got.Body())
+		}
+	}
+}
@@ -5,79 +5,123 @@ import (
 	"context"
 	"errors"
 	"github.com/cloudwego/hertz/pkg/app"
-	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+	"my-digital-home/pkg/common/auth"
 	"my-digital-home/pkg/common/config"
 	errors2 "my-digital-home/pkg/common/errors"
+	"my-digital-home/pkg/common/mail"
+	"my-digital-home/pkg/common/metrics"
+	"my-digital-home/pkg/common/oauth"
+	"my-digital-home/pkg/common/pwned"
+	"my-digital-home/pkg/common/storage"
 	dao_model "my-digital-home/pkg/core/user/model"
 	"my-digital-home/pkg/core/user/repository/dao"
-	dao2 "my-digital-home/pkg/core/user/repository/dao/impl"
+	"my-digital-home/pkg/core/user/service"
 	"my-digital-home/pkg/web/model"
+	"my-digital-home/pkg/web/validation"
+	"my-digital-home/pkg/web/ws"
 	"time"
-	"unicode"
 )
 
 type UserHandler struct {
-	UserRepo  dao.UserRepository // 使用具体接口
-	JWTSecret string
+	UserRepo    dao.UserRepository // 使用具体接口
+	JWTSecret   string
+	JWT         *auth.JWTService     // 访问令牌签发，与JWT中间件共用配置
+	JWTCfg      config.JWTAuthConfig // Cookie下发相关配置
+	BcryptCost  int                  // 为0时使用bcrypt.DefaultCost
+	AccountCfg  config.AccountConfig
+	Mailer      mail.EmailSender
+	MailEnabled bool                   // 配置了SMTP；未配置时不发送安全通知类邮件
+	MailBaseURL string                 // 邮件内链接前缀
+	Auth        *service.Authenticator // 登录凭证校验
+	Audit       *service.AuditRecorder // 为nil时不记录审计日志
+	Sessions    dao.SessionRepository  // 为nil时登录不创建会话、不下发刷新令牌
+	Notifier    *ws.Hub                // 为nil时不推送实时通知
+	Storage     storage.Storage        // 为nil时不支持上传头像
+	UploadCfg   config.UploadConfig
+	Breach      *pwned.Checker // 为nil时不检查泄露密码
+	// 第三方登录提供方，键为路径中的名称（如google）；为空时不支持第三方登录
+	OAuthProviders map[string]oauth.Provider
+	OAuthAccounts  *service.OAuthAccounts
+	OAuthCfg       config.OAuthConfig
 }
 
-var (
-	DefaultUserHandler *UserHandler
-)
-
-func NewUserHandler(cfg *config.Config) UserHandler {
-	if DefaultUserHandler == nil {
-		DefaultUserHandler = &UserHandler{
-			UserRepo:  dao2.DefaultUserRepo, /* 注入实际的仓储实现 */
-			JWTSecret: cfg.Middleware.JWT.Secret,
-		}
+// NewUserHandler 依赖由调用方注入，便于测试时替换为假仓储
+func NewUserHandler(cfg *config.Config, userRepo dao.UserRepository, mailer mail.EmailSender, jwtService *auth.JWTService) *UserHandler {
+	return &UserHandler{
+		UserRepo:    userRepo,
+		JWTSecret:   cfg.Middleware.JWT.Secret,
+		JWT:         jwtService,
+		JWTCfg:      cfg.Middleware.JWT,
+		BcryptCost:  cfg.Middleware.Security.BcryptCost,
+		AccountCfg:  cfg.Account,
+		Mailer:      mailer,
+		MailEnabled: cfg.Mail.Host != "",
+		MailBaseURL: cfg.Mail.BaseURL,
+		Auth:        service.NewAuthenticator(userRepo, cfg),
+		UploadCfg:   cfg.Upload,
+
+		OAuthAccounts: service.NewOAuthAccounts(userRepo, cfg),
+		OAuthCfg:      cfg.OAuth,
 	}
-
-	return *DefaultUserHandler
 }
 
 // 注册接口优化
 func (h *UserHandler) Register(ctx context.Context, c *app.RequestContext) {
 	var req model.RegisterReq
-	if err := c.BindAndValidate(&req); err != nil {
-		respondError(c, 400, "参数校验失败: "+err.Error())
+	if err := bindAndValidate(c, &req); err != nil {
+		respondMappedErr(c, err)
 		return
 	}
 
 	// 密码合规性检查（复用公共方法）
-	if err := validatePasswordStrength(req.Password); err != nil {
-		respondError(c, 400, err.Error())
+	if err := service.ValidatePasswordStrength(h.AccountCfg.PasswordPolicy, req.Password); err != nil {
+		respondErr(c, 400, errors2.CodeWeakPassword, err.Error())
 		return
 	}
+	if err := h.checkPasswordBreached(ctx, req.Password); err != nil {
+		respondErr(c, 400, errors2.CodeWeakPassword, err.Error())
+		return
+	}
+
+	// 规范化后再校验唯一性，避免仅大小写或空白不同的重复账户
+	req.Username = h.normalizeUsername(req.Username)
+	req.Email = dao_model.NormalizeEmail(req.Email)
 
 	// 检查用户名唯一性（活跃用户）
-	exists, err := h.UserRepo.IsUsernameExists(req.Username)
+	exists, err := h.UserRepo.IsUsernameExists(ctx, req.Username)
 	if err != nil {
-		respondError(c, 500, errors2.WrapGormError(err).Error())
+		respondMappedErr(c, errors2.WrapGormError(err))
 		return
 	}
 	if exists {
-		respondError(c, 409, "用户名已存在")
+		respondErr(c, 409, errors2.CodeUsernameTaken, "用户名已存在")
 		return
 	}
 
 	// 检查邮箱唯一性（活跃用户）
-	exists, err = h.UserRepo.IsEmailExists(req.Email)
+	exists, err = h.UserRepo.IsEmailExists(ctx, req.Email)
 	if err != nil {
-		respondError(c, 500, errors2.WrapGormError(err).Error())
+		respondMappedErr(c, errors2.WrapGormError(err))
 		return
 	}
 	if exists {
-		respondError(c, 409, "邮箱已被注册")
+		respondErr(c, 409, errors2.CodeEmailTaken, "邮箱已被注册")
+		return
+	}
+
+	// 已注销账户仍占用唯一索引时，按策略拒绝或释放，保证校验结果与数据库约束一致
+	release, ok := h.resolveDeletedIdentity(ctx, c, req.Username, req.Email)
+	if !ok {
 		return
 	}
 
 	// 密码加密
-	hashedPwd, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPwd, err := h.hashPassword(req.Password)
 	if err != nil {
-		respondError(c, 500, "密码加密失败")
+		respondErr(c, 500, errors2.CodeInternal, "密码加密失败")
 		return
 	}
 
@@ -87,153 +131,359 @@ func (h *UserHandler) Register(ctx context.Context, c *app.RequestContext) {
 		Email:        req.Email,
 		PasswordHash: string(hashedPwd),
 		IsActive:     true,
+		Roles:        dao_model.RoleUser,
 		Version:      1,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
 
-	// 调用DAO层方法时传递完整实体
-	if err := h.UserRepo.CreateUser(user); err != nil {
-		if errors.Is(err, errors2.ErrDuplicateEntry) {
-			respondError(c, 409, "用户已存在")
-		} else {
-			respondError(c, 500, "注册失败")
-		}
+	// 调用DAO层方法时传递完整实体；需要释放时与创建在同一事务中，注册失败不会留下被改写的注销账户
+	if release {
+		err = h.UserRepo.WithTx(ctx, func(tx dao.UserRepository) error {
+			if err := tx.ReleaseDeletedIdentity(ctx, req.Username, req.Email); err != nil {
+				return err
+			}
+			return tx.CreateUser(ctx, user)
+		})
+	} else {
+		err = h.UserRepo.CreateUser(ctx, user)
+	}
+	if err != nil {
+		metrics.SetOutcome(c, metrics.OutcomeRegisterFailure)
+		respondMappedErr(c, err)
 		return
 	}
 
-	c.JSON(201, utils.H{"message": "注册成功"})
+	// 发送邮箱验证邮件
+	if created, err := h.UserRepo.QueryByUsername(ctx, req.Username); err == nil {
+		h.sendVerification(ctx, created)
+	} else {
+		hlog.CtxWarnf(ctx, "load registered user failed, verification not sent: %v", err)
+	}
+
+	metrics.SetOutcome(c, metrics.OutcomeRegisterSuccess)
+	respondMsg(c, 201, "注册成功")
+}
+
+// resolveDeletedIdentity 处理已注销账户占用的用户名/邮箱；release为true时需在创建用户前释放占用
+// ok为false时已写入响应
+func (h *UserHandler) resolveDeletedIdentity(ctx context.Context, c *app.RequestContext, username, email string) (release, ok bool) {
+	usernameHeld, err := h.UserRepo.IsUsernameHeldByDeleted(ctx, username)
+	if err != nil {
+		respondMappedErr(c, errors2.WrapGormError(err))
+		return false, false
+	}
+	emailHeld, err := h.UserRepo.IsEmailHeldByDeleted(ctx, email)
+	if err != nil {
+		respondMappedErr(c, errors2.WrapGormError(err))
+		return false, false
+	}
+	if !usernameHeld && !emailHeld {
+		return false, true
+	}
+
+	if h.AccountCfg.DeletedIdentityPolicy == config.DeletedIdentityRelease {
+		return true, true
+	}
+
+	if usernameHeld {
+		respondErr(c, 409, errors2.CodeIdentityHeld, "用户名属于已注销账户，暂不可使用")
+	} else {
+		respondErr(c, 409, errors2.CodeIdentityHeld, "邮箱属于已注销账户，请联系管理员恢复")
+	}
+	return false, false
 }
 
 func (h *UserHandler) Login(ctx context.Context, c *app.RequestContext) {
 	var req model.LoginReq
-	if err := c.BindAndValidate(&req); err != nil {
-		c.JSON(400, utils.H{"error": "参数错误"})
+	if err := bindAndValidate(c, &req); err != nil {
+		respondMappedErr(c, err)
 		return
 	}
 
-	// 获取存储的密码哈希
-	storedHash, userID, err := h.UserRepo.GetPasswordHash(req.Username)
-	if err != nil {
-		c.JSON(401, utils.H{"error": "用户不存在"})
+	// 登录标识可以是用户名或邮箱；用户不存在与密码错误返回相同提示，避免账户枚举
+	user, err := h.Auth.Authenticate(ctx, req.Username, req.Password)
+	switch {
+	case errors.Is(err, service.ErrBadCredential):
+		metrics.SetOutcome(c, metrics.OutcomeLoginFailure)
+		respondErr(c, 401, errors2.CodeBadCredential, "用户名或密码错误")
+		return
+	case err != nil:
+		respondMappedErr(c, err)
 		return
 	}
 
-	// 校验密码
-	if err := bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(req.Password)); err != nil {
-		c.JSON(401, utils.H{"error": "密码错误"})
+	if h.AccountCfg.RequireEmailVerification && !user.EmailVerified {
+		metrics.SetOutcome(c, metrics.OutcomeLoginFailure)
+		respondErr(c, 403, errors2.CodeEmailUnverify, "邮箱未验证")
 		return
 	}
 
-	// 生成 JWT
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id":  userID,
-		"username": req.Username,
-		"exp":      time.Now().Add(24 * time.Hour).Unix(), // 过期时间
-		"iss":      "my-digital-home",                     // 签发方
-	})
+	h.completeLogin(ctx, c, user, req.Device)
+}
+
+// completeLogin 凭证校验通过后建会话、签发令牌并写入登录响应，密码登录与第三方登录共用
+func (h *UserHandler) completeLogin(ctx context.Context, c *app.RequestContext, user dao_model.User, device string) {
+	userID := user.ID
+
+	// 启用会话存储时先建会话，访问令牌通过sid关联，撤销会话即可使其失效
+	now := time.Now()
+	var refreshToken string
+	var sessionID int64
+	if h.Sessions != nil {
+		var err error
+		refreshToken, sessionID, err = h.startSession(ctx, c, userID, device, now)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "create session failed: user=%d err=%v", userID, err)
+			respondErr(c, 500, errors2.CodeInternal, "会话创建失败")
+			return
+		}
+	}
 
-	signedToken, err := token.SignedString([]byte(h.JWTSecret))
+	// 生成 JWT
+	signedToken, err := h.issueAccessToken(c, user, sessionID, now)
 	if err != nil {
-		c.JSON(500, utils.H{"error": "令牌生成失败"})
+		respondErr(c, 500, errors2.CodeInternal, "令牌生成失败")
 		return
 	}
 
-	c.JSON(200, utils.H{
-		"token":    signedToken,
-		"user_id":  userID,
-		"username": req.Username,
+	// 记录最近登录时间，失败不影响本次登录
+	if err := h.UserRepo.UpdateLastLogin(ctx, userID, now); err != nil {
+		hlog.CtxWarnf(ctx, "update last login failed: user=%d err=%v", userID, err)
+	}
+
+	h.Audit.Record(newAuditEntry(c, dao_model.AuditLogin, userID, userID))
+	metrics.SetOutcome(c, metrics.OutcomeLoginSuccess)
+	respondOK(c, model.LoginRes{
+		Token:        signedToken,
+		RefreshToken: refreshToken,
+		SessionID:    sessionID,
+		UserID:       userID,
+		Username:     user.Username,
+		Roles:        user.RoleList(),
 	})
 }
 
-// 密码修改接口（增强验证）
-func (h *UserHandler) ChangePassword(ctx context.Context, c *app.RequestContext) {
-	claims, exist := c.Get("jwt_claims")
-	if !exist {
-		respondError(c, 401, "未授权访问")
-		return
+// issueAccessToken 签发访问令牌，sessionID为0时不带sid声明；开启Cookie认证时同时写入Cookie
+func (h *UserHandler) issueAccessToken(c *app.RequestContext, user dao_model.User, sessionID int64, authTime time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id":   user.ID,
+		"username":  user.Username,
+		"roles":     user.RoleList(),
+		"auth_time": authTime.Unix(), // 密码认证时间，用于敏感操作的新鲜度校验
+	}
+	if sessionID != 0 {
+		claims["sid"] = sessionID
+	}
+	signedToken, err := h.JWT.Issue(claims)
+	if err != nil {
+		return "", err
 	}
 
-	// 安全提取用户ID和用户名
-	jwtClaims, ok := claims.(jwt.MapClaims)
-	if !ok {
-		respondError(c, 401, "无效令牌类型")
-		return
+	// 浏览器客户端通过httpOnly Cookie携带令牌，脚本无法读取
+	if h.JWTCfg.CookieEnabled {
+		c.SetCookie(h.JWTCfg.CookieName, signedToken, int(h.JWT.TTL().Seconds()), "/", "",
+			h.JWTCfg.SameSiteMode(), h.JWTCfg.CookieSecure, true)
 	}
+	return signedToken, nil
+}
 
-	userID, ok := jwtClaims["user_id"].(float64)
+// 密码修改接口（增强验证）
+func (h *UserHandler) ChangePassword(ctx context.Context, c *app.RequestContext) {
+	userID, ok := currentUserID(c)
 	if !ok {
-		respondError(c, 401, "用户信息解析失败")
 		return
 	}
 
 	// 提取修改密码请求数据
 	var req model.ChangePwdReq
-	if err := c.BindAndValidate(&req); err != nil {
-		respondError(c, 400, "参数错误: "+err.Error())
+	if err := bindAndValidate(c, &req); err != nil {
+		respondMappedErr(c, err)
 		return
 	}
 
 	// 严格校验新密码复杂度
-	if err := validatePasswordStrength(req.NewPassword); err != nil {
-		respondError(c, 400, "新密码不符合复杂度要求")
+	if err := service.ValidatePasswordStrength(h.AccountCfg.PasswordPolicy, req.NewPassword); err != nil {
+		respondErr(c, 400, errors2.CodeWeakPassword, "新密码不符合复杂度要求: "+err.Error())
+		return
+	}
+	if err := h.checkPasswordBreached(ctx, req.NewPassword); err != nil {
+		respondErr(c, 400, errors2.CodeWeakPassword, err.Error())
 		return
 	}
 
 	// 新密码哈希生成
-	newHash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	newHash, err := h.hashPassword(req.NewPassword)
 	if err != nil {
-		respondError(c, 500, "系统错误")
+		respondErr(c, 500, errors2.CodeInternal, "系统错误")
 		return
 	}
 
 	// 更新密码，带版本校验
-	if err := h.UserRepo.UpdatePassword(uint(userID), string(newHash)); err != nil {
-		if errors.Is(err, errors2.ErrUserNotFound) {
-			respondError(c, 404, "用户不存在或已注销")
-		} else if errors.Is(err, dao2.ErrDatabaseInternal) {
-			respondError(c, 500, "数据库错误")
-		} else {
-			respondError(c, 500, "密码更新失败: "+err.Error())
-		}
+	if err := h.UserRepo.UpdatePassword(ctx, uint(userID), string(newHash)); err != nil {
+		respondMappedErr(c, err)
 		return
 	}
 
-	c.JSON(200, utils.H{"message": "密码更新成功"})
+	// 其余设备需重新登录，保留发起修改的当前会话
+	h.revokeSessions(ctx, userID, currentSessionID(c))
+	h.Notifier.Notify(userID, EventPasswordChanged, nil)
+
+	h.Audit.Record(newAuditEntry(c, dao_model.AuditPasswordChange, userID, userID))
+	respondMsg(c, 200, "密码更新成功")
+}
+
+// hashPassword 按配置的代价生成密码哈希
+func (h *UserHandler) hashPassword(password string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost())
 }
 
-func validatePasswordStrength(password string) error {
-	if len(password) < 8 {
-		return errors.New("密码至少8位")
+func (h *UserHandler) bcryptCost() int {
+	if h.BcryptCost == 0 {
+		return bcrypt.DefaultCost
 	}
+	return h.BcryptCost
+}
 
-	hasNumber := false
-	hasLetter := false
-	hasSpecial := false
+// normalizeUsername 按配置的大小写策略规范化用户名
+func (h *UserHandler) normalizeUsername(username string) string {
+	return dao_model.NormalizeUsername(username, h.AccountCfg.UsernameCase != config.UsernameCasePreserve)
+}
 
-	for _, c := range password {
-		switch {
-		case unicode.IsNumber(c):
-			hasNumber = true
-		case unicode.IsLetter(c):
-			hasLetter = true
-		case unicode.IsSymbol(c) || unicode.IsPunct(c):
-			hasSpecial = true
-		}
+// respondPartial 批量接口的部分成功响应，汇总结果放在统一响应的data中
+// 业务码按处理结果区分，不随useMultiStatus变化：部分成功207000，全部失败422000
+func respondPartial(c *app.RequestContext, result *model.PartialResult, useMultiStatus bool) {
+	code, msg := errors2.CodeOK, "ok"
+	switch {
+	case result.Failed == 0:
+	case result.Succeeded == 0:
+		code, msg = errors2.CodeAllFailed, "全部处理失败"
+	default:
+		code, msg = errors2.CodePartialSuccess, "部分处理失败"
 	}
+	c.JSON(result.HTTPStatus(useMultiStatus), model.Response[*model.PartialResult]{
+		Code:    code,
+		Message: msg,
+		Data:    result,
+	})
+}
+
+// respondOK 成功响应，业务码为CodeOK
+func respondOK[T any](c *app.RequestContext, data T) {
+	respond(c, 200, "ok", data)
+}
+
+// respondMsg 无数据的成功响应，仅携带提示信息
+func respondMsg(c *app.RequestContext, status int, msg string) {
+	respond[any](c, status, msg, nil)
+}
+
+func respond[T any](c *app.RequestContext, status int, msg string, data T) {
+	c.JSON(status, model.Response[T]{
+		Code:    errors2.CodeOK,
+		Message: msg,
+		Data:    data,
+	})
+}
 
-	if !(hasNumber && hasLetter && hasSpecial) {
-		return errors.New("需包含数字、字母和特殊字符")
+// respondMappedErr 按errors.MapError的统一策略输出错误响应，参数校验错误附带字段详情
+func respondMappedErr(c *app.RequestContext, err error) {
+	httpStatus, bizCode, msg := errors2.MapError(err)
+	var fields validation.FieldErrors
+	if !errors.As(err, &fields) {
+		respondErr(c, httpStatus, bizCode, msg)
+		return
 	}
+	c.JSON(httpStatus, model.Response[any]{
+		Code:    bizCode,
+		Message: msg,
+		Fields:  fields,
+	})
+}
+
+// bindRequests 经bindAndValidate绑定的请求结构体，启动时校验其binding标签
+var bindRequests = []interface{}{
+	model.RegisterReq{}, model.LoginReq{}, model.ChangePwdReq{}, model.RefreshReq{},
+	model.ResetPasswordReq{}, model.UpdateProfileReq{}, model.CreateAPIKeyReq{}, model.DBLogLevelReq{},
+}
+
+func init() {
+	validation.MustCompile(bindRequests...)
+}
 
-	return nil
+// bindAndValidate 绑定请求后再执行binding标签声明的校验规则
+// 绑定与校验失败包装为参数错误（400）；规则本身写错（未在bindRequests登记的类型）按系统错误返回
+func bindAndValidate(c *app.RequestContext, req interface{}) error {
+	if err := c.BindAndValidate(req); err != nil {
+		return errors2.NewValidationError(err)
+	}
+	err := validation.Struct(req)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, validation.ErrInvalidRule):
+		hlog.Errorf("validate %T: %v", req, err)
+		return err
+	default:
+		return errors2.NewValidationError(err)
+	}
 }
 
-// 统一错误响应方法
-func respondError(c *app.RequestContext, code int, msg string) {
-	c.JSON(code, utils.H{
-		"error":   msg,
-		"code":    code,
-		"success": false,
+// respondErr 统一错误响应：HTTP状态表达错误大类，bizCode区分具体原因
+func respondErr(c *app.RequestContext, httpStatus, bizCode int, msg string) {
+	c.JSON(httpStatus, model.Response[any]{
+		Code:    bizCode,
+		Message: msg,
 	})
 }
+
+// Profile 查询当前登录用户的资料
+func (h *UserHandler) Profile(ctx context.Context, c *app.RequestContext) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.UserRepo.QueryByID(ctx, userID)
+	if err != nil {
+		respondMappedErr(c, err)
+		return
+	}
+	// 资料修改会递增Version，登录时间与邮箱验证状态不递增，一并参与计算
+	if notModified(c, weakETag(user.ID, user.Version, user.UpdatedAt, user.LastLoginAt)) {
+		return
+	}
+	respondOK(c, toUserRes(user))
+}
+
+// currentUserID 从JWT声明中提取用户ID，失败时已写入401响应
+func currentUserID(c *app.RequestContext) (int64, bool) {
+	claims, ok := auth.ClaimsFromContext(c)
+	if !ok {
+		respondErr(c, 401, errors2.CodeUnauthorized, "未授权访问")
+		return 0, false
+	}
+
+	// 安全提取用户ID
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		respondErr(c, 401, errors2.CodeUnauthorized, "用户信息解析失败")
+		return 0, false
+	}
+	return int64(userID), true
+}
+
+// toUserRes 将领域模型映射为对外的用户资料
+func toUserRes(u dao_model.User) model.UserRes {
+	return model.UserRes{
+		ID:            uint(u.ID),
+		Username:      u.Username,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		Roles:         u.RoleList(),
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
+		AvatarURL:     u.AvatarURL,
+		LastLoginAt:   u.LastLoginAt,
+	}
+}
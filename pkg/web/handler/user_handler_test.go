@@ -0,0 +1,412 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+	"my-digital-home/pkg/common/auth"
+	"my-digital-home/pkg/common/config"
+	errors2 "my-digital-home/pkg/common/errors"
+	dao_model "my-digital-home/pkg/core/user/model"
+	"my-digital-home/pkg/core/user/repository/dao"
+	dao2 "my-digital-home/pkg/core/user/repository/dao/impl"
+	"my-digital-home/pkg/web/model"
+)
+
+// fakeRegisterRepo 模拟用户名已被活跃用户占用
+type fakeRegisterRepo struct {
+	dao.UserRepository
+}
+
+func (fakeRegisterRepo) IsUsernameExists(_ context.Context, username string) (bool, error) {
+	return username == "taken", nil
+}
+
+func TestRegisterReturnsFieldErrors(t *testing.T) {
+	h := NewUserHandler(&config.Config{}, fakeRegisterRepo{}, &fakeSender{sent: make(chan sentMail, 1)}, nil)
+	srv := server.New()
+	srv.POST("/register", h.Register)
+
+	body := `{"username":"bob","email":"bob-at-example","password":"Passw0rd!"}`
+	w := ut.PerformRequest(srv.Engine, "POST", "/register",
+		&ut.Body{Body: bytes.NewBufferString(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"})
+
+	var resp model.Response[any]
+	if err := json.Unmarshal(w.Result().Body(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if w.Result().StatusCode() != 400 || resp.Code != errors2.CodeBadRequest || resp.Message == "" {
+		t.Fatalf("Expected 400 with message, got %d: %s", w.Result().StatusCode(), w.Result().Body())
+	}
+	if len(resp.Fields) != 2 || resp.Fields["username"] == "" || resp.Fields["email"] == "" {
+		t.Fatalf("Expected username and email field errors, got %v", resp.Fields)
+	}
+}
+
+func TestRegisterRejectsTakenUsername(t *testing.T) {
+	h := NewUserHandler(&config.Config{}, fakeRegisterRepo{}, &fakeSender{sent: make(chan sentMail, 1)}, nil)
+	srv := server.New()
+	srv.POST("/register", h.Register)
+
+	body := `{"username":"taken","email":"taken@example.com","password":"Passw0rd!"}`
+	w := ut.PerformRequest(srv.Engine, "POST", "/register",
+		&ut.Body{Body: bytes.NewBufferString(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"})
+
+	if w.Result().StatusCode() != 409 {
+		t.Fatalf("Expected 409, got %d", w.Result().StatusCode())
+	}
+
+	var resp model.Response[any]
+	if err := json.Unmarshal(w.Result().Body(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != errors2.CodeUsernameTaken {
+		t.Fatalf("Expected business code %d, got %d", errors2.CodeUsernameTaken, resp.Code)
+	}
+}
+
+func TestRegisterNormalizesUsernameCase(t *testing.T) {
+	h := NewUserHandler(&config.Config{}, fakeRegisterRepo{}, &fakeSender{sent: make(chan sentMail, 1)}, nil)
+	srv := server.New()
+	srv.POST("/register", h.Register)
+
+	// 仅大小写与空白不同，应视为同一用户名
+	body := `{"username":" TAKEN ","email":"Taken@Example.com","password":"Passw0rd!"}`
+	w := ut.PerformRequest(srv.Engine, "POST", "/register",
+		&ut.Body{Body: bytes.NewBufferString(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"})
+
+	if w.Result().StatusCode() != 409 {
+		t.Fatalf("Expected 409, got %d", w.Result().StatusCode())
+	}
+}
+
+// fakeReleaseRepo 用户名被已注销账户占用，按调用顺序记录事务内的操作
+type fakeReleaseRepo struct {
+	dao.UserRepository
+	createErr error
+	calls     []string
+}
+
+func (r *fakeReleaseRepo) IsUsernameExists(context.Context, string) (bool, error) { return false, nil }
+func (r *fakeReleaseRepo) IsEmailExists(context.Context, string) (bool, error)    { return false, nil }
+func (r *fakeReleaseRepo) IsUsernameHeldByDeleted(context.Context, string) (bool, error) {
+	return true, nil
+}
+func (r *fakeReleaseRepo) IsEmailHeldByDeleted(context.Context, string) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeReleaseRepo) WithTx(_ context.Context, fn func(tx dao.UserRepository) error) error {
+	r.calls = append(r.calls, "begin")
+	if err := fn(r); err != nil {
+		r.calls = append(r.calls, "rollback")
+		return err
+	}
+	r.calls = append(r.calls, "commit")
+	return nil
+}
+
+func (r *fakeReleaseRepo) ReleaseDeletedIdentity(context.Context, string, string) error {
+	r.calls = append(r.calls, "release")
+	return nil
+}
+
+func (r *fakeReleaseRepo) CreateUser(context.Context, dao_model.User) error {
+	r.calls = append(r.calls, "create")
+	return r.createErr
+}
+
+func (r *fakeReleaseRepo) QueryByUsername(context.Context, string) (dao_model.User, error) {
+	return dao_model.User{}, dao2.ErrUserNotFound
+}
+
+func TestRegisterReleasesDeletedIdentityInCreateTransaction(t *testing.T) {
+	cfg := fastHashConfig()
+	cfg.Account.DeletedIdentityPolicy = config.DeletedIdentityRelease
+
+	for name, tc := range map[string]struct {
+		createErr  error
+		wantStatus int
+		wantCalls  []string
+	}{
+		"re-registered": {nil, 201, []string{"begin", "release", "create", "commit"}},
+		// 创建失败时释放随事务回滚，注销账户保持原样
+		"create failed": {dao2.ErrEmailTaken, 409, []string{"begin", "release", "create", "rollback"}},
+	} {
+		repo := &fakeReleaseRepo{createErr: tc.createErr}
+		h := NewUserHandler(cfg, repo, &fakeSender{sent: make(chan sentMail, 1)}, nil)
+		srv := server.New()
+		srv.POST("/register", h.Register)
+
+		body := `{"username":"olduser","email":"new@example.com","password":"Passw0rd!"}`
+		w := ut.PerformRequest(srv.Engine, "POST", "/register",
+			&ut.Body{Body: bytes.NewBufferString(body), Len: len(body)},
+			ut.Header{Key: "Content-Type", Value: "application/json"})
+
+		if w.Result().StatusCode() != tc.wantStatus {
+			t.Fatalf("%s: expected %d, got %d: %s", name, tc.wantStatus, w.Result().StatusCode(), w.Result().Body())
+		}
+		if !reflect.DeepEqual(repo.calls, tc.wantCalls) {
+			t.Fatalf("%s: expected %v, got %v", name, tc.wantCalls, repo.calls)
+		}
+	}
+}
+
+// fakeLoginRepo 保存单个用户的密码哈希，记录登录时的哈希升级
+type fakeLoginRepo struct {
+	dao.UserRepository
+	hash      string
+	updated   string
+	lastLogin *time.Time
+}
+
+func (r *fakeLoginRepo) GetPasswordHash(_ context.Context, username string) (string, int64, error) {
+	if username != "alice" {
+		return "", 0, dao2.ErrUserNotFound
+	}
+	return r.hash, 7, nil
+}
+
+func (r *fakeLoginRepo) GetPasswordHashByEmail(_ context.Context, email string) (string, int64, error) {
+	if email != "alice@example.com" {
+		return "", 0, dao2.ErrUserNotFound
+	}
+	return r.hash, 7, nil
+}
+
+func (r *fakeLoginRepo) QueryByID(_ context.Context, id int64) (dao_model.User, error) {
+	return dao_model.User{
+		ID:           id,
+		Username:     "alice",
+		Email:        "alice@example.com",
+		PasswordHash: r.hash,
+		Roles:        dao_model.RoleUser,
+		Version:      3,
+		CreatedAt:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		UpdatedAt:    time.Date(2024, 6, 7, 8, 9, 10, 0, time.UTC),
+		LastLoginAt:  r.lastLogin,
+	}, nil
+}
+
+func (r *fakeLoginRepo) UpdateLastLogin(_ context.Context, _ int64, at time.Time) error {
+	r.lastLogin = &at
+	return nil
+}
+
+func (r *fakeLoginRepo) UpdatePassword(_ context.Context, _ uint, hash string) error {
+	r.updated = hash
+	return nil
+}
+
+func TestLoginUpgradesLowCostHash(t *testing.T) {
+	oldHash, err := bcrypt.GenerateFromPassword([]byte("Passw0rd!"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &fakeLoginRepo{hash: string(oldHash)}
+
+	cfg := config.Default()
+	cfg.Middleware.Security.BcryptCost = bcrypt.MinCost + 1
+	h := newLoginHandler(t, cfg, repo)
+	srv := server.New()
+	srv.POST("/login", h.Login)
+
+	body := `{"username":"alice","password":"Passw0rd!"}`
+	w := ut.PerformRequest(srv.Engine, "POST", "/login",
+		&ut.Body{Body: bytes.NewBufferString(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"})
+	if w.Result().StatusCode() != 200 {
+		t.Fatalf("Expected 200, got %d: %s", w.Result().StatusCode(), w.Result().Body())
+	}
+
+	if repo.updated == "" {
+		t.Fatal("Expected password hash to be upgraded")
+	}
+	if cost, _ := bcrypt.Cost([]byte(repo.updated)); cost != bcrypt.MinCost+1 {
+		t.Fatalf("Expected upgraded cost %d, got %d", bcrypt.MinCost+1, cost)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(repo.updated), []byte("Passw0rd!")) != nil {
+		t.Fatal("Upgraded hash does not match the password")
+	}
+}
+
+// fastHashConfig 使用最低bcrypt代价，缩短测试耗时
+func fastHashConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Middleware.Security.BcryptCost = bcrypt.MinCost
+	return cfg
+}
+
+// newLoginHandler 构造可签发令牌的Handler
+func newLoginHandler(t *testing.T, cfg *config.Config, repo dao.UserRepository) *UserHandler {
+	t.Helper()
+	jwtService, err := auth.NewJWTService(cfg.Middleware.JWT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewUserHandler(cfg, repo, nil, jwtService)
+}
+
+func postLogin(t *testing.T, h *UserHandler, body string) *model.Response[any] {
+	t.Helper()
+	srv := server.New()
+	srv.POST("/login", h.Login)
+
+	w := ut.PerformRequest(srv.Engine, "POST", "/login",
+		&ut.Body{Body: bytes.NewBufferString(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"})
+
+	var resp model.Response[any]
+	if err := json.Unmarshal(w.Result().Body(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return &resp
+}
+
+func TestLoginAcceptsEmail(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("Passw0rd!"), bcrypt.MinCost)
+	h := newLoginHandler(t, fastHashConfig(), &fakeLoginRepo{hash: string(hash)})
+
+	if resp := postLogin(t, h, `{"username":"Alice@Example.com","password":"Passw0rd!"}`); resp.Code != errors2.CodeOK {
+		t.Fatalf("Expected login by email to succeed, got %+v", resp)
+	}
+}
+
+func TestLoginRecordsLastLogin(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("Passw0rd!"), bcrypt.MinCost)
+	repo := &fakeLoginRepo{hash: string(hash)}
+	h := newLoginHandler(t, fastHashConfig(), repo)
+
+	if postLogin(t, h, `{"username":"alice","password":"Wrong0rd!"}`); repo.lastLogin != nil {
+		t.Fatal("Expected failed login not to record last login")
+	}
+	if postLogin(t, h, `{"username":"alice","password":"Passw0rd!"}`); repo.lastLogin == nil {
+		t.Fatal("Expected successful login to record last login")
+	}
+}
+
+func TestLoginFailuresAreIndistinguishable(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("Passw0rd!"), bcrypt.MinCost)
+	h := newLoginHandler(t, fastHashConfig(), &fakeLoginRepo{hash: string(hash)})
+	srv := server.New()
+	srv.POST("/login", h.Login)
+
+	login := func(body string) (int, model.Response[any]) {
+		w := ut.PerformRequest(srv.Engine, "POST", "/login",
+			&ut.Body{Body: bytes.NewBufferString(body), Len: len(body)},
+			ut.Header{Key: "Content-Type", Value: "application/json"})
+		var resp model.Response[any]
+		if err := json.Unmarshal(w.Result().Body(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return w.Result().StatusCode(), resp
+	}
+
+	// 用户不存在与密码错误：状态码、业务码与提示完全一致，无法据此枚举账户
+	unknownStatus, unknown := login(`{"username":"nobody","password":"Passw0rd!"}`)
+	wrongStatus, wrong := login(`{"username":"alice","password":"Wrong0rd!"}`)
+
+	if unknownStatus != 401 || wrongStatus != unknownStatus {
+		t.Fatalf("Expected both failures to return 401, got %d and %d", unknownStatus, wrongStatus)
+	}
+	if unknown.Code != errors2.CodeBadCredential || wrong.Code != unknown.Code {
+		t.Fatalf("Expected both failures to use code %d, got %d and %d", errors2.CodeBadCredential, unknown.Code, wrong.Code)
+	}
+	if unknown.Message == "" || wrong.Message != unknown.Message {
+		t.Fatalf("Expected identical messages, got %q and %q", unknown.Message, wrong.Message)
+	}
+	if !reflect.DeepEqual(unknown, wrong) {
+		t.Fatalf("Expected identical bodies, got %+v and %+v", unknown, wrong)
+	}
+}
+
+func TestLoginSetsHTTPOnlyCookieWhenEnabled(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("Passw0rd!"), bcrypt.MinCost)
+	cfg := fastHashConfig()
+	cfg.Middleware.JWT.CookieEnabled = true
+	h := newLoginHandler(t, cfg, &fakeLoginRepo{hash: string(hash)})
+
+	srv := server.New()
+	srv.POST("/login", h.Login)
+	body := `{"username":"alice","password":"Passw0rd!"}`
+	w := ut.PerformRequest(srv.Engine, "POST", "/login",
+		&ut.Body{Body: bytes.NewBufferString(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"})
+
+	cookie := string(w.Result().Header.Peek("Set-Cookie"))
+	for _, attr := range []string{"jwt=", "HttpOnly", "secure", "SameSite=Lax"} {
+		if !strings.Contains(cookie, attr) {
+			t.Fatalf("Expected %q in Set-Cookie, got %q", attr, cookie)
+		}
+	}
+}
+
+func TestProfileExposesTimestampsOnly(t *testing.T) {
+	h := newLoginHandler(t, config.Default(), &fakeLoginRepo{hash: "secret-hash"})
+
+	srv := server.New()
+	srv.GET("/me", func(c context.Context, ctx *app.RequestContext) {
+		ctx.Set("jwt_claims", jwt.MapClaims{"user_id": float64(7)})
+		ctx.Next(c)
+	}, h.Profile)
+
+	w := ut.PerformRequest(srv.Engine, "GET", "/me", nil)
+	if w.Result().StatusCode() != 200 {
+		t.Fatalf("Expected 200, got %d: %s", w.Result().StatusCode(), w.Result().Body())
+	}
+
+	var resp model.Response[model.UserRes]
+	if err := json.Unmarshal(w.Result().Body(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.ID != 7 || resp.Data.CreatedAt.Year() != 2024 || resp.Data.UpdatedAt.Month() != time.June {
+		t.Fatalf("Expected profile with timestamps, got %+v", resp.Data)
+	}
+	for _, leaked := range []string{"secret-hash", "version", "password"} {
+		if strings.Contains(string(w.Result().Body()), leaked) {
+			t.Fatalf("Expected %q to be kept out of the response, got %s", leaked, w.Result().Body())
+		}
+	}
+}
+
+func TestProfileHonoursIfNoneMatch(t *testing.T) {
+	h := newLoginHandler(t, config.Default(), &fakeLoginRepo{hash: "secret-hash"})
+
+	srv := server.New()
+	srv.GET("/me", func(c context.Context, ctx *app.RequestContext) {
+		ctx.Set("jwt_claims", jwt.MapClaims{"user_id": float64(7)})
+		ctx.Next(c)
+	}, h.Profile)
+
+	w := ut.PerformRequest(srv.Engine, "GET", "/me", nil)
+	etag := string(w.Result().Header.Peek("ETag"))
+	if w.Result().StatusCode() != 200 || !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("Expected 200 with a weak ETag, got %d %q", w.Result().StatusCode(), etag)
+	}
+
+	// 客户端或代理可能去掉W/前缀，弱比较下仍视为匹配
+	for _, inm := range []string{etag, strings.TrimPrefix(etag, "W/"), `"other", ` + etag, "*"} {
+		w = ut.PerformRequest(srv.Engine, "GET", "/me", nil, ut.Header{Key: "If-None-Match", Value: inm})
+		if w.Result().StatusCode() != 304 || len(w.Result().Body()) != 0 || string(w.Result().Header.Peek("ETag")) != etag {
+			t.Fatalf("Expected 304 with ETag for If-None-Match %q, got %d %q", inm, w.Result().StatusCode(), w.Result().Body())
+		}
+	}
+
+	w = ut.PerformRequest(srv.Engine, "GET", "/me", nil, ut.Header{Key: "If-None-Match", Value: `W/"7-2-0-0"`})
+	if w.Result().StatusCode() != 200 {
+		t.Fatalf("Expected 200 for a stale ETag, got %d", w.Result().StatusCode())
+	}
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	errors2 "my-digital-home/pkg/common/errors"
+)
+
+// WebhookSignatureHeader 通用验签方案的签名头：t=<Unix秒>,v1=<十六进制HMAC-SHA256>
+// 签名内容为"<t>.<原始请求体>"，可携带多个v1（密钥轮换期间新旧密钥各签一次）
+const WebhookSignatureHeader = "X-Webhook-Signature"
+
+var (
+	// ErrWebhookSignature 签名头缺失、格式错误或签名不匹配
+	ErrWebhookSignature = errors.New("invalid webhook signature")
+	// ErrWebhookTimestamp 签名时间戳超出允许偏差，可能是重放
+	ErrWebhookTimestamp = errors.New("webhook timestamp outside tolerance")
+)
+
+// WebhookProvider 一个接入方的回调处理：Verify校验签名，通过后Handle处理事件
+// 两者拿到的都是未经解析的原始请求体；Handle返回错误时响应500，接入方通常会重试
+// Handle为nil表示尚未接入业务处理，验签通过后响应503让接入方稍后重投，而不是确认后丢弃事件
+type WebhookProvider struct {
+	Verify func(c *app.RequestContext, body []byte) error
+	Handle func(ctx context.Context, c *app.RequestContext, body []byte) error
+}
+
+// WebhookHandler 按接入方名称分发回调，路由形如POST /webhooks/:provider
+type WebhookHandler struct {
+	providers map[string]WebhookProvider
+}
+
+func NewWebhookHandler() *WebhookHandler {
+	return &WebhookHandler{providers: make(map[string]WebhookProvider)}
+}
+
+// Register 注册接入方，需在开始处理请求前调用；同名接入方后注册的覆盖先注册的
+func (h *WebhookHandler) Register(name string, p WebhookProvider) {
+	h.providers[name] = p
+}
+
+// OnEvent 为已注册的接入方设置事件处理，供各功能在开始处理请求前接入；接入方未注册（未配置密钥）时返回false
+func (h *WebhookHandler) OnEvent(name string, handle func(ctx context.Context, c *app.RequestContext, body []byte) error) bool {
+	p, ok := h.providers[name]
+	if !ok {
+		return false
+	}
+	p.Handle = handle
+	h.providers[name] = p
+	return true
+}
+
+// Len 已注册的接入方数量
+func (h *WebhookHandler) Len() int {
+	return len(h.providers)
+}
+
+// Receive 校验签名后交给接入方处理；未注册的接入方返回404，验签失败返回401，尚无事件处理时返回503
+func (h *WebhookHandler) Receive(ctx context.Context, c *app.RequestContext) {
+	name := c.Param("provider")
+	p, ok := h.providers[name]
+	if !ok {
+		respondErr(c, 404, errors2.CodeNotFound, "未知的回调来源")
+		return
+	}
+
+	// 必须对原始字节验签，任何解析再序列化都会改变签名内容
+	body := c.Request.Body()
+	if err := p.Verify(c, body); err != nil {
+		hlog.CtxWarnf(ctx, "[WEBHOOK] rejected provider=%s ip=%s err=%v", name, c.ClientIP(), err)
+		respondErr(c, 401, errors2.CodeBadSignature, "签名校验失败")
+		return
+	}
+	if p.Handle == nil {
+		hlog.CtxWarnf(ctx, "[WEBHOOK] no handler for provider=%s, asking sender to retry", name)
+		respondErr(c, 503, errors2.CodeUnavailable, "回调处理未就绪")
+		return
+	}
+	if err := p.Handle(ctx, c, body); err != nil {
+		hlog.CtxErrorf(ctx, "[WEBHOOK] handle failed provider=%s err=%v", name, err)
+		respondErr(c, 500, errors2.CodeInternal, "回调处理失败")
+		return
+	}
+	respondMsg(c, 200, "ok")
+}
+
+// HMACVerifier 通用验签方案（见WebhookSignatureHeader），Tolerance为0时不检查时间戳
+type HMACVerifier struct {
+	Secret    string
+	Tolerance time.Duration
+	Now       func() time.Time // 为nil时使用time.Now
+}
+
+func (v HMACVerifier) Verify(c *app.RequestContext, body []byte) error {
+	timestamp, signatures := parseSignatureHeader(string(c.GetHeader(WebhookSignatureHeader)))
+	if timestamp == "" || len(signatures) == 0 {
+		return ErrWebhookSignature
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrWebhookSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(v.Secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	matched := false
+	for _, sig := range signatures {
+		if got, err := hex.DecodeString(sig); err == nil && hmac.Equal(got, expected) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return ErrWebhookSignature
+	}
+
+	// 先验签再检查时间戳：时间戳参与签名，验签通过才可信
+	if v.Tolerance > 0 {
+		now := time.Now
+		if v.Now != nil {
+			now = v.Now
+		}
+		if skew := now().Sub(time.Unix(ts, 0)); skew > v.Tolerance || skew < -v.Tolerance {
+			return ErrWebhookTimestamp
+		}
+	}
+	return nil
+}
+
+// parseSignatureHeader 解析"t=...,v1=...,v1=..."，忽略未知字段
+func parseSignatureHeader(header string) (timestamp string, signatures []string) {
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	return timestamp, signatures
+}
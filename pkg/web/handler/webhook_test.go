@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+)
+
+func signWebhook(secret string, ts int64, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10) + "." + body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhookReceive(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	var received []string
+	failNext := false
+
+	wh := NewWebhookHandler()
+	wh.Register("payments", WebhookProvider{
+		Verify: HMACVerifier{Secret: "s3cret", Tolerance: 5 * time.Minute, Now: func() time.Time { return now }}.Verify,
+		Handle: func(ctx context.Context, c *app.RequestContext, body []byte) error {
+			if failNext {
+				return errors.New("downstream unavailable")
+			}
+			received = append(received, string(body))
+			return nil
+		},
+	})
+	srv := server.New()
+	srv.POST("/webhooks/:provider", wh.Receive)
+
+	// 请求体含空白与键顺序，签名必须基于原始字节
+	body := `{"type": "charge.succeeded",  "id":"evt_1"}`
+	post := func(path, header string) int {
+		w := ut.PerformRequest(srv.Engine, "POST", path, &ut.Body{Body: strings.NewReader(body), Len: len(body)},
+			ut.Header{Key: "Content-Type", Value: "application/json"},
+			ut.Header{Key: WebhookSignatureHeader, Value: header})
+		return w.Result().StatusCode()
+	}
+	ts := now.Unix()
+	valid := "t=" + strconv.FormatInt(ts, 10) + ",v1=" + signWebhook("s3cret", ts, body)
+
+	if code := post("/webhooks/payments", valid); code != 200 || len(received) != 1 || received[0] != body {
+		t.Fatalf("Expected signed webhook to be handled with the raw body, got %d %q", code, received)
+	}
+	// 密钥轮换：任一v1匹配即可
+	rotated := "t=" + strconv.FormatInt(ts, 10) + ",v1=" + signWebhook("old", ts, body) + ",v1=" + signWebhook("s3cret", ts, body)
+	if code := post("/webhooks/payments", rotated); code != 200 {
+		t.Fatalf("Expected any matching v1 signature to pass, got %d", code)
+	}
+
+	stale := now.Add(-10 * time.Minute).Unix()
+	cases := map[string]string{
+		"missing header": "",
+		"wrong secret":   "t=" + strconv.FormatInt(ts, 10) + ",v1=" + signWebhook("other", ts, body),
+		"tampered ts":    "t=" + strconv.FormatInt(ts+1, 10) + ",v1=" + signWebhook("s3cret", ts, body),
+		"stale":          "t=" + strconv.FormatInt(stale, 10) + ",v1=" + signWebhook("s3cret", stale, body),
+		"not hex":        "t=" + strconv.FormatInt(ts, 10) + ",v1=zz",
+	}
+	for name, header := range cases {
+		if code := post("/webhooks/payments", header); code != 401 {
+			t.Errorf("%s: expected 401, got %d", name, code)
+		}
+	}
+	if len(received) != 2 {
+		t.Fatalf("Expected rejected webhooks not to reach the handler, got %d deliveries", len(received))
+	}
+
+	// 已配置密钥但尚未接入事件处理：不能以2xx确认后丢弃
+	wh.Register("pending", WebhookProvider{Verify: HMACVerifier{Secret: "s3cret"}.Verify})
+	if code := post("/webhooks/pending", valid); code != 503 {
+		t.Fatalf("Expected 503 for a provider without a handler, got %d", code)
+	}
+	if wh.OnEvent("unknown", func(context.Context, *app.RequestContext, []byte) error { return nil }) {
+		t.Fatal("Expected OnEvent to refuse an unregistered provider")
+	}
+	if !wh.OnEvent("pending", func(context.Context, *app.RequestContext, []byte) error { return nil }) {
+		t.Fatal("Expected OnEvent to attach to a registered provider")
+	}
+	if code := post("/webhooks/pending", valid); code != 200 {
+		t.Fatalf("Expected 200 once a handler is attached, got %d", code)
+	}
+
+	if code := post("/webhooks/unknown", valid); code != 404 {
+		t.Fatalf("Expected 404 for unregistered provider, got %d", code)
+	}
+	failNext = true
+	if code := post("/webhooks/payments", valid); code != 500 {
+		t.Fatalf("Expected 500 when the provider handler fails, got %d", code)
+	}
+}
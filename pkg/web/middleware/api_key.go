@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"my-digital-home/pkg/common/auth"
+)
+
+// APIKeyHeader 服务间调用携带API Key的请求头
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyValidator 校验API Key明文，ok为false表示Key不存在或已吊销
+type APIKeyValidator func(c context.Context, key string) (identity auth.ServiceIdentity, ok bool, err error)
+
+// APIKeyMiddleware 服务间调用的认证：X-API-Key须为有效Key，通过后以auth.ServiceKey写入调用方服务身份
+// 与JWT认证互不依赖，挂在只供内部服务调用的路由组上
+func APIKeyMiddleware(validate APIKeyValidator) app.HandlerFunc {
+	return func(c context.Context, ctx *app.RequestContext) {
+		key := string(ctx.GetHeader(APIKeyHeader))
+		if key == "" {
+			ctx.AbortWithStatusJSON(401, utils.H{
+				"code":    401006,
+				"message": "missing api key",
+			})
+			return
+		}
+
+		identity, ok, err := validate(c, key)
+		if err != nil {
+			hlog.CtxErrorf(c, "api key validation failed: %v", err)
+			ctx.AbortWithStatusJSON(500, utils.H{
+				"code":    500000,
+				"message": "internal server error",
+			})
+			return
+		}
+		if !ok {
+			hlog.CtxWarnf(c, "[API KEY REJECTED] ip=%s path=%s", ctx.ClientIP(), ctx.Path())
+			ctx.AbortWithStatusJSON(401, utils.H{
+				"code":    401006,
+				"message": "invalid api key",
+			})
+			return
+		}
+
+		ctx.Set(auth.ServiceKey, identity)
+		ctx.Next(c)
+	}
+}
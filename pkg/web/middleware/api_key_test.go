@@ -0,0 +1,51 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"my-digital-home/pkg/common/auth"
+	"my-digital-home/pkg/web/middleware"
+)
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	validate := func(_ context.Context, key string) (auth.ServiceIdentity, bool, error) {
+		switch key {
+		case "good":
+			return auth.ServiceIdentity{KeyID: 3, Name: "billing", Scopes: []string{"users:read"}}, true, nil
+		case "broken":
+			return auth.ServiceIdentity{}, false, errors.New("db down")
+		}
+		return auth.ServiceIdentity{}, false, nil
+	}
+	h := server.New()
+	h.GET("/internal", middleware.APIKeyMiddleware(validate), func(c context.Context, ctx *app.RequestContext) {
+		identity, _ := auth.ServiceFromContext(ctx)
+		ctx.JSON(200, identity)
+	})
+
+	for _, tc := range []struct {
+		key    string
+		status int
+	}{{"", 401}, {"revoked", 401}, {"broken", 500}, {"good", 200}} {
+		var headers []ut.Header
+		if tc.key != "" {
+			headers = append(headers, ut.Header{Key: middleware.APIKeyHeader, Value: tc.key})
+		}
+		w := ut.PerformRequest(h.Engine, "GET", "/internal", nil, headers...)
+		if w.Result().StatusCode() != tc.status {
+			t.Fatalf("key %q: expected %d, got %d", tc.key, tc.status, w.Result().StatusCode())
+		}
+		if tc.status == 200 {
+			var identity auth.ServiceIdentity
+			if err := json.Unmarshal(w.Result().Body(), &identity); err != nil || identity.Name != "billing" {
+				t.Fatalf("Expected service identity on the context, got %s", w.Result().Body())
+			}
+		}
+	}
+}
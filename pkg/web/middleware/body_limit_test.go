@@ -0,0 +1,83 @@
+package middleware_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"my-digital-home/pkg/web/middleware"
+)
+
+func TestSecurityCheckAppliesRouteBodyLimits(t *testing.T) {
+	h := server.New()
+	h.Use(middleware.SecurityCheckMiddleware(middleware.BodyLimits{
+		Default: 100,
+		Routes: map[string]int64{
+			"/login":       10,
+			"/upload":      1000,
+			"/items/:id/x": 1000,
+		},
+	}, nil, nil))
+	ok := func(c context.Context, ctx *app.RequestContext) {
+		ctx.JSON(200, nil)
+	}
+	h.POST("/login", ok)
+	h.POST("/upload", ok)
+	h.POST("/items/:id/x", ok)
+	h.POST("/other", ok)
+
+	cases := []struct {
+		path string
+		size int
+		want int
+	}{
+		{"/login", 10, 200},
+		{"/login", 11, 413},
+		{"/upload", 500, 200},
+		{"/upload", 1001, 413},
+		{"/items/42/x", 500, 200}, // 按路由模板而非实际路径匹配
+		{"/other", 100, 200},
+		{"/other", 500, 413},
+	}
+	for _, tc := range cases {
+		body := &ut.Body{Body: strings.NewReader(strings.Repeat("a", tc.size)), Len: tc.size}
+		w := ut.PerformRequest(h.Engine, "POST", tc.path, body, ut.Header{Key: "User-Agent", Value: "test"})
+		if got := w.Result().StatusCode(); got != tc.want {
+			t.Errorf("POST %s with %d bytes: expected %d, got %d", tc.path, tc.size, tc.want, got)
+		}
+	}
+}
+
+// 回调只跳过恶意字符检查，请求体大小、方法与User-Agent仍校验
+func TestSecurityCheckContentSkipKeepsOtherChecks(t *testing.T) {
+	h := server.New()
+	h.Use(middleware.SecurityCheckMiddleware(middleware.BodyLimits{Default: 100}, nil, middleware.SkipPaths("/webhooks/")))
+	ok := func(c context.Context, ctx *app.RequestContext) {
+		ctx.JSON(200, nil)
+	}
+	h.POST("/webhooks/:provider", ok)
+	h.POST("/other", ok)
+
+	form := ut.Header{Key: "Content-Type", Value: "application/x-www-form-urlencoded"}
+	agent := ut.Header{Key: "User-Agent", Value: "test"}
+	send := func(path, body string, headers ...ut.Header) int {
+		w := ut.PerformRequest(h.Engine, "POST", path, &ut.Body{Body: strings.NewReader(body), Len: len(body)}, headers...)
+		return w.Result().StatusCode()
+	}
+
+	if got := send("/webhooks/acme", "q=select", form, agent); got != 200 {
+		t.Errorf("Expected webhook content check to be skipped, got %d", got)
+	}
+	if got := send("/other", "q=select", form, agent); got != 422 {
+		t.Errorf("Expected content check on other routes, got %d", got)
+	}
+	if got := send("/webhooks/acme", strings.Repeat("a", 101), agent); got != 413 {
+		t.Errorf("Expected body limit on webhooks, got %d", got)
+	}
+	if got := send("/webhooks/acme", "{}"); got != 400 {
+		t.Errorf("Expected User-Agent check on webhooks, got %d", got)
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactBodyMasksSensitiveFields(t *testing.T) {
+	cases := []struct {
+		body   string
+		hidden string
+		kept   string
+	}{
+		{`{"username":"alice","password":"Passw0rd!"}`, "Passw0rd!", "alice"},
+		{`{"data":{"token":"eyJhbGciOi","user_id":7}}`, "eyJhbGciOi", `"user_id":7`},
+		{`[{"new_password":"n3w!","old_password":"0ld!"}]`, "n3w!", "new_password"},
+		{`username=alice&password=Passw0rd!&remember=1`, "Passw0rd!", "remember=1"},
+	}
+	for _, tc := range cases {
+		got := redactBody([]byte(tc.body), 0)
+		if strings.Contains(got, tc.hidden) || !strings.Contains(got, tc.kept) {
+			t.Fatalf("%s: expected %q hidden and %q kept, got %s", tc.body, tc.hidden, tc.kept, got)
+		}
+	}
+}
+
+func TestRedactBodyTruncates(t *testing.T) {
+	got := redactBody([]byte(strings.Repeat("a", 100)), 10)
+	if got != strings.Repeat("a", 10)+"...(truncated, 100 bytes)" {
+		t.Fatalf("Unexpected truncation: %s", got)
+	}
+}
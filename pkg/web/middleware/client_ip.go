@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// NewClientIPFunc 构造ctx.ClientIP()的取值函数，通过engine.SetClientIPFunc注册后，
+// 日志、限流等依赖ClientIP的逻辑都能拿到真实客户端地址
+func NewClientIPFunc(trustedProxies []string) app.ClientIP {
+	trusted := mustParsePrefixes(trustedProxies)
+	return func(ctx *app.RequestContext) string {
+		ip, ok := resolveClientIP(ctx, trusted)
+		if !ok {
+			return ""
+		}
+		return ip.String()
+	}
+}
+
+// resolveClientIP 直连方不是可信代理时直接使用socket地址；
+// 否则从右向左取X-Forwarded-For中第一个非代理地址，没有该头时使用X-Real-IP
+func resolveClientIP(ctx *app.RequestContext, trusted []netip.Prefix) (netip.Addr, bool) {
+	remote, ok := parseAddr(ctx.RemoteAddr().String())
+	if !ok || !containsIP(trusted, remote) {
+		return remote, ok
+	}
+
+	if xff := string(ctx.GetHeader("X-Forwarded-For")); xff != "" {
+		hops := strings.Split(xff, ",")
+		client := remote
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, ok := parseAddr(strings.TrimSpace(hops[i]))
+			if !ok {
+				break
+			}
+			client = hop
+			if !containsIP(trusted, hop) {
+				break
+			}
+		}
+		return client, true
+	}
+
+	if realIP, ok := parseAddr(strings.TrimSpace(string(ctx.GetHeader("X-Real-IP")))); ok {
+		return realIP, true
+	}
+	return remote, true
+}
+
+// parseAddr 兼容"ip:port"与纯IP两种形式
+func parseAddr(s string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+func containsIP(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// mustParsePrefixes 解析CIDR列表，单个IP视为/32或/128；配置错误时拒绝启动
+func mustParsePrefixes(values []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if !strings.Contains(v, "/") {
+			addr, err := netip.ParseAddr(v)
+			if err != nil {
+				hlog.Fatalf("Invalid IP filter entry %q: %v", v, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			hlog.Fatalf("Invalid IP filter entry %q: %v", v, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
+}
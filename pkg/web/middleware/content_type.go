@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"mime"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// ContentTypeMiddleware 要求携带请求体的方法（POST/PUT/PATCH）使用指定的媒体类型，否则返回415
+// 不带请求体的请求（如恢复账户、退出其他设备等动作型POST）没有可校验的媒体类型，直接放行
+// 按路由组挂载，使用表单绑定的接口（如登录）在挂载前注册即可不受影响
+func ContentTypeMiddleware(allowed ...string) app.HandlerFunc {
+	allowedTypes := make(map[string]bool, len(allowed))
+	for _, t := range allowed {
+		allowedTypes[t] = true
+	}
+
+	return func(c context.Context, ctx *app.RequestContext) {
+		switch string(ctx.Method()) {
+		case "POST", "PUT", "PATCH":
+		default:
+			ctx.Next(c)
+			return
+		}
+		if !hasBody(ctx) {
+			ctx.Next(c)
+			return
+		}
+
+		// 忽略charset等参数，仅比较媒体类型
+		mediaType, _, err := mime.ParseMediaType(string(ctx.ContentType()))
+		if err != nil || !allowedTypes[mediaType] {
+			ctx.AbortWithStatusJSON(415, utils.H{
+				"code":    415001,
+				"message": "unsupported media type",
+			})
+			return
+		}
+		ctx.Next(c)
+	}
+}
+
+// hasBody 请求是否携带请求体：流式读取时以Content-Length判断（分块传输为-1），否则看已读取的请求体
+func hasBody(ctx *app.RequestContext) bool {
+	if ctx.Request.IsBodyStream() {
+		return ctx.Request.Header.ContentLength() != 0
+	}
+	return len(ctx.Request.Body()) > 0
+}
+
+// RequireJSON 仅接受application/json请求体
+func RequireJSON() app.HandlerFunc {
+	return ContentTypeMiddleware("application/json")
+}
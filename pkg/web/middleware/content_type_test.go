@@ -0,0 +1,76 @@
+// pkg/web/middleware/content_type_test.go
+package middleware_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"my-digital-home/pkg/web/middleware"
+)
+
+func TestRequireJSON(t *testing.T) {
+	h := server.New()
+	h.Use(middleware.RequireJSON())
+	ok := func(c context.Context, ctx *app.RequestContext) {
+		ctx.JSON(200, nil)
+	}
+	h.GET("/users", ok)
+	h.POST("/users", ok)
+
+	cases := []struct {
+		method      string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"POST", "application/json", "{}", 200},
+		{"POST", "application/json; charset=utf-8", "{}", 200},
+		{"POST", "application/x-www-form-urlencoded", "a=1", 415},
+		{"POST", "", "{}", 415},
+		{"POST", "", "", 200}, // 不带请求体的动作型请求不校验
+		{"GET", "", "", 200},  // 无请求体的方法不校验
+	}
+
+	for _, tc := range cases {
+		var headers []ut.Header
+		if tc.contentType != "" {
+			headers = append(headers, ut.Header{Key: "Content-Type", Value: tc.contentType})
+		}
+		var body *ut.Body
+		if tc.body != "" {
+			body = &ut.Body{Body: strings.NewReader(tc.body), Len: len(tc.body)}
+		}
+		w := ut.PerformRequest(h.Engine, tc.method, "/users", body, headers...)
+		if got := w.Result().StatusCode(); got != tc.want {
+			t.Errorf("%s with %q: expected %d, got %d", tc.method, tc.contentType, tc.want, got)
+		}
+	}
+}
+
+// 动作型POST没有请求体，挂在RequireJSON的路由组下仍应可用
+func TestRequireJSONAllowsBodylessActions(t *testing.T) {
+	h := server.New()
+	ok := func(c context.Context, ctx *app.RequestContext) {
+		ctx.JSON(200, nil)
+	}
+	users := h.Group("/api/v1/users", middleware.RequireJSON())
+	users.POST("/me/sessions/revoke-others", ok)
+	admin := h.Group("/api/v1/admin", middleware.RequireJSON())
+	admin.POST("/users/:id/restore", ok)
+
+	for _, path := range []string{"/api/v1/users/me/sessions/revoke-others", "/api/v1/admin/users/7/restore"} {
+		w := ut.PerformRequest(h.Engine, "POST", path, nil)
+		if got := w.Result().StatusCode(); got != 200 {
+			t.Errorf("POST %s without body: expected 200, got %d: %s", path, got, w.Result().Body())
+		}
+		w = ut.PerformRequest(h.Engine, "POST", path, &ut.Body{Body: strings.NewReader("x"), Len: 1},
+			ut.Header{Key: "Content-Type", Value: "text/plain"})
+		if got := w.Result().StatusCode(); got != 415 {
+			t.Errorf("POST %s with a text body: expected 415, got %d", path, got)
+		}
+	}
+}
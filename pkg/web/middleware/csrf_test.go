@@ -0,0 +1,57 @@
+// pkg/web/middleware/csrf_test.go
+package middleware_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/web/middleware"
+)
+
+func newCSRFServer() *server.Hertz {
+	h := server.New()
+	h.Use(middleware.CSRFMiddleware(config.Default().Middleware.CSRF))
+	ok := func(c context.Context, ctx *app.RequestContext) {
+		ctx.JSON(200, map[string]string{"message": "ok"})
+	}
+	h.GET("/profile", ok)
+	h.POST("/profile", ok)
+	return h
+}
+
+func TestCSRFSafeMethodIssuesCookie(t *testing.T) {
+	w := ut.PerformRequest(newCSRFServer().Engine, "GET", "/profile", nil)
+	resp := w.Result()
+
+	if resp.StatusCode() != 200 {
+		t.Fatalf("Expected 200, got %d", resp.StatusCode())
+	}
+	if cookie := string(resp.Header.Peek("Set-Cookie")); !strings.HasPrefix(cookie, "csrf_token=") {
+		t.Fatalf("Expected csrf_token cookie to be issued, got %q", cookie)
+	}
+}
+
+func TestCSRFRejectsMismatchedToken(t *testing.T) {
+	w := ut.PerformRequest(newCSRFServer().Engine, "POST", "/profile", nil,
+		ut.Header{Key: "Cookie", Value: "csrf_token=abc"},
+		ut.Header{Key: "X-CSRF-Token", Value: "xyz"})
+
+	if w.Result().StatusCode() != 403 {
+		t.Fatalf("Expected 403, got %d", w.Result().StatusCode())
+	}
+}
+
+func TestCSRFAcceptsMatchingToken(t *testing.T) {
+	w := ut.PerformRequest(newCSRFServer().Engine, "POST", "/profile", nil,
+		ut.Header{Key: "Cookie", Value: "csrf_token=abc"},
+		ut.Header{Key: "X-CSRF-Token", Value: "abc"})
+
+	if w.Result().StatusCode() != 200 {
+		t.Fatalf("Expected 200, got %d", w.Result().StatusCode())
+	}
+}
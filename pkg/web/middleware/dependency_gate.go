@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// DependencyGate 依赖（如数据库）就绪前拒绝业务请求，用于降级启动
+type DependencyGate struct {
+	name       string
+	ready      atomic.Bool
+	retryAfter time.Duration
+}
+
+// NewDependencyGate 创建时处于未就绪状态，依赖可用后调用SetReady
+func NewDependencyGate(name string, retryAfter time.Duration) *DependencyGate {
+	return &DependencyGate{name: name, retryAfter: retryAfter}
+}
+
+func (g *DependencyGate) Ready() bool {
+	return g.ready.Load()
+}
+
+// SetReady 标记依赖已就绪，此后请求正常放行
+func (g *DependencyGate) SetReady() {
+	if !g.ready.Swap(true) {
+		hlog.Infof("[DEGRADED] %s is ready, leaving degraded mode", g.name)
+	}
+}
+
+// Middleware 依赖就绪前返回503及Retry-After
+func (g *DependencyGate) Middleware() app.HandlerFunc {
+	retryAfter := strconv.Itoa(int(g.retryAfter.Seconds()))
+
+	return func(c context.Context, ctx *app.RequestContext) {
+		if g.Ready() {
+			ctx.Next(c)
+			return
+		}
+
+		ctx.Header("Retry-After", retryAfter)
+		ctx.AbortWithStatusJSON(503, utils.H{
+			"code":    503004,
+			"message": g.name + " unavailable",
+		})
+	}
+}
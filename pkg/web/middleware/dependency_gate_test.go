@@ -0,0 +1,34 @@
+package middleware_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"my-digital-home/pkg/web/middleware"
+)
+
+func TestDependencyGateRejectsUntilReady(t *testing.T) {
+	gate := middleware.NewDependencyGate("database", 5*time.Second)
+
+	h := server.New()
+	h.GET("/api/v1/users/me", gate.Middleware(), func(c context.Context, ctx *app.RequestContext) {
+		ctx.JSON(200, nil)
+	})
+
+	w := ut.PerformRequest(h.Engine, "GET", "/api/v1/users/me", nil)
+	if w.Result().StatusCode() != 503 {
+		t.Fatalf("Expected 503 before dependency is ready, got %d", w.Result().StatusCode())
+	}
+	if got := string(w.Result().Header.Peek("Retry-After")); got != "5" {
+		t.Fatalf("Expected Retry-After 5, got %q", got)
+	}
+
+	gate.SetReady()
+	if w := ut.PerformRequest(h.Engine, "GET", "/api/v1/users/me", nil); w.Result().StatusCode() != 200 {
+		t.Fatalf("Expected 200 once ready, got %d", w.Result().StatusCode())
+	}
+}
@@ -0,0 +1,59 @@
+// pkg/web/middleware/fresh_auth_test.go
+package middleware_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/golang-jwt/jwt/v5"
+	"my-digital-home/pkg/common/auth"
+	"my-digital-home/pkg/web/middleware"
+)
+
+// newSensitiveServer 模拟JWT认证已通过、声明写入上下文后的敏感路由
+func newSensitiveServer(authTime time.Time) *server.Hertz {
+	h := server.New()
+	h.PUT("/password",
+		func(c context.Context, ctx *app.RequestContext) {
+			ctx.Set(auth.ClaimsKey, jwt.MapClaims{
+				"user_id":   float64(1),
+				"iat":       float64(authTime.Unix()),
+				"auth_time": float64(authTime.Unix()),
+			})
+			ctx.Next(c)
+		},
+		middleware.FreshAuthMiddleware(5*time.Minute),
+		func(c context.Context, ctx *app.RequestContext) {
+			ctx.JSON(200, map[string]string{"message": "ok"})
+		},
+	)
+	return h
+}
+
+func TestFreshAuthRejectsStaleToken(t *testing.T) {
+	// 令牌仍在有效期内，但认证发生在一小时前
+	h := newSensitiveServer(time.Now().Add(-time.Hour))
+
+	w := ut.PerformRequest(h.Engine, "PUT", "/password", nil)
+	resp := w.Result()
+	if resp.StatusCode() != 401 {
+		t.Fatalf("Expected 401, got %d", resp.StatusCode())
+	}
+	if body := string(resp.Body()); !strings.Contains(body, "reauth_required") {
+		t.Fatalf("Expected reauth_required in body, got %s", body)
+	}
+}
+
+func TestFreshAuthAcceptsFreshToken(t *testing.T) {
+	h := newSensitiveServer(time.Now().Add(-time.Minute))
+
+	w := ut.PerformRequest(h.Engine, "PUT", "/password", nil)
+	if w.Result().StatusCode() != 200 {
+		t.Fatalf("Expected 200, got %d", w.Result().StatusCode())
+	}
+}
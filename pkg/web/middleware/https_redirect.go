@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// NewHTTPSRedirectHandler 将明文请求308跳转到同一主机的HTTPS地址，保留路径与查询参数
+// httpsAddr为HTTPS监听地址，端口为443时跳转URL中省略端口
+func NewHTTPSRedirectHandler(httpsAddr string) http.Handler {
+	_, httpsPort, _ := net.SplitHostPort(httpsAddr)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		// 308保证POST等请求跳转后方法与请求体不变
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// StartHTTPSRedirectServer 在独立端口启动明文跳转服务，返回的Server用于停机时关闭
+func StartHTTPSRedirectServer(addr, httpsAddr string) *http.Server {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           NewHTTPSRedirectHandler(httpsAddr),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		hlog.Infof("HTTPS redirect listening on %s", addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			hlog.Errorf("HTTPS redirect server stopped: %v", err)
+		}
+	}()
+	return srv
+}
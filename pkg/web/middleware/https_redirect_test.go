@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPSRedirectKeepsPathAndQuery(t *testing.T) {
+	cases := []struct {
+		httpsAddr string
+		want      string
+	}{
+		{":443", "https://home.example.com/api/v1/users/me?x=1"},
+		{":8443", "https://home.example.com:8443/api/v1/users/me?x=1"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest("POST", "http://home.example.com:8080/api/v1/users/me?x=1", nil)
+		w := httptest.NewRecorder()
+		NewHTTPSRedirectHandler(tc.httpsAddr).ServeHTTP(w, req)
+
+		if w.Code != http.StatusPermanentRedirect || w.Header().Get("Location") != tc.want {
+			t.Fatalf("%s: expected 308 to %s, got %d %s", tc.httpsAddr, tc.want, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// IdempotencyKeyHeader 客户端为同一次业务操作生成的唯一键，重试时原样携带
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLen 幂等键长度上限，避免任意长度的键占用存储
+const maxIdempotencyKeyLen = 255
+
+// CachedResponse 已完成请求的响应，重复请求时原样返回
+type CachedResponse struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// IdempotencyStore 幂等键存储，多实例部署时需替换为共享存储（如Redis）
+type IdempotencyStore interface {
+	// Reserve 占用键并返回true；键已存在时返回false、占用时的请求指纹及已保存的响应（仍在处理中时为nil）
+	Reserve(key, fingerprint string, ttl time.Duration) (reserved bool, storedFingerprint string, resp *CachedResponse)
+	// Save 保存处理结果，ttl内的重复请求直接返回该响应
+	Save(key string, resp CachedResponse, ttl time.Duration)
+	// Release 释放未完成的键（如处理失败），允许客户端重试
+	Release(key string)
+}
+
+// IdempotencyMiddleware 携带Idempotency-Key的请求只处理一次，ttl内的重复请求返回首次的响应
+// 键按路径区分；同一键携带不同请求体返回422，首次请求尚未完成时返回409；5xx响应不保存，允许重试
+func IdempotencyMiddleware(store IdempotencyStore, ttl time.Duration) app.HandlerFunc {
+	return func(c context.Context, ctx *app.RequestContext) {
+		key := string(ctx.GetHeader(IdempotencyKeyHeader))
+		if key == "" {
+			ctx.Next(c)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			ctx.AbortWithStatusJSON(400, utils.H{
+				"code":    400000,
+				"message": "idempotency key too long",
+			})
+			return
+		}
+
+		key = string(ctx.Method()) + " " + string(ctx.Path()) + " " + key
+		sum := sha256.Sum256(ctx.Request.Body())
+		fingerprint := hex.EncodeToString(sum[:])
+
+		reserved, storedFingerprint, cached := store.Reserve(key, fingerprint, ttl)
+		switch {
+		case reserved:
+		case storedFingerprint != fingerprint:
+			ctx.AbortWithStatusJSON(422, utils.H{
+				"code":    422002,
+				"message": "idempotency key reused with a different request",
+			})
+			return
+		case cached == nil:
+			ctx.AbortWithStatusJSON(409, utils.H{
+				"code":    409006,
+				"message": "a request with this idempotency key is in progress",
+			})
+			return
+		default:
+			hlog.CtxInfof(c, "[IDEMPOTENT REPLAY] path=%s", ctx.Path())
+			ctx.Header("Idempotent-Replayed", "true")
+			ctx.Data(cached.Status, cached.ContentType, cached.Body)
+			ctx.Abort()
+			return
+		}
+
+		// 处理失败或panic时释放键，避免客户端在ttl内一直收到409
+		saved := false
+		defer func() {
+			if !saved {
+				store.Release(key)
+			}
+		}()
+
+		ctx.Next(c)
+
+		if status := ctx.Response.StatusCode(); status < 500 {
+			store.Save(key, CachedResponse{
+				Status:      status,
+				ContentType: string(ctx.Response.Header.ContentType()),
+				Body:        append([]byte(nil), ctx.Response.Body()...),
+			}, ttl)
+			saved = true
+		}
+	}
+}
+
+// MemoryIdempotencyStore 进程内幂等键存储，仅适用于单实例部署
+type MemoryIdempotencyStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]*idempotencyEntry
+	lastSweep time.Time
+}
+
+type idempotencyEntry struct {
+	fingerprint string
+	resp        *CachedResponse // 处理中为nil
+	expiresAt   time.Time
+}
+
+// idempotencySweepInterval 过期键的清理间隔
+const idempotencySweepInterval = time.Minute
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		now:     time.Now,
+		entries: make(map[string]*idempotencyEntry),
+	}
+}
+
+func (s *MemoryIdempotencyStore) Reserve(key, fingerprint string, ttl time.Duration) (bool, string, *CachedResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+	if e, ok := s.entries[key]; ok && now.Before(e.expiresAt) {
+		return false, e.fingerprint, e.resp
+	}
+	s.entries[key] = &idempotencyEntry{fingerprint: fingerprint, expiresAt: now.Add(ttl)}
+	return true, fingerprint, nil
+}
+
+func (s *MemoryIdempotencyStore) Save(key string, resp CachedResponse, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		e.resp = &resp
+		e.expiresAt = s.now().Add(ttl)
+	}
+}
+
+func (s *MemoryIdempotencyStore) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// sweep 定期清理过期的键
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < idempotencySweepInterval {
+		return
+	}
+	for key, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,80 @@
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"my-digital-home/pkg/web/middleware"
+)
+
+func TestIdempotencyReplaysFirstResponse(t *testing.T) {
+	registered := 0
+	h := server.New()
+	h.POST("/register", middleware.IdempotencyMiddleware(middleware.NewMemoryIdempotencyStore(), time.Hour),
+		func(c context.Context, ctx *app.RequestContext) {
+			registered++
+			if registered > 1 {
+				ctx.JSON(409, map[string]string{"message": "用户名已存在"})
+				return
+			}
+			ctx.JSON(201, map[string]string{"message": "注册成功"})
+		})
+
+	post := func(key, body string) (int, string, string) {
+		headers := []ut.Header{{Key: "Content-Type", Value: "application/json"}}
+		if key != "" {
+			headers = append(headers, ut.Header{Key: "Idempotency-Key", Value: key})
+		}
+		w := ut.PerformRequest(h.Engine, "POST", "/register",
+			&ut.Body{Body: bytes.NewBufferString(body), Len: len(body)}, headers...)
+		return w.Result().StatusCode(), string(w.Result().Body()), string(w.Result().Header.Peek("Idempotent-Replayed"))
+	}
+
+	body := `{"username":"alice"}`
+	status, first, _ := post("k1", body)
+	if status != 201 {
+		t.Fatalf("Expected first request to be processed, got %d", status)
+	}
+
+	// 重试返回首次响应，不再执行处理器
+	status, replay, replayed := post("k1", body)
+	if status != 201 || replay != first || replayed != "true" || registered != 1 {
+		t.Fatalf("Expected replayed 201, got %d %s replayed=%q handled=%d", status, replay, replayed, registered)
+	}
+
+	if status, _, _ := post("k1", `{"username":"bob"}`); status != 422 {
+		t.Fatalf("Expected 422 for a different payload under the same key, got %d", status)
+	}
+	if status, _, _ := post("", body); status != 409 || registered != 2 {
+		t.Fatalf("Expected requests without a key to be processed normally, got %d", status)
+	}
+}
+
+func TestIdempotencyReleasesKeyOnServerError(t *testing.T) {
+	calls := 0
+	h := server.New()
+	h.POST("/register", middleware.IdempotencyMiddleware(middleware.NewMemoryIdempotencyStore(), time.Hour),
+		func(c context.Context, ctx *app.RequestContext) {
+			calls++
+			if calls == 1 {
+				ctx.JSON(500, nil)
+				return
+			}
+			ctx.JSON(201, nil)
+		})
+
+	for _, want := range []int{500, 201, 201} {
+		w := ut.PerformRequest(h.Engine, "POST", "/register", nil, ut.Header{Key: "Idempotency-Key", Value: "k1"})
+		if w.Result().StatusCode() != want {
+			t.Fatalf("Expected %d, got %d", want, w.Result().StatusCode())
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("Expected the failed attempt to be retried once, got %d calls", calls)
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"my-digital-home/pkg/common/config"
+)
+
+// IPFilterMiddleware 按CIDR黑白名单过滤来源IP：黑名单优先，白名单为空时放行黑名单以外的全部地址
+// 仅当直连地址属于可信代理时才采信X-Forwarded-For，可挂载到全局或单个路由组
+func IPFilterMiddleware(cfg config.IPFilterConfig, trustedProxies []string) app.HandlerFunc {
+	allow := mustParsePrefixes(cfg.Allow)
+	deny := mustParsePrefixes(cfg.Deny)
+	trusted := mustParsePrefixes(trustedProxies)
+
+	return func(c context.Context, ctx *app.RequestContext) {
+		if len(allow) == 0 && len(deny) == 0 {
+			ctx.Next(c)
+			return
+		}
+
+		ip, ok := resolveClientIP(ctx, trusted)
+		if !ok || containsIP(deny, ip) || (len(allow) > 0 && !containsIP(allow, ip)) {
+			hlog.CtxWarnf(c, "[IP DENIED] ip=%s path=%s", ip, ctx.Path())
+			ctx.AbortWithStatusJSON(403, utils.H{
+				"code":    403004,
+				"message": "access denied",
+			})
+			return
+		}
+		ctx.Next(c)
+	}
+}
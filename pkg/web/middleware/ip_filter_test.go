@@ -0,0 +1,84 @@
+// pkg/web/middleware/ip_filter_test.go
+package middleware_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/web/middleware"
+)
+
+// ut发出的请求直连地址为0.0.0.0，将其视为可信代理以便通过X-Forwarded-For模拟来源
+var testProxies = []string{"0.0.0.0"}
+
+func ipStatus(t *testing.T, cfg config.IPFilterConfig, proxies []string, xff string) int {
+	t.Helper()
+	h := server.New()
+	h.Use(middleware.IPFilterMiddleware(cfg, proxies))
+	h.GET("/admin", func(c context.Context, ctx *app.RequestContext) {
+		ctx.JSON(200, nil)
+	})
+
+	w := ut.PerformRequest(h.Engine, "GET", "/admin", nil,
+		ut.Header{Key: "X-Forwarded-For", Value: xff})
+	return w.Result().StatusCode()
+}
+
+func TestIPFilterAllowList(t *testing.T) {
+	cfg := config.IPFilterConfig{Allow: []string{"10.0.0.0/8"}}
+
+	if got := ipStatus(t, cfg, testProxies, "10.1.2.3"); got != 200 {
+		t.Fatalf("Expected office IP to pass, got %d", got)
+	}
+	if got := ipStatus(t, cfg, testProxies, "203.0.113.9"); got != 403 {
+		t.Fatalf("Expected outside IP to be denied, got %d", got)
+	}
+}
+
+func TestIPFilterDenyTakesPrecedence(t *testing.T) {
+	cfg := config.IPFilterConfig{
+		Allow: []string{"10.0.0.0/8"},
+		Deny:  []string{"10.6.6.6"},
+	}
+
+	if got := ipStatus(t, cfg, testProxies, "10.6.6.6"); got != 403 {
+		t.Fatalf("Expected denied IP to be rejected even though allowed, got %d", got)
+	}
+}
+
+func TestIPFilterIgnoresForwardedForFromUntrustedPeer(t *testing.T) {
+	// 直连方不是可信代理：伪造的X-Forwarded-For不应被采信
+	cfg := config.IPFilterConfig{Allow: []string{"10.0.0.0/8"}}
+
+	if got := ipStatus(t, cfg, nil, "10.1.2.3"); got != 403 {
+		t.Fatalf("Expected spoofed header to be ignored, got %d", got)
+	}
+}
+
+func TestClientIPFuncUsesRealIPBehindTrustedProxy(t *testing.T) {
+	clientIP := middleware.NewClientIPFunc(testProxies)
+
+	// 客户端伪造了最左侧地址，应取最右侧的非代理地址
+	ctx := app.NewContext(0)
+	ctx.Request.Header.Set("X-Forwarded-For", "1.1.1.1, 198.51.100.7")
+	if got := clientIP(ctx); got != "198.51.100.7" {
+		t.Fatalf("Expected 198.51.100.7, got %q", got)
+	}
+
+	ctx = app.NewContext(0)
+	ctx.Request.Header.Set("X-Real-IP", "198.51.100.8")
+	if got := clientIP(ctx); got != "198.51.100.8" {
+		t.Fatalf("Expected 198.51.100.8, got %q", got)
+	}
+
+	// 直连方不可信时忽略转发头
+	ctx = app.NewContext(0)
+	ctx.Request.Header.Set("X-Forwarded-For", "198.51.100.7")
+	if got := middleware.NewClientIPFunc(nil)(ctx); got != "0.0.0.0" {
+		t.Fatalf("Expected socket address, got %q", got)
+	}
+}
@@ -0,0 +1,107 @@
+// pkg/web/middleware/jwt_auth_test.go
+package middleware_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/golang-jwt/jwt/v5"
+	"my-digital-home/pkg/common/auth"
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/web/middleware"
+)
+
+func newJWTService(t *testing.T, cfg config.JWTAuthConfig) *auth.JWTService {
+	t.Helper()
+	jwtService, err := auth.NewJWTService(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jwtService
+}
+
+func newAdminServer(t *testing.T, cfg *config.JWTAuthConfig) *server.Hertz {
+	h := server.New()
+	h.GET("/admin",
+		middleware.JWTAuthMiddleware(cfg, newJWTService(t, *cfg)),
+		middleware.RequireRole("admin"),
+		func(c context.Context, ctx *app.RequestContext) {
+			// 处理器通过ClaimsFromContext读取声明
+			claims, ok := auth.ClaimsFromContext(ctx)
+			if !ok {
+				ctx.JSON(500, map[string]string{"message": "claims missing"})
+				return
+			}
+			ctx.JSON(200, map[string]interface{}{"user_id": claims["user_id"]})
+		},
+	)
+	return h
+}
+
+func TestJWTAuthAcceptsIssuedToken(t *testing.T) {
+	cfg := config.Default().Middleware.JWT
+	token, err := newJWTService(t, cfg).Issue(jwt.MapClaims{"user_id": 1, "roles": []string{"admin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := ut.PerformRequest(newAdminServer(t, &cfg).Engine, "GET", "/admin", nil,
+		ut.Header{Key: "Authorization", Value: "Bearer " + token})
+	if w.Result().StatusCode() != 200 || string(w.Result().Body()) != `{"user_id":1}` {
+		t.Fatalf("Expected claims to reach the handler, got %d: %s", w.Result().StatusCode(), w.Result().Body())
+	}
+}
+
+func TestJWTAuthRejectsMissingOrForgedToken(t *testing.T) {
+	cfg := config.Default().Middleware.JWT
+	forgedCfg := cfg
+	forgedCfg.Secret = "not-the-server-secret"
+	forged, _ := newJWTService(t, forgedCfg).Issue(jwt.MapClaims{"user_id": 1, "roles": []string{"admin"}})
+
+	h := newAdminServer(t, &cfg)
+	for name, headers := range map[string][]ut.Header{
+		"missing": nil,
+		"forged":  {{Key: "Authorization", Value: "Bearer " + forged}},
+	} {
+		w := ut.PerformRequest(h.Engine, "GET", "/admin", nil, headers...)
+		if w.Result().StatusCode() != 401 {
+			t.Fatalf("%s token: expected 401, got %d", name, w.Result().StatusCode())
+		}
+	}
+}
+
+func TestJWTAuthRejectsRevokedSession(t *testing.T) {
+	cfg := config.Default().Middleware.JWT
+	jwtService := newJWTService(t, cfg)
+	checkSession := func(_ context.Context, userID, sessionID int64) (bool, error) {
+		return userID == 1 && sessionID == 10, nil // 仅会话10有效
+	}
+
+	h := server.New()
+	h.GET("/me", middleware.JWTAuthMiddleware(&cfg, jwtService, checkSession),
+		func(c context.Context, ctx *app.RequestContext) {
+			ctx.String(200, "ok")
+		})
+
+	for name, tc := range map[string]struct {
+		claims jwt.MapClaims
+		status int
+	}{
+		"active session":  {jwt.MapClaims{"user_id": 1, "sid": 10}, 200},
+		"revoked session": {jwt.MapClaims{"user_id": 1, "sid": 11}, 401},
+		"legacy token":    {jwt.MapClaims{"user_id": 1}, 200},
+	} {
+		token, err := jwtService.Issue(tc.claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := ut.PerformRequest(h.Engine, "GET", "/me", nil,
+			ut.Header{Key: "Authorization", Value: "Bearer " + token})
+		if w.Result().StatusCode() != tc.status {
+			t.Fatalf("%s: expected %d, got %d", name, tc.status, w.Result().StatusCode())
+		}
+	}
+}
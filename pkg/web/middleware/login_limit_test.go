@@ -0,0 +1,67 @@
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/web/middleware"
+)
+
+func TestLoginRateLimitPerAccount(t *testing.T) {
+	h := server.New()
+	h.POST("/login", middleware.LoginRateLimitMiddleware(config.LoginRateLimitConfig{
+		PerIP:      config.RateLimitConfig{Rate: 100, Interval: time.Minute},
+		PerAccount: config.RateLimitConfig{Rate: 2, Interval: time.Minute},
+	}), func(c context.Context, ctx *app.RequestContext) {
+		ctx.JSON(401, nil) // 模拟密码错误
+	})
+
+	attempt := func(body string) (int, string) {
+		w := ut.PerformRequest(h.Engine, "POST", "/login",
+			&ut.Body{Body: bytes.NewBufferString(body), Len: len(body)},
+			ut.Header{Key: "Content-Type", Value: "application/json"})
+		return w.Result().StatusCode(), string(w.Result().Header.Peek("Retry-After"))
+	}
+
+	attempt(`{"username":"alice","password":"x"}`)
+	attempt(`{"username":"alice","password":"y"}`)
+	// 大小写与空白不同仍计入同一账户
+	status, retryAfter := attempt(`{"username":" Alice ","password":"z"}`)
+	if status != 429 || retryAfter != "60" {
+		t.Fatalf("Expected 429 with Retry-After 60, got %d %q", status, retryAfter)
+	}
+
+	if status, _ := attempt(`{"username":"bob","password":"x"}`); status != 401 {
+		t.Fatalf("Expected other accounts to be unaffected, got %d", status)
+	}
+}
+
+func TestLoginRateLimitPerIP(t *testing.T) {
+	h := server.New()
+	h.POST("/login", middleware.LoginRateLimitMiddleware(config.LoginRateLimitConfig{
+		PerIP: config.RateLimitConfig{Rate: 2, Interval: time.Minute},
+	}), func(c context.Context, ctx *app.RequestContext) {
+		ctx.JSON(401, nil)
+	})
+
+	// 逐个换用户名也无法绕过按IP的限制；表单提交同样生效
+	for i, username := range []string{"alice", "bob", "carol"} {
+		body := "username=" + username + "&password=x"
+		w := ut.PerformRequest(h.Engine, "POST", "/login",
+			&ut.Body{Body: bytes.NewBufferString(body), Len: len(body)},
+			ut.Header{Key: "Content-Type", Value: "application/x-www-form-urlencoded"})
+		want := 401
+		if i == 2 {
+			want = 429
+		}
+		if w.Result().StatusCode() != want {
+			t.Fatalf("Attempt %d: expected %d, got %d", i+1, want, w.Result().StatusCode())
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// Maintenance 维护模式开关，运行时可切换，无需重新部署
+type Maintenance struct {
+	enabled    atomic.Bool
+	retryAfter time.Duration
+}
+
+func NewMaintenance(enabled bool, retryAfter time.Duration) *Maintenance {
+	m := &Maintenance{retryAfter: retryAfter}
+	m.enabled.Store(enabled)
+	if enabled {
+		hlog.Warnf("[MAINTENANCE] starting in maintenance mode")
+	}
+	return m
+}
+
+func (m *Maintenance) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// SetEnabled 切换维护模式，状态发生变化时记录日志
+func (m *Maintenance) SetEnabled(enabled bool) {
+	if m.enabled.Swap(enabled) == enabled {
+		return
+	}
+	if enabled {
+		hlog.Warnf("[MAINTENANCE] entered maintenance mode")
+	} else {
+		hlog.Infof("[MAINTENANCE] left maintenance mode")
+	}
+}
+
+// Middleware 维护期间除豁免路径外一律返回503及Retry-After
+func (m *Maintenance) Middleware(exemptPaths ...string) app.HandlerFunc {
+	exempt := make(map[string]bool, len(exemptPaths))
+	for _, p := range exemptPaths {
+		exempt[p] = true
+	}
+	retryAfter := strconv.Itoa(int(m.retryAfter.Seconds()))
+
+	return func(c context.Context, ctx *app.RequestContext) {
+		if !m.Enabled() || exempt[string(ctx.Path())] {
+			ctx.Next(c)
+			return
+		}
+
+		ctx.Header("Retry-After", retryAfter)
+		ctx.AbortWithStatusJSON(503, utils.H{
+			"code":    503003,
+			"message": "service under maintenance",
+		})
+	}
+}
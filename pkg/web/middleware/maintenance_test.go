@@ -0,0 +1,42 @@
+// pkg/web/middleware/maintenance_test.go
+package middleware_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"my-digital-home/pkg/web/middleware"
+)
+
+func TestMaintenanceModeToggle(t *testing.T) {
+	m := middleware.NewMaintenance(true, 2*time.Minute)
+
+	h := server.New()
+	h.Use(m.Middleware("/livez"))
+	ok := func(c context.Context, ctx *app.RequestContext) {
+		ctx.JSON(200, nil)
+	}
+	h.GET("/livez", ok)
+	h.GET("/api/v1/users/me", ok)
+
+	w := ut.PerformRequest(h.Engine, "GET", "/api/v1/users/me", nil)
+	if w.Result().StatusCode() != 503 {
+		t.Fatalf("Expected 503 during maintenance, got %d", w.Result().StatusCode())
+	}
+	if got := string(w.Result().Header.Peek("Retry-After")); got != "120" {
+		t.Fatalf("Expected Retry-After 120, got %q", got)
+	}
+
+	if w := ut.PerformRequest(h.Engine, "GET", "/livez", nil); w.Result().StatusCode() != 200 {
+		t.Fatalf("Expected exempt path to pass, got %d", w.Result().StatusCode())
+	}
+
+	m.SetEnabled(false)
+	if w := ut.PerformRequest(h.Engine, "GET", "/api/v1/users/me", nil); w.Result().StatusCode() != 200 {
+		t.Fatalf("Expected 200 after leaving maintenance, got %d", w.Result().StatusCode())
+	}
+}
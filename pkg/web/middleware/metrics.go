@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/adaptor"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/common/metrics"
+)
+
+// MetricsMiddleware 请求指标采集（方法/路由/状态码 + Handler上报的业务结果）
+func MetricsMiddleware(cfg config.MetricsConfig) app.HandlerFunc {
+	allowed := make(map[string]bool, len(cfg.AllowedOutcomes))
+	for _, outcome := range cfg.AllowedOutcomes {
+		allowed[outcome] = true
+	}
+
+	return func(c context.Context, ctx *app.RequestContext) {
+		start := time.Now()
+		ctx.Next(c)
+
+		// 使用路由模板而非原始路径，避免路径参数导致标签基数爆炸
+		path := ctx.FullPath()
+		if path == "" {
+			path = "unmatched"
+		}
+		method := string(ctx.Method())
+
+		metrics.HTTPRequestsTotal.
+			WithLabelValues(method, path, strconv.Itoa(ctx.Response.StatusCode())).
+			Inc()
+		metrics.HTTPRequestDuration.
+			WithLabelValues(method, path).
+			Observe(time.Since(start).Seconds())
+
+		if outcome, ok := metrics.OutcomeFromContext(ctx); ok {
+			if !allowed[outcome] {
+				outcome = metrics.OutcomeOther
+			}
+			metrics.BusinessOutcomesTotal.WithLabelValues(path, outcome).Inc()
+		}
+	}
+}
+
+// MetricsHandler 以Prometheus文本格式暴露指标
+func MetricsHandler() app.HandlerFunc {
+	h := promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})
+
+	return func(c context.Context, ctx *app.RequestContext) {
+		req, err := adaptor.GetCompatRequest(&ctx.Request)
+		if err != nil {
+			hlog.CtxErrorf(c, "metrics request convert failed: %v", err)
+			ctx.AbortWithStatus(500)
+			return
+		}
+		h.ServeHTTP(adaptor.GetCompatResponseWriter(&ctx.Response), req)
+	}
+}
@@ -0,0 +1,62 @@
+// pkg/web/middleware/metrics_test.go
+package middleware_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/common/metrics"
+	"my-digital-home/pkg/web/middleware"
+)
+
+func TestBusinessOutcomeOnLoginFailure(t *testing.T) {
+	h := server.New()
+	h.Use(middleware.MetricsMiddleware(config.MetricsConfig{
+		AllowedOutcomes: []string{metrics.OutcomeLoginFailure},
+	}))
+
+	// 模拟登录失败：状态码401，Handler上报业务结果
+	h.POST("/login", func(c context.Context, ctx *app.RequestContext) {
+		metrics.SetOutcome(ctx, metrics.OutcomeLoginFailure)
+		ctx.JSON(401, map[string]string{"error": "密码错误"})
+	})
+
+	counter := metrics.BusinessOutcomesTotal.WithLabelValues("/login", metrics.OutcomeLoginFailure)
+	before := testutil.ToFloat64(counter)
+
+	w := ut.PerformRequest(h.Engine, "POST", "/login", nil)
+	if w.Result().StatusCode() != 401 {
+		t.Fatalf("Expected 401, got %d", w.Result().StatusCode())
+	}
+
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Fatalf("Expected login_failure counter to increase by 1, got %v", got)
+	}
+}
+
+func TestBusinessOutcomeOutsideAllowlist(t *testing.T) {
+	h := server.New()
+	h.Use(middleware.MetricsMiddleware(config.MetricsConfig{
+		AllowedOutcomes: []string{metrics.OutcomeLoginFailure},
+	}))
+
+	// 未在白名单中的取值应归并为other
+	h.POST("/login-other", func(c context.Context, ctx *app.RequestContext) {
+		metrics.SetOutcome(ctx, "user-supplied-value")
+		ctx.JSON(200, nil)
+	})
+
+	counter := metrics.BusinessOutcomesTotal.WithLabelValues("/login-other", metrics.OutcomeOther)
+	before := testutil.ToFloat64(counter)
+
+	ut.PerformRequest(h.Engine, "POST", "/login-other", nil)
+
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Fatalf("Expected other counter to increase by 1, got %v", got)
+	}
+}
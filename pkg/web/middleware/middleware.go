@@ -5,11 +5,16 @@ import (
 	"fmt"
 	"github.com/cloudwego/hertz/pkg/common/hlog"
 	"github.com/cloudwego/hertz/pkg/common/utils"
-	jwth "github.com/hertz-contrib/jwt"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	"math"
+	"my-digital-home/pkg/common/auth"
 	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/common/requestid"
 	"regexp"
 	"runtime/debug"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -25,13 +30,14 @@ func LoggerMiddleware() app.HandlerFunc {
 		latency := time.Since(start)
 
 		// 结构化日志输出
-		hlog.CtxTracef(c, "| %3d | %13v | %15s | %-7s | %s | UA=%s",
+		hlog.CtxTracef(c, "| %3d | %13v | %15s | %-7s | %s | UA=%s | request_id=%s",
 			ctx.Response.StatusCode(),
 			latency,
 			ctx.ClientIP(),
 			ctx.Method(),
 			ctx.Path(),
 			ctx.GetHeader("User-Agent"),
+			requestid.FromContext(c),
 		)
 	}
 }
@@ -42,15 +48,30 @@ func LoggerMiddleware() app.HandlerFunc {
 	go run main.go
 */
 
+// PanicHook panic上报回调（如发送到Sentry），在写响应之前调用
+// err为脱敏后的panic信息（string），原始panic值可能带有凭据，不会传给回调
+type PanicHook func(ctx context.Context, err interface{}, stack []byte)
+
 // RecoveryMiddleware 增强型异常捕获（带配置依赖版本）
-func RecoveryMiddleware(cfg *config.Config) app.HandlerFunc {
+// onPanic可选，未提供时仅记录日志
+// 错误信息与堆栈按cfg.Middleware.Recovery.RedactPatterns脱敏后再记录、上报和返回
+func RecoveryMiddleware(cfg *config.Config, onPanic ...PanicHook) app.HandlerFunc {
+	redact := newRedactor(cfg.Middleware.Recovery.RedactPatterns)
+
 	return func(c context.Context, ctx *app.RequestContext) {
 		defer func() {
 			if err := recover(); err != nil {
 				// 获取调用堆栈
-				stack := string(debug.Stack())
+				stack := redact(string(debug.Stack()))
+				errMsg := redact(fmt.Sprintf("%v", err))
 
-				hlog.CtxErrorf(c, "[PANIC RECOVERED] %v\n%s", err, stack)
+				hlog.CtxErrorf(c, "[PANIC RECOVERED] %s\n%s", errMsg, stack)
+
+				// 无论哪种环境都先上报，生产环境虽不返回堆栈但上报内容完整
+				// 上报的信息与堆栈均已脱敏
+				for _, hook := range onPanic {
+					runPanicHook(c, hook, errMsg, []byte(stack))
+				}
 
 				// 生产环境处理
 				if cfg.IsProd() { // 使用注入的配置实例判断环境
@@ -61,7 +82,7 @@ func RecoveryMiddleware(cfg *config.Config) app.HandlerFunc {
 				} else { // 开发环境显示详细错误
 					ctx.AbortWithStatusJSON(500, map[string]interface{}{
 						"code":  500,
-						"error": fmt.Sprintf("%v", err),     // 转换为字符串格式
+						"error": errMsg,                     // 转换为字符串格式
 						"stack": strings.Split(stack, "\n"), // 切割为字符串数组更易读
 					})
 				}
@@ -71,6 +92,36 @@ func RecoveryMiddleware(cfg *config.Config) app.HandlerFunc {
 	}
 }
 
+// newRedactor 编译脱敏正则，无效的正则告警后跳过
+func newRedactor(patterns []string) func(string) string {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			hlog.Warnf("Invalid panic redact pattern %q ignored: %v", pattern, err)
+			continue
+		}
+		compiled = append(compiled, re)
+	}
+
+	return func(s string) string {
+		for _, re := range compiled {
+			s = re.ReplaceAllString(s, "${1}***${2}")
+		}
+		return s
+	}
+}
+
+// runPanicHook 隔离上报回调自身的panic，避免影响错误响应
+func runPanicHook(c context.Context, hook PanicHook, err interface{}, stack []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			hlog.CtxErrorf(c, "panic hook failed: %v", r)
+		}
+	}()
+	hook(c, err, stack)
+}
+
 // CORSMiddleware 安全的跨域配置
 func CORSMiddleware(corsConfig config.CORSConfig) app.HandlerFunc {
 	return cors.New(
@@ -94,11 +145,22 @@ func CORSMiddleware(corsConfig config.CORSConfig) app.HandlerFunc {
 	)
 }
 
-func TimeoutMiddleware(seconds int) app.HandlerFunc {
+// TimeoutMiddleware 限制请求处理时长；skippers命中的请求（如长连接推送）不受超时限制
+func TimeoutMiddleware(seconds int, skippers ...Skipper) app.HandlerFunc {
 	return func(c context.Context, ctx *app.RequestContext) {
+		if shouldSkip(skippers, ctx) {
+			ctx.Next(c)
+			return
+		}
+
 		timeoutCtx, cancel := context.WithTimeout(c, time.Duration(seconds)*time.Second)
 		defer cancel()
 
+		// 后续处理器在副本上执行，超时后其写入只落在副本里，不会与503响应重复写出
+		cp := ctx.Copy()
+		cp.SetHandlers(ctx.Handlers())
+		cp.SetIndex(ctx.GetIndex())
+
 		// 通过goroutine执行后续处理器
 		done := make(chan struct{})
 		var panicErr interface{}
@@ -110,7 +172,7 @@ func TimeoutMiddleware(seconds int) app.HandlerFunc {
 				}
 				close(done)
 			}()
-			ctx.Next(timeoutCtx) // 关键：传入超时上下文
+			cp.Next(timeoutCtx) // 关键：传入超时上下文，仓储据此取消数据库操作
 		}()
 
 		// 监听超时或完成
@@ -125,91 +187,240 @@ func TimeoutMiddleware(seconds int) app.HandlerFunc {
 			if panicErr != nil {
 				panic(panicErr) // 交给全局recovery处理
 			}
+			// 按时完成：把副本的响应与上下文数据提交回原请求
+			cp.Response.CopyTo(&ctx.Response)
+			cp.ForEachKey(func(k string, v interface{}) { ctx.Set(k, v) })
+			// 连接劫持（如WebSocket升级）注册在副本上，需转交原请求才会生效
+			if hijack := cp.GetHijackHandler(); hijack != nil {
+				ctx.Hijack(hijack)
+			}
+			ctx.Abort() // 后续处理器已在副本上执行过
 		}
 	}
 }
 
 // RateLimitMiddleware 令牌桶算法限流
-func RateLimitMiddleware(rate int, interval time.Duration) app.HandlerFunc {
+// 响应均带X-RateLimit-Limit/Remaining/Reset，被拒绝时另带Retry-After
+// skippers命中的请求不消耗令牌
+func RateLimitMiddleware(rate int, interval time.Duration, skippers ...Skipper) app.HandlerFunc {
 	limiter := NewTokenBucket(rate, interval)
 
 	return func(c context.Context, ctx *app.RequestContext) {
-		if !limiter.Allow() {
-			hlog.CtxInfof(c, "[RATE LIMIT] path=%s", ctx.Path())
-			ctx.AbortWithStatusJSON(429, map[string]interface{}{
-				"code":    429001,
-				"message": "too many requests",
-			})
+		if shouldSkip(skippers, ctx) {
+			ctx.Next(c)
+			return
+		}
+		if !admitRateLimited(c, ctx, limiter) {
 			return
 		}
 		ctx.Next(c)
 	}
 }
 
-// 令牌桶实现
+// RateLimiter 速率可热更新的限流器，更新时整体替换令牌桶，进行中的请求不受影响
+type RateLimiter struct {
+	bucket atomic.Pointer[TokenBucket]
+}
+
+func NewRateLimiter(rate int, interval time.Duration) *RateLimiter {
+	l := &RateLimiter{}
+	l.bucket.Store(NewTokenBucket(rate, interval))
+	return l
+}
+
+// Middleware 与RateLimitMiddleware行为一致，但每次请求读取当前令牌桶
+// skippers命中的请求（如健康探针）不消耗令牌
+func (l *RateLimiter) Middleware(skippers ...Skipper) app.HandlerFunc {
+	return func(c context.Context, ctx *app.RequestContext) {
+		if shouldSkip(skippers, ctx) {
+			ctx.Next(c)
+			return
+		}
+		if !admitRateLimited(c, ctx, l.bucket.Load()) {
+			return
+		}
+		ctx.Next(c)
+	}
+}
+
+// Update 按新速率重建令牌桶（初始装满）
+func (l *RateLimiter) Update(rate int, interval time.Duration) {
+	l.bucket.Store(NewTokenBucket(rate, interval))
+}
+
+// admitRateLimited 取令牌并写入限流响应头，无令牌时写入429并返回false
+// X-RateLimit-Reset为距下一个令牌补充的秒数，桶满时为0
+func admitRateLimited(c context.Context, ctx *app.RequestContext, limiter *TokenBucket) bool {
+	ok, remaining, reset := limiter.Take()
+	resetSeconds := strconv.Itoa(int(math.Ceil(reset.Seconds())))
+	ctx.Header("X-RateLimit-Limit", strconv.Itoa(limiter.Capacity()))
+	ctx.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	ctx.Header("X-RateLimit-Reset", resetSeconds)
+	if ok {
+		return true
+	}
+
+	ctx.Header("Retry-After", resetSeconds)
+	hlog.CtxInfof(c, "[RATE LIMIT] ip=%s path=%s", ctx.ClientIP(), ctx.Path())
+	ctx.AbortWithStatusJSON(429, map[string]interface{}{
+		"code":    429001,
+		"message": "too many requests",
+	})
+	return false
+}
+
+// SwappableHandler 可在运行时原子替换的中间件，用于配置热更新（如CORS）
+type SwappableHandler struct {
+	handler atomic.Pointer[app.HandlerFunc]
+}
+
+func NewSwappableHandler(h app.HandlerFunc) *SwappableHandler {
+	s := &SwappableHandler{}
+	s.Swap(h)
+	return s
+}
+
+// Swap 替换后续请求使用的处理器
+func (s *SwappableHandler) Swap(h app.HandlerFunc) {
+	s.handler.Store(&h)
+}
+
+// Handler 每次请求读取当前处理器
+func (s *SwappableHandler) Handler() app.HandlerFunc {
+	return func(c context.Context, ctx *app.RequestContext) {
+		(*s.handler.Load())(c, ctx)
+	}
+}
+
+// 令牌桶实现：容量为capacity，每interval补充一个令牌
+// 取令牌时按流逝时间补充，不依赖后台协程
 type TokenBucket struct {
 	capacity int
-	tokens   chan struct{}
-	rate     time.Duration
+	interval time.Duration
+	now      func() time.Time
+
+	mu     sync.Mutex
+	tokens int
+	last   time.Time // 最近一次补充令牌的时间
 }
 
+// NewTokenBucket 创建装满令牌的桶
+// 空桶启动时，进程刚启动或配置热更新替换限流器后的第一个周期内所有请求都会被拒绝（429），
+// 探针与重启后的首批请求首当其冲；装满后启动即可承受capacity个突发请求，之后按interval匀速补充
 func NewTokenBucket(rate int, interval time.Duration) *TokenBucket {
 	tb := &TokenBucket{
 		capacity: rate,
-		tokens:   make(chan struct{}, rate),
-		rate:     interval,
+		interval: interval,
+		now:      time.Now,
+		tokens:   rate,
 	}
-
-	// 定时器生产令牌
-	go func() {
-		ticker := time.NewTicker(tb.rate)
-		for range ticker.C {
-			select {
-			case tb.tokens <- struct{}{}:
-			default:
-			}
-		}
-	}()
+	tb.last = tb.now()
 	return tb
 }
 
+// Capacity 桶容量，即突发请求上限
+func (tb *TokenBucket) Capacity() int {
+	return tb.capacity
+}
+
 func (tb *TokenBucket) Allow() bool {
-	select {
-	case <-tb.tokens:
-		return true
-	default:
-		return false
+	ok, _, _ := tb.Take()
+	return ok
+}
+
+// Take 尝试取一个令牌，返回是否成功、剩余令牌数与距下一个令牌补充的时长（桶满时为0）
+func (tb *TokenBucket) Take() (bool, int, time.Duration) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	now := tb.now()
+	tb.refill(now)
+
+	ok := tb.tokens > 0
+	if ok {
+		tb.tokens--
+	}
+
+	var reset time.Duration
+	if tb.tokens < tb.capacity {
+		reset = tb.last.Add(tb.interval).Sub(now)
+	}
+	return ok, tb.tokens, reset
+}
+
+// refill 按上次补充以来经过的完整间隔数补充令牌；桶满时补充时钟从当前时间重新计
+func (tb *TokenBucket) refill(now time.Time) {
+	if tb.tokens >= tb.capacity || tb.interval <= 0 {
+		tb.tokens = tb.capacity
+		tb.last = now
+		return
+	}
+	n := int(now.Sub(tb.last) / tb.interval)
+	if n <= 0 {
+		return
+	}
+	tb.tokens = min(tb.capacity, tb.tokens+n)
+	tb.last = tb.last.Add(time.Duration(n) * tb.interval)
+	if tb.tokens == tb.capacity {
+		tb.last = now
 	}
 }
 
+// BodyLimits 请求体字节上限：Routes按路由模板（ctx.FullPath()，如/api/v1/items/:id）覆盖Default
+type BodyLimits struct {
+	Default int64
+	Routes  map[string]int64
+}
+
+// For 返回当前请求所匹配路由的上限，未单独配置时返回Default
+func (l BodyLimits) For(ctx *app.RequestContext) int64 {
+	if limit, ok := l.Routes[ctx.FullPath()]; ok {
+		return limit
+	}
+	return l.Default
+}
+
 // SecurityCheckMiddleware 全局安全校验中间件
-func SecurityCheckMiddleware(maxBodySize int64) app.HandlerFunc {
+// allowedMethods为允许的HTTP方法（取自SecurityConfig.AllowedMethods），为空时不限制；
+// CORS预检（OPTIONS）按其声明的Access-Control-Request-Method判断，放行后由CORS中间件应答
+// skipContent命中的请求只跳过恶意字符检查（如请求体格式由第三方决定的回调），其余校验照常；可为nil
+// skippers命中的请求不做任何校验，用于不带User-Agent的健康探针等
+func SecurityCheckMiddleware(bodyLimits BodyLimits, allowedMethods []string, skipContent Skipper, skippers ...Skipper) app.HandlerFunc {
+	methods := make(map[string]bool, len(allowedMethods))
+	for _, m := range allowedMethods {
+		methods[strings.ToUpper(strings.TrimSpace(m))] = true
+	}
+
 	// 预编译恶意字符正则
 	xssRegex := regexp.MustCompile(`<script.*?>|<\/script>|alert\(|onerror=`)
 	sqlInjectRegex := regexp.MustCompile(`\b(union|select|drop|delete|insert)\b`)
 
 	return func(c context.Context, ctx *app.RequestContext) {
+		if shouldSkip(skippers, ctx) {
+			ctx.Next(c)
+			return
+		}
+
 		// 防护机制1：检查User-Agent
 		if isInvalidUserAgent(ctx) {
 			securityResponse(ctx, 400001, "missing required header: User-Agent", 400)
 			return
 		}
 
-		// 防护机制2：请求体大小限制
-		// 修复：将 ContentLength() 的返回值转换为 int64
-		if int64(ctx.Request.Header.ContentLength()) > maxBodySize {
+		// 防护机制2：请求体大小限制，路由单独配置的上限优先于全局上限
+		if int64(ctx.Request.Header.ContentLength()) > bodyLimits.For(ctx) {
 			securityResponse(ctx, 413001, "request body exceeds max size", 413)
 			return
 		}
 
 		// 防护机制3：参数恶意字符检查
-		if hasMaliciousContent(ctx, xssRegex, sqlInjectRegex) {
+		if (skipContent == nil || !skipContent(ctx)) && hasMaliciousContent(ctx, xssRegex, sqlInjectRegex) {
 			securityResponse(ctx, 422001, "request contains invalid characters", 422)
 			return
 		}
 
 		// 防护机制4：检查HTTP方法
-		if !isAllowedMethod(ctx) {
+		if !isAllowedMethod(ctx, methods) {
 			securityResponse(ctx, 405001, "method not allowed", 405)
 			return
 		}
@@ -218,46 +429,137 @@ func SecurityCheckMiddleware(maxBodySize int64) app.HandlerFunc {
 	}
 }
 
-func JWTAuthMiddleware(cfg *config.JWTAuthConfig) app.HandlerFunc {
-	authMiddleware, err := jwth.New(&jwth.HertzJWTMiddleware{
-		Realm:            cfg.Issuer,
-		SigningAlgorithm: cfg.SigningMethod,
-		Key:              []byte(cfg.Secret),
-		Timeout:          cfg.ExpireDuration,
-		TimeFunc:         time.Now,
-		Authenticator:    authenticator, // TODO: 实际用户验证逻辑
-		IdentityKey:      "user_id",
-		Unauthorized:     handleJWTError,
-	})
+// SessionChecker 判断令牌关联的登录会话是否仍有效（未撤销、未过期）
+type SessionChecker func(c context.Context, userID, sessionID int64) (bool, error)
+
+// JWTAuthMiddleware 令牌校验，与登录接口共用同一JWTService
+// 请求头优先，开启Cookie认证时再回退到httpOnly Cookie
+// 传入checkSession时，携带sid声明的令牌须对应有效会话，撤销会话后其访问令牌立即失效；
+// 不含sid的旧令牌仍按签名与有效期校验
+func JWTAuthMiddleware(cfg *config.JWTAuthConfig, jwtService *auth.JWTService, checkSession ...SessionChecker) app.HandlerFunc {
+	return func(c context.Context, ctx *app.RequestContext) {
+		tokenStr := bearerToken(ctx)
+		if tokenStr == "" && cfg.CookieEnabled {
+			tokenStr = string(ctx.Cookie(cfg.CookieName))
+		}
+		if tokenStr == "" {
+			handleJWTError(c, ctx, 401, "missing token")
+			ctx.Abort()
+			return
+		}
+
+		claims, err := jwtService.Parse(tokenStr)
+		if err != nil {
+			handleJWTError(c, ctx, 401, err.Error())
+			ctx.Abort()
+			return
+		}
 
+		if len(checkSession) > 0 {
+			if msg := verifySession(c, claims, checkSession[0]); msg != "" {
+				handleJWTError(c, ctx, 401, msg)
+				ctx.Abort()
+				return
+			}
+		}
+
+		ctx.Set(auth.ClaimsKey, claims)
+		ctx.Next(c)
+	}
+}
+
+// verifySession 校验sid声明对应的会话，返回非空提示表示拒绝；查询失败时按拒绝处理
+func verifySession(c context.Context, claims map[string]interface{}, check SessionChecker) string {
+	sid, ok := claims["sid"].(float64)
+	if !ok {
+		return ""
+	}
+	userID, _ := claims["user_id"].(float64)
+
+	active, err := check(c, int64(userID), int64(sid))
 	if err != nil {
-		hlog.Fatal("JWT Middleware init failed: %v", err)
+		hlog.CtxErrorf(c, "session check failed: user=%v sid=%v err=%v", claims["user_id"], claims["sid"], err)
+		return "session check failed"
+	}
+	if !active {
+		return "session revoked or expired"
 	}
+	return ""
+}
 
-	return authMiddleware.MiddlewareFunc()
+// bearerToken 提取Authorization: Bearer <token>
+func bearerToken(ctx *app.RequestContext) string {
+	header := string(ctx.GetHeader("Authorization"))
+	if scheme, token, ok := strings.Cut(header, " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
 }
 
-func authenticator(ctx context.Context, c *app.RequestContext) (interface{}, error) {
-	var loginReq struct {
-		Username string `form:"username" binding:"required"`
-		Password string `form:"password" binding:"required"`
+// RequireRole 要求当前用户至少拥有其中一个角色（需挂载在JWT认证之后）
+func RequireRole(roles ...string) app.HandlerFunc {
+	required := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		required[role] = true
 	}
 
-	if err := c.BindAndValidate(&loginReq); err != nil {
-		return nil, jwth.ErrMissingLoginValues
+	return func(c context.Context, ctx *app.RequestContext) {
+		claims, _ := auth.ClaimsFromContext(ctx)
+		for _, role := range rolesFromClaims(claims) {
+			if required[role] {
+				ctx.Next(c)
+				return
+			}
+		}
+
+		hlog.CtxWarnf(c, "[ROLE DENIED] user=%v required=%v path=%s", claims["user_id"], roles, ctx.Path())
+		ctx.AbortWithStatusJSON(403, utils.H{
+			"code":    403001,
+			"message": "insufficient role",
+		})
 	}
+}
+
+// FreshAuthMiddleware 敏感操作要求近期完成过认证（需挂载在JWT认证之后）
+// 优先使用auth_time声明，缺失时退化为iat
+func FreshAuthMiddleware(window time.Duration) app.HandlerFunc {
+	return func(c context.Context, ctx *app.RequestContext) {
+		claims, _ := auth.ClaimsFromContext(ctx)
 
-	// 查询数据库验证用户，此处需要实际数据访问
-	// user, err := userRepository.FindByUsername(loginReq.Username)
-	if loginReq.Username != "admin" || loginReq.Password != "password" {
-		return nil, jwth.ErrFailedAuthentication
+		authTime, ok := claims["auth_time"].(float64)
+		if !ok {
+			authTime, ok = claims["iat"].(float64)
+		}
+		if !ok || time.Since(time.Unix(int64(authTime), 0)) > window {
+			hlog.CtxInfof(c, "[REAUTH REQUIRED] user=%v path=%s", claims["user_id"], ctx.Path())
+			ctx.AbortWithStatusJSON(401, utils.H{
+				"code":    401002,
+				"reason":  "reauth_required",
+				"message": "recent authentication required",
+			})
+			return
+		}
+		ctx.Next(c)
 	}
+}
 
-	// 返回用户身份标识
-	return map[string]interface{}{
-		"user_id":  1,
-		"username": loginReq.Username,
-	}, nil
+// rolesFromClaims 兼容JSON解码后的[]interface{}及直接写入的[]string
+func rolesFromClaims(claims map[string]interface{}) []string {
+	switch v := claims["roles"].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		roles := make([]string, 0, len(v))
+		for _, r := range v {
+			if role, ok := r.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+		return roles
+	case string:
+		return []string{v}
+	}
+	return nil
 }
 
 func handleJWTError(ctx context.Context, c *app.RequestContext, code int, message string) {
@@ -304,14 +606,16 @@ func hasMaliciousContent(ctx *app.RequestContext, xss *regexp.Regexp, sql *regex
 	return atomic.LoadInt32(&found) == 1
 }
 
-// 辅助方法：允许的HTTP方法检查
-func isAllowedMethod(ctx *app.RequestContext) bool {
-	allowed := map[string]bool{
-		"GET":  true,
-		"POST": true,
-		"PUT":  true,
+// 辅助方法：允许的HTTP方法检查，预检请求按其声明的实际方法判断
+func isAllowedMethod(ctx *app.RequestContext, allowed map[string]bool) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	method := string(ctx.Method())
+	if method == consts.MethodOptions {
+		method = string(ctx.Request.Header.Peek("Access-Control-Request-Method"))
 	}
-	return allowed[string(ctx.Method())]
+	return allowed[strings.ToUpper(method)]
 }
 
 // 安全响应统一处理
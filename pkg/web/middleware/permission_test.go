@@ -0,0 +1,58 @@
+package middleware_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/golang-jwt/jwt/v5"
+	"my-digital-home/pkg/common/auth"
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/web/middleware"
+)
+
+func TestRequirePermission(t *testing.T) {
+	authz := middleware.NewAuthorizer(config.AuthzConfig{RolePermissions: map[string][]string{
+		"support": {auth.PermUsersRead},
+	}})
+
+	// 以查询参数模拟认证中间件写入的身份
+	identify := func(c context.Context, ctx *app.RequestContext) {
+		if role := ctx.Query("role"); role != "" {
+			ctx.Set(auth.ClaimsKey, jwt.MapClaims{"user_id": float64(1), "roles": []interface{}{role}})
+		}
+		if scope := ctx.Query("scope"); scope != "" {
+			ctx.Set(auth.ServiceKey, auth.ServiceIdentity{Name: "billing", Scopes: []string{scope}})
+		}
+		ctx.Next(c)
+	}
+	ok := func(c context.Context, ctx *app.RequestContext) { ctx.Status(200) }
+	h := server.New()
+	h.GET("/read", identify, authz.RequirePermission(auth.PermUsersRead), ok)
+	h.GET("/write", identify, authz.RequirePermission(auth.PermUsersWrite), ok)
+	h.GET("/admin-write", identify, middleware.RequireRole("admin"), authz.RequirePermission(auth.PermUsersWrite), ok)
+
+	for _, tc := range []struct {
+		path   string
+		status int
+	}{
+		{"/read", 403},                     // 未认证
+		{"/read?role=user", 403},           // 默认映射中普通用户没有额外权限
+		{"/read?role=admin", 200},          // 默认映射
+		{"/read?role=support", 200},        // 配置新增的角色
+		{"/write?role=support", 403},       // 只授予了读权限
+		{"/read?scope=users:read", 200},    // API Key授权范围
+		{"/write?scope=users:*", 200},      // 资源通配
+		{"/write?scope=api_keys:*", 403},   // 通配不跨资源
+		{"/admin-write?scope=*", 403},      // 权限足够但角色不符
+		{"/admin-write?role=admin", 200},   // 角色与权限均满足
+		{"/admin-write?role=support", 403}, // 角色不符
+	} {
+		w := ut.PerformRequest(h.Engine, "GET", tc.path, nil)
+		if w.Result().StatusCode() != tc.status {
+			t.Errorf("%s: expected %d, got %d", tc.path, tc.status, w.Result().StatusCode())
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+)
+
+func TestTokenBucketRefillsLazily(t *testing.T) {
+	now := time.Unix(1000, 0)
+	tb := NewTokenBucket(2, time.Second)
+	tb.now = func() time.Time { return now }
+	tb.last = now
+
+	if ok, remaining, reset := tb.Take(); !ok || remaining != 1 || reset != time.Second {
+		t.Fatalf("Expected first take to leave 1 token with 1s reset, got %v %d %v", ok, remaining, reset)
+	}
+	now = now.Add(400 * time.Millisecond)
+	tb.Take()
+	if ok, remaining, reset := tb.Take(); ok || remaining != 0 || reset != 600*time.Millisecond {
+		t.Fatalf("Expected empty bucket refilling in 600ms, got %v %d %v", ok, remaining, reset)
+	}
+
+	// 经过2.5个间隔补满，多余的时间不累积
+	now = now.Add(2500 * time.Millisecond)
+	if ok, remaining, _ := tb.Take(); !ok || remaining != 1 {
+		t.Fatalf("Expected bucket to refill to capacity, got %v %d", ok, remaining)
+	}
+}
+
+func TestTokenBucketStartsFull(t *testing.T) {
+	tb := NewTokenBucket(3, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		if !tb.Allow() {
+			t.Fatalf("Expected request %d right after construction to pass", i+1)
+		}
+	}
+	if tb.Allow() {
+		t.Fatal("Expected the burst to be capped at the bucket capacity")
+	}
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	h := server.New()
+	h.GET("/ping", RateLimitMiddleware(1, time.Minute), func(c context.Context, ctx *app.RequestContext) {
+		ctx.String(200, "pong")
+	})
+
+	w := ut.PerformRequest(h.Engine, "GET", "/ping", nil)
+	header := &w.Result().Header
+	if w.Result().StatusCode() != 200 || string(header.Peek("X-RateLimit-Limit")) != "1" ||
+		string(header.Peek("X-RateLimit-Remaining")) != "0" || string(header.Peek("X-RateLimit-Reset")) != "60" {
+		t.Fatalf("Expected rate limit headers on success, got %d %s", w.Result().StatusCode(), header.Header())
+	}
+
+	w = ut.PerformRequest(h.Engine, "GET", "/ping", nil)
+	header = &w.Result().Header
+	if w.Result().StatusCode() != 429 || string(header.Peek("Retry-After")) != "60" ||
+		string(header.Peek("X-RateLimit-Remaining")) != "0" {
+		t.Fatalf("Expected 429 with Retry-After, got %d %s", w.Result().StatusCode(), header.Header())
+	}
+}
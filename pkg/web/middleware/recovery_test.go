@@ -0,0 +1,93 @@
+// pkg/web/middleware/recovery_test.go
+package middleware_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/web/middleware"
+)
+
+func TestRecoveryInvokesPanicHookInProduction(t *testing.T) {
+	cfg := config.Default()
+	cfg.Env = "production"
+
+	var reported interface{}
+	var reportedStack []byte
+	hook := func(ctx context.Context, err interface{}, stack []byte) {
+		reported, reportedStack = err, stack
+	}
+
+	h := server.New()
+	h.Use(middleware.RecoveryMiddleware(cfg, hook))
+	h.GET("/boom", func(c context.Context, ctx *app.RequestContext) {
+		panic("boom")
+	})
+
+	w := ut.PerformRequest(h.Engine, "GET", "/boom", nil)
+	if w.Result().StatusCode() != 500 {
+		t.Fatalf("Expected 500, got %d", w.Result().StatusCode())
+	}
+	if reported != "boom" || len(reportedStack) == 0 {
+		t.Fatalf("Expected hook to receive panic and stack, got %v", reported)
+	}
+	// 生产环境不向客户端暴露堆栈
+	if strings.Contains(string(w.Result().Body()), "stack") {
+		t.Fatalf("Expected no stack in production response, got %s", w.Result().Body())
+	}
+}
+
+func TestRecoverySurvivesFailingHook(t *testing.T) {
+	h := server.New()
+	h.Use(middleware.RecoveryMiddleware(config.Default(), func(context.Context, interface{}, []byte) {
+		panic("reporter down")
+	}))
+	h.GET("/boom", func(c context.Context, ctx *app.RequestContext) {
+		panic("boom")
+	})
+
+	if w := ut.PerformRequest(h.Engine, "GET", "/boom", nil); w.Result().StatusCode() != 500 {
+		t.Fatalf("Expected 500 despite hook failure, got %d", w.Result().StatusCode())
+	}
+}
+
+func TestRecoveryRedactsSecrets(t *testing.T) {
+	var reported interface{}
+	var reportedStack []byte
+	h := server.New()
+	h.Use(middleware.RecoveryMiddleware(config.Default(), func(_ context.Context, err interface{}, stack []byte) {
+		reported, reportedStack = err, stack
+	}))
+	h.GET("/boom", func(c context.Context, ctx *app.RequestContext) {
+		panic("connect root:hunter2@tcp(db:3306) failed, token=eyJhbGciOi, Authorization: Bearer abc.def")
+	})
+
+	w := ut.PerformRequest(h.Engine, "GET", "/boom", nil)
+	body := string(w.Result().Body())
+	for _, secret := range []string{"hunter2", "eyJhbGciOi", "abc.def"} {
+		if strings.Contains(body, secret) {
+			t.Fatalf("Expected %q to be redacted, got %s", secret, body)
+		}
+	}
+	if !strings.Contains(body, "root:***@tcp") || !strings.Contains(body, "token=***") {
+		t.Fatalf("Expected redaction markers in response, got %s", body)
+	}
+	if len(reportedStack) == 0 {
+		t.Fatal("Expected hook to receive the redacted stack")
+	}
+	// 上报回调拿到的panic信息同样脱敏
+	msg, _ := reported.(string)
+	for _, secret := range []string{"hunter2", "eyJhbGciOi", "abc.def"} {
+		if strings.Contains(msg, secret) {
+			t.Fatalf("Expected %q to be redacted before reaching the hook, got %v", secret, reported)
+		}
+	}
+	if !strings.Contains(msg, "root:***@tcp") {
+		t.Fatalf("Expected the hook to receive the redacted message, got %v", reported)
+	}
+}
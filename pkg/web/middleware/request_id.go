@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"my-digital-home/pkg/common/requestid"
+)
+
+// RequestIDMiddleware 为每个请求确定请求ID：采信上游代理传入的合法X-Request-ID，否则生成新ID
+// 请求ID写入响应头，并记录在传给后续处理器的上下文中，供访问日志、数据库日志与出站请求使用
+func RequestIDMiddleware() app.HandlerFunc {
+	return func(c context.Context, ctx *app.RequestContext) {
+		id := string(ctx.GetHeader(requestid.Header))
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		ctx.Response.Header.Set(requestid.Header, id)
+		ctx.Next(requestid.NewContext(c, id))
+	}
+}
@@ -0,0 +1,31 @@
+package middleware_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"my-digital-home/pkg/common/requestid"
+	"my-digital-home/pkg/web/middleware"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	h := server.New()
+	h.Use(middleware.RequestIDMiddleware())
+	h.GET("/ping", func(c context.Context, ctx *app.RequestContext) {
+		ctx.String(200, requestid.FromContext(c))
+	})
+
+	w := ut.PerformRequest(h.Engine, "GET", "/ping", nil, ut.Header{Key: "X-Request-ID", Value: "upstream-1"})
+	if got := string(w.Result().Body()); got != "upstream-1" || string(w.Result().Header.Peek("X-Request-ID")) != got {
+		t.Fatalf("Expected upstream request id to be kept, got body %q header %q", got, w.Result().Header.Peek("X-Request-ID"))
+	}
+
+	w = ut.PerformRequest(h.Engine, "GET", "/ping", nil, ut.Header{Key: "X-Request-ID", Value: "bad id"})
+	got := string(w.Result().Body())
+	if got == "bad id" || len(got) != 32 || string(w.Result().Header.Peek("X-Request-ID")) != got {
+		t.Fatalf("Expected a generated request id for an invalid header, got body %q", got)
+	}
+}
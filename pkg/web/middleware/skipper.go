@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// Skipper 返回true时中间件不处理该请求，直接执行后续处理器
+// 全局中间件通过构造函数的skippers参数安装，只影响该中间件本身，不影响注册在它前后的其他中间件
+type Skipper func(ctx *app.RequestContext) bool
+
+// SkipPaths 按路径前缀跳过：路径等于前缀或位于其下（前缀后紧跟"/"）时跳过，
+// 如"/health"匹配/health与/health/db，不匹配/healthz；以"/"结尾的前缀按普通前缀匹配
+func SkipPaths(prefixes ...string) Skipper {
+	var cleaned []string
+	for _, p := range prefixes {
+		if p != "" {
+			cleaned = append(cleaned, p)
+		}
+	}
+	return func(ctx *app.RequestContext) bool {
+		path := string(ctx.Path())
+		for _, prefix := range cleaned {
+			if matchPathPrefix(path, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func matchPathPrefix(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
+
+// shouldSkip 任一Skipper返回true即跳过，nil项忽略
+func shouldSkip(skippers []Skipper, ctx *app.RequestContext) bool {
+	for _, skip := range skippers {
+		if skip != nil && skip(ctx) {
+			return true
+		}
+	}
+	return false
+}
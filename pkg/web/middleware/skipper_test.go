@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+func TestSkipPaths(t *testing.T) {
+	skip := SkipPaths("/health", "/webhooks/", "")
+	cases := map[string]bool{
+		"/health":          true,
+		"/health/db":       true,
+		"/healthz":         false,
+		"/webhooks/stripe": true,
+		"/webhooks":        false,
+		"/api/v1/users":    false,
+		"/":                false,
+	}
+	for path, want := range cases {
+		ctx := app.NewContext(0)
+		ctx.Request.SetRequestURI(path)
+		if got := skip(ctx); got != want {
+			t.Errorf("SkipPaths(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	ctx := app.NewContext(0)
+	ctx.Request.SetRequestURI("/health")
+	if shouldSkip([]Skipper{nil, SkipPaths()}, ctx) {
+		t.Error("Expected nil and empty skippers not to skip")
+	}
+}
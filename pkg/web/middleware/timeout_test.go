@@ -0,0 +1,106 @@
+// pkg/web/middleware/timeout_test.go
+package middleware_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/network"
+	"my-digital-home/pkg/web/middleware"
+)
+
+func TestTimeoutDiscardsLateHandlerWrite(t *testing.T) {
+	handlerDone := make(chan error, 1)
+
+	h := server.New()
+	h.Use(middleware.TimeoutMiddleware(1))
+	h.GET("/slow", func(c context.Context, ctx *app.RequestContext) {
+		<-c.Done() // 模拟尊重取消的数据库操作
+		ctx.JSON(200, map[string]string{"status": "late"})
+		handlerDone <- c.Err()
+	})
+
+	w := ut.PerformRequest(h.Engine, "GET", "/slow", nil)
+	if w.Result().StatusCode() != 503 {
+		t.Fatalf("Expected 503, got %d", w.Result().StatusCode())
+	}
+
+	select {
+	case err := <-handlerDone:
+		if err != context.DeadlineExceeded {
+			t.Fatalf("Expected handler to observe deadline, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected handler to be cancelled")
+	}
+
+	// 处理器的迟到写入不应覆盖已发出的503
+	if w.Result().StatusCode() != 503 {
+		t.Fatalf("Expected late write to be discarded, got %d", w.Result().StatusCode())
+	}
+}
+
+func TestTimeoutCommitsResponseOnTime(t *testing.T) {
+	var outcome interface{}
+
+	h := server.New()
+	// 超时中间件之前的中间件（如日志）应能看到处理器的结果
+	h.Use(func(c context.Context, ctx *app.RequestContext) {
+		ctx.Next(c)
+		outcome, _ = ctx.Get("outcome")
+	})
+	h.Use(middleware.TimeoutMiddleware(1))
+	h.GET("/fast", func(c context.Context, ctx *app.RequestContext) {
+		ctx.Set("outcome", "ok")
+		ctx.JSON(201, map[string]string{"status": "ok"})
+	})
+
+	w := ut.PerformRequest(h.Engine, "GET", "/fast", nil)
+	if w.Result().StatusCode() != 201 || string(w.Result().Body()) != `{"status":"ok"}` {
+		t.Fatalf("Expected handler response, got %d %s", w.Result().StatusCode(), w.Result().Body())
+	}
+	if outcome != "ok" {
+		t.Fatalf("Expected context keys to be committed, got %v", outcome)
+	}
+}
+
+func TestTimeoutPreservesHijack(t *testing.T) {
+	var hijacked bool
+
+	h := server.New()
+	h.Use(func(c context.Context, ctx *app.RequestContext) {
+		ctx.Next(c)
+		hijacked = ctx.Hijacked()
+	}, middleware.TimeoutMiddleware(1))
+	h.GET("/ws", func(c context.Context, ctx *app.RequestContext) {
+		ctx.Hijack(func(conn network.Conn) {})
+	})
+
+	ut.PerformRequest(h.Engine, "GET", "/ws", nil)
+	if !hijacked {
+		t.Fatal("Expected hijack handler registered behind the timeout to reach the original request")
+	}
+}
+
+func TestTimeoutSkipsExemptPaths(t *testing.T) {
+	deadlines := map[string]bool{}
+
+	h := server.New()
+	h.Use(middleware.TimeoutMiddleware(1, middleware.SkipPaths("/stream")))
+	record := func(c context.Context, ctx *app.RequestContext) {
+		_, ok := c.Deadline()
+		deadlines[string(ctx.Path())] = ok
+	}
+	h.GET("/stream", record)
+	h.GET("/api", record)
+
+	ut.PerformRequest(h.Engine, "GET", "/stream", nil)
+	ut.PerformRequest(h.Engine, "GET", "/api", nil)
+	if deadlines["/stream"] || !deadlines["/api"] {
+		t.Fatalf("Expected only non-exempt paths to get a deadline, got %v", deadlines)
+	}
+}
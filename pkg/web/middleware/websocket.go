@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"my-digital-home/pkg/web/ws"
+)
+
+// WebSocketTokenMiddleware 浏览器的WebSocket API无法设置请求头，握手时的令牌可放在
+// access_token查询参数或子协议（"bearer, <token>"）中；此处转为Authorization头，须挂在JWT认证之前
+func WebSocketTokenMiddleware() app.HandlerFunc {
+	return func(c context.Context, ctx *app.RequestContext) {
+		if len(ctx.GetHeader("Authorization")) == 0 {
+			if token := webSocketToken(ctx); token != "" {
+				ctx.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		ctx.Next(c)
+	}
+}
+
+func webSocketToken(ctx *app.RequestContext) string {
+	if token := ctx.Query("access_token"); token != "" {
+		return token
+	}
+	protocols := strings.Split(string(ctx.GetHeader("Sec-WebSocket-Protocol")), ",")
+	for i := 0; i+1 < len(protocols); i++ {
+		if strings.TrimSpace(protocols[i]) == ws.BearerSubprotocol {
+			return strings.TrimSpace(protocols[i+1])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package middleware_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"my-digital-home/pkg/web/middleware"
+)
+
+func TestWebSocketTokenMiddlewareMovesTokenToHeader(t *testing.T) {
+	var got string
+	h := server.New()
+	h.GET("/ws", middleware.WebSocketTokenMiddleware(), func(c context.Context, ctx *app.RequestContext) {
+		got = string(ctx.GetHeader("Authorization"))
+	})
+
+	cases := []struct {
+		name    string
+		url     string
+		headers []ut.Header
+		want    string
+	}{
+		{"query", "/ws?access_token=abc", nil, "Bearer abc"},
+		{"subprotocol", "/ws", []ut.Header{{Key: "Sec-WebSocket-Protocol", Value: "bearer, abc"}}, "Bearer abc"},
+		{"header wins", "/ws?access_token=abc", []ut.Header{{Key: "Authorization", Value: "Bearer xyz"}}, "Bearer xyz"},
+		{"none", "/ws", []ut.Header{{Key: "Sec-WebSocket-Protocol", Value: "chat"}}, ""},
+	}
+	for _, tc := range cases {
+		got = ""
+		ut.PerformRequest(h.Engine, "GET", tc.url, nil, tc.headers...)
+		if got != tc.want {
+			t.Errorf("%s: expected Authorization %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
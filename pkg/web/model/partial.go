@@ -0,0 +1,58 @@
+package model
+
+// 批量操作中单项的处理状态
+const (
+	ItemSucceeded = "succeeded"
+	ItemWarning   = "warning" // 处理成功但附带告警（如字段被规范化）
+	ItemFailed    = "failed"
+)
+
+type (
+	// ItemResult 批量操作中单项的处理结果
+	ItemResult struct {
+		Index   int    `json:"index"`         // 请求数组中的下标
+		Key     string `json:"key,omitempty"` // 业务标识，如用户名
+		Status  string `json:"status"`
+		Code    int    `json:"code,omitempty"` // 失败/告警时的业务错误码
+		Message string `json:"message,omitempty"`
+	}
+
+	// PartialResult 批量操作的部分成功响应体
+	PartialResult struct {
+		Total     int          `json:"total"`
+		Succeeded int          `json:"succeeded"` // 含带告警的成功项
+		Warnings  int          `json:"warnings"`
+		Failed    int          `json:"failed"`
+		Items     []ItemResult `json:"items"`
+	}
+)
+
+// Add 追加单项结果并更新汇总计数
+func (r *PartialResult) Add(item ItemResult) {
+	r.Items = append(r.Items, item)
+	r.Total++
+	switch item.Status {
+	case ItemSucceeded:
+		r.Succeeded++
+	case ItemWarning:
+		r.Succeeded++
+		r.Warnings++
+	default:
+		r.Failed++
+	}
+}
+
+// HTTPStatus 根据汇总结果选择状态码：全部成功200，全部失败422，部分成功207（Multi-Status）
+// useMultiStatus为false时部分成功也返回200，兼容不识别207的客户端
+func (r *PartialResult) HTTPStatus(useMultiStatus bool) int {
+	switch {
+	case r.Failed == 0:
+		return 200
+	case r.Succeeded == 0:
+		return 422
+	case useMultiStatus:
+		return 207
+	default:
+		return 200
+	}
+}
@@ -0,0 +1,43 @@
+package model
+
+import "testing"
+
+func TestPartialResultMixedBatch(t *testing.T) {
+	var result PartialResult
+	result.Add(ItemResult{Index: 0, Key: "alice", Status: ItemSucceeded})
+	result.Add(ItemResult{Index: 1, Key: "bob", Status: ItemFailed, Code: 409001, Message: "用户名已存在"})
+	result.Add(ItemResult{Index: 2, Key: "Carol", Status: ItemWarning, Message: "用户名已转换为小写"})
+
+	if result.Total != 3 || result.Succeeded != 2 || result.Warnings != 1 || result.Failed != 1 {
+		t.Fatalf("Unexpected counts: %+v", result)
+	}
+
+	wantStatus := []string{ItemSucceeded, ItemFailed, ItemWarning}
+	for i, item := range result.Items {
+		if item.Index != i || item.Status != wantStatus[i] {
+			t.Fatalf("Item %d: expected status %s, got %+v", i, wantStatus[i], item)
+		}
+	}
+
+	if got := result.HTTPStatus(true); got != 207 {
+		t.Fatalf("Expected 207 for mixed batch, got %d", got)
+	}
+	if got := result.HTTPStatus(false); got != 200 {
+		t.Fatalf("Expected 200 when multi-status disabled, got %d", got)
+	}
+}
+
+func TestPartialResultStatusCodes(t *testing.T) {
+	var allOK PartialResult
+	allOK.Add(ItemResult{Status: ItemSucceeded})
+	if got := allOK.HTTPStatus(true); got != 200 {
+		t.Fatalf("Expected 200 when all succeeded, got %d", got)
+	}
+
+	var allFailed PartialResult
+	allFailed.Add(ItemResult{Status: ItemFailed})
+	allFailed.Add(ItemResult{Status: ItemFailed})
+	if got := allFailed.HTTPStatus(true); got != 422 {
+		t.Fatalf("Expected 422 when all failed, got %d", got)
+	}
+}
@@ -0,0 +1,10 @@
+package model
+
+// Response 统一响应结构，HTTP状态码表达大类，Code为稳定的业务码
+type Response[T any] struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    T      `json:"data,omitempty"`
+	// 参数校验失败时各字段的错误提示（json字段名 -> 提示），其余情况省略
+	Fields map[string]string `json:"fields,omitempty"`
+}
@@ -1,5 +1,10 @@
 package model
 
+import (
+	"my-digital-home/pkg/common/pagination"
+	"time"
+)
+
 // 请求/响应数据结构
 type (
 	RegisterReq struct {
@@ -8,9 +13,21 @@ type (
 		Password string `json:"password" binding:"required"`
 	}
 
+	// 修改资料，只更新给出的字段
+	UpdateProfileReq struct {
+		Username *string `json:"username" binding:"omitempty,min=4,max=20"`
+		Email    *string `json:"email" binding:"omitempty,email"`
+	}
+
 	LoginReq struct {
-		Username string `json:"username" binding:"required"`
+		Username string `json:"username" binding:"required"` // 用户名或邮箱
 		Password string `json:"password" binding:"required"`
+		Device   string `json:"device" binding:"max=100"` // 可选，会话列表中展示的设备名
+	}
+
+	// 以刷新令牌换取新的访问令牌，刷新令牌同时轮换
+	RefreshReq struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
 	}
 
 	ChangePwdReq struct {
@@ -18,10 +35,140 @@ type (
 		NewPassword string `json:"new_password" binding:"required"`
 	}
 
+	VerifyEmailReq struct {
+		Token string `query:"token"`
+	}
+
+	ResendVerificationReq struct {
+		Email string `json:"email"`
+	}
+
+	ForgotPasswordReq struct {
+		Email string `json:"email"`
+	}
+
+	ResetPasswordReq struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required"`
+	}
+
+	LoginRes struct {
+		Token        string   `json:"token"`
+		RefreshToken string   `json:"refresh_token,omitempty"` // 未启用会话存储时为空
+		SessionID    int64    `json:"session_id,omitempty"`
+		UserID       int64    `json:"user_id"`
+		Username     string   `json:"username"`
+		Roles        []string `json:"roles"`
+	}
+
+	// 登录会话（不含刷新令牌）
+	SessionRes struct {
+		ID         int64     `json:"id"`
+		Device     string    `json:"device"`
+		UserAgent  string    `json:"user_agent"`
+		IP         string    `json:"ip"`
+		CreatedAt  time.Time `json:"created_at"`
+		LastUsedAt time.Time `json:"last_used_at"`
+		ExpiresAt  time.Time `json:"expires_at"`
+		Current    bool      `json:"current"` // 是否为发起本次请求的会话
+	}
+
+	RevokeSessionsRes struct {
+		Revoked int64 `json:"revoked"`
+	}
+
+	// 用户资料（不含密码哈希与版本号）
 	UserRes struct {
-		ID       uint   `json:"id"`
-		Username string `json:"username"`
-		Email    string `json:"email"`
+		ID            uint       `json:"id"`
+		Username      string     `json:"username"`
+		Email         string     `json:"email"`
+		EmailVerified bool       `json:"email_verified"`
+		Roles         []string   `json:"roles"`
+		CreatedAt     time.Time  `json:"created_at"`
+		UpdatedAt     time.Time  `json:"updated_at"`
+		LastLoginAt   *time.Time `json:"last_login_at"` // 从未登录时为null
+		AvatarURL     string     `json:"avatar_url"`    // 未上传头像时为空串
+	}
+
+	AvatarRes struct {
+		AvatarURL string `json:"avatar_url"`
+	}
+
+	// 批量导入的单个用户：提供明文密码或旧系统导出的bcrypt哈希之一
+	ImportUserReq struct {
+		Username     string   `json:"username"`
+		Email        string   `json:"email"`
+		Password     string   `json:"password,omitempty"`
+		PasswordHash string   `json:"password_hash,omitempty"`
+		Roles        []string `json:"roles,omitempty"` // 为空时为普通用户
+	}
+
+	// 管理后台统计
+	StatsRes struct {
+		ActiveUsers int64 `json:"active_users"`
+	}
+
+	// 维护模式开关
+	MaintenanceReq struct {
+		Enabled *bool `json:"enabled"`
+	}
+
+	MaintenanceRes struct {
+		Enabled bool `json:"enabled"`
+	}
+
+	DBLogLevelReq struct {
+		Level string `json:"level" binding:"required"` // silent/error/warn/info
+	}
+
+	DBLogLevelRes struct {
+		Level string `json:"level"`
+	}
+
+	// 管理员用户列表查询参数
+	ListUsersReq struct {
+		Page     int    `query:"page"`
+		PageSize int    `query:"page_size"`
+		Username string `query:"username"` // 用户名前缀
+		IsActive *bool  `query:"is_active"`
+		Mode     string `query:"mode"`   // offset（默认）或keyset；keyset且无cursor时返回游标分页的第一页
+		Cursor   string `query:"cursor"` // 非空时使用游标分页，忽略page
+	}
+
+	// 用户名前缀搜索（输入联想）
+	SearchUsersReq struct {
+		Q     string `query:"q"`
+		Limit int    `query:"limit"`
+	}
+
+	ListUsersRes struct {
+		Items []UserRes `json:"items"`
+		pagination.Meta
+	}
+
+	// 创建服务API Key
+	CreateAPIKeyReq struct {
+		Name   string   `json:"name" binding:"required"` // 调用方服务名
+		Scopes []string `json:"scopes"`
+	}
+
+	// API Key（不含明文与哈希）
+	APIKeyRes struct {
+		ID         int64      `json:"id"`
+		Name       string     `json:"name"`
+		Prefix     string     `json:"prefix"` // 明文开头几位，用于辨认
+		Scopes     []string   `json:"scopes"`
+		Active     bool       `json:"active"`
+		CreatedBy  int64      `json:"created_by"`
+		CreatedAt  time.Time  `json:"created_at"`
+		LastUsedAt *time.Time `json:"last_used_at"`
+		RevokedAt  *time.Time `json:"revoked_at"`
+	}
+
+	// 创建结果，Key明文只在此返回一次
+	CreatedAPIKeyRes struct {
+		APIKeyRes
+		Key string `json:"key"`
 	}
 )
 
@@ -1,46 +1,466 @@
 package router
 
 import (
+	"context"
+	"errors"
+	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+	"my-digital-home/pkg/common/auth"
 	"my-digital-home/pkg/common/config"
+	"my-digital-home/pkg/common/httpclient"
+	"my-digital-home/pkg/common/mail"
+	"my-digital-home/pkg/common/metrics"
+	"my-digital-home/pkg/common/oauth"
+	"my-digital-home/pkg/common/pwned"
+	"my-digital-home/pkg/common/storage"
+	dao_model "my-digital-home/pkg/core/user/model"
+	dao "my-digital-home/pkg/core/user/repository/dao/impl"
+	"my-digital-home/pkg/core/user/service"
+	"my-digital-home/pkg/web/debug"
+	"my-digital-home/pkg/web/docs"
 	"my-digital-home/pkg/web/handler"
 	"my-digital-home/pkg/web/middleware"
+	"my-digital-home/pkg/web/ws"
+	"reflect"
+	"strings"
+	"time"
 )
 
-// RegisterAPIs 注册所有API路由
-func RegisterAPIs(h *server.Hertz, cfg *config.Config) {
-	// 初始化Handler实例
-	healthHandler := handler.NewHealthCheckHandler()
-	userHandler := handler.NewUserHandler(cfg)
+// maintenanceTogglePath 维护模式开关接口，维护期间需保持可访问
+const maintenanceTogglePath = "/api/v1/admin/maintenance"
+
+// loginPath 登录接口；维护期间管理员需先登录才能关闭维护模式
+const loginPath = "/api/v1/users/login"
+
+// healthStreamPath 健康状态事件流，长连接推送，不受请求超时限制
+const healthStreamPath = "/events/health"
+
+// importUsersPath 批量导入用户，逐行哈希明文密码，耗时可能超过请求超时
+const importUsersPath = "/api/v1/admin/users/import"
+
+// webhookPathPrefix 第三方回调，以签名而非令牌或Cookie认证
+const webhookPathPrefix = "/webhooks/"
+
+// 健康检查与存活、就绪探针
+const (
+	healthPath = "/health"
+	livezPath  = "/livez"
+	readyzPath = "/readyz"
+)
+
+// APIs RegisterAPIs创建的、注册完路由后仍需由调用方使用的组件
+type APIs struct {
+	// Health 健康检查；停机信号到达时由调用方标记，先让就绪探针失败再关闭连接
+	Health *handler.HealthCheckHandler
+	// Webhooks 第三方回调分发，各功能在h.Spin前经OnEvent接入事件处理；经RegisterBaseAPIs注册时为nil
+	Webhooks *handler.WebhookHandler
+}
+
+// RegisterAPIsWithDefaults 使用默认配置、不连接数据库注册路由，仅基础接口可用（用于测试）
+func RegisterAPIsWithDefaults(h *server.Hertz) {
+	RegisterBaseAPIs(h, nil, config.NewReloader(config.Default()))
+}
+
+// RegisterBaseAPIs 只注册不依赖数据库的基础接口：探针、指标、接口文档与静态文件（用于测试）
+// Webhooks为nil，/api/v1下的业务接口一律不存在
+func RegisterBaseAPIs(h, admin *server.Hertz, reloader *config.Reloader) *APIs {
+	return registerAPIs(h, admin, reloader, nil, nil)
+}
+
+// RegisterAPIs 注册所有API路由；db为nil时拒绝启动，避免业务接口整体缺失却只表现为404
+// admin非nil时探针、指标与配置导出注册在admin（内部运维端口），否则与业务接口共用h
+// 限流、CORS与维护模式随reloader热更新，其余配置取注册时的快照
+// dbGate非nil表示降级启动：数据库就绪前业务接口返回503、就绪探针失败
+// replicas为只读副本，可容忍复制延迟的用户查询路由到副本
+func RegisterAPIs(h, admin *server.Hertz, reloader *config.Reloader, db *gorm.DB, dbGate *middleware.DependencyGate, replicas ...*gorm.DB) *APIs {
+	if db == nil {
+		hlog.Fatalf("RegisterAPIs: database is nil, business routes cannot be registered")
+	}
+	return registerAPIs(h, admin, reloader, db, dbGate, replicas...)
+}
+
+// registerAPIs db为nil时注册完基础接口即返回
+func registerAPIs(h, admin *server.Hertz, reloader *config.Reloader, db *gorm.DB, dbGate *middleware.DependencyGate, replicas ...*gorm.DB) *APIs {
+	cfg := reloader.Current()
+	healthHandler := handler.NewHealthCheckHandler(db, externalChecks(cfg)...)
+	apis := &APIs{Health: healthHandler}
+	if dbGate != nil {
+		healthHandler.RequireReady("database_init", dbGate.Ready)
+	}
+	// 目前没有功能依赖Redis，不作为核心组件；限流、会话等迁移到Redis后应改为核心
+	if cfg.Redis.Addr != "" {
+		healthHandler.AddRedisCheck(cfg.Redis, false)
+	}
+	maintenance := middleware.NewMaintenance(cfg.Maintenance.Enabled, cfg.Maintenance.RetryAfter)
+	rateLimiter := middleware.NewRateLimiter(cfg.Middleware.RateLimit.Rate, cfg.Middleware.RateLimit.Interval)
+	cors := middleware.NewSwappableHandler(middleware.CORSMiddleware(cfg.Middleware.CORS))
+
+	reloader.OnReload(func(old, updated *config.Config) {
+		if updated.Middleware.RateLimit != old.Middleware.RateLimit {
+			rateLimiter.Update(updated.Middleware.RateLimit.Rate, updated.Middleware.RateLimit.Interval)
+		}
+		if !reflect.DeepEqual(updated.Middleware.CORS, old.Middleware.CORS) {
+			cors.Swap(middleware.CORSMiddleware(updated.Middleware.CORS))
+		}
+		// 仅在配置值变化时覆盖，避免重载抹掉管理员接口的手动切换
+		if updated.Maintenance.Enabled != old.Maintenance.Enabled {
+			maintenance.SetEnabled(updated.Maintenance.Enabled)
+		}
+	})
+
+	// 仅采信可信代理转发的来源地址，ctx.ClientIP()在日志、限流等处保持一致
+	h.SetClientIPFunc(middleware.NewClientIPFunc(cfg.Middleware.Security.TrustedProxies))
+
+	// 运维接口与业务端口共用时，不受安全校验（探针通常不带User-Agent）与限流影响，保证总能到达处理器
+	skipOps := middleware.SkipPaths(healthPath, livezPath, readyzPath, cfg.Middleware.Metrics.Path)
+	// 回调请求体由接入方决定，只跳过恶意字符检查（真实性由签名保证），请求体大小、方法与User-Agent照常校验
+	skipWebhooks := middleware.SkipPaths(webhookPathPrefix)
+	skip := cfg.Middleware.SkipPaths
+	bodyLimits := middleware.BodyLimits{
+		Default: cfg.Middleware.Security.MaxBodySize,
+		Routes:  cfg.Middleware.Security.RouteBodyLimits,
+	}
 
 	// 注册全局中间件（按执行顺序）
+	// 跳过只对所在的中间件生效：恢复、IP过滤、访问日志与维护模式（另有豁免列表）对所有请求生效，
+	// 路由组上的认证等中间件也不受影响
 	h.Use(
 		middleware.RecoveryMiddleware(cfg),
+		middleware.RequestIDMiddleware(),
+		middleware.IPFilterMiddleware(cfg.Middleware.IPFilter, cfg.Middleware.Security.TrustedProxies),
 		middleware.LoggerMiddleware(),
-		middleware.SecurityCheckMiddleware(cfg.Middleware.Security.MaxBodySize),
-		middleware.TimeoutMiddleware(cfg.Middleware.Timeout.RequestTimeout),
-		middleware.CORSMiddleware(cfg.Middleware.CORS),
-		middleware.RateLimitMiddleware(
-			cfg.Middleware.RateLimit.Rate,
-			cfg.Middleware.RateLimit.Interval,
-		),
+		// 维护期间探针、登录与开关接口仍需可用；就绪探针照常反映依赖状态，避免实例被摘除后无法恢复
+		maintenance.Middleware(livezPath, readyzPath, healthPath, loginPath, maintenanceTogglePath),
+		middleware.SecurityCheckMiddleware(bodyLimits, cfg.Middleware.Security.AllowedMethods, skipWebhooks, skipOps, middleware.SkipPaths(skip.Security...)),
+		middleware.TimeoutMiddleware(cfg.Middleware.Timeout.RequestTimeout, middleware.SkipPaths(healthStreamPath, importUsersPath), middleware.SkipPaths(skip.Timeout...)),
+		cors.Handler(),
+		rateLimiter.Middleware(skipOps, middleware.SkipPaths(skip.RateLimit...)),
 	)
 
+	// 指标采集
+	if cfg.Middleware.Metrics.Enabled {
+		h.Use(middleware.MetricsMiddleware(cfg.Middleware.Metrics))
+	}
+
+	// 调试用请求/响应体日志，默认关闭；含明文密码或明文API Key的接口默认不记录
+	if cfg.Debug.LogBodies {
+		h.Use(middleware.BodyLogMiddleware(cfg.Debug,
+			loginPath,
+			"/api/v1/users/register",
+			"/api/v1/users/password",
+			"/api/v1/users/reset-password",
+			"/api/v1/admin/users/import",
+			"/api/v1/admin/api-keys",
+		))
+	}
+
+	// 令牌放在Cookie中时启用CSRF防护
+	if cfg.Middleware.CSRF.Enabled {
+		h.Use(middleware.CSRFMiddleware(cfg.Middleware.CSRF, skipWebhooks))
+	}
+
+	// 运维接口所在实例：独立端口只挂异常捕获与访问日志，不受业务端口的限流、CORS等影响
+	ops := h
+	if admin != nil {
+		ops = admin
+		ops.SetClientIPFunc(middleware.NewClientIPFunc(cfg.Middleware.Security.TrustedProxies))
+		ops.Use(middleware.RecoveryMiddleware(cfg), middleware.LoggerMiddleware())
+	}
+
 	// 基础接口组
-	h.GET("/health", healthHandler.AdvancedHealthCheck)
+	ops.GET(healthPath, healthHandler.AdvancedHealthCheck)
+	ops.GET(livezPath, healthHandler.Livez)
+	ops.GET(readyzPath, healthHandler.Readyz)
+
+	if cfg.Middleware.Metrics.Enabled {
+		ops.GET(cfg.Middleware.Metrics.Path, middleware.MetricsHandler())
+	}
+
+	// 接口文档
+	h.GET("/openapi.json", docs.OpenAPIHandler)
+	h.GET("/docs", docs.SwaggerUIHandler)
+
+	// 前端静态文件：只处理未匹配任何路由的请求，接口与探针始终优先
+	if cfg.Static.Dir != "" {
+		h.NoRoute(handler.NewStaticHandler(cfg.Static))
+	}
+
+	if db == nil {
+		return apis
+	}
+
+	// 显式构建依赖并注入Handler，不依赖包级单例
+	userRepo := dao.NewUserRepository(db, replicas...)
+	mailer := mail.NewSender(cfg.Mail)
+	// 调用外部服务（对象存储、OAuth等）共用的HTTP客户端，统一超时与重试
+	outbound := httpclient.New(cfg.HTTPClient)
+
+	// 登录签发与接口校验共用同一令牌服务，算法配置错误时拒绝启动
+	jwtService, err := auth.NewJWTService(cfg.Middleware.JWT)
+	if err != nil {
+		hlog.Fatalf("JWT init failed: %v", err)
+	}
+
+	userHandler := handler.NewUserHandler(cfg, userRepo, mailer, jwtService)
+	sessionRepo := dao.NewSessionRepository(db)
+	idempotencyStore := middleware.NewMemoryIdempotencyStore()
+	userHandler.Sessions = sessionRepo
+	// 实时通知：服务端代码经hub按用户推送，停机时先关闭全部连接
+	notifyHub := ws.NewHub(cfg.API.WebSocket)
+	userHandler.Notifier = notifyHub
+	notificationHandler := handler.NewNotificationHandler(notifyHub)
+	h.OnShutdown = append(h.OnShutdown, func(ctx context.Context) {
+		notifyHub.Close()
+	})
+	adminHandler := handler.NewAdminHandler(cfg, userRepo, maintenance)
+
+	// 服务间调用：API Key只存哈希，认证时按哈希查找有效Key
+	apiKeyRepo := dao.NewAPIKeyRepository(db)
+	adminHandler.APIKeys = apiKeyRepo
+	validateAPIKey := func(ctx context.Context, key string) (auth.ServiceIdentity, bool, error) {
+		apiKey, err := apiKeyRepo.AuthenticateAPIKey(ctx, auth.HashAPIKey(key), time.Now())
+		if errors.Is(err, dao.ErrAPIKeyNotFound) {
+			return auth.ServiceIdentity{}, false, nil
+		}
+		if err != nil {
+			return auth.ServiceIdentity{}, false, err
+		}
+		return auth.ServiceIdentity{KeyID: apiKey.ID, Name: apiKey.Name, Scopes: apiKey.ScopeList()}, true, nil
+	}
+
+	// 第三方回调：配置了密钥的接入方使用通用HMAC验签，业务处理由各功能经APIs.Webhooks.OnEvent接入，
+	// 接入前验签通过的回调返回503，接入方会稍后重投
+	webhookHandler := handler.NewWebhookHandler()
+	apis.Webhooks = webhookHandler
+	for name, secret := range cfg.Webhook.Secrets {
+		webhookHandler.Register(name, handler.WebhookProvider{
+			Verify: handler.HMACVerifier{Secret: secret, Tolerance: cfg.Webhook.Tolerance}.Verify,
+		})
+	}
+	if webhookHandler.Len() > 0 {
+		h.POST(webhookPathPrefix+":provider", webhookHandler.Receive)
+	}
+
+	// 上传文件存储：本地磁盘由本服务提供访问，S3由存储桶或CDN提供访问；后端不可用时关闭上传，不影响其余接口
+	if store, err := storage.New(cfg.Storage, outbound); err != nil {
+		hlog.Errorf("storage init failed, uploads disabled: %v", err)
+	} else {
+		userHandler.Storage = store
+		if localStore, ok := store.(*storage.LocalStorage); ok {
+			h.StaticFS(localStore.URLPrefix(), &app.FS{
+				Root:        localStore.Dir(),
+				PathRewrite: app.NewPathSlashesStripper(strings.Count(localStore.URLPrefix(), "/")),
+			})
+		}
+	}
+
+	// 泄露密码检查：依赖外部服务，显式开启后才生效
+	if cfg.Account.PasswordPolicy.BreachCheck.Enabled {
+		userHandler.Breach = pwned.New(cfg.Account.PasswordPolicy.BreachCheck, outbound)
+	}
+
+	// 第三方登录：只注册配置完整的OIDC提供方，发现文档在首次登录时读取
+	userHandler.OAuthProviders = map[string]oauth.Provider{}
+	for name, provider := range cfg.OAuth.Providers {
+		if !provider.Enabled() {
+			hlog.Warnf("oauth provider %s incomplete (issuer, client id/secret and redirect url required), skipped", name)
+			continue
+		}
+		userHandler.OAuthProviders[name] = oauth.NewOIDC(name, provider, outbound)
+	}
+
+	// 审计日志异步落库，停机时写完队列中剩余记录
+	auditRecorder := service.NewAuditRecorder(dao.NewAuditRepository(db), cfg.Audit.BufferSize)
+	userHandler.Audit = auditRecorder
+	adminHandler.Audit = auditRecorder
+
+	// 数据库日志级别：管理员接口与配置热更新均可调整
+	if dbLogger, ok := config.DBLoggerOf(db); ok {
+		adminHandler.DBLogger = dbLogger
+		reloader.OnReload(func(old, updated *config.Config) {
+			if updated.Database.LogLevel != old.Database.LogLevel {
+				dbLogger.SetLevel(updated.Database.LogLevel)
+			}
+		})
+	}
+	h.OnShutdown = append(h.OnShutdown, func(ctx context.Context) {
+		if err := auditRecorder.Close(ctx); err != nil {
+			hlog.CtxWarnf(ctx, "flush audit log failed: %v", err)
+		}
+	})
+
+	// 后台定期刷新活跃用户数指标，停机时退出
+	if cfg.Middleware.Metrics.Enabled && cfg.Middleware.Metrics.ActiveUsersInterval > 0 {
+		refreshCtx, stopRefresh := context.WithCancel(context.Background())
+		metrics.StartGaugeRefresher(refreshCtx, metrics.ActiveUsers,
+			cfg.Middleware.Metrics.ActiveUsersInterval, userRepo.CountActiveUsers)
+		h.OnShutdown = append(h.OnShutdown, func(ctx context.Context) {
+			stopRefresh()
+		})
+	}
+
+	// 连接池指标：按间隔采样，便于在InUse接近上限时告警
+	if cfg.Middleware.Metrics.Enabled && cfg.Middleware.Metrics.DBStatsInterval > 0 {
+		if sqlDB, err := db.DB(); err == nil {
+			samplerCtx, stopSampler := context.WithCancel(context.Background())
+			metrics.StartDBStatsSampler(samplerCtx, cfg.Middleware.Metrics.DBStatsInterval, sqlDB.Stats)
+			h.OnShutdown = append(h.OnShutdown, func(ctx context.Context) {
+				stopSampler()
+			})
+		}
+	}
+
+	// 携带sid的访问令牌须对应未撤销的会话
+	checkSession := func(ctx context.Context, userID, sessionID int64) (bool, error) {
+		return sessionRepo.TouchSession(ctx, userID, sessionID, time.Now())
+	}
+
+	// 管理员鉴权：来源IP白名单 + 管理员角色
+	adminAuth := []app.HandlerFunc{
+		middleware.IPFilterMiddleware(cfg.Middleware.AdminIP, cfg.Middleware.Security.TrustedProxies),
+		middleware.JWTAuthMiddleware(&cfg.Middleware.JWT, jwtService, checkSession),
+		middleware.RequireRole(dao_model.RoleAdmin),
+	}
+
+	// 细粒度权限：在角色校验之外逐个接口要求具体权限，API Key按授权范围判定
+	authz := middleware.NewAuthorizer(cfg.Authz)
+	canReadUsers := authz.RequirePermission(auth.PermUsersRead)
+	canWriteUsers := authz.RequirePermission(auth.PermUsersWrite)
+	canManageKeys := authz.RequirePermission(auth.PermAPIKeysManage)
+	canManageSystem := authz.RequirePermission(auth.PermSystemManage)
+
+	// 诊断接口（仅管理员）
+	debugGroup := ops.Group("/debug", adminAuth...)
+	debugGroup.GET("/config", adminHandler.GetConfig)
+	// 性能分析：有运维端口时与其他诊断接口一样需管理员鉴权；没有运维端口时由调用方在独立的本机端口提供
+	if cfg.Debug.EnablePprof && admin != nil {
+		debugGroup.GET("/pprof/*path", debug.PprofHandler())
+		debugGroup.POST("/pprof/*path", debug.PprofHandler())
+	}
+
+	// 健康状态事件流（仅管理员），复用/health的检查项
+	ops.GET(healthStreamPath, append(adminAuth, healthHandler.StreamHealth)...)
 
 	// 业务接口组
-	apiGroup := h.Group("/api/v1")
+	var apiMiddleware []app.HandlerFunc
+	if dbGate != nil {
+		apiMiddleware = append(apiMiddleware, dbGate.Middleware())
+	}
+	apiGroup := h.Group("/api/v1", apiMiddleware...)
 	{
 		// 用户相关接口
 		userGroup := apiGroup.Group("/users")
 		{
-			userGroup.POST("/register", userHandler.Register)
-			userGroup.POST("/login", userHandler.Login)
+			// 登录兼容表单提交，在强制JSON之前注册
+			userGroup.POST("/login",
+				middleware.LoginRateLimitMiddleware(cfg.Account.LoginRateLimit),
+				userHandler.Login,
+			)
+			// 头像以multipart上传，同样在强制JSON之前注册
+			userGroup.POST("/me/avatar",
+				middleware.JWTAuthMiddleware(&cfg.Middleware.JWT, jwtService, checkSession),
+				middleware.ContentTypeMiddleware("multipart/form-data"),
+				userHandler.UploadAvatar,
+			)
+
+			userGroup.Use(middleware.RequireJSON())
+			// 移动端网络不稳定时会重试注册，同一Idempotency-Key返回首次的结果
+			userGroup.POST("/register",
+				middleware.IdempotencyMiddleware(idempotencyStore, cfg.API.IdempotencyTTL),
+				userHandler.Register,
+			)
+			userGroup.GET("/verify", userHandler.VerifyEmail)
+			userGroup.POST("/resend-verification",
+				middleware.RateLimitMiddleware(
+					cfg.Account.EmailRateLimit.Rate,
+					cfg.Account.EmailRateLimit.Interval,
+				),
+				userHandler.ResendVerification,
+			)
+			userGroup.POST("/forgot-password",
+				middleware.RateLimitMiddleware(
+					cfg.Account.EmailRateLimit.Rate,
+					cfg.Account.EmailRateLimit.Interval,
+				),
+				userHandler.ForgotPassword,
+			)
+			userGroup.POST("/reset-password", userHandler.ResetPassword)
+			userGroup.POST("/token/refresh", userHandler.RefreshToken)
 
 			// 需要身份认证的接口
-			userGroup.Use(middleware.JWTAuthMiddleware(&cfg.Middleware.JWT))
-			userGroup.PUT("/password", userHandler.ChangePassword)
+			userGroup.Use(middleware.JWTAuthMiddleware(&cfg.Middleware.JWT, jwtService, checkSession))
+			// 敏感操作：按配置要求近期认证
+			var sensitive []app.HandlerFunc
+			if cfg.Middleware.Security.RequireFreshAuth {
+				sensitive = append(sensitive, middleware.FreshAuthMiddleware(cfg.Middleware.Security.FreshAuthWindow))
+			}
+			userGroup.GET("/me", userHandler.Profile)
+			userGroup.PATCH("/me", append(sensitive, userHandler.UpdateProfile)...)
+			userGroup.PUT("/password", append(sensitive, userHandler.ChangePassword)...)
+			userGroup.GET("/me/sessions", userHandler.ListSessions)
+			userGroup.DELETE("/me/sessions/:id", userHandler.RevokeSession)
+			userGroup.POST("/me/sessions/revoke-others", userHandler.RevokeOtherSessions)
+		}
+
+		// 第三方登录：浏览器跳转到login，提供方授权后回调callback，响应与密码登录相同
+		if len(userHandler.OAuthProviders) > 0 {
+			authGroup := apiGroup.Group("/auth")
+			authGroup.GET("/:provider/login", userHandler.OAuthLogin)
+			authGroup.GET("/:provider/callback", userHandler.OAuthCallback)
+		}
+
+		// 实时通知：浏览器无法为WebSocket设置请求头，令牌可放在查询参数或子协议中
+		apiGroup.GET("/ws",
+			middleware.WebSocketTokenMiddleware(),
+			middleware.JWTAuthMiddleware(&cfg.Middleware.JWT, jwtService, checkSession),
+			notificationHandler.Connect,
+		)
+
+		// 管理员接口
+		adminGroup := apiGroup.Group("/admin", append(adminAuth, middleware.RequireJSON())...)
+		{
+			adminGroup.GET("/users", canReadUsers, adminHandler.ListUsers)
+			adminGroup.GET("/users/search", canReadUsers, adminHandler.SearchUsers)
+			adminGroup.DELETE("/users/:id", canWriteUsers, adminHandler.DeleteUser)
+			adminGroup.POST("/users/:id/restore", canWriteUsers, adminHandler.RestoreUser)
+			adminGroup.GET("/stats", canReadUsers, adminHandler.Stats)
+			adminGroup.POST("/users/import", canWriteUsers, adminHandler.ImportUsers)
+			adminGroup.GET("/maintenance", canManageSystem, adminHandler.GetMaintenance)
+			adminGroup.PUT("/maintenance", canManageSystem, adminHandler.SetMaintenance)
+			adminGroup.GET("/db-log-level", canManageSystem, adminHandler.GetDBLogLevel)
+			adminGroup.PUT("/db-log-level", canManageSystem, adminHandler.SetDBLogLevel)
+			adminGroup.GET("/api-keys", canManageKeys, adminHandler.ListAPIKeys)
+			adminGroup.POST("/api-keys", canManageKeys, adminHandler.CreateAPIKey)
+			adminGroup.DELETE("/api-keys/:id", canManageKeys, adminHandler.RevokeAPIKey)
 		}
+
+		// 内部服务接口：以X-API-Key认证，不接受用户令牌，按Key的授权范围校验权限
+		internalGroup := apiGroup.Group("/internal", middleware.APIKeyMiddleware(validateAPIKey))
+		{
+			internalGroup.GET("/users", canReadUsers, adminHandler.ListUsers)
+			internalGroup.GET("/users/search", canReadUsers, adminHandler.SearchUsers)
+		}
+	}
+	return apis
+}
+
+// externalChecks 外部依赖的健康检查项；这些依赖只影响部分功能，异常时报告warning而不使整体降级
+func externalChecks(cfg *config.Config) []handler.ComponentCheck {
+	var checks []handler.ComponentCheck
+	if cfg.Mail.Host != "" {
+		smtpSender := mail.NewSMTPSender(cfg.Mail)
+		checks = append(checks, handler.ComponentCheck{
+			Name:    "smtp",
+			Timeout: 3 * time.Second,
+			Check: func(ctx context.Context) handler.ComponentStatus {
+				if err := smtpSender.Ping(ctx); err != nil {
+					return handler.ComponentStatus{Status: "warning", Error: err.Error()}
+				}
+				return handler.ComponentStatus{}
+			},
+		})
 	}
+	return checks
 }
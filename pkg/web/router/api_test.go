@@ -2,22 +2,222 @@
 package router_test
 
 import (
+	"bytes"
+	"os"
+	"strings"
 	"testing"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
 	"github.com/cloudwego/hertz/pkg/common/ut"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"my-digital-home/pkg/common/config"
 	"my-digital-home/pkg/web/router"
 )
 
 func TestHealthCheckRoute(t *testing.T) {
-	// 正确初始化方式（注意去掉了config包）
+	// 默认配置、无数据库：只注册基础接口
 	h := server.New()
-	router.RegisterAPIs(h)
+	router.RegisterAPIsWithDefaults(h)
 
+	// 探针不带User-Agent也不会被安全中间件拦截
 	w := ut.PerformRequest(h.Engine, "GET", "/health", nil)
 	resp := w.Result()
 
+	if resp.StatusCode() != 200 {
+		t.Fatalf("Expected 200, got %d: %s", resp.StatusCode(), resp.Body())
+	}
+}
+
+func TestProbesBypassRateLimit(t *testing.T) {
+	cfg := config.Default()
+	cfg.Middleware.RateLimit.Rate = 1
+	h := server.New()
+	router.RegisterBaseAPIs(h, nil, config.NewReloader(cfg))
+
+	for i := 0; i < 5; i++ {
+		for _, path := range []string{"/health", "/livez", "/readyz"} {
+			if w := ut.PerformRequest(h.Engine, "GET", path, nil); w.Result().StatusCode() != 200 {
+				t.Fatalf("Expected probe %s to reach the handler, got %d", path, w.Result().StatusCode())
+			}
+		}
+	}
+
+	// 其余接口仍受安全校验与限流约束
+	if w := ut.PerformRequest(h.Engine, "GET", "/openapi.json", nil); w.Result().StatusCode() != 400 {
+		t.Fatalf("Expected missing User-Agent to be rejected, got %d", w.Result().StatusCode())
+	}
+	ua := ut.Header{Key: "User-Agent", Value: "router-test"}
+	ut.PerformRequest(h.Engine, "GET", "/openapi.json", nil, ua)
+	if w := ut.PerformRequest(h.Engine, "GET", "/openapi.json", nil, ua); w.Result().StatusCode() != 429 {
+		t.Fatalf("Expected API routes to stay rate limited, got %d", w.Result().StatusCode())
+	}
+}
+
+func TestOpenAPIRoute(t *testing.T) {
+	h := server.New()
+	router.RegisterAPIsWithDefaults(h)
+
+	w := ut.PerformRequest(h.Engine, "GET", "/openapi.json", nil,
+		ut.Header{Key: "User-Agent", Value: "router-test"})
+	resp := w.Result()
+
 	if resp.StatusCode() != 200 {
 		t.Fatalf("Expected 200, got %d", resp.StatusCode())
 	}
+	if ct := string(resp.Header.ContentType()); !strings.HasPrefix(ct, "application/json") {
+		t.Fatalf("Expected JSON content type, got %s", ct)
+	}
+}
+
+func TestOpsRoutesMoveToAdminServer(t *testing.T) {
+	h, admin := server.New(), server.New()
+	router.RegisterBaseAPIs(h, admin, config.NewReloader(config.Default()))
+
+	ua := ut.Header{Key: "User-Agent", Value: "router-test"}
+	for _, path := range []string{"/health", "/metrics"} {
+		if w := ut.PerformRequest(admin.Engine, "GET", path, nil, ua); w.Result().StatusCode() != 200 {
+			t.Fatalf("Expected %s on admin server, got %d", path, w.Result().StatusCode())
+		}
+		if w := ut.PerformRequest(h.Engine, "GET", path, nil, ua); w.Result().StatusCode() != 404 {
+			t.Fatalf("Expected %s to be absent from public server, got %d", path, w.Result().StatusCode())
+		}
+	}
+	// 业务端口保留接口文档
+	if w := ut.PerformRequest(h.Engine, "GET", "/openapi.json", nil, ua); w.Result().StatusCode() != 200 {
+		t.Fatalf("Expected API docs on public server, got %d", w.Result().StatusCode())
+	}
+}
+
+// newMockDB 基于sqlmock的数据库连接，供需要注册业务接口的测试使用，不期望任何查询
+func newMockDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	sqlDB, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestPprofOnAdminServerRequiresAdminAuth(t *testing.T) {
+	db := newMockDB(t)
+	cfg := config.Default()
+	cfg.Debug.EnablePprof = true
+	h, admin := server.New(), server.New()
+	router.RegisterAPIs(h, admin, config.NewReloader(cfg), db, nil)
+
+	ua := ut.Header{Key: "User-Agent", Value: "router-test"}
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/cmdline", "/debug/config"} {
+		if code := ut.PerformRequest(admin.Engine, "GET", path, nil, ua).Result().StatusCode(); code != 401 {
+			t.Fatalf("Expected %s to require admin auth, got %d", path, code)
+		}
+	}
+	if code := ut.PerformRequest(h.Engine, "GET", "/debug/pprof/", nil, ua).Result().StatusCode(); code != 404 {
+		t.Fatalf("Expected pprof to be absent from public server, got %d", code)
+	}
+}
+
+func TestMaintenanceKeepsProbesAndLoginReachable(t *testing.T) {
+	cfg := config.Default()
+	cfg.Maintenance.Enabled = true
+	h := server.New()
+	router.RegisterAPIs(h, nil, config.NewReloader(cfg), newMockDB(t), nil)
+
+	ua := ut.Header{Key: "User-Agent", Value: "router-test"}
+	for _, path := range []string{"/livez", "/readyz", "/health"} {
+		if code := ut.PerformRequest(h.Engine, "GET", path, nil, ua).Result().StatusCode(); code == 503 {
+			t.Fatalf("Expected %s to bypass maintenance, got %d", path, code)
+		}
+	}
+	body := `{"username":"admin","password":"Passw0rd!"}`
+	w := ut.PerformRequest(h.Engine, "POST", "/api/v1/users/login",
+		&ut.Body{Body: strings.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"}, ua)
+	// 请求到达登录处理（mock数据库不返回数据，结果不重要），而非被维护模式拦截
+	if code := w.Result().StatusCode(); code == 503 {
+		t.Fatalf("Expected login to bypass maintenance, got %d: %s", code, w.Result().Body())
+	}
+
+	if code := ut.PerformRequest(h.Engine, "GET", "/api/v1/users/me", nil, ua).Result().StatusCode(); code != 503 {
+		t.Fatalf("Expected other API routes to be blocked during maintenance, got %d", code)
+	}
+}
+
+func TestCORSPreflightPassesSecurityCheck(t *testing.T) {
+	h := server.New()
+	router.RegisterAPIs(h, nil, config.NewReloader(config.Default()), newMockDB(t), nil)
+
+	for _, tc := range []struct{ method, path string }{
+		{"PATCH", "/api/v1/users/me"},
+		{"DELETE", "/api/v1/admin/users/7"},
+	} {
+		w := ut.PerformRequest(h.Engine, "OPTIONS", tc.path, nil,
+			ut.Header{Key: "User-Agent", Value: "router-test"},
+			ut.Header{Key: "Origin", Value: "http://localhost:3000"},
+			ut.Header{Key: "Access-Control-Request-Method", Value: tc.method})
+		resp := w.Result()
+		if resp.StatusCode() < 200 || resp.StatusCode() >= 300 {
+			t.Fatalf("Expected preflight for %s %s to succeed, got %d: %s", tc.method, tc.path, resp.StatusCode(), resp.Body())
+		}
+		if got := string(resp.Header.Peek("Access-Control-Allow-Origin")); got != "http://localhost:3000" {
+			t.Fatalf("Expected CORS headers on preflight for %s %s, got %q", tc.method, tc.path, got)
+		}
+	}
+
+	// 未声明实际方法的OPTIONS与不在白名单中的方法仍被拒绝
+	ua := ut.Header{Key: "User-Agent", Value: "router-test"}
+	if code := ut.PerformRequest(h.Engine, "OPTIONS", "/api/v1/users/me", nil, ua).Result().StatusCode(); code != 405 {
+		t.Fatalf("Expected bare OPTIONS to be rejected, got %d", code)
+	}
+	if code := ut.PerformRequest(h.Engine, "TRACE", "/api/v1/users/me", nil, ua).Result().StatusCode(); code != 405 {
+		t.Fatalf("Expected TRACE to be rejected, got %d", code)
+	}
+}
+
+func TestPurgeRouteGetsPastSecurityCheck(t *testing.T) {
+	h := server.New()
+	router.RegisterAPIs(h, nil, config.NewReloader(config.Default()), newMockDB(t), nil)
+
+	// 方法校验放行后由管理员鉴权拒绝，而不是被安全校验以405拦截
+	w := ut.PerformRequest(h.Engine, "DELETE", "/api/v1/admin/users/7?purge=true&confirm=7", nil,
+		ut.Header{Key: "User-Agent", Value: "router-test"})
+	if code := w.Result().StatusCode(); code != 401 {
+		t.Fatalf("Expected purge to reach admin auth (401), got %d: %s", code, w.Result().Body())
+	}
+}
+
+func TestBodyLogSkipsAPIKeyCreation(t *testing.T) {
+	var logs bytes.Buffer
+	hlog.SetOutput(&logs)
+	hlog.SetLevel(hlog.LevelDebug)
+	defer func() {
+		hlog.SetOutput(os.Stderr)
+		hlog.SetLevel(hlog.LevelInfo)
+	}()
+
+	cfg := config.Default()
+	cfg.Debug.LogBodies = true
+	h := server.New()
+	router.RegisterAPIs(h, nil, config.NewReloader(cfg), newMockDB(t), nil)
+
+	ua := ut.Header{Key: "User-Agent", Value: "router-test"}
+	ut.PerformRequest(h.Engine, "POST", "/api/v1/admin/api-keys", nil, ua)
+	ut.PerformRequest(h.Engine, "GET", "/api/v1/users/me", nil, ua)
+
+	// 创建接口的响应含明文Key，不记录请求体；普通接口照常记录
+	if strings.Contains(logs.String(), "[BODY] POST /api/v1/admin/api-keys") {
+		t.Fatalf("Expected API key creation bodies to be skipped, got logs: %s", logs.String())
+	}
+	if !strings.Contains(logs.String(), "[BODY] GET /api/v1/users/me") {
+		t.Fatalf("Expected other bodies to be logged, got logs: %s", logs.String())
+	}
 }
@@ -0,0 +1,229 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	netmail "net/mail"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// tagName 请求结构体中的校验规则标签，语法与go-playground/validator一致
+// Hertz默认只识别vd标签，binding标签由本包负责执行
+const tagName = "binding"
+
+// ErrInvalidRule binding标签含不支持的规则或参数不是整数，属于编码错误而非请求错误
+var ErrInvalidRule = errors.New("validation: invalid binding rule")
+
+// rule 解析后的单条规则，n为min/max/len的整数参数
+type rule struct {
+	name  string
+	param string
+	n     int
+}
+
+// fieldRules 一个带binding标签的导出字段
+type fieldRules struct {
+	index int
+	name  string // json名
+	rules []rule
+}
+
+// compiledStruct 按类型缓存的解析结果，标签有误时err非nil
+type compiledStruct struct {
+	fields []fieldRules
+	err    error
+}
+
+// compiled reflect.Type到*compiledStruct，每个类型只解析一次
+var compiled sync.Map
+
+// FieldErrors 字段（json名）到错误提示的映射，每个字段只报告第一条未通过的规则
+type FieldErrors map[string]string
+
+// Error 按字段名排序拼接，保证提示稳定
+func (e FieldErrors) Error() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+": "+e[name])
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Compile 解析结构体（或其指针）的binding标签并缓存，标签有误时返回包装ErrInvalidRule的错误
+func Compile(obj interface{}) error {
+	t := reflect.TypeOf(obj)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	return compile(t).err
+}
+
+// MustCompile 启动时校验各请求结构体的binding标签，有误时panic，让规则写错在启动而非首个请求时暴露
+func MustCompile(objs ...interface{}) {
+	for _, obj := range objs {
+		if err := Compile(obj); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Struct 按binding标签校验结构体（或其指针）的全部字段，全部通过时返回nil
+// 支持的规则：required、omitempty、min、max、len、email；标签有误时返回包装ErrInvalidRule的错误
+// 指针字段的omitempty与required针对指针本身，其余规则作用于指向的值
+func Struct(obj interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	c := compile(v.Type())
+	if c.err != nil {
+		return c.err
+	}
+	errs := FieldErrors{}
+	for _, f := range c.fields {
+		if msg := checkField(v.Field(f.index), f.rules); msg != "" {
+			errs[f.name] = msg
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// compile 返回类型的解析结果，首次使用时解析并缓存
+func compile(t reflect.Type) *compiledStruct {
+	if c, ok := compiled.Load(t); ok {
+		return c.(*compiledStruct)
+	}
+
+	c := &compiledStruct{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup(tagName)
+		if !ok || !field.IsExported() {
+			continue
+		}
+		rules, err := parseRules(tag)
+		if err != nil {
+			c = &compiledStruct{err: fmt.Errorf("%w: %s.%s: %v", ErrInvalidRule, t.Name(), field.Name, err)}
+			break
+		}
+		c.fields = append(c.fields, fieldRules{index: i, name: fieldName(field), rules: rules})
+	}
+	actual, _ := compiled.LoadOrStore(t, c)
+	return actual.(*compiledStruct)
+}
+
+// parseRules 解析逗号分隔的规则，拒绝未知规则与非整数参数
+func parseRules(tag string) ([]rule, error) {
+	var rules []rule
+	for _, raw := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(raw), "=")
+		r := rule{name: name, param: param}
+		switch name {
+		case "":
+			continue
+		case "required", "omitempty", "email":
+		case "min", "max", "len":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q needs an integer parameter, got %q", name, param)
+			}
+			r.n = n
+		default:
+			return nil, fmt.Errorf("unsupported rule %q", name)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// checkField 依次执行规则，返回第一条失败规则的提示
+func checkField(value reflect.Value, rules []rule) string {
+	elem := reflect.Indirect(value)
+	for _, r := range rules {
+		switch r.name {
+		case "required":
+			if value.IsZero() {
+				return "不能为空"
+			}
+		case "omitempty":
+			// 未提供时跳过其余规则
+			if value.IsZero() {
+				return ""
+			}
+		case "min":
+			if size(elem) < r.n {
+				return limitMsg(elem, "不能少于", "不能小于", r.param)
+			}
+		case "max":
+			if size(elem) > r.n {
+				return limitMsg(elem, "不能超过", "不能大于", r.param)
+			}
+		case "len":
+			if size(elem) != r.n {
+				return limitMsg(elem, "必须为", "必须等于", r.param)
+			}
+		case "email":
+			if !isEmail(elem.String()) {
+				return "必须是有效的邮箱地址"
+			}
+		}
+	}
+	return ""
+}
+
+// size 字符串取字符数，切片/映射取元素数，数值取值本身
+func size(value reflect.Value) int {
+	switch value.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(value.String())
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return value.Len()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(value.Uint())
+	}
+	return 0
+}
+
+// limitMsg 按字段类型生成长度/数量/取值限制的提示
+func limitMsg(value reflect.Value, countVerb, valueVerb, param string) string {
+	switch value.Kind() {
+	case reflect.String:
+		return "长度" + countVerb + param + "个字符"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return "数量" + countVerb + param + "个"
+	}
+	return valueVerb + param
+}
+
+// fieldName 优先使用json名，与客户端提交的字段一致
+func fieldName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
+
+func isEmail(s string) bool {
+	addr, err := netmail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
@@ -0,0 +1,94 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"my-digital-home/pkg/web/model"
+)
+
+func TestStructReportsEveryFailingField(t *testing.T) {
+	err := Struct(&model.RegisterReq{Username: "bob", Email: "not-an-email"})
+
+	var fields FieldErrors
+	if !errors.As(err, &fields) {
+		t.Fatalf("Expected FieldErrors, got %v", err)
+	}
+	want := FieldErrors{
+		"username": "长度不能少于4个字符",
+		"email":    "必须是有效的邮箱地址",
+		"password": "不能为空",
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, fields)
+	}
+	for name, msg := range want {
+		if fields[name] != msg {
+			t.Fatalf("Expected %s: %q, got %q", name, msg, fields[name])
+		}
+	}
+}
+
+func TestStructReportsFirstFailingRulePerField(t *testing.T) {
+	// 为空时只报告required，不再叠加长度提示
+	err := Struct(&model.LoginReq{Password: "x"})
+	fields, ok := err.(FieldErrors)
+	if !ok || len(fields) != 1 || fields["username"] != "不能为空" {
+		t.Fatalf("Expected only username required error, got %v", err)
+	}
+}
+
+func TestStructAcceptsValidRequest(t *testing.T) {
+	req := model.RegisterReq{Username: "alice", Email: "alice@example.com", Password: "Passw0rd!"}
+	if err := Struct(&req); err != nil {
+		t.Fatalf("Expected valid request to pass, got %v", err)
+	}
+	if err := Struct(&model.RegisterReq{Username: "用户名超过二十个字符的情况需要按字符计算长度", Email: "a@b.co", Password: "x"}); err == nil {
+		t.Fatal("Expected overlong username to fail")
+	}
+}
+
+func TestStructOptionalPointerFields(t *testing.T) {
+	if err := Struct(&model.UpdateProfileReq{}); err != nil {
+		t.Fatalf("Expected omitted fields to pass, got %v", err)
+	}
+
+	short, email := "bob", "not-an-email"
+	err := Struct(&model.UpdateProfileReq{Username: &short, Email: &email})
+	fields, ok := err.(FieldErrors)
+	if !ok || fields["username"] != "长度不能少于4个字符" || fields["email"] != "必须是有效的邮箱地址" {
+		t.Fatalf("Expected rules to apply to provided values, got %v", err)
+	}
+
+	// 显式提交空字符串不视为未提供
+	empty := ""
+	if err := Struct(&model.UpdateProfileReq{Username: &empty}); err == nil {
+		t.Fatal("Expected empty username to fail")
+	}
+}
+
+func TestInvalidRulesAreReportedNotPanicked(t *testing.T) {
+	type unknownRule struct {
+		Name string `json:"name" binding:"required,uuid"`
+	}
+	type badParam struct {
+		Name string `json:"name" binding:"max=ten"`
+	}
+
+	for _, obj := range []interface{}{unknownRule{}, &badParam{}} {
+		if err := Compile(obj); !errors.Is(err, ErrInvalidRule) {
+			t.Fatalf("Expected Compile to reject %T, got %v", obj, err)
+		}
+		// 未在启动时检查的类型：请求时返回错误而不是panic
+		if err := Struct(obj); !errors.Is(err, ErrInvalidRule) {
+			t.Fatalf("Expected Struct to report invalid rules of %T, got %v", obj, err)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected MustCompile to panic on invalid rules")
+		}
+	}()
+	MustCompile(model.RegisterReq{}, badParam{})
+}
@@ -0,0 +1,291 @@
+// Package ws 实时通知使用的WebSocket服务端：握手、帧读写与按用户登记连接的Hub
+//
+// 只实现服务端推送所需的RFC 6455子集（不支持扩展与压缩），客户端发来的数据帧读取后丢弃
+//
+// 原计划使用github.com/hertz-contrib/websocket，但该模块不在go.mod中，当前构建环境也无法拉取，
+// 因此暂以本包的握手与帧读写代替。握手与帧读写只在本文件（Upgrade与Conn），Hub只用到
+// SetReadLimit、SetReadTimeout、ReadMessage、WriteMessage与Close；引入该依赖后替换本文件即可，
+// hub.go与调用方（handler.NotificationHandler）不需要改动
+package ws
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/network"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// 帧类型
+const (
+	OpContinuation = 0x0
+	OpText         = 0x1
+	OpBinary       = 0x2
+	OpClose        = 0x8
+	OpPing         = 0x9
+	OpPong         = 0xA
+)
+
+// 关闭状态码
+const (
+	CloseNormal         = 1000
+	CloseGoingAway      = 1001
+	CloseProtocolError  = 1002
+	CloseMessageTooBig  = 1009
+	closeNoStatusRecvd  = 1005
+	maxControlPayload   = 125
+	websocketAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+// BearerSubprotocol 客户端以子协议"bearer, <token>"携带访问令牌时，服务端回写该子协议完成协商
+const BearerSubprotocol = "bearer"
+
+var (
+	// ErrBadHandshake 请求不是合法的WebSocket握手
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+	// ErrClosed 对端发送了关闭帧，已回复关闭
+	ErrClosed = errors.New("websocket: connection closed")
+	// ErrMessageTooLarge 客户端消息超过读取上限
+	ErrMessageTooLarge = errors.New("websocket: message too large")
+	errProtocol        = errors.New("websocket: protocol error")
+)
+
+// Upgrade 校验握手请求并设置101响应，响应写出后由handler接管连接，handler返回时连接关闭
+// subprotocols为服务端支持的子协议，按客户端给出的顺序选取第一个匹配项回写
+func Upgrade(c *app.RequestContext, subprotocols []string, handler func(*Conn)) error {
+	key := string(c.GetHeader("Sec-WebSocket-Key"))
+	if !c.IsGet() ||
+		!headerHasToken(string(c.GetHeader("Connection")), "upgrade") ||
+		!headerHasToken(string(c.GetHeader("Upgrade")), "websocket") ||
+		string(c.GetHeader("Sec-WebSocket-Version")) != "13" ||
+		key == "" {
+		return ErrBadHandshake
+	}
+
+	c.SetStatusCode(consts.StatusSwitchingProtocols)
+	c.Response.Header.Set("Upgrade", "websocket")
+	c.Response.Header.Set("Connection", "Upgrade")
+	c.Response.Header.Set("Sec-WebSocket-Accept", AcceptKey(key))
+	if p := selectSubprotocol(string(c.GetHeader("Sec-WebSocket-Protocol")), subprotocols); p != "" {
+		c.Response.Header.Set("Sec-WebSocket-Protocol", p)
+	}
+	c.Hijack(func(conn network.Conn) {
+		handler(&Conn{conn: conn})
+	})
+	return nil
+}
+
+// AcceptKey 由客户端的Sec-WebSocket-Key计算握手响应的Sec-WebSocket-Accept
+func AcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + websocketAcceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerHasToken 判断逗号分隔的头部值中是否含有token（不区分大小写）
+func headerHasToken(header, token string) bool {
+	for _, v := range strings.Split(header, ",") {
+		if strings.EqualFold(strings.TrimSpace(v), token) {
+			return true
+		}
+	}
+	return false
+}
+
+func selectSubprotocol(offered string, supported []string) string {
+	for _, p := range strings.Split(offered, ",") {
+		p = strings.TrimSpace(p)
+		for _, s := range supported {
+			if p == s {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// Conn 已完成握手的WebSocket连接；写操作可并发调用，读操作只能由一个协程调用
+type Conn struct {
+	conn      network.Conn
+	readLimit int64
+
+	writeMu   sync.Mutex
+	closeSent atomic.Bool
+}
+
+// SetReadLimit 客户端单条消息（含分片合并后）的字节上限，0表示不限制
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// SetReadTimeout 每次等待客户端数据的最长时间，超时后ReadMessage返回错误
+func (c *Conn) SetReadTimeout(d time.Duration) error {
+	return c.conn.SetReadTimeout(d)
+}
+
+// SetWriteTimeout 每次写出的最长时间
+func (c *Conn) SetWriteTimeout(d time.Duration) error {
+	return c.conn.SetWriteTimeout(d)
+}
+
+// ReadMessage 读取下一条数据消息，期间自动回复ping；onPong在收到pong时调用（可为nil）
+// 对端关闭时回复关闭帧并返回ErrClosed
+func (c *Conn) ReadMessage(onPong func()) (int, []byte, error) {
+	var (
+		opcode  int
+		message []byte
+	)
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case OpPing:
+			if err := c.WriteMessage(OpPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case OpPong:
+			if onPong != nil {
+				onPong()
+			}
+			continue
+		case OpClose:
+			code := closeNoStatusRecvd
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+			if code == closeNoStatusRecvd {
+				code = CloseNormal
+			}
+			_ = c.Close(code, "")
+			return 0, nil, ErrClosed
+		case OpText, OpBinary:
+			if opcode != 0 {
+				return 0, nil, c.fail(CloseProtocolError, errProtocol)
+			}
+			opcode = op
+		case OpContinuation:
+			if opcode == 0 {
+				return 0, nil, c.fail(CloseProtocolError, errProtocol)
+			}
+		default:
+			return 0, nil, c.fail(CloseProtocolError, errProtocol)
+		}
+
+		if c.readLimit > 0 && int64(len(message)+len(payload)) > c.readLimit {
+			return 0, nil, c.fail(CloseMessageTooBig, ErrMessageTooLarge)
+		}
+		message = append(message, payload...)
+		if fin {
+			return opcode, message, nil
+		}
+	}
+}
+
+// readFrame 读取一帧并去掉掩码；客户端帧必须带掩码
+func (c *Conn) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	header, err := c.conn.ReadBinary(2)
+	if err != nil {
+		return false, 0, nil, err
+	}
+	fin = header[0]&0x80 != 0
+	opcode = int(header[0] & 0x0F)
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	if header[0]&0x70 != 0 || !masked {
+		return false, 0, nil, c.fail(CloseProtocolError, errProtocol)
+	}
+	isControl := opcode&0x8 != 0
+	if isControl && (!fin || length > maxControlPayload) {
+		return false, 0, nil, c.fail(CloseProtocolError, errProtocol)
+	}
+
+	switch length {
+	case 126:
+		ext, err := c.conn.ReadBinary(2)
+		if err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext, err := c.conn.ReadBinary(8)
+		if err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext)
+	}
+	// 先于分配内存检查长度，避免恶意长度撑爆内存
+	if length > math.MaxInt32 || c.readLimit > 0 && length > uint64(c.readLimit) {
+		return false, 0, nil, c.fail(CloseMessageTooBig, ErrMessageTooLarge)
+	}
+
+	mask, err := c.conn.ReadBinary(4)
+	if err != nil {
+		return false, 0, nil, err
+	}
+	if length == 0 {
+		return fin, opcode, nil, nil
+	}
+	payload, err = c.conn.ReadBinary(int(length))
+	if err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// WriteMessage 写出一条不分片的消息；服务端帧不带掩码
+func (c *Conn) WriteMessage(opcode int, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|byte(opcode))
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	frame = append(frame, payload...)
+
+	if _, err := c.conn.WriteBinary(frame); err != nil {
+		return err
+	}
+	return c.conn.Flush()
+}
+
+// Close 发送关闭帧（只发送一次）；底层连接在Upgrade的handler返回后关闭
+func (c *Conn) Close(code int, reason string) error {
+	if c.closeSent.Swap(true) {
+		return nil
+	}
+	if len(reason) > maxControlPayload-2 {
+		reason = reason[:maxControlPayload-2]
+	}
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	return c.WriteMessage(OpClose, append(payload, reason...))
+}
+
+// fail 以指定状态码关闭连接并返回err
+func (c *Conn) fail(code int, err error) error {
+	_ = c.Close(code, "")
+	return err
+}
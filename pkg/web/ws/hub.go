@@ -0,0 +1,173 @@
+package ws
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"my-digital-home/pkg/common/config"
+)
+
+// Event 推送给客户端的通知，序列化为JSON文本帧
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+// Hub 在线连接中心：按用户ID登记连接，服务端代码通过Notify/SendToUser向某用户的全部在线连接推送
+// 连接只登记在本进程内，多实例部署时需经消息队列等转发到用户所在实例
+type Hub struct {
+	cfg config.WebSocketConfig
+
+	mu      sync.RWMutex
+	clients map[int64]map[*client]struct{}
+	closing chan struct{}
+	closed  bool
+}
+
+type client struct {
+	conn *Conn
+	send chan []byte
+}
+
+func NewHub(cfg config.WebSocketConfig) *Hub {
+	if cfg.SendBuffer <= 0 {
+		cfg.SendBuffer = 1
+	}
+	return &Hub{
+		cfg:     cfg,
+		clients: make(map[int64]map[*client]struct{}),
+		closing: make(chan struct{}),
+	}
+}
+
+// Serve 登记连接并阻塞至断开：读协程负责回复ping、检测超时，当前协程写出推送消息与定时ping
+// 连接断开、超时或Hub关闭时注销连接
+func (h *Hub) Serve(userID int64, conn *Conn) {
+	cl := &client{conn: conn, send: make(chan []byte, h.cfg.SendBuffer)}
+	if !h.register(userID, cl) {
+		_ = conn.Close(CloseGoingAway, "server shutting down")
+		return
+	}
+	defer h.unregister(userID, cl)
+
+	conn.SetReadLimit(h.cfg.MaxMessageSize)
+	if h.cfg.PongTimeout > 0 {
+		// 超过PongTimeout未收到任何帧（正常客户端至少会回复pong）视为连接已失效
+		_ = conn.SetReadTimeout(h.cfg.PongTimeout)
+	}
+
+	readDone := make(chan error, 1)
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(nil); err != nil {
+				readDone <- err
+				return
+			}
+		}
+	}()
+
+	var ping <-chan time.Time
+	if h.cfg.PingInterval > 0 {
+		ticker := time.NewTicker(h.cfg.PingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+
+	for {
+		select {
+		case msg := <-cl.send:
+			if err := conn.WriteMessage(OpText, msg); err != nil {
+				hlog.Debugf("websocket write failed: user=%d err=%v", userID, err)
+				return
+			}
+		case <-ping:
+			if err := conn.WriteMessage(OpPing, nil); err != nil {
+				hlog.Debugf("websocket ping failed: user=%d err=%v", userID, err)
+				return
+			}
+		case err := <-readDone:
+			if err != ErrClosed {
+				hlog.Debugf("websocket read ended: user=%d err=%v", userID, err)
+			}
+			return
+		case <-h.closing:
+			_ = conn.Close(CloseGoingAway, "server shutting down")
+			return
+		}
+	}
+}
+
+func (h *Hub) register(userID int64, cl *client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	if h.clients[userID] == nil {
+		h.clients[userID] = make(map[*client]struct{})
+	}
+	h.clients[userID][cl] = struct{}{}
+	return true
+}
+
+func (h *Hub) unregister(userID int64, cl *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.clients[userID], cl)
+	if len(h.clients[userID]) == 0 {
+		delete(h.clients, userID)
+	}
+}
+
+// SendToUser 向用户的全部在线连接发送一条文本消息，返回已投递的连接数
+// 发送队列已满的连接（客户端读取过慢）丢弃本条消息，不阻塞调用方；h为nil时不发送
+func (h *Hub) SendToUser(userID int64, msg []byte) int {
+	if h == nil {
+		return 0
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	delivered := 0
+	for cl := range h.clients[userID] {
+		select {
+		case cl.send <- msg:
+			delivered++
+		default:
+			hlog.Warnf("websocket send buffer full, message dropped: user=%d", userID)
+		}
+	}
+	return delivered
+}
+
+// Notify 将事件序列化后发送给用户，返回已投递的连接数
+func (h *Hub) Notify(userID int64, eventType string, data interface{}) int {
+	if h == nil {
+		return 0
+	}
+	msg, err := json.Marshal(Event{Type: eventType, Data: data})
+	if err != nil {
+		hlog.Errorf("websocket event encode failed: type=%s err=%v", eventType, err)
+		return 0
+	}
+	return h.SendToUser(userID, msg)
+}
+
+// Connections 用户当前的在线连接数
+func (h *Hub) Connections(userID int64) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients[userID])
+}
+
+// Close 通知全部连接以1001关闭并拒绝新连接，用于停机；被劫持的连接不受HTTP优雅停机管理
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.closed {
+		h.closed = true
+		close(h.closing)
+	}
+}
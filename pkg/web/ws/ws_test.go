@@ -0,0 +1,222 @@
+package ws
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	hconfig "github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/network/standard"
+	"my-digital-home/pkg/common/config"
+)
+
+// startServer 在随机端口启动挂载了/ws的服务，连接登记在userID名下
+func startServer(t *testing.T, hub *Hub, userID int64, opts ...hconfig.Option) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	srv := server.New(append(opts, server.WithHostPorts(addr))...)
+	srv.GET("/ws", func(c context.Context, ctx *app.RequestContext) {
+		if err := Upgrade(ctx, []string{BearerSubprotocol}, func(conn *Conn) { hub.Serve(userID, conn) }); err != nil {
+			ctx.String(400, err.Error())
+		}
+	})
+	go srv.Run()
+	t.Cleanup(func() {
+		hub.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	})
+
+	for i := 0; i < 50; i++ {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			return addr
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("server did not start")
+	return ""
+}
+
+// dial 完成握手，返回连接与响应
+func dial(t *testing.T, addr string) (net.Conn, *bufio.Reader, *http.Response) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req := "GET /ws HTTP/1.1\r\nHost: " + addr + "\r\n" +
+		"Connection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Protocol: bearer, token\r\n\r\n"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, br, resp
+}
+
+// writeClientFrame 写出带掩码的客户端帧
+func writeClientFrame(t *testing.T, conn net.Conn, opcode byte, payload []byte) {
+	t.Helper()
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{0x80 | opcode, 0x80 | byte(len(payload))}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readServerFrame(t *testing.T, br *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(br, header); err != nil {
+		t.Fatal(err)
+	}
+	if header[1]&0x80 != 0 {
+		t.Fatal("Expected server frames to be unmasked")
+	}
+	length := int(header[1] & 0x7F)
+	if length == 126 {
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(br, ext); err != nil {
+			t.Fatal(err)
+		}
+		length = int(binary.BigEndian.Uint16(ext))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		t.Fatal(err)
+	}
+	return header[0] & 0x0F, payload
+}
+
+func waitConnections(t *testing.T, hub *Hub, userID int64, want int) {
+	t.Helper()
+	for i := 0; i < 100 && hub.Connections(userID) != want; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := hub.Connections(userID); got != want {
+		t.Fatalf("Expected %d connections for user %d, got %d", want, userID, got)
+	}
+}
+
+func TestAcceptKey(t *testing.T) {
+	// RFC 6455 第1.3节的示例
+	if got := AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Unexpected accept key %q", got)
+	}
+}
+
+func TestHubNotifiesPingsAndCleansUp(t *testing.T) {
+	transports := map[string][]hconfig.Option{
+		"netpoll":  nil,
+		"standard": {server.WithTransport(standard.NewTransporter)},
+	}
+	for name, opts := range transports {
+		t.Run(name, func(t *testing.T) {
+			hub := NewHub(config.WebSocketConfig{PongTimeout: time.Minute, SendBuffer: 4, MaxMessageSize: 1024})
+			addr := startServer(t, hub, 7, opts...)
+
+			conn, br, resp := dial(t, addr)
+			if resp.StatusCode != 101 ||
+				resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" ||
+				resp.Header.Get("Sec-WebSocket-Protocol") != BearerSubprotocol ||
+				!strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") {
+				t.Fatalf("Expected 101 upgrade response, got %d %v", resp.StatusCode, resp.Header)
+			}
+			waitConnections(t, hub, 7, 1)
+
+			if n := hub.Notify(7, "password_changed", nil); n != 1 {
+				t.Fatalf("Expected one delivery, got %d", n)
+			}
+			if hub.Notify(8, "password_changed", nil) != 0 {
+				t.Fatal("Expected no delivery to a user without connections")
+			}
+			op, payload := readServerFrame(t, br)
+			var event Event
+			if op != OpText || json.Unmarshal(payload, &event) != nil || event.Type != "password_changed" {
+				t.Fatalf("Expected password_changed text frame, got op=%d %s", op, payload)
+			}
+
+			writeClientFrame(t, conn, OpPing, []byte("hi"))
+			if op, payload := readServerFrame(t, br); op != OpPong || string(payload) != "hi" {
+				t.Fatalf("Expected pong echoing ping payload, got op=%d %q", op, payload)
+			}
+
+			writeClientFrame(t, conn, OpClose, binary.BigEndian.AppendUint16(nil, CloseNormal))
+			if op, payload := readServerFrame(t, br); op != OpClose || binary.BigEndian.Uint16(payload) != CloseNormal {
+				t.Fatalf("Expected close reply, got op=%d %v", op, payload)
+			}
+			waitConnections(t, hub, 7, 0)
+		})
+	}
+}
+
+func TestHubClosesIdleAndOversizedConnections(t *testing.T) {
+	hub := NewHub(config.WebSocketConfig{PingInterval: 20 * time.Millisecond, PongTimeout: 100 * time.Millisecond, MaxMessageSize: 8})
+	addr := startServer(t, hub, 7)
+
+	// 不回复pong的客户端在PongTimeout后被断开
+	_, br, _ := dial(t, addr)
+	waitConnections(t, hub, 7, 1)
+	if op, _ := readServerFrame(t, br); op != OpPing {
+		t.Fatalf("Expected keepalive ping, got op=%d", op)
+	}
+	waitConnections(t, hub, 7, 0)
+
+	// 超过上限的消息以1009关闭
+	conn, br, _ := dial(t, addr)
+	waitConnections(t, hub, 7, 1)
+	writeClientFrame(t, conn, OpText, []byte("too long message"))
+	for {
+		op, payload := readServerFrame(t, br)
+		if op == OpPing {
+			continue
+		}
+		if op != OpClose || binary.BigEndian.Uint16(payload) != CloseMessageTooBig {
+			t.Fatalf("Expected 1009 close, got op=%d %v", op, payload)
+		}
+		break
+	}
+	waitConnections(t, hub, 7, 0)
+}
+
+func TestUpgradeRejectsPlainRequest(t *testing.T) {
+	hub := NewHub(config.WebSocketConfig{})
+	addr := startServer(t, hub, 7)
+
+	resp, err := http.Get("http://" + addr + "/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 400 {
+		t.Fatalf("Expected 400 for a request without upgrade headers, got %d", resp.StatusCode)
+	}
+}